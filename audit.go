@@ -0,0 +1,131 @@
+package goth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// AuditEventType identifies what an AuditEvent records.
+type AuditEventType string
+
+const (
+	// AuditAuthStarted is emitted when a user is sent to the provider to authenticate.
+	AuditAuthStarted AuditEventType = "auth_started"
+	// AuditAuthCompleted is emitted when a user returned from the provider and was fetched successfully.
+	AuditAuthCompleted AuditEventType = "auth_completed"
+	// AuditAuthFailed is emitted when beginning or completing authentication failed.
+	AuditAuthFailed AuditEventType = "auth_failed"
+	// AuditTokenRefreshed is emitted after every refresh attempt made through goth.RefreshToken.
+	AuditTokenRefreshed AuditEventType = "token_refreshed"
+	// AuditTokenRevoked is emitted after every revocation attempt made through goth.RevokeToken.
+	AuditTokenRevoked AuditEventType = "token_revoked"
+	// AuditLogout is emitted when a user is logged out with gothic.Logout.
+	AuditLogout AuditEventType = "logout"
+)
+
+// AuditEvent is a security relevant event. Fields that are not known where
+// the event is emitted are left empty: goth.RefreshToken, for instance, knows
+// neither the subject nor the client IP.
+type AuditEvent struct {
+	Type     AuditEventType `json:"type"`
+	Time     time.Time      `json:"time"`
+	Provider string         `json:"provider,omitempty"`
+	// Subject is the user ID at the provider.
+	Subject string `json:"subject,omitempty"`
+	// IP is the address of the client the request came from.
+	IP string `json:"ip,omitempty"`
+	// StateID correlates the events of one login without disclosing the state
+	// parameter itself, see StateID.
+	StateID string `json:"state_id,omitempty"`
+	// ErrorClass is set for AuditAuthFailed events.
+	ErrorClass ErrorClass `json:"error_class,omitempty"`
+	// Error is the error message of failed attempts.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink receives audit events, e.g. to forward them to a SIEM.
+// Implementations must be safe for concurrent use and should not block, as
+// events are delivered synchronously on the request path.
+type AuditSink interface {
+	Audit(ctx context.Context, event AuditEvent)
+}
+
+// NopAuditSink discards all events. It is used until UseAuditSink is called.
+type NopAuditSink struct{}
+
+func (NopAuditSink) Audit(context.Context, AuditEvent) {}
+
+var auditSinkLock sync.RWMutex
+var auditSink AuditSink = NopAuditSink{}
+
+// UseAuditSink sets the AuditSink goth and gothic emit events to. Passing nil
+// restores the default NopAuditSink.
+func UseAuditSink(s AuditSink) {
+	if s == nil {
+		s = NopAuditSink{}
+	}
+	auditSinkLock.Lock()
+	auditSink = s
+	auditSinkLock.Unlock()
+}
+
+// GetAuditSink returns the AuditSink currently in use.
+func GetAuditSink() AuditSink {
+	auditSinkLock.RLock()
+	defer auditSinkLock.RUnlock()
+	return auditSink
+}
+
+// Audit emits event to the configured AuditSink, setting its Time if it is zero.
+func Audit(ctx context.Context, event AuditEvent) {
+	if event.Time.IsZero() {
+		event.Time = GetClock().Now()
+	}
+	GetAuditSink().Audit(ctx, event)
+}
+
+// StateID returns an identifier for the state parameter of a login that is safe
+// to log: a truncated SHA-256 of it. It returns "" for an empty state.
+func StateID(state string) string {
+	if state == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:8])
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// JSONAuditSink writes every event as a line of JSON to an io.Writer, the
+// format most log shippers ingest.
+type JSONAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONAuditSink returns a JSONAuditSink writing to w.
+func NewJSONAuditSink(w io.Writer) *JSONAuditSink {
+	return &JSONAuditSink{w: w}
+}
+
+func (s *JSONAuditSink) Audit(_ context.Context, event AuditEvent) {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(b, '\n')); err != nil {
+		GetLogger().Warn("failed to write audit event", "error", err)
+	}
+}
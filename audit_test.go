@@ -0,0 +1,73 @@
+package goth_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingAuditSink struct {
+	events []goth.AuditEvent
+}
+
+func (s *recordingAuditSink) Audit(_ context.Context, e goth.AuditEvent) {
+	s.events = append(s.events, e)
+}
+
+func Test_Audit(t *testing.T) {
+	a := assert.New(t)
+
+	s := &recordingAuditSink{}
+	goth.UseAuditSink(s)
+	defer goth.UseAuditSink(nil)
+
+	p := gothtest.New("fake", "http://localhost/callback")
+	p.AddUser("homer", goth.User{UserID: "1"})
+	p.Fail(gothtest.OpRefreshToken, errors.New("boom"))
+
+	_, err := goth.RefreshToken(p, gothtest.RefreshToken("homer"))
+	a.Error(err)
+	a.NoError(goth.RevokeToken(context.Background(), p, gothtest.AccessToken("homer")))
+
+	a.Len(s.events, 2)
+	a.Equal(goth.AuditTokenRefreshed, s.events[0].Type)
+	a.Equal("fake", s.events[0].Provider)
+	a.Equal("boom", s.events[0].Error)
+	a.False(s.events[0].Time.IsZero())
+	a.Equal(goth.AuditTokenRevoked, s.events[1].Type)
+	a.Empty(s.events[1].Error)
+}
+
+func Test_StateID(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Empty(goth.StateID(""))
+	a.Equal(goth.StateID("state"), goth.StateID("state"))
+	a.NotEqual(goth.StateID("state"), goth.StateID("other"))
+	a.NotContains(goth.StateID("state"), "state")
+}
+
+func Test_JSONAuditSink(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	s := goth.NewJSONAuditSink(buf)
+	s.Audit(context.Background(), goth.AuditEvent{Type: goth.AuditLogout, Provider: "github"})
+	s.Audit(context.Background(), goth.AuditEvent{Type: goth.AuditAuthFailed, ErrorClass: goth.ErrorClassState})
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	a.Len(lines, 2)
+	e := map[string]interface{}{}
+	a.NoError(json.Unmarshal(lines[1], &e))
+	a.Equal("auth_failed", e["type"])
+	a.Equal("state", e["error_class"])
+	a.NotContains(e, "subject")
+}
@@ -0,0 +1,59 @@
+package goth
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultFetchUsersConcurrency is the number of concurrent requests FetchUsers
+// makes when no concurrency is given.
+const DefaultFetchUsersConcurrency = 8
+
+// FetchUserResult is the outcome of fetching the user of one session with FetchUsers.
+type FetchUserResult struct {
+	User User
+	Err  error
+}
+
+// FetchUsers fetches the users of many sessions from the provider, making at
+// most concurrency requests at a time (DefaultFetchUsersConcurrency if it is
+// <= 0). It is meant for jobs re-resolving stored sessions in bulk.
+//
+// The results are in the same order as sessions, and a failure only affects
+// the result of the session concerned. Once ctx is done the remaining
+// sessions are not fetched and their results hold ctx.Err().
+func FetchUsers(ctx context.Context, provider Provider, sessions []Session, concurrency int) []FetchUserResult {
+	if concurrency <= 0 {
+		concurrency = DefaultFetchUsersConcurrency
+	}
+	results := make([]FetchUserResult, len(sessions))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, sess := range sessions {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < len(sessions); j++ {
+				results[j].Err = ctx.Err()
+			}
+			wg.Wait()
+			return results
+		}
+
+		wg.Add(1)
+		go func(i int, sess Session) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := ctx.Err(); err != nil {
+				results[i].Err = err
+				return
+			}
+			results[i].User, results[i].Err = FetchUser(ctx, provider, sess)
+		}(i, sess)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,68 @@
+package goth_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/faux"
+	"github.com/stretchr/testify/assert"
+)
+
+type slowProvider struct {
+	faux.Provider
+	active, peak int32
+}
+
+func (p *slowProvider) FetchUser(s goth.Session) (goth.User, error) {
+	n := atomic.AddInt32(&p.active, 1)
+	defer atomic.AddInt32(&p.active, -1)
+	for {
+		peak := atomic.LoadInt32(&p.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&p.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return p.Provider.FetchUser(s)
+}
+
+func Test_FetchUsers(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := &slowProvider{}
+	sessions := []goth.Session{}
+	for _, id := range []string{"1", "2", "", "4", "5", "6"} {
+		token := "token"
+		if id == "" {
+			token = ""
+		}
+		sessions = append(sessions, &faux.Session{ID: id, AccessToken: token})
+	}
+
+	results := goth.FetchUsers(context.Background(), p, sessions, 2)
+	a.Len(results, 6)
+	for i, r := range results {
+		if i == 2 {
+			a.Error(r.Err)
+			continue
+		}
+		a.NoError(r.Err)
+		a.Equal(sessions[i].(*faux.Session).ID, r.User.UserID)
+	}
+	a.Equal(int32(2), atomic.LoadInt32(&p.peak))
+}
+
+func Test_FetchUsers_Cancelled(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := goth.FetchUsers(ctx, &faux.Provider{}, []goth.Session{&faux.Session{ID: "1", AccessToken: "token"}}, 0)
+	a.Len(results, 1)
+	a.Equal(context.Canceled, results[0].Err)
+}
@@ -0,0 +1,118 @@
+package goth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultInitProvidersConcurrency is the number of providers InitProviders
+// constructs at once when no concurrency is given.
+const DefaultInitProvidersConcurrency = 16
+
+// ProviderFactory constructs a provider, e.g. by running OpenID Connect
+// discovery for one tenant. It should give up once ctx is done.
+type ProviderFactory func(ctx context.Context) (Provider, error)
+
+// errNilProvider is recorded for factories returning neither a provider nor an
+// error.
+var errNilProvider = errors.New("provider factory returned a nil provider")
+
+// ErrProviderInit is returned by InitProviders when some providers could not
+// be constructed, keyed by their name.
+type ErrProviderInit struct {
+	Errors map[string]error
+}
+
+func (e *ErrProviderInit) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = name + ": " + e.Errors[name].Error()
+	}
+	return fmt.Sprintf("failed to initialize %d provider(s): %s", len(names), strings.Join(msgs, "; "))
+}
+
+// InitProviders constructs providers concurrently, running at most
+// concurrency factories at a time (DefaultInitProvidersConcurrency if it is
+// <= 0), and registers those that succeed under their key in factories,
+// calling SetName if the provider is named differently. It is meant for
+// applications starting up with many providers whose construction involves
+// network requests.
+//
+// A failing factory does not stop the others; all failures are returned
+// together as an *ErrProviderInit. Once ctx is done the factories not yet
+// started fail with ctx.Err().
+func InitProviders(ctx context.Context, factories map[string]ProviderFactory, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = DefaultInitProvidersConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	built := make(Providers, len(factories))
+	failed := map[string]error{}
+
+	for name, factory := range factories {
+		wg.Add(1)
+		go func(name string, factory ProviderFactory) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+			}
+
+			var p Provider
+			err := ctx.Err()
+			if err == nil {
+				p, err = factory(ctx)
+				if err == nil && isNil(p) {
+					err = errNilProvider
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				GetLogger().Warn("failed to initialize provider", "provider", name, "error", err)
+				failed[name] = err
+				return
+			}
+			if p.Name() != name {
+				p.SetName(name)
+			}
+			built[name] = p
+		}(name, factory)
+	}
+	wg.Wait()
+
+	providerLock.Lock()
+	updated := copyProviders(len(built))
+	for name, p := range built {
+		updated[name] = p
+	}
+	providers.Store(updated)
+	providerLock.Unlock()
+
+	if len(failed) > 0 {
+		return &ErrProviderInit{Errors: failed}
+	}
+	return nil
+}
+
+// isNil reports whether p is nil or a nil pointer, whose methods would panic.
+func isNil(p Provider) bool {
+	if p == nil {
+		return true
+	}
+	v := reflect.ValueOf(p)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
@@ -0,0 +1,83 @@
+package goth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/faux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_InitProviders(t *testing.T) {
+	a := assert.New(t)
+	defer goth.ClearProviders()
+
+	var active, peak int32
+	factories := map[string]goth.ProviderFactory{}
+	for i := 0; i < 20; i++ {
+		factories[fmt.Sprintf("tenant-%d", i)] = func(ctx context.Context) (goth.Provider, error) {
+			n := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return &faux.Provider{}, nil
+		}
+	}
+	factories["broken"] = func(ctx context.Context) (goth.Provider, error) {
+		return nil, errors.New("discovery failed")
+	}
+
+	err := goth.InitProviders(context.Background(), factories, 4)
+	a.Error(err)
+	initErr, ok := err.(*goth.ErrProviderInit)
+	a.True(ok)
+	a.Len(initErr.Errors, 1)
+	a.Contains(err.Error(), "broken: discovery failed")
+	a.Equal(int32(4), atomic.LoadInt32(&peak))
+
+	a.Len(goth.GetProviders(), 20)
+	p, err := goth.GetProvider("tenant-7")
+	a.NoError(err)
+	a.NotNil(p)
+	_, err = goth.GetProvider("broken")
+	a.Error(err)
+}
+
+func Test_InitProviders_Cancelled(t *testing.T) {
+	a := assert.New(t)
+	defer goth.ClearProviders()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := goth.InitProviders(ctx, map[string]goth.ProviderFactory{
+		"faux": func(ctx context.Context) (goth.Provider, error) { return &faux.Provider{}, nil },
+	}, 0)
+	a.Error(err)
+	a.Equal(context.Canceled, err.(*goth.ErrProviderInit).Errors["faux"])
+	a.Len(goth.GetProviders(), 0)
+}
+
+func Test_InitProviders_NilProvider(t *testing.T) {
+	a := assert.New(t)
+	defer goth.ClearProviders()
+
+	err := goth.InitProviders(context.Background(), map[string]goth.ProviderFactory{
+		"nil":   func(ctx context.Context) (goth.Provider, error) { return nil, nil },
+		"typed": func(ctx context.Context) (goth.Provider, error) { return (*faux.Provider)(nil), nil },
+		"faux":  func(ctx context.Context) (goth.Provider, error) { return &faux.Provider{}, nil },
+	}, 0)
+	a.Error(err)
+	a.Len(err.(*goth.ErrProviderInit).Errors, 2)
+	a.Contains(err.Error(), "nil: provider factory returned a nil provider")
+	a.Len(goth.GetProviders(), 1)
+}
@@ -0,0 +1,174 @@
+package goth
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for requests to a provider while its circuit
+// breaker is open. http.Client wraps it in a *url.Error; use errors.As to
+// detect it.
+type ErrCircuitOpen struct {
+	Provider string
+	// RetryAt is when the breaker will let a request through to probe the provider again.
+	RetryAt time.Time
+}
+
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("circuit breaker for %s is open until %s", e.Provider, e.RetryAt.Format(time.RFC3339))
+}
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails all requests fast with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a single request through to probe whether the provider has recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerSettings configures a CircuitBreaker.
+type CircuitBreakerSettings struct {
+	// FailureThreshold is the number of consecutive failures that open the breaker. Defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before probing the provider. Defaults to 30 seconds.
+	OpenTimeout time.Duration
+}
+
+// CircuitBreaker stops sending requests to a provider after repeated network
+// errors or 5xx responses, so a degraded provider fails fast instead of tying
+// up goroutines waiting on timeouts. Use one breaker per provider.
+type CircuitBreaker struct {
+	provider string
+	settings CircuitBreakerSettings
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker for the named provider.
+func NewCircuitBreaker(provider string, settings CircuitBreakerSettings) *CircuitBreaker {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = 5
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 30 * time.Second
+	}
+	return &CircuitBreaker{provider: provider, settings: settings}
+}
+
+// State returns the current state of the breaker.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == CircuitOpen && GetClock().Now().Sub(cb.openedAt) >= cb.settings.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+// allow reports whether a request may be sent, moving an expired open breaker
+// to half-open and admitting a single probe.
+func (cb *CircuitBreaker) allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitOpen {
+		if GetClock().Now().Sub(cb.openedAt) < cb.settings.OpenTimeout {
+			return &ErrCircuitOpen{Provider: cb.provider, RetryAt: cb.openedAt.Add(cb.settings.OpenTimeout)}
+		}
+		cb.state = CircuitHalfOpen
+	}
+	if cb.state == CircuitHalfOpen {
+		if cb.probing {
+			return &ErrCircuitOpen{Provider: cb.provider, RetryAt: GetClock().Now().Add(cb.settings.OpenTimeout)}
+		}
+		cb.probing = true
+	}
+	return nil
+}
+
+// release gives up a probe without recording an outcome.
+func (cb *CircuitBreaker) release() {
+	cb.mu.Lock()
+	cb.probing = false
+	cb.mu.Unlock()
+}
+
+func (cb *CircuitBreaker) record(success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probing = false
+	if success {
+		cb.state = CircuitClosed
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.settings.FailureThreshold {
+		if cb.state != CircuitOpen {
+			GetLogger().Warn("circuit breaker opened", "provider", cb.provider, "failures", cb.failures)
+		}
+		cb.state = CircuitOpen
+		cb.openedAt = GetClock().Now()
+	}
+}
+
+// Transport returns an http.RoundTripper guarded by the breaker. If next is
+// nil http.DefaultTransport is used.
+func (cb *CircuitBreaker) Transport(next http.RoundTripper) http.RoundTripper {
+	return &breakerTransport{cb: cb, next: next}
+}
+
+type breakerTransport struct {
+	cb   *CircuitBreaker
+	next http.RoundTripper
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if err := t.cb.allow(); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	res, err := next.RoundTrip(req)
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		// the caller gave up, that says nothing about the provider
+		t.cb.release()
+	case err != nil:
+		t.cb.record(false)
+	default:
+		t.cb.record(res.StatusCode < 500)
+	}
+	return res, err
+}
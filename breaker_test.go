@@ -0,0 +1,64 @@
+package goth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var healthy int32
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	cb := goth.NewCircuitBreaker("faux", goth.CircuitBreakerSettings{
+		FailureThreshold: 2,
+		OpenTimeout:      20 * time.Millisecond,
+	})
+	client := &http.Client{Transport: cb.Transport(nil)}
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(ts.URL)
+		a.NoError(err)
+		res.Body.Close()
+	}
+	a.Equal(goth.CircuitOpen, cb.State())
+
+	_, err := client.Get(ts.URL)
+	var open *goth.ErrCircuitOpen
+	a.True(errors.As(err, &open))
+	a.Equal("faux", open.Provider)
+	a.Equal(int32(2), atomic.LoadInt32(&calls))
+
+	time.Sleep(25 * time.Millisecond)
+	a.Equal(goth.CircuitHalfOpen, cb.State())
+
+	// a failing probe opens the breaker again straight away
+	res, err := client.Get(ts.URL)
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal(goth.CircuitOpen, cb.State())
+
+	time.Sleep(25 * time.Millisecond)
+	atomic.StoreInt32(&healthy, 1)
+	res, err = client.Get(ts.URL)
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal(http.StatusOK, res.StatusCode)
+	a.Equal(goth.CircuitClosed, cb.State())
+}
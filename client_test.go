@@ -0,0 +1,147 @@
+package goth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HTTPClientWithFallBack(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal(goth.DefaultHTTPClient, goth.HTTPClientWithFallBack(nil))
+	a.True(goth.DefaultHTTPClient == goth.HTTPClientWithFallBack(nil))
+	a.NotZero(goth.HTTPClientWithFallBack(nil).Timeout)
+
+	c := &http.Client{}
+	a.Equal(c, goth.HTTPClientWithFallBack(c))
+}
+
+func Test_NewHTTPClient(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	c := goth.NewHTTPClient(goth.HTTPClientOptions{
+		Timeout:             time.Second,
+		MaxIdleConnsPerHost: 42,
+	})
+	a.Equal(time.Second, c.Timeout)
+	tr := c.Transport.(*http.Transport)
+	a.Equal(42, tr.MaxIdleConnsPerHost)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	c = goth.NewHTTPClient(goth.HTTPClientOptions{ResponseHeaderTimeout: 10 * time.Millisecond})
+	_, err := c.Get(ts.URL)
+	a.Error(err)
+}
+
+func Test_NewHTTPClient_Proxy(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var host, auth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.URL.Host
+		auth = r.Header.Get("Proxy-Authorization")
+	}))
+	defer proxy.Close()
+
+	u, _ := url.Parse(proxy.URL)
+	u.User = url.UserPassword("user", "pass")
+	c := goth.NewHTTPClient(goth.HTTPClientOptions{Proxy: u})
+	res, err := c.Get("http://idp.example.com/token")
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal("idp.example.com", host)
+	a.Equal("Basic dXNlcjpwYXNz", auth)
+
+	c = goth.NewHTTPClient(goth.HTTPClientOptions{NoProxy: true})
+	a.Nil(c.Transport.(*http.Transport).Proxy)
+}
+
+func headerMiddleware(name, value string) goth.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Add(name, value)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+func Test_Middleware(t *testing.T) {
+	a := assert.New(t)
+
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer ts.Close()
+
+	goth.UseMiddleware(headerMiddleware("X-Order", "global"))
+	defer goth.ClearMiddleware()
+
+	c := goth.NewHTTPClient(goth.HTTPClientOptions{
+		Middleware: []goth.Middleware{headerMiddleware("X-Order", "provider")},
+	})
+	res, err := goth.HTTPClientWithFallBack(c).Get(ts.URL)
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal([]string{"global", "provider"}, got["X-Order"])
+
+	res, err = goth.HTTPClientWithFallBack(nil).Get(ts.URL)
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal([]string{"global"}, got["X-Order"])
+
+	// the configured clients themselves are left untouched
+	a.Nil(goth.DefaultHTTPClient.Transport)
+}
+
+func Test_Middleware_KeepsState(t *testing.T) {
+	a := assert.New(t)
+
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	// a middleware creating its breaker when it wraps a transport only trips
+	// if clients are wrapped once
+	goth.UseMiddleware(func(next http.RoundTripper) http.RoundTripper {
+		return goth.NewCircuitBreaker("test", goth.CircuitBreakerSettings{FailureThreshold: 2, OpenTimeout: time.Hour}).Transport(next)
+	})
+	defer goth.ClearMiddleware()
+
+	c := &http.Client{}
+	for i := 0; i < 2; i++ {
+		res, err := goth.HTTPClientWithFallBack(c).Get(ts.URL)
+		a.NoError(err)
+		res.Body.Close()
+	}
+	_, err := goth.HTTPClientWithFallBack(c).Get(ts.URL)
+	var open *goth.ErrCircuitOpen
+	a.True(errors.As(err, &open))
+	a.Equal(2, calls)
+
+	// clients are wrapped again once the middleware changes
+	goth.ClearMiddleware()
+	res, err := goth.HTTPClientWithFallBack(c).Get(ts.URL)
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal(3, calls)
+}
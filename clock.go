@@ -0,0 +1,40 @@
+package goth
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells goth and gothic the time. It is consulted for the expiry of
+// cached users, key sets, sessions and refreshed tokens, the state of circuit
+// breakers and the refresh schedule, so tests can simulate the passing of
+// time instead of sleeping. See gothtest.Clock for a clock that only moves
+// when told to.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+var clockLock sync.RWMutex
+var clock Clock = systemClock{}
+
+// UseClock sets the Clock used by goth and gothic. Passing nil restores the
+// system clock.
+func UseClock(c Clock) {
+	if c == nil {
+		c = systemClock{}
+	}
+	clockLock.Lock()
+	clock = c
+	clockLock.Unlock()
+}
+
+// GetClock returns the Clock currently in use.
+func GetClock() Clock {
+	clockLock.RLock()
+	defer clockLock.RUnlock()
+	return clock
+}
@@ -0,0 +1,265 @@
+/*
+Command goth runs the login flow of a goth provider from the terminal, for
+debugging provider configurations. It listens for the callback on a local
+address, prints the URL to open in a browser and, once the provider redirects
+back, prints the user and the claims of its ID token:
+
+	goth -provider github -key $GITHUB_KEY -secret $GITHUB_SECRET
+
+The key and secret default to the <PROVIDER>_KEY and <PROVIDER>_SECRET
+environment variables used by the examples. Register
+http://localhost:8085/callback (or the address given with -addr) as the
+callback URL of the client. With -refresh the refresh token is exchanged for a
+new access token, and with -revoke the tokens are revoked afterwards.
+
+Tokens are redacted in the output unless -show-tokens is given.
+*/
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/markbates/goth"
+)
+
+type options struct {
+	Provider   string
+	Key        string
+	Secret     string
+	Issuer     string
+	Scopes     string
+	Addr       string
+	Timeout    time.Duration
+	Refresh    bool
+	Revoke     bool
+	ShowTokens bool
+}
+
+func main() {
+	opts := options{}
+	fs := flag.NewFlagSet("goth", flag.ExitOnError)
+	fs.StringVar(&opts.Provider, "provider", "", "provider to log in with: "+providerNames())
+	fs.StringVar(&opts.Key, "key", "", "client key, defaults to $<PROVIDER>_KEY")
+	fs.StringVar(&opts.Secret, "secret", "", "client secret, defaults to $<PROVIDER>_SECRET")
+	fs.StringVar(&opts.Issuer, "issuer", "", "Auth0 domain, Okta org URL or OpenID Connect discovery URL")
+	fs.StringVar(&opts.Scopes, "scopes", "", "comma separated scopes to request")
+	fs.StringVar(&opts.Addr, "addr", "localhost:8085", "address to listen on for the callback")
+	fs.DurationVar(&opts.Timeout, "timeout", 5*time.Minute, "how long to wait for the login")
+	fs.BoolVar(&opts.Refresh, "refresh", false, "refresh the access token after logging in")
+	fs.BoolVar(&opts.Revoke, "revoke", false, "revoke the tokens when done")
+	fs.BoolVar(&opts.ShowTokens, "show-tokens", false, "print tokens instead of redacting them")
+	fs.Parse(os.Args[1:])
+
+	if err := realMain(opts); err != nil {
+		fmt.Fprintln(os.Stderr, "goth:", err)
+		os.Exit(1)
+	}
+}
+
+func realMain(opts options) error {
+	newProvider, ok := constructors[opts.Provider]
+	if !ok {
+		return fmt.Errorf("unknown provider %q, choose one of %s", opts.Provider, providerNames())
+	}
+	if opts.Key == "" {
+		opts.Key = os.Getenv(envName(opts.Provider) + "_KEY")
+	}
+	if opts.Secret == "" {
+		opts.Secret = os.Getenv(envName(opts.Provider) + "_SECRET")
+	}
+	var scopes []string
+	if opts.Scopes != "" {
+		scopes = strings.Split(opts.Scopes, ",")
+	}
+
+	ln, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return err
+	}
+	p, err := newProvider(opts.Key, opts.Secret, "http://"+opts.Addr+"/callback", opts.Issuer, scopes)
+	if err != nil {
+		ln.Close()
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	return run(ctx, opts, p, ln, os.Stdout, func(authURL string) {
+		fmt.Fprintf(os.Stderr, "Open this URL in your browser to log in with %s:\n\n  %s\n\n", p.Name(), authURL)
+	})
+}
+
+type result struct {
+	user goth.User
+	err  error
+}
+
+// run performs the login flow, serving the callback on ln. visit is called
+// with the auth URL the user has to open.
+func run(ctx context.Context, opts options, p goth.Provider, ln net.Listener, out io.Writer, visit func(authURL string)) error {
+	state, err := randomState()
+	if err != nil {
+		return err
+	}
+	sess, err := p.BeginAuth(state)
+	if err != nil {
+		return err
+	}
+	authURL, err := sess.GetAuthURL()
+	if err != nil {
+		return err
+	}
+
+	results := make(chan result, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		user, err := complete(r, p, sess, state)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Logged in, you can close this window.")
+		}
+		select {
+		case results <- result{user, err}:
+		default:
+		}
+	})
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	visit(authURL)
+
+	var res result
+	select {
+	case res = <-results:
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for the callback: %v", ctx.Err())
+	}
+	if res.err != nil {
+		return res.err
+	}
+
+	user := res.user
+	printJSON(out, "User", redactUser(user, opts.ShowTokens))
+	if claims, err := idTokenClaims(user.IDToken); err == nil {
+		printJSON(out, "ID token claims", claims)
+	}
+
+	if opts.Refresh {
+		if err := refresh(ctx, opts, p, &user, out); err != nil {
+			return err
+		}
+	}
+	if opts.Revoke {
+		token := user.RefreshToken
+		if token == "" {
+			token = user.AccessToken
+		}
+		if err := goth.RevokeToken(ctx, p, token); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Tokens revoked")
+	}
+	return nil
+}
+
+// complete handles the callback the way gothic.CompleteUserAuth does.
+func complete(r *http.Request, p goth.Provider, sess goth.Session, state string) (goth.User, error) {
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		return goth.User{}, fmt.Errorf("provider returned error %s: %s", e, q.Get("error_description"))
+	}
+	if q.Get("state") != state {
+		return goth.User{}, errors.New("state token mismatch")
+	}
+	if _, err := goth.Authorize(r.Context(), sess, p, q); err != nil {
+		return goth.User{}, fmt.Errorf("exchanging the code: %v", err)
+	}
+	user, err := goth.FetchUser(r.Context(), p, sess)
+	if err != nil {
+		return user, fmt.Errorf("fetching the user: %v", err)
+	}
+	return user, nil
+}
+
+func refresh(ctx context.Context, opts options, p goth.Provider, user *goth.User, out io.Writer) error {
+	if !p.RefreshTokenAvailable() {
+		fmt.Fprintf(out, "%s does not support refreshing tokens\n", p.Name())
+		return nil
+	}
+	if user.RefreshToken == "" {
+		fmt.Fprintln(out, "No refresh token was issued, you may need to request offline access")
+		return nil
+	}
+	token, err := goth.RefreshTokenContext(ctx, p, user.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("refreshing the token: %v", err)
+	}
+	if token.RefreshToken != "" {
+		user.RefreshToken = token.RefreshToken
+	}
+	printJSON(out, "Refreshed token", map[string]interface{}{
+		"access_token":  redact(token.AccessToken, opts.ShowTokens),
+		"refresh_token": redact(token.RefreshToken, opts.ShowTokens),
+		"expiry":        token.Expiry,
+	})
+	return nil
+}
+
+func redact(token string, show bool) string {
+	if show || token == "" {
+		return token
+	}
+	return goth.RedactedValue
+}
+
+func redactUser(u goth.User, show bool) goth.User {
+	u.AccessToken = redact(u.AccessToken, show)
+	u.AccessTokenSecret = redact(u.AccessTokenSecret, show)
+	u.RefreshToken = redact(u.RefreshToken, show)
+	u.IDToken = redact(u.IDToken, show)
+	u.RawJSON = nil
+	return u
+}
+
+// idTokenClaims decodes the claims of a JWT without verifying it; the provider
+// has done that already.
+func idTokenClaims(idToken string) (map[string]interface{}, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("not a JWT")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	claims := map[string]interface{}{}
+	return claims, json.Unmarshal(b, &claims)
+}
+
+func printJSON(out io.Writer, title string, v interface{}) {
+	b, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Fprintf(out, "%s:\n%s\n", title, b)
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
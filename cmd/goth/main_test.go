@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Run(t *testing.T) {
+	a := assert.New(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	a.NoError(err)
+
+	p := gothtest.New("fake", "http://"+ln.Addr().String()+"/callback")
+	p.AddUser("homer", goth.User{UserID: "1", Email: "homer@example.com"})
+	idp := gothtest.NewIdP(p)
+	defer idp.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out := &bytes.Buffer{}
+	err = run(ctx, options{Refresh: true, Revoke: true}, p, ln, out, func(authURL string) {
+		// play the browser: the IdP redirects straight back to the callback
+		go func() {
+			res, err := http.Get(authURL)
+			if err == nil {
+				res.Body.Close()
+			}
+		}()
+	})
+	a.NoError(err)
+	a.Contains(out.String(), "homer@example.com")
+	a.Contains(out.String(), "Refreshed token")
+	a.Contains(out.String(), "Tokens revoked")
+	a.NotContains(out.String(), gothtest.AccessToken("homer"))
+}
+
+func Test_IDTokenClaims(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	claims, err := idTokenClaims("eyJhbGciOiJub25lIn0.eyJzdWIiOiIxMjMifQ.")
+	a.NoError(err)
+	a.Equal("123", claims["sub"])
+
+	_, err = idTokenClaims("")
+	a.Error(err)
+}
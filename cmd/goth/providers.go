@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/auth0"
+	"github.com/markbates/goth/providers/bitbucket"
+	"github.com/markbates/goth/providers/discord"
+	"github.com/markbates/goth/providers/facebook"
+	"github.com/markbates/goth/providers/gitea"
+	"github.com/markbates/goth/providers/github"
+	"github.com/markbates/goth/providers/gitlab"
+	"github.com/markbates/goth/providers/google"
+	"github.com/markbates/goth/providers/linkedin"
+	"github.com/markbates/goth/providers/microsoftonline"
+	"github.com/markbates/goth/providers/okta"
+	"github.com/markbates/goth/providers/openidConnect"
+	"github.com/markbates/goth/providers/slack"
+	"github.com/markbates/goth/providers/spotify"
+	"github.com/markbates/goth/providers/twitch"
+)
+
+// constructors builds the providers the CLI knows about. issuer is the Auth0
+// domain, Okta org URL or OpenID Connect discovery URL for the providers that
+// need one.
+var constructors = map[string]func(key, secret, callbackURL, issuer string, scopes []string) (goth.Provider, error){
+	"auth0": func(key, secret, callbackURL, issuer string, scopes []string) (goth.Provider, error) {
+		if issuer == "" {
+			return nil, fmt.Errorf("auth0 needs -issuer set to the Auth0 domain")
+		}
+		return auth0.New(key, secret, callbackURL, issuer, scopes...), nil
+	},
+	"bitbucket": func(key, secret, callbackURL, _ string, scopes []string) (goth.Provider, error) {
+		return bitbucket.New(key, secret, callbackURL, scopes...), nil
+	},
+	"discord": func(key, secret, callbackURL, _ string, scopes []string) (goth.Provider, error) {
+		if len(scopes) == 0 {
+			scopes = []string{discord.ScopeIdentify, discord.ScopeEmail}
+		}
+		return discord.New(key, secret, callbackURL, scopes...), nil
+	},
+	"facebook": func(key, secret, callbackURL, _ string, scopes []string) (goth.Provider, error) {
+		return facebook.New(key, secret, callbackURL, scopes...), nil
+	},
+	"gitea": func(key, secret, callbackURL, _ string, scopes []string) (goth.Provider, error) {
+		return gitea.New(key, secret, callbackURL, scopes...), nil
+	},
+	"github": func(key, secret, callbackURL, _ string, scopes []string) (goth.Provider, error) {
+		return github.New(key, secret, callbackURL, scopes...), nil
+	},
+	"gitlab": func(key, secret, callbackURL, _ string, scopes []string) (goth.Provider, error) {
+		return gitlab.New(key, secret, callbackURL, scopes...), nil
+	},
+	"google": func(key, secret, callbackURL, _ string, scopes []string) (goth.Provider, error) {
+		return google.New(key, secret, callbackURL, scopes...), nil
+	},
+	"linkedin": func(key, secret, callbackURL, _ string, scopes []string) (goth.Provider, error) {
+		return linkedin.New(key, secret, callbackURL, scopes...), nil
+	},
+	"microsoftonline": func(key, secret, callbackURL, _ string, scopes []string) (goth.Provider, error) {
+		return microsoftonline.New(key, secret, callbackURL, scopes...), nil
+	},
+	"okta": func(key, secret, callbackURL, issuer string, scopes []string) (goth.Provider, error) {
+		if issuer == "" {
+			return nil, fmt.Errorf("okta needs -issuer set to the Okta org URL")
+		}
+		if len(scopes) == 0 {
+			scopes = []string{"openid", "profile", "email"}
+		}
+		return okta.New(key, secret, issuer, callbackURL, scopes...), nil
+	},
+	"openid-connect": func(key, secret, callbackURL, issuer string, scopes []string) (goth.Provider, error) {
+		if issuer == "" {
+			return nil, fmt.Errorf("openid-connect needs -issuer set to the discovery URL")
+		}
+		p, err := openidConnect.New(key, secret, callbackURL, issuer, scopes...)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	},
+	"slack": func(key, secret, callbackURL, _ string, scopes []string) (goth.Provider, error) {
+		return slack.New(key, secret, callbackURL, scopes...), nil
+	},
+	"spotify": func(key, secret, callbackURL, _ string, scopes []string) (goth.Provider, error) {
+		return spotify.New(key, secret, callbackURL, scopes...), nil
+	},
+	"twitch": func(key, secret, callbackURL, _ string, scopes []string) (goth.Provider, error) {
+		return twitch.New(key, secret, callbackURL, scopes...), nil
+	},
+}
+
+func providerNames() string {
+	names := make([]string, 0, len(constructors))
+	for name := range constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// envName returns the environment variable prefix of a provider, following
+// the examples: GITHUB for github, OPENID_CONNECT for openid-connect.
+func envName(provider string) string {
+	return strings.ToUpper(strings.Replace(provider, "-", "_", -1))
+}
@@ -0,0 +1,64 @@
+package goth
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// ContextAuthorizer can optionally be implemented by sessions whose token
+// exchange can be bound to a context, so that the deadline and cancellation of
+// the incoming request carry over to the call to the provider.
+type ContextAuthorizer interface {
+	AuthorizeContext(ctx context.Context, provider Provider, params Params) (string, error)
+}
+
+// ContextUserFetcher can optionally be implemented by providers whose
+// FetchUser can be bound to a context.
+type ContextUserFetcher interface {
+	FetchUserContext(ctx context.Context, session Session) (User, error)
+}
+
+// ContextTokenRefresher can optionally be implemented by providers whose
+// RefreshToken can be bound to a context.
+type ContextTokenRefresher interface {
+	RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error)
+}
+
+// ContextWithClient returns a child of ctx that makes oauth2 use h for its
+// requests. If h is nil ctx is returned unchanged.
+func ContextWithClient(ctx context.Context, h *http.Client) context.Context {
+	if h == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, h)
+}
+
+// Authorize authorizes the session, passing ctx on to the provider if the
+// session implements ContextAuthorizer. Failures are logged at debug level.
+func Authorize(ctx context.Context, session Session, provider Provider, params Params) (string, error) {
+	var token string
+	var err error
+	if ca, ok := session.(ContextAuthorizer); ok {
+		token, err = ca.AuthorizeContext(ctx, provider, params)
+	} else {
+		token, err = session.Authorize(provider, params)
+	}
+	logProviderError("failed to authorize session", provider, err)
+	return token, err
+}
+
+// FetchUser fetches the user from the provider, passing ctx on if the
+// provider implements ContextUserFetcher. Failures are logged at debug level.
+func FetchUser(ctx context.Context, provider Provider, session Session) (User, error) {
+	var user User
+	var err error
+	if cf, ok := provider.(ContextUserFetcher); ok {
+		user, err = cf.FetchUserContext(ctx, session)
+	} else {
+		user, err = provider.FetchUser(session)
+	}
+	logProviderError("failed to fetch user", provider, err)
+	return user, err
+}
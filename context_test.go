@@ -0,0 +1,57 @@
+package goth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/faux"
+	"github.com/markbates/goth/providers/github"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func Test_ContextWithClient(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	type key struct{}
+	parent := context.WithValue(context.Background(), key{}, "v")
+	a.Equal(parent, goth.ContextWithClient(parent, nil))
+
+	ctx := goth.ContextWithClient(parent, goth.DefaultHTTPClient)
+	a.Equal("v", ctx.Value(key{}))
+	a.Equal(goth.DefaultHTTPClient, ctx.Value(oauth2.HTTPClient))
+}
+
+func Test_FetchUser_Context(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 1, "login": "homer"}`))
+	}))
+	defer ts.Close()
+
+	p := github.NewCustomisedURL("key", "secret", "/callback", ts.URL, ts.URL, ts.URL, ts.URL)
+	sess := &github.Session{AccessToken: "token"}
+
+	user, err := goth.FetchUser(context.Background(), p, sess)
+	a.NoError(err)
+	a.Equal("homer", user.NickName)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = goth.FetchUser(ctx, p, sess)
+	a.Error(err)
+	_, err = goth.Authorize(ctx, &github.Session{}, p, url.Values{"code": {"code"}})
+	a.Error(err)
+
+	// providers without context support are called as before
+	user, err = goth.FetchUser(ctx, &faux.Provider{}, &faux.Session{ID: "1", AccessToken: "token"})
+	a.NoError(err)
+	a.Equal("1", user.UserID)
+}
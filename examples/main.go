@@ -11,13 +11,23 @@ import (
 	"github.com/gorilla/pat"
 	"github.com/markbates/goth"
 	"github.com/markbates/goth/gothic"
+	"github.com/markbates/goth/providers/airtable"
 	"github.com/markbates/goth/providers/amazon"
 	"github.com/markbates/goth/providers/apple"
+	"github.com/markbates/goth/providers/asana"
+	"github.com/markbates/goth/providers/atlassian"
 	"github.com/markbates/goth/providers/auth0"
+	"github.com/markbates/goth/providers/authelia"
+	"github.com/markbates/goth/providers/authentik"
 	"github.com/markbates/goth/providers/azuread"
+	"github.com/markbates/goth/providers/azureadb2c"
+	"github.com/markbates/goth/providers/basecamp"
 	"github.com/markbates/goth/providers/battlenet"
 	"github.com/markbates/goth/providers/bitbucket"
+	"github.com/markbates/goth/providers/bitbucketdatacenter"
 	"github.com/markbates/goth/providers/box"
+	"github.com/markbates/goth/providers/clickup"
+	"github.com/markbates/goth/providers/cognito"
 	"github.com/markbates/goth/providers/dailymotion"
 	"github.com/markbates/goth/providers/deezer"
 	"github.com/markbates/goth/providers/digitalocean"
@@ -25,39 +35,58 @@ import (
 	"github.com/markbates/goth/providers/dropbox"
 	"github.com/markbates/goth/providers/eveonline"
 	"github.com/markbates/goth/providers/facebook"
+	"github.com/markbates/goth/providers/figma"
 	"github.com/markbates/goth/providers/fitbit"
+	"github.com/markbates/goth/providers/fusionauth"
 	"github.com/markbates/goth/providers/gitea"
+	"github.com/markbates/goth/providers/gitee"
 	"github.com/markbates/goth/providers/github"
 	"github.com/markbates/goth/providers/gitlab"
 	"github.com/markbates/goth/providers/google"
 	"github.com/markbates/goth/providers/gplus"
 	"github.com/markbates/goth/providers/heroku"
+	"github.com/markbates/goth/providers/hubspot"
 	"github.com/markbates/goth/providers/instagram"
 	"github.com/markbates/goth/providers/intercom"
+	"github.com/markbates/goth/providers/intuit"
+	"github.com/markbates/goth/providers/jumpcloud"
 	"github.com/markbates/goth/providers/kakao"
+	"github.com/markbates/goth/providers/keycloak"
 	"github.com/markbates/goth/providers/lastfm"
 	"github.com/markbates/goth/providers/line"
+	"github.com/markbates/goth/providers/linear"
 	"github.com/markbates/goth/providers/linkedin"
 	"github.com/markbates/goth/providers/mastodon"
 	"github.com/markbates/goth/providers/meetup"
 	"github.com/markbates/goth/providers/microsoftonline"
+	"github.com/markbates/goth/providers/miro"
+	"github.com/markbates/goth/providers/monday"
 	"github.com/markbates/goth/providers/naver"
 	"github.com/markbates/goth/providers/nextcloud"
+	"github.com/markbates/goth/providers/notion"
 	"github.com/markbates/goth/providers/okta"
 	"github.com/markbates/goth/providers/onedrive"
+	"github.com/markbates/goth/providers/onelogin"
 	"github.com/markbates/goth/providers/openidConnect"
+	"github.com/markbates/goth/providers/ory"
 	"github.com/markbates/goth/providers/patreon"
 	"github.com/markbates/goth/providers/paypal"
+	"github.com/markbates/goth/providers/pingone"
 	"github.com/markbates/goth/providers/salesforce"
+	"github.com/markbates/goth/providers/sap"
 	"github.com/markbates/goth/providers/seatalk"
+	"github.com/markbates/goth/providers/servicenow"
 	"github.com/markbates/goth/providers/shopify"
 	"github.com/markbates/goth/providers/slack"
 	"github.com/markbates/goth/providers/soundcloud"
+	"github.com/markbates/goth/providers/sourcehut"
 	"github.com/markbates/goth/providers/spotify"
 	"github.com/markbates/goth/providers/steam"
 	"github.com/markbates/goth/providers/strava"
 	"github.com/markbates/goth/providers/stripe"
 	"github.com/markbates/goth/providers/tiktok"
+	"github.com/markbates/goth/providers/todoist"
+	"github.com/markbates/goth/providers/trello"
 	"github.com/markbates/goth/providers/twitch"
 	"github.com/markbates/goth/providers/twitter"
 	"github.com/markbates/goth/providers/twitterv2"
@@ -66,10 +95,16 @@ import (
 	"github.com/markbates/goth/providers/vk"
 	"github.com/markbates/goth/providers/wecom"
 	"github.com/markbates/goth/providers/wepay"
+	"github.com/markbates/goth/providers/workday"
+	"github.com/markbates/goth/providers/workos"
+	"github.com/markbates/goth/providers/x"
 	"github.com/markbates/goth/providers/xero"
 	"github.com/markbates/goth/providers/yahoo"
 	"github.com/markbates/goth/providers/yammer"
 	"github.com/markbates/goth/providers/yandex"
+	"github.com/markbates/goth/providers/zendesk"
+	"github.com/markbates/goth/providers/zitadel"
+	"github.com/markbates/goth/providers/zoho"
 	"github.com/markbates/goth/providers/zoom"
 )
 
@@ -82,6 +117,8 @@ func main() {
 		// twitterv2.NewAuthenticate(os.Getenv("TWITTER_KEY"), os.Getenv("TWITTER_SECRET"), "http://localhost:3000/auth/twitterv2/callback"),
 
 		twitter.New(os.Getenv("TWITTER_KEY"), os.Getenv("TWITTER_SECRET"), "http://localhost:3000/auth/twitter/callback"),
+		// x uses OAuth 2.0 client credentials rather than the OAuth 1.0a keys of the twitter providers
+		x.New(os.Getenv("X_KEY"), os.Getenv("X_SECRET"), "http://localhost:3000/auth/x/callback"),
 		// If you'd like to use authenticate instead of authorize in Twitter provider, use this instead.
 		// twitter.NewAuthenticate(os.Getenv("TWITTER_KEY"), os.Getenv("TWITTER_SECRET"), "http://localhost:3000/auth/twitter/callback"),
 
@@ -105,13 +142,16 @@ func main() {
 		salesforce.New(os.Getenv("SALESFORCE_KEY"), os.Getenv("SALESFORCE_SECRET"), "http://localhost:3000/auth/salesforce/callback"),
 		seatalk.New(os.Getenv("SEATALK_KEY"), os.Getenv("SEATALK_SECRET"), "http://localhost:3000/auth/seatalk/callback"),
 		amazon.New(os.Getenv("AMAZON_KEY"), os.Getenv("AMAZON_SECRET"), "http://localhost:3000/auth/amazon/callback"),
+		cognito.New(os.Getenv("COGNITO_KEY"), os.Getenv("COGNITO_SECRET"), "http://localhost:3000/auth/cognito/callback", os.Getenv("COGNITO_DOMAIN")),
 		yammer.New(os.Getenv("YAMMER_KEY"), os.Getenv("YAMMER_SECRET"), "http://localhost:3000/auth/yammer/callback"),
 		onedrive.New(os.Getenv("ONEDRIVE_KEY"), os.Getenv("ONEDRIVE_SECRET"), "http://localhost:3000/auth/onedrive/callback"),
 		azuread.New(os.Getenv("AZUREAD_KEY"), os.Getenv("AZUREAD_SECRET"), "http://localhost:3000/auth/azuread/callback", nil),
+		azureadb2c.New(os.Getenv("AZUREADB2C_KEY"), os.Getenv("AZUREADB2C_SECRET"), "http://localhost:3000/auth/azureadb2c/callback", os.Getenv("AZUREADB2C_TENANT"), os.Getenv("AZUREADB2C_POLICY"), azureadb2c.ProviderOptions{}),
 		microsoftonline.New(os.Getenv("MICROSOFTONLINE_KEY"), os.Getenv("MICROSOFTONLINE_SECRET"), "http://localhost:3000/auth/microsoftonline/callback"),
 		battlenet.New(os.Getenv("BATTLENET_KEY"), os.Getenv("BATTLENET_SECRET"), "http://localhost:3000/auth/battlenet/callback"),
 		eveonline.New(os.Getenv("EVEONLINE_KEY"), os.Getenv("EVEONLINE_SECRET"), "http://localhost:3000/auth/eveonline/callback"),
 		kakao.New(os.Getenv("KAKAO_KEY"), os.Getenv("KAKAO_SECRET"), "http://localhost:3000/auth/kakao/callback"),
+		keycloak.New(os.Getenv("KEYCLOAK_KEY"), os.Getenv("KEYCLOAK_SECRET"), "http://localhost:3000/auth/keycloak/callback", os.Getenv("KEYCLOAK_URL"), os.Getenv("KEYCLOAK_REALM")),
 
 		// Pointed localhost.com to http://localhost:3000/auth/yahoo/callback through proxy as yahoo
 		// does not allow to put custom ports in redirection uri
@@ -145,6 +185,36 @@ func main() {
 		apple.New(os.Getenv("APPLE_KEY"), os.Getenv("APPLE_SECRET"), "http://localhost:3000/auth/apple/callback", nil, apple.ScopeName, apple.ScopeEmail),
 		strava.New(os.Getenv("STRAVA_KEY"), os.Getenv("STRAVA_SECRET"), "http://localhost:3000/auth/strava/callback"),
 		okta.New(os.Getenv("OKTA_ID"), os.Getenv("OKTA_SECRET"), os.Getenv("OKTA_ORG_URL"), "http://localhost:3000/auth/okta/callback", "openid", "profile", "email"),
+		onelogin.New(os.Getenv("ONELOGIN_KEY"), os.Getenv("ONELOGIN_SECRET"), "http://localhost:3000/auth/onelogin/callback", os.Getenv("ONELOGIN_SUBDOMAIN")),
+		pingone.New(os.Getenv("PINGONE_KEY"), os.Getenv("PINGONE_SECRET"), "http://localhost:3000/auth/pingone/callback", pingone.RegionNorthAmerica, os.Getenv("PINGONE_ENVIRONMENT_ID")),
+		jumpcloud.New(os.Getenv("JUMPCLOUD_KEY"), os.Getenv("JUMPCLOUD_SECRET"), "http://localhost:3000/auth/jumpcloud/callback"),
+		zitadel.New(os.Getenv("ZITADEL_KEY"), os.Getenv("ZITADEL_SECRET"), "http://localhost:3000/auth/zitadel/callback", os.Getenv("ZITADEL_ISSUER")),
+		authentik.New(os.Getenv("AUTHENTIK_KEY"), os.Getenv("AUTHENTIK_SECRET"), "http://localhost:3000/auth/authentik/callback", os.Getenv("AUTHENTIK_URL"), os.Getenv("AUTHENTIK_SLUG")),
+		authelia.New(os.Getenv("AUTHELIA_KEY"), os.Getenv("AUTHELIA_SECRET"), "http://localhost:3000/auth/authelia/callback", os.Getenv("AUTHELIA_URL")),
+		fusionauth.New(os.Getenv("FUSIONAUTH_KEY"), os.Getenv("FUSIONAUTH_SECRET"), "http://localhost:3000/auth/fusionauth/callback", os.Getenv("FUSIONAUTH_URL")),
+		ory.New(os.Getenv("ORY_KEY"), os.Getenv("ORY_SECRET"), "http://localhost:3000/auth/ory/callback", os.Getenv("ORY_ISSUER")),
+		gitee.New(os.Getenv("GITEE_KEY"), os.Getenv("GITEE_SECRET"), "http://localhost:3000/auth/gitee/callback"),
+		sourcehut.New(os.Getenv("SOURCEHUT_KEY"), os.Getenv("SOURCEHUT_SECRET"), "http://localhost:3000/auth/sourcehut/callback"),
+		bitbucketdatacenter.New(os.Getenv("BITBUCKET_DC_KEY"), os.Getenv("BITBUCKET_DC_SECRET"), "http://localhost:3000/auth/bitbucketdatacenter/callback", os.Getenv("BITBUCKET_DC_URL")),
+		notion.New(os.Getenv("NOTION_KEY"), os.Getenv("NOTION_SECRET"), "http://localhost:3000/auth/notion/callback"),
+		figma.New(os.Getenv("FIGMA_KEY"), os.Getenv("FIGMA_SECRET"), "http://localhost:3000/auth/figma/callback"),
+		miro.New(os.Getenv("MIRO_KEY"), os.Getenv("MIRO_SECRET"), "http://localhost:3000/auth/miro/callback"),
+		asana.New(os.Getenv("ASANA_KEY"), os.Getenv("ASANA_SECRET"), "http://localhost:3000/auth/asana/callback"),
+		trello.New(os.Getenv("TRELLO_KEY"), os.Getenv("TRELLO_SECRET"), "http://localhost:3000/auth/trello/callback"),
+		monday.New(os.Getenv("MONDAY_KEY"), os.Getenv("MONDAY_SECRET"), "http://localhost:3000/auth/monday/callback"),
+		airtable.New(os.Getenv("AIRTABLE_KEY"), os.Getenv("AIRTABLE_SECRET"), "http://localhost:3000/auth/airtable/callback"),
+		linear.New(os.Getenv("LINEAR_KEY"), os.Getenv("LINEAR_SECRET"), "http://localhost:3000/auth/linear/callback"),
+		clickup.New(os.Getenv("CLICKUP_KEY"), os.Getenv("CLICKUP_SECRET"), "http://localhost:3000/auth/clickup/callback"),
+		todoist.New(os.Getenv("TODOIST_KEY"), os.Getenv("TODOIST_SECRET"), "http://localhost:3000/auth/todoist/callback"),
+		basecamp.New(os.Getenv("BASECAMP_KEY"), os.Getenv("BASECAMP_SECRET"), "http://localhost:3000/auth/basecamp/callback"),
+		atlassian.New(os.Getenv("ATLASSIAN_KEY"), os.Getenv("ATLASSIAN_SECRET"), "http://localhost:3000/auth/atlassian/callback"),
+		zendesk.New(os.Getenv("ZENDESK_KEY"), os.Getenv("ZENDESK_SECRET"), "http://localhost:3000/auth/zendesk/callback", os.Getenv("ZENDESK_SUBDOMAIN")),
+		hubspot.New(os.Getenv("HUBSPOT_KEY"), os.Getenv("HUBSPOT_SECRET"), "http://localhost:3000/auth/hubspot/callback"),
+		intuit.New(os.Getenv("INTUIT_KEY"), os.Getenv("INTUIT_SECRET"), "http://localhost:3000/auth/intuit/callback"),
+		zoho.New(os.Getenv("ZOHO_KEY"), os.Getenv("ZOHO_SECRET"), "http://localhost:3000/auth/zoho/callback"),
+		servicenow.New(os.Getenv("SERVICENOW_KEY"), os.Getenv("SERVICENOW_SECRET"), "http://localhost:3000/auth/servicenow/callback", os.Getenv("SERVICENOW_INSTANCE_URL")),
+		workday.New(os.Getenv("WORKDAY_KEY"), os.Getenv("WORKDAY_SECRET"), "http://localhost:3000/auth/workday/callback", os.Getenv("WORKDAY_AUTH_URL"), os.Getenv("WORKDAY_TOKEN_URL"), os.Getenv("WORKDAY_REST_API_URL")),
+		sap.NewXSUAA(os.Getenv("SAP_KEY"), os.Getenv("SAP_SECRET"), "http://localhost:3000/auth/sap/callback", os.Getenv("SAP_SUBDOMAIN"), os.Getenv("SAP_REGION")),
 		mastodon.New(os.Getenv("MASTODON_KEY"), os.Getenv("MASTODON_SECRET"), "http://localhost:3000/auth/mastodon/callback", "read:accounts"),
 		wecom.New(os.Getenv("WECOM_CORP_ID"), os.Getenv("WECOM_SECRET"), os.Getenv("WECOM_AGENT_ID"), "http://localhost:3000/auth/wecom/callback"),
 		zoom.New(os.Getenv("ZOOM_KEY"), os.Getenv("ZOOM_SECRET"), "http://localhost:3000/auth/zoom/callback", "read:user"),
@@ -159,14 +229,29 @@ func main() {
 		goth.UseProviders(openidConnect)
 	}
 
+	// WorkOS needs to know which customer's connection to send users to
+	workosProvider := workos.New(os.Getenv("WORKOS_CLIENT_ID"), os.Getenv("WORKOS_API_KEY"), "http://localhost:3000/auth/workos/callback")
+	workosProvider.SetOrganization(os.Getenv("WORKOS_ORGANIZATION"))
+	goth.UseProviders(workosProvider)
+
 	m := make(map[string]string)
+	m["airtable"] = "Airtable"
 	m["amazon"] = "Amazon"
+	m["cognito"] = "Amazon Cognito"
 	m["apple"] = "Apple"
+	m["asana"] = "Asana"
+	m["atlassian"] = "Atlassian"
 	m["auth0"] = "Auth0"
+	m["authelia"] = "Authelia"
+	m["authentik"] = "Authentik"
 	m["azuread"] = "Azure AD"
+	m["azureadb2c"] = "Azure AD B2C"
+	m["basecamp"] = "Basecamp"
 	m["battlenet"] = "Battlenet"
 	m["bitbucket"] = "Bitbucket"
+	m["bitbucketdatacenter"] = "Bitbucket Data Center"
 	m["box"] = "Box"
+	m["clickup"] = "ClickUp"
 	m["dailymotion"] = "Dailymotion"
 	m["deezer"] = "Deezer"
 	m["digitalocean"] = "Digital Ocean"
@@ -174,39 +259,58 @@ func main() {
 	m["dropbox"] = "Dropbox"
 	m["eveonline"] = "Eve Online"
 	m["facebook"] = "Facebook"
+	m["figma"] = "Figma"
 	m["fitbit"] = "Fitbit"
+	m["fusionauth"] = "FusionAuth"
 	m["gitea"] = "Gitea"
+	m["gitee"] = "Gitee"
 	m["github"] = "Github"
 	m["gitlab"] = "Gitlab"
 	m["google"] = "Google"
 	m["gplus"] = "Google Plus"
 	m["heroku"] = "Heroku"
+	m["hubspot"] = "HubSpot"
 	m["instagram"] = "Instagram"
 	m["intercom"] = "Intercom"
+	m["intuit"] = "Intuit"
+	m["jumpcloud"] = "JumpCloud"
 	m["kakao"] = "Kakao"
+	m["keycloak"] = "Keycloak"
 	m["lastfm"] = "Last FM"
 	m["line"] = "LINE"
+	m["linear"] = "Linear"
 	m["linkedin"] = "Linkedin"
 	m["mastodon"] = "Mastodon"
 	m["meetup"] = "Meetup.com"
 	m["microsoftonline"] = "Microsoft Online"
+	m["miro"] = "Miro"
+	m["monday"] = "monday.com"
 	m["naver"] = "Naver"
 	m["nextcloud"] = "NextCloud"
+	m["notion"] = "Notion"
 	m["okta"] = "Okta"
 	m["onedrive"] = "Onedrive"
+	m["onelogin"] = "OneLogin"
 	m["openid-connect"] = "OpenID Connect"
+	m["ory"] = "Ory"
 	m["patreon"] = "Patreon"
 	m["paypal"] = "Paypal"
+	m["pingone"] = "PingOne"
 	m["salesforce"] = "Salesforce"
+	m["sap"] = "SAP"
 	m["seatalk"] = "SeaTalk"
+	m["servicenow"] = "ServiceNow"
 	m["shopify"] = "Shopify"
 	m["slack"] = "Slack"
 	m["soundcloud"] = "SoundCloud"
+	m["sourcehut"] = "sourcehut"
 	m["spotify"] = "Spotify"
 	m["steam"] = "Steam"
 	m["strava"] = "Strava"
 	m["stripe"] = "Stripe"
 	m["tiktok"] = "TikTok"
+	m["todoist"] = "Todoist"
+	m["trello"] = "Trello"
 	m["twitch"] = "Twitch"
 	m["twitter"] = "Twitter"
 	m["twitterv2"] = "Twitter"
@@ -215,10 +319,16 @@ func main() {
 	m["vk"] = "VK"
 	m["wecom"] = "WeCom"
 	m["wepay"] = "Wepay"
+	m["workday"] = "Workday"
+	m["workos"] = "WorkOS"
+	m["x"] = "X"
 	m["xero"] = "Xero"
 	m["yahoo"] = "Yahoo"
 	m["yammer"] = "Yammer"
 	m["yandex"] = "Yandex"
+	m["zendesk"] = "Zendesk"
+	m["zitadel"] = "ZITADEL"
+	m["zoho"] = "Zoho"
 	m["zoom"] = "Zoom"
 
 	var keys []string
@@ -4,7 +4,6 @@ go 1.15
 
 require (
 	cloud.google.com/go v0.67.0 // indirect
-	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/golang-jwt/jwt/v4 v4.2.0
 	github.com/gorilla/mux v1.6.2
 	github.com/gorilla/pat v0.0.0-20180118222023-199c85a7f6d1
@@ -13,6 +12,10 @@ require (
 	github.com/lestrrat-go/jwx v1.2.21
 	github.com/markbates/going v1.0.0
 	github.com/mrjones/oauth v0.0.0-20180629183705-f4e24b6d100c
+	github.com/prometheus/client_golang v1.11.1
 	github.com/stretchr/testify v1.7.1
+	go.opentelemetry.io/otel v1.0.1
+	go.opentelemetry.io/otel/sdk v1.0.1
+	go.opentelemetry.io/otel/trace v1.0.1
 	golang.org/x/oauth2 v0.0.0-20200902213428-5d25da1a8d43
 )
@@ -0,0 +1,179 @@
+/*
+Package gothdev is an embeddable version of the example server, for checking
+provider credentials without writing an application first. Providers are
+registered from the same environment variables the example uses, e.g.
+GITHUB_KEY and GITHUB_SECRET, and an index page links to the login of each:
+
+	func main() {
+		log.Fatal(gothdev.ListenAndServe("localhost:3000"))
+	}
+
+Register http://localhost:3000/auth/<provider>/callback as the callback URL
+of each client. Providers needing more than a key and secret read further
+variables, e.g. AUTH0_DOMAIN or OPENID_CONNECT_DISCOVERY_URL, and the scopes
+requested can be set with <PROVIDER>_SCOPES.
+
+The server is meant for development only: it uses gothic's default session
+store and shows everything the provider returned about the user.
+*/
+package gothdev
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+)
+
+// RegisterFromEnv registers every provider in Factories whose environment
+// variables are set, with callback URLs below baseURL, e.g.
+// http://localhost:3000/auth/github/callback. It returns the names of the
+// registered providers. Providers that fail to build are skipped and their
+// errors returned together.
+func RegisterFromEnv(baseURL string) ([]string, error) {
+	return register(baseURL, os.Getenv)
+}
+
+func register(baseURL string, getenv func(string) string) ([]string, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	var names []string
+	var errs []string
+	for name, factory := range Factories {
+		prefix := envPrefix(name)
+		env := func(suffix string) string {
+			return getenv(prefix + "_" + suffix)
+		}
+		p, err := factory(baseURL+"/auth/"+name+"/callback", env)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if p == nil {
+			continue
+		}
+		goth.UseProviders(p)
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return names, fmt.Errorf("gothdev: %s", strings.Join(errs, "; "))
+	}
+	return names, nil
+}
+
+// ListenAndServe registers the providers configured in the environment and
+// serves a Server on addr.
+func ListenAndServe(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	names, err := RegisterFromEnv("http://" + net.JoinHostPort(host, port))
+	if err != nil {
+		log.Println(err)
+	}
+	if len(names) == 0 {
+		log.Println("gothdev: no providers configured, set e.g. GITHUB_KEY and GITHUB_SECRET")
+	}
+	log.Printf("gothdev: listening on http://%s", net.JoinHostPort(host, port))
+	return http.ListenAndServe(addr, &Server{})
+}
+
+// Server serves the index page at /, the login of each registered provider
+// at /auth/<provider>, its callback at /auth/<provider>/callback and
+// /logout/<provider>.
+type Server struct {
+	// ShowTokens makes the user page show the tokens, which are redacted by default.
+	ShowTokens bool
+}
+
+func (s *Server) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case req.URL.Path == "/":
+		s.index(res)
+	case len(parts) == 2 && parts[0] == "auth":
+		req = gothic.GetContextWithProvider(req, parts[1])
+		// try to get the user without re-authenticating
+		if user, err := gothic.CompleteUserAuth(res, req); err == nil {
+			s.user(res, user)
+			return
+		}
+		gothic.BeginAuthHandler(res, req)
+	case len(parts) == 3 && parts[0] == "auth" && parts[2] == "callback":
+		req = gothic.GetContextWithProvider(req, parts[1])
+		user, err := gothic.CompleteUserAuth(res, req)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.user(res, user)
+	case len(parts) == 2 && parts[0] == "logout":
+		gothic.Logout(res, gothic.GetContextWithProvider(req, parts[1]))
+		http.Redirect(res, req, "/", http.StatusTemporaryRedirect)
+	default:
+		http.NotFound(res, req)
+	}
+}
+
+func (s *Server) index(res http.ResponseWriter) {
+	var names []string
+	for name := range goth.GetProviders() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	indexTemplate.Execute(res, names)
+}
+
+func (s *Server) user(res http.ResponseWriter, user goth.User) {
+	if !s.ShowTokens {
+		user.AccessToken = redact(user.AccessToken)
+		user.AccessTokenSecret = redact(user.AccessTokenSecret)
+		user.RefreshToken = redact(user.RefreshToken)
+		user.IDToken = redact(user.IDToken)
+	}
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	userTemplate.Execute(res, user)
+}
+
+func redact(token string) string {
+	if token == "" {
+		return ""
+	}
+	return goth.RedactedValue
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<title>goth</title>
+{{range .}}<p><a href="/auth/{{.}}">Log in with {{.}}</a></p>
+{{else}}<p>No providers are configured. Set e.g. GITHUB_KEY and GITHUB_SECRET and restart.</p>
+{{end}}`))
+
+var userTemplate = template.Must(template.New("user").Parse(`<!DOCTYPE html>
+<title>goth: {{.Provider}}</title>
+<p><a href="/logout/{{.Provider}}">logout</a> <a href="/">back</a></p>
+<p>Name: {{.Name}} [{{.LastName}}, {{.FirstName}}]</p>
+<p>Email: {{.Email}}</p>
+<p>NickName: {{.NickName}}</p>
+<p>Location: {{.Location}}</p>
+<p>AvatarURL: {{.AvatarURL}} <img src="{{.AvatarURL}}"></p>
+<p>Description: {{.Description}}</p>
+<p>UserID: {{.UserID}}</p>
+<p>AccessToken: {{.AccessToken}}</p>
+<p>ExpiresAt: {{.ExpiresAt}}</p>
+<p>RefreshToken: {{.RefreshToken}}</p>
+<p>IDToken: {{.IDToken}}</p>
+{{range $key, $value := .RawData}}<p>{{$key}}: {{$value}}</p>
+{{end}}`))
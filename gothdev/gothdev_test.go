@@ -0,0 +1,84 @@
+package gothdev
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Register(t *testing.T) {
+	a := assert.New(t)
+	defer goth.ClearProviders()
+
+	env := map[string]string{
+		"GITHUB_KEY":    "key",
+		"GITHUB_SECRET": "secret",
+		"AUTH0_KEY":     "key",
+		"OKTA_ID":       "id",
+		"OKTA_SECRET":   "secret",
+		"OKTA_ORG_URL":  "https://example.okta.com",
+		"OKTA_SCOPES":   "openid,email",
+		"UNKNOWN_KEY":   "key",
+		"GOOGLE_SECRET": "secret",
+	}
+	names, err := register("http://localhost:3000/", func(k string) string { return env[k] })
+	a.Error(err)
+	a.Contains(err.Error(), "AUTH0_DOMAIN")
+	a.Equal([]string{"github", "okta"}, names)
+
+	p, err := goth.GetProvider("github")
+	a.NoError(err)
+	sess, err := p.BeginAuth("state")
+	a.NoError(err)
+	authURL, _ := sess.GetAuthURL()
+	a.Contains(authURL, "redirect_uri=http%3A%2F%2Flocalhost%3A3000%2Fauth%2Fgithub%2Fcallback")
+}
+
+func Test_Server(t *testing.T) {
+	a := assert.New(t)
+
+	app := httptest.NewServer(&Server{})
+	defer app.Close()
+
+	p := gothtest.New("fake", app.URL+"/auth/fake/callback")
+	p.AddUser("homer", goth.User{UserID: "1", Email: "homer@example.com"})
+	idp := gothtest.NewIdP(p)
+	defer idp.Close()
+	goth.UseProviders(p)
+	defer goth.ClearProviders()
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+
+	body := get(t, client, app.URL+"/")
+	a.Contains(body, `href="/auth/fake"`)
+
+	body = get(t, client, app.URL+"/auth/fake")
+	a.Contains(body, "homer@example.com")
+	a.Contains(body, goth.RedactedValue)
+	a.NotContains(body, gothtest.AccessToken("homer"))
+
+	res, err := client.Get(app.URL + "/nope")
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal(http.StatusNotFound, res.StatusCode)
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	res, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: %s: %s", url, res.Status, b)
+	}
+	return string(b)
+}
@@ -0,0 +1,184 @@
+package gothdev
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/amazon"
+	"github.com/markbates/goth/providers/auth0"
+	"github.com/markbates/goth/providers/bitbucket"
+	"github.com/markbates/goth/providers/box"
+	"github.com/markbates/goth/providers/digitalocean"
+	"github.com/markbates/goth/providers/discord"
+	"github.com/markbates/goth/providers/dropbox"
+	"github.com/markbates/goth/providers/facebook"
+	"github.com/markbates/goth/providers/gitea"
+	"github.com/markbates/goth/providers/github"
+	"github.com/markbates/goth/providers/gitlab"
+	"github.com/markbates/goth/providers/google"
+	"github.com/markbates/goth/providers/heroku"
+	"github.com/markbates/goth/providers/linkedin"
+	"github.com/markbates/goth/providers/microsoftonline"
+	"github.com/markbates/goth/providers/nextcloud"
+	"github.com/markbates/goth/providers/okta"
+	"github.com/markbates/goth/providers/openidConnect"
+	"github.com/markbates/goth/providers/salesforce"
+	"github.com/markbates/goth/providers/slack"
+	"github.com/markbates/goth/providers/spotify"
+	"github.com/markbates/goth/providers/strava"
+	"github.com/markbates/goth/providers/twitch"
+	"github.com/markbates/goth/providers/yahoo"
+	"github.com/markbates/goth/providers/zoom"
+)
+
+// Factory builds a provider from the environment. env looks up a variable
+// with the provider's prefix, e.g. env("KEY") returns $GITHUB_KEY for github.
+// It returns a nil provider if the variables it needs are not set.
+type Factory func(callbackURL string, env func(suffix string) string) (goth.Provider, error)
+
+// Factories maps provider names to the factories RegisterFromEnv uses.
+// Add to it to make further providers available.
+var Factories = map[string]Factory{
+	"amazon": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return amazon.New(key, secret, callbackURL, scopes...)
+	}),
+	"auth0": func(callbackURL string, env func(string) string) (goth.Provider, error) {
+		if env("KEY") == "" {
+			return nil, nil
+		}
+		if env("DOMAIN") == "" {
+			return nil, errors.New("AUTH0_DOMAIN is not set")
+		}
+		return auth0.New(env("KEY"), env("SECRET"), callbackURL, env("DOMAIN"), scopes(env)...), nil
+	},
+	"bitbucket": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return bitbucket.New(key, secret, callbackURL, scopes...)
+	}),
+	"box": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return box.New(key, secret, callbackURL, scopes...)
+	}),
+	"digitalocean": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		if len(scopes) == 0 {
+			scopes = []string{"read"}
+		}
+		return digitalocean.New(key, secret, callbackURL, scopes...)
+	}),
+	"discord": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		if len(scopes) == 0 {
+			scopes = []string{discord.ScopeIdentify, discord.ScopeEmail}
+		}
+		return discord.New(key, secret, callbackURL, scopes...)
+	}),
+	"dropbox": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return dropbox.New(key, secret, callbackURL, scopes...)
+	}),
+	"facebook": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return facebook.New(key, secret, callbackURL, scopes...)
+	}),
+	"gitea": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return gitea.New(key, secret, callbackURL, scopes...)
+	}),
+	"github": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return github.New(key, secret, callbackURL, scopes...)
+	}),
+	"gitlab": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return gitlab.New(key, secret, callbackURL, scopes...)
+	}),
+	"google": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return google.New(key, secret, callbackURL, scopes...)
+	}),
+	"heroku": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return heroku.New(key, secret, callbackURL, scopes...)
+	}),
+	"linkedin": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return linkedin.New(key, secret, callbackURL, scopes...)
+	}),
+	"microsoftonline": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return microsoftonline.New(key, secret, callbackURL, scopes...)
+	}),
+	"nextcloud": func(callbackURL string, env func(string) string) (goth.Provider, error) {
+		if env("KEY") == "" {
+			return nil, nil
+		}
+		if env("URL") == "" {
+			return nil, errors.New("NEXTCLOUD_URL is not set")
+		}
+		return nextcloud.NewCustomisedDNS(env("KEY"), env("SECRET"), callbackURL, env("URL"), scopes(env)...), nil
+	},
+	"okta": func(callbackURL string, env func(string) string) (goth.Provider, error) {
+		// the examples use OKTA_ID rather than OKTA_KEY
+		if env("ID") == "" {
+			return nil, nil
+		}
+		if env("ORG_URL") == "" {
+			return nil, errors.New("OKTA_ORG_URL is not set")
+		}
+		s := scopes(env)
+		if len(s) == 0 {
+			s = []string{"openid", "profile", "email"}
+		}
+		return okta.New(env("ID"), env("SECRET"), env("ORG_URL"), callbackURL, s...), nil
+	},
+	"openid-connect": func(callbackURL string, env func(string) string) (goth.Provider, error) {
+		if env("KEY") == "" {
+			return nil, nil
+		}
+		if env("DISCOVERY_URL") == "" {
+			return nil, errors.New("OPENID_CONNECT_DISCOVERY_URL is not set")
+		}
+		p, err := openidConnect.New(env("KEY"), env("SECRET"), callbackURL, env("DISCOVERY_URL"), scopes(env)...)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	},
+	"salesforce": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return salesforce.New(key, secret, callbackURL, scopes...)
+	}),
+	"slack": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return slack.New(key, secret, callbackURL, scopes...)
+	}),
+	"spotify": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return spotify.New(key, secret, callbackURL, scopes...)
+	}),
+	"strava": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return strava.New(key, secret, callbackURL, scopes...)
+	}),
+	"twitch": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return twitch.New(key, secret, callbackURL, scopes...)
+	}),
+	"yahoo": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		return yahoo.New(key, secret, callbackURL, scopes...)
+	}),
+	"zoom": keyed(func(key, secret, callbackURL string, scopes []string) goth.Provider {
+		if len(scopes) == 0 {
+			scopes = []string{"read:user"}
+		}
+		return zoom.New(key, secret, callbackURL, scopes...)
+	}),
+}
+
+// keyed returns a Factory for a provider that only needs a key and secret.
+func keyed(fn func(key, secret, callbackURL string, scopes []string) goth.Provider) Factory {
+	return func(callbackURL string, env func(string) string) (goth.Provider, error) {
+		if env("KEY") == "" {
+			return nil, nil
+		}
+		return fn(env("KEY"), env("SECRET"), callbackURL, scopes(env)), nil
+	}
+}
+
+// scopes reads the comma separated <PROVIDER>_SCOPES variable.
+func scopes(env func(string) string) []string {
+	if env("SCOPES") == "" {
+		return nil
+	}
+	return strings.Split(env("SCOPES"), ",")
+}
+
+// envPrefix returns the environment variable prefix of a provider, following
+// the examples: GITHUB for github, OPENID_CONNECT for openid-connect.
+func envPrefix(provider string) string {
+	return strings.ToUpper(strings.Replace(provider, "-", "_", -1))
+}
@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
@@ -41,6 +42,21 @@ type key int
 // ProviderParamKey can be used as a key in context when passing in a provider
 const ProviderParamKey key = iota
 
+// AllowBearerTokens enables CompleteUserAuth to resolve the user from an
+// "Authorization: Bearer <token>" header, so the same handlers can serve both
+// browser sessions and API clients. The token is validated with the provider,
+// which must implement goth.TokenIntrospector. It is disabled by default.
+var AllowBearerTokens = false
+
+// Transcripts, if set, keeps a redacted transcript of the provider requests
+// made by every CompleteUserAuth call, stored by the state ID of the attempt
+// as found in its audit events. Requests are only recorded once
+// goth.TranscriptMiddleware is installed:
+//
+//	goth.UseMiddleware(goth.TranscriptMiddleware)
+//	gothic.Transcripts = goth.NewTranscriptStore(100)
+var Transcripts *goth.TranscriptStore
+
 func init() {
 	key := []byte(os.Getenv("SESSION_SECRET"))
 	keySet = len(key) != 0
@@ -63,6 +79,11 @@ See https://github.com/markbates/goth/examples/main.go to see this in action.
 */
 func BeginAuthHandler(res http.ResponseWriter, req *http.Request) {
 	url, err := GetAuthURL(res, req)
+	if err == ErrRateLimited {
+		res.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprintln(res, err)
+		return
+	}
 	if err != nil {
 		res.WriteHeader(http.StatusBadRequest)
 		fmt.Fprintln(res, err)
@@ -115,37 +136,46 @@ as either "provider" or ":provider".
 
 I would recommend using the BeginAuthHandler instead of doing all of these steps
 yourself, but that's entirely up to you.
+
+If BeginAuthLimiter is set and rejects the request, ErrRateLimited is returned.
 */
 func GetAuthURL(res http.ResponseWriter, req *http.Request) (string, error) {
 	if !keySet && defaultStore == Store {
-		fmt.Println("goth/gothic: no SESSION_SECRET environment variable is set. The default cookie store is not available and any calls will fail. Ignore this warning if you are using a different store.")
+		goth.GetLogger().Warn("goth/gothic: no SESSION_SECRET environment variable is set. The default cookie store is not available and any calls will fail. Ignore this warning if you are using a different store.")
+	}
+
+	if err := allowRequest(BeginAuthLimiter, req); err != nil {
+		return "", authFailed(req, "", goth.ErrorClassRateLimited, err)
 	}
 
 	providerName, err := GetProviderName(req)
 	if err != nil {
-		return "", err
+		return "", authFailed(req, "", goth.ErrorClassProvider, err)
 	}
 
 	provider, err := goth.GetProvider(providerName)
 	if err != nil {
-		return "", err
+		return "", authFailed(req, providerName, goth.ErrorClassProvider, err)
 	}
-	sess, err := provider.BeginAuth(SetState(req))
+	state := SetState(req)
+	sess, err := provider.BeginAuth(state)
 	if err != nil {
-		return "", err
+		return "", authFailed(req, providerName, goth.ErrorClassProvider, err)
 	}
 
 	url, err := sess.GetAuthURL()
 	if err != nil {
-		return "", err
+		return "", authFailed(req, providerName, goth.ErrorClassProvider, err)
 	}
 
 	err = StoreInSession(providerName, sess.Marshal(), req, res)
 
 	if err != nil {
-		return "", err
+		return "", authFailed(req, providerName, goth.ErrorClassInternal, err)
 	}
 
+	goth.GetMetrics().AuthStarted(providerName)
+	audit(req, goth.AuditEvent{Type: goth.AuditAuthStarted, Provider: providerName, StateID: goth.StateID(state)})
 	return url, err
 }
 
@@ -156,66 +186,172 @@ process and fetches all the basic information about the user from the provider.
 It expects to be able to get the name of the provider from the query parameters
 as either "provider" or ":provider".
 
+If AllowBearerTokens is enabled and the request carries a bearer token, the
+user the token was issued to is returned instead and no session is consulted.
+
+If CallbackLimiter is set and rejects the request, ErrRateLimited is returned
+and the pending session is left untouched.
+
 See https://github.com/markbates/goth/examples/main.go to see this in action.
 */
 var CompleteUserAuth = func(res http.ResponseWriter, req *http.Request) (goth.User, error) {
 	if !keySet && defaultStore == Store {
-		fmt.Println("goth/gothic: no SESSION_SECRET environment variable is set. The default cookie store is not available and any calls will fail. Ignore this warning if you are using a different store.")
+		goth.GetLogger().Warn("goth/gothic: no SESSION_SECRET environment variable is set. The default cookie store is not available and any calls will fail. Ignore this warning if you are using a different store.")
+	}
+
+	if err := allowRequest(CallbackLimiter, req); err != nil {
+		return goth.User{}, authFailed(req, "", goth.ErrorClassRateLimited, err)
 	}
 
 	providerName, err := GetProviderName(req)
 	if err != nil {
-		return goth.User{}, err
+		return goth.User{}, authFailed(req, "", goth.ErrorClassProvider, err)
 	}
 
 	provider, err := goth.GetProvider(providerName)
 	if err != nil {
-		return goth.User{}, err
+		return goth.User{}, authFailed(req, providerName, goth.ErrorClassProvider, err)
+	}
+
+	if token, ok := GetBearerToken(req); ok && AllowBearerTokens {
+		user, err := goth.IntrospectToken(req.Context(), provider, token)
+		if err != nil {
+			return goth.User{}, authFailed(req, providerName, goth.ErrorClassFetchUser, err)
+		}
+		goth.GetMetrics().AuthCompleted(providerName)
+		audit(req, goth.AuditEvent{Type: goth.AuditAuthCompleted, Provider: providerName, Subject: user.UserID})
+		return user, nil
 	}
 
 	value, err := GetFromSession(providerName, req)
 	if err != nil {
-		return goth.User{}, err
+		return goth.User{}, authFailed(req, providerName, goth.ErrorClassSession, err)
 	}
-	defer Logout(res, req)
+	defer logout(res, req)
 	sess, err := provider.UnmarshalSession(value)
 	if err != nil {
-		return goth.User{}, err
+		return goth.User{}, authFailed(req, providerName, goth.ErrorClassSession, err)
+	}
+
+	params, err := ParseCallback(req)
+	if err != nil {
+		return goth.User{}, authFailed(req, providerName, goth.ErrorClassCallback, err)
+	}
+
+	if Transcripts != nil {
+		t := goth.NewTranscript(goth.StateID(GetState(req)), providerName)
+		Transcripts.Add(t)
+		req = req.WithContext(goth.ContextWithTranscript(req.Context(), t))
 	}
 
 	err = validateState(req, sess)
 	if err != nil {
-		return goth.User{}, err
+		return goth.User{}, authFailed(req, providerName, goth.ErrorClassState, err)
 	}
 
-	user, err := provider.FetchUser(sess)
+	user, err := goth.FetchUser(req.Context(), provider, sess)
 	if err == nil {
 		// user can be found with existing session data
+		goth.GetMetrics().AuthCompleted(providerName)
+		audit(req, goth.AuditEvent{Type: goth.AuditAuthCompleted, Provider: providerName, Subject: user.UserID})
 		return user, err
 	}
 
-	params := req.URL.Query()
-	if params.Encode() == "" && req.Method == "POST" {
-		req.ParseForm()
-		params = req.Form
-	}
-
 	// get new token and retry fetch
-	_, err = sess.Authorize(provider, params)
+	_, err = goth.Authorize(req.Context(), sess, provider, params)
 	if err != nil {
-		return goth.User{}, err
+		return goth.User{}, authFailed(req, providerName, goth.ErrorClassExchange, err)
 	}
 
 	err = StoreInSession(providerName, sess.Marshal(), req, res)
 
 	if err != nil {
-		return goth.User{}, err
+		return goth.User{}, authFailed(req, providerName, goth.ErrorClassInternal, err)
 	}
 
-	gu, err := provider.FetchUser(sess)
+	gu, err := goth.FetchUser(req.Context(), provider, sess)
+	if err != nil {
+		return gu, authFailed(req, providerName, goth.ErrorClassFetchUser, err)
+	}
+	goth.GetMetrics().AuthCompleted(providerName)
+	audit(req, goth.AuditEvent{Type: goth.AuditAuthCompleted, Provider: providerName, Subject: gu.UserID})
 	return gu, err
 }
 
+// AuditClientIP is used to get the client IP recorded in audit events. By
+// default it is the address of the remote peer; assign your own function when
+// running behind a trusted proxy.
+var AuditClientIP = IPRateLimitKey
+
+// audit emits event to the configured goth.AuditSink, filling in the client
+// IP and, on callbacks, the state ID from req.
+func audit(req *http.Request, event goth.AuditEvent) {
+	event.IP = AuditClientIP(req)
+	if event.StateID == "" {
+		event.StateID = goth.StateID(GetState(req))
+	}
+	goth.Audit(req.Context(), event)
+}
+
+// ParseCallback returns the parameters of a callback request: its query or,
+// for providers posting the response (e.g. Apple's form_post), its form. In
+// goth.StrictParsing mode callbacks larger than goth.MaxCallbackSize, posts
+// that are not form encoded and callbacks repeating the code, state or error
+// parameter are rejected.
+func ParseCallback(req *http.Request) (url.Values, error) {
+	if goth.StrictParsing && int64(len(req.URL.RawQuery)) > goth.MaxCallbackSize {
+		return nil, errors.New("gothic: callback query too large")
+	}
+	params := req.URL.Query()
+	if params.Encode() == "" && req.Method == http.MethodPost {
+		if goth.StrictParsing {
+			ct := req.Header.Get("Content-Type")
+			if mt, _, _ := mime.ParseMediaType(ct); mt != "application/x-www-form-urlencoded" {
+				return nil, &goth.ErrUnexpectedContentType{ContentType: ct}
+			}
+			req.Body = http.MaxBytesReader(nil, req.Body, goth.MaxCallbackSize)
+			if err := req.ParseForm(); err != nil {
+				return nil, err
+			}
+		} else {
+			req.ParseForm()
+		}
+		params = req.Form
+	}
+	if goth.StrictParsing {
+		for _, name := range []string{"code", "state", "error"} {
+			if len(params[name]) > 1 {
+				return nil, fmt.Errorf("gothic: callback repeats the %s parameter", name)
+			}
+		}
+	}
+	return params, nil
+}
+
+// authFailed reports a failed authentication attempt to the configured
+// goth.Metrics, AuditSink and Logger and returns err. providerName is empty
+// if the failure happened before the provider was resolved.
+func authFailed(req *http.Request, providerName string, class goth.ErrorClass, err error) error {
+	goth.GetMetrics().AuthFailed(providerName, class)
+	if t := goth.TranscriptFromContext(req.Context()); t != nil {
+		t.SetError(err)
+	}
+	audit(req, goth.AuditEvent{Type: goth.AuditAuthFailed, Provider: providerName, ErrorClass: class, Error: err.Error()})
+	goth.GetLogger().Debug("goth/gothic: authentication failed", "provider", providerName, "class", class, "error", err)
+	return err
+}
+
+// GetBearerToken returns the token from the request's "Authorization: Bearer"
+// header, if there is one.
+func GetBearerToken(req *http.Request) (string, bool) {
+	h := req.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "bearer ") {
+		return "", false
+	}
+	token := strings.TrimSpace(h[7:])
+	return token, token != ""
+}
+
 // validateState ensures that the state token param from the original
 // AuthURL matches the one included in the current (callback) request.
 func validateState(req *http.Request, sess goth.Session) error {
@@ -238,8 +374,35 @@ func validateState(req *http.Request, sess goth.Session) error {
 	return nil
 }
 
-// Logout invalidates a user session.
+// Logout invalidates a user session and emits a goth.AuditLogout event.
 func Logout(res http.ResponseWriter, req *http.Request) error {
+	providerName, _ := GetProviderName(req)
+	audit(req, goth.AuditEvent{Type: goth.AuditLogout, Provider: providerName})
+	return logout(res, req)
+}
+
+// LogoutAndRedirect is like Logout but then redirects the user to the
+// provider's logout endpoint, if it implements goth.LogoutURLProvider, which
+// redirects back to postLogoutRedirectURI. Otherwise the user is redirected to
+// postLogoutRedirectURI straight away.
+func LogoutAndRedirect(res http.ResponseWriter, req *http.Request, postLogoutRedirectURI string) error {
+	if err := Logout(res, req); err != nil {
+		return err
+	}
+
+	target := postLogoutRedirectURI
+	if providerName, err := GetProviderName(req); err == nil {
+		if provider, err := goth.GetProvider(providerName); err == nil {
+			if u, ok := goth.LogoutURL(provider, postLogoutRedirectURI); ok {
+				target = u
+			}
+		}
+	}
+	http.Redirect(res, req, target, http.StatusTemporaryRedirect)
+	return nil
+}
+
+func logout(res http.ResponseWriter, req *http.Request) error {
 	session, err := Store.Get(req, SessionName)
 	if err != nil {
 		return err
@@ -336,12 +499,25 @@ func getSessionValue(session *sessions.Session, key string) (string, error) {
 		return "", fmt.Errorf("could not find a matching session for this request")
 	}
 
-	rdata := strings.NewReader(value.(string))
-	r, err := gzip.NewReader(rdata)
+	compressed, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("could not find a matching session for this request")
+	}
+	r, err := gzip.NewReader(strings.NewReader(compressed))
 	if err != nil {
 		return "", err
 	}
-	s, err := ioutil.ReadAll(r)
+	var s []byte
+	if goth.StrictParsing {
+		// the compressed value is small enough to fit a cookie, but may
+		// expand to far more than any session
+		s, err = ioutil.ReadAll(io.LimitReader(r, int64(goth.MaxSessionSize)+1))
+		if err == nil && len(s) > goth.MaxSessionSize {
+			err = goth.ErrSessionTooLarge
+		}
+	} else {
+		s, err = ioutil.ReadAll(r)
+	}
 	if err != nil {
 		return "", err
 	}
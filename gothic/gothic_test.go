@@ -3,6 +3,7 @@ package gothic_test
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"html"
 	"io/ioutil"
@@ -188,6 +189,43 @@ func Test_CompleteUserAuthWithContextParamProvider(t *testing.T) {
 	a.Equal(user.Email, "homer@example.com")
 }
 
+func Test_CompleteUserAuthWithBearerToken(t *testing.T) {
+	a := assert.New(t)
+
+	req, err := http.NewRequest("GET", "/api/me?provider=faux", nil)
+	a.NoError(err)
+	req.Header.Set("Authorization", "Bearer api-token")
+
+	token, ok := GetBearerToken(req)
+	a.True(ok)
+	a.Equal("api-token", token)
+
+	// bearer tokens are ignored unless explicitly enabled
+	_, err = CompleteUserAuth(httptest.NewRecorder(), req)
+	a.Error(err)
+
+	AllowBearerTokens = true
+	defer func() { AllowBearerTokens = false }()
+
+	m := &recordingMetrics{}
+	goth.UseMetrics(m)
+	defer goth.UseMetrics(nil)
+	s := &recordingAuditSink{}
+	goth.UseAuditSink(s)
+	defer goth.UseAuditSink(nil)
+
+	user, err := CompleteUserAuth(httptest.NewRecorder(), req)
+	a.NoError(err)
+	a.Equal("api-token", user.AccessToken)
+	a.Equal("id", user.UserID)
+
+	// bearer logins are reported like any other
+	a.Equal([]string{"faux"}, m.completed)
+	a.Len(s.events, 1)
+	a.Equal(goth.AuditAuthCompleted, s.events[0].Type)
+	a.Equal("id", s.events[0].Subject)
+}
+
 func Test_Logout(t *testing.T) {
 	a := assert.New(t)
 
@@ -213,6 +251,39 @@ func Test_Logout(t *testing.T) {
 	a.Equal(session.Options.MaxAge, -1)
 }
 
+type logoutProvider struct {
+	faux.Provider
+}
+
+func (p *logoutProvider) Name() string {
+	return "faux-logout"
+}
+
+func (p *logoutProvider) LogoutURL(postLogoutRedirectURI string) string {
+	return "https://idp.example.com/logout?post_logout_redirect_uri=" + url.QueryEscape(postLogoutRedirectURI)
+}
+
+func Test_LogoutAndRedirect(t *testing.T) {
+	a := assert.New(t)
+
+	res := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/logout?provider=faux", nil)
+	a.NoError(err)
+	a.NoError(LogoutAndRedirect(res, req, "/signed-out"))
+	a.Equal(http.StatusTemporaryRedirect, res.Code)
+	a.Equal("/signed-out", res.Header().Get("Location"))
+
+	goth.UseProviders(&logoutProvider{})
+	defer goth.RemoveProvider("faux-logout")
+
+	res = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "/logout?provider=faux-logout", nil)
+	a.NoError(err)
+	a.NoError(LogoutAndRedirect(res, req, "https://app.example.com/signed-out"))
+	a.Equal(http.StatusTemporaryRedirect, res.Code)
+	a.Equal("https://idp.example.com/logout?post_logout_redirect_uri=https%3A%2F%2Fapp.example.com%2Fsigned-out", res.Header().Get("Location"))
+}
+
 func Test_SetState(t *testing.T) {
 	a := assert.New(t)
 
@@ -290,3 +361,141 @@ func ungzipString(value string) string {
 
 	return string(s)
 }
+
+type recordingMetrics struct {
+	goth.NopMetrics
+	started, completed []string
+	failed             []goth.ErrorClass
+}
+
+func (m *recordingMetrics) AuthStarted(p string)   { m.started = append(m.started, p) }
+func (m *recordingMetrics) AuthCompleted(p string) { m.completed = append(m.completed, p) }
+func (m *recordingMetrics) AuthFailed(p string, c goth.ErrorClass) {
+	m.failed = append(m.failed, c)
+}
+
+func Test_Metrics(t *testing.T) {
+	a := assert.New(t)
+
+	m := &recordingMetrics{}
+	goth.UseMetrics(m)
+	defer goth.UseMetrics(nil)
+
+	Store = NewProviderStore()
+	for _, state := range []string{"state_REAL", "state_FAKE"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/auth?provider=faux&state=state_REAL", nil)
+		BeginAuthHandler(res, req)
+		session, _ := Store.Get(req, SessionName)
+
+		req, _ = http.NewRequest("GET", "/auth/callback?provider=faux&state="+state, nil)
+		session.Save(req, res)
+		CompleteUserAuth(res, req)
+	}
+
+	a.Equal([]string{"faux", "faux"}, m.started)
+	a.Equal([]string{"faux"}, m.completed)
+	a.Equal([]goth.ErrorClass{goth.ErrorClassState}, m.failed)
+}
+
+type recordingAuditSink struct {
+	events []goth.AuditEvent
+}
+
+func (s *recordingAuditSink) Audit(_ context.Context, e goth.AuditEvent) {
+	s.events = append(s.events, e)
+}
+
+func Test_Audit(t *testing.T) {
+	a := assert.New(t)
+
+	s := &recordingAuditSink{}
+	goth.UseAuditSink(s)
+	defer goth.UseAuditSink(nil)
+
+	Store = NewProviderStore()
+	for _, state := range []string{"state_REAL", "state_FAKE"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/auth?provider=faux&state=state_REAL", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		BeginAuthHandler(res, req)
+		session, _ := Store.Get(req, SessionName)
+
+		req, _ = http.NewRequest("GET", "/auth/callback?provider=faux&state="+state, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		session.Save(req, res)
+		CompleteUserAuth(res, req)
+		if state == "state_REAL" {
+			Logout(res, req)
+		}
+	}
+
+	var types []goth.AuditEventType
+	for _, e := range s.events {
+		types = append(types, e.Type)
+		a.Equal("faux", e.Provider)
+		a.Equal("192.0.2.1", e.IP)
+	}
+	a.Equal([]goth.AuditEventType{
+		goth.AuditAuthStarted, goth.AuditAuthCompleted, goth.AuditLogout,
+		goth.AuditAuthStarted, goth.AuditAuthFailed,
+	}, types)
+	a.Equal(goth.StateID("state_REAL"), s.events[0].StateID)
+	a.Equal(s.events[0].StateID, s.events[1].StateID)
+	a.Equal(goth.StateID("state_FAKE"), s.events[4].StateID)
+	a.Equal(goth.ErrorClassState, s.events[4].ErrorClass)
+}
+
+func Test_Transcripts(t *testing.T) {
+	a := assert.New(t)
+
+	Transcripts = goth.NewTranscriptStore(10)
+	defer func() { Transcripts = nil }()
+
+	Store = NewProviderStore()
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth?provider=faux&state=state_REAL", nil)
+	BeginAuthHandler(res, req)
+	session, _ := Store.Get(req, SessionName)
+
+	req, _ = http.NewRequest("GET", "/auth/callback?provider=faux&state=state_FAKE", nil)
+	session.Save(req, res)
+	_, err := CompleteUserAuth(res, req)
+	a.Error(err)
+
+	tr, ok := Transcripts.Get(goth.StateID("state_FAKE"))
+	a.True(ok)
+	a.Equal("faux", tr.Provider)
+	a.Equal(err.Error(), tr.Err())
+}
+
+func Test_ParseCallback(t *testing.T) {
+	a := assert.New(t)
+
+	req, _ := http.NewRequest("GET", "/auth/callback?code=a&code=b&state=s", nil)
+	params, err := ParseCallback(req)
+	a.NoError(err)
+	a.Equal("a", params.Get("code"))
+
+	post := func(contentType, body string) *http.Request {
+		req, _ := http.NewRequest("POST", "/auth/callback", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+	params, err = ParseCallback(post("application/x-www-form-urlencoded", "code=a&state=s"))
+	a.NoError(err)
+	a.Equal("s", params.Get("state"))
+
+	goth.StrictParsing = true
+	defer func() { goth.StrictParsing = false }()
+
+	_, err = ParseCallback(req)
+	a.Error(err)
+	_, err = ParseCallback(post("text/plain", "code=a&state=s"))
+	a.Error(err)
+	_, err = ParseCallback(post("application/x-www-form-urlencoded", "code="+strings.Repeat("a", int(goth.MaxCallbackSize))))
+	a.Error(err)
+	params, err = ParseCallback(post("application/x-www-form-urlencoded; charset=utf-8", "code=a&state=s"))
+	a.NoError(err)
+	a.Equal("a", params.Get("code"))
+}
@@ -0,0 +1,215 @@
+package gothic
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/markbates/goth"
+)
+
+// MemoryStore is a sessions.Store keeping session values on the server, in
+// memory, with only a random session ID in the cookie. It is meant for single
+// instance applications that want neither a cookie store nor an external one:
+//
+//	gothic.Store = gothic.NewMemoryStore(10000, 15*time.Minute)
+//
+// Sessions expire ttl after they were last saved, and once maxEntries sessions
+// are stored the least recently saved one is evicted, so abandoned logins
+// cannot make it grow without bounds. Everything is lost on restart.
+type MemoryStore struct {
+	// Options are the options of the session cookie. MaxAge defaults to the TTL.
+	Options *sessions.Options
+
+	maxEntries int
+	ttl        time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*list.Element
+	order     *list.List // of *memoryEntry, least recently saved first
+	evicted   uint64
+	expired   uint64
+	hits      uint64
+	misses    uint64
+	lastPrune time.Time
+}
+
+type memoryEntry struct {
+	id      string
+	values  map[interface{}]interface{}
+	expires time.Time
+}
+
+// MemoryStoreStats are counters describing a MemoryStore.
+type MemoryStoreStats struct {
+	// Entries is the number of sessions stored, including expired ones not pruned yet.
+	Entries int
+	// Evicted counts the sessions dropped to stay within the maximum number of entries.
+	Evicted uint64
+	// Expired counts the sessions dropped because their TTL passed.
+	Expired uint64
+	// Hits and Misses count requests whose session ID was, or was not, found.
+	Hits, Misses uint64
+}
+
+// NewMemoryStore returns a MemoryStore holding at most maxEntries sessions
+// (unbounded if maxEntries <= 0), each for ttl.
+func NewMemoryStore(maxEntries int, ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		Options: &sessions.Options{
+			Path:     "/",
+			MaxAge:   int(ttl / time.Second),
+			HttpOnly: true,
+		},
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+// Get returns the session cached for the request, or loads it from the store.
+func (s *MemoryStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns the session stored for the request's cookie, or a new one.
+func (s *MemoryStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil || c.Value == "" {
+		return session, nil
+	}
+	if values, ok := s.load(c.Value); ok {
+		session.ID = c.Value
+		session.Values = values
+		session.IsNew = false
+	}
+	return session, nil
+}
+
+// Save stores the session and sets its cookie, or deletes both if the
+// session's MaxAge is negative.
+func (s *MemoryStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			s.mu.Lock()
+			s.remove(session.ID)
+			s.mu.Unlock()
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		id, err := newSessionID()
+		if err != nil {
+			return err
+		}
+		session.ID = id
+	}
+	s.store(session.ID, session.Values)
+	http.SetCookie(w, sessions.NewCookie(session.Name(), session.ID, session.Options))
+	return nil
+}
+
+// Stats returns the store's counters.
+func (s *MemoryStore) Stats() MemoryStoreStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return MemoryStoreStats{
+		Entries: len(s.entries),
+		Evicted: s.evicted,
+		Expired: s.expired,
+		Hits:    s.hits,
+		Misses:  s.misses,
+	}
+}
+
+func (s *MemoryStore) load(id string) (map[interface{}]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[id]
+	if ok && goth.GetClock().Now().After(el.Value.(*memoryEntry).expires) {
+		s.remove(id)
+		s.expired++
+		ok = false
+	}
+	if !ok {
+		s.misses++
+		return nil, false
+	}
+	s.hits++
+	return copyValues(el.Value.(*memoryEntry).values), true
+}
+
+func (s *MemoryStore) store(id string, values map[interface{}]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := goth.GetClock().Now()
+	if now.Sub(s.lastPrune) > s.ttl/2 {
+		s.prune(now)
+	}
+
+	e := &memoryEntry{id: id, values: copyValues(values), expires: now.Add(s.ttl)}
+	if el, ok := s.entries[id]; ok {
+		el.Value = e
+		s.order.MoveToBack(el)
+		return
+	}
+	for s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		s.remove(s.order.Front().Value.(*memoryEntry).id)
+		s.evicted++
+	}
+	s.entries[id] = s.order.PushBack(e)
+}
+
+// prune drops expired sessions. As sessions are ordered by when they were
+// saved, which is when their TTL started, it can stop at the first live one.
+// It must be called with s.mu held.
+func (s *MemoryStore) prune(now time.Time) {
+	s.lastPrune = now
+	for el := s.order.Front(); el != nil; el = s.order.Front() {
+		e := el.Value.(*memoryEntry)
+		if !now.After(e.expires) {
+			return
+		}
+		s.remove(e.id)
+		s.expired++
+	}
+}
+
+// remove must be called with s.mu held.
+func (s *MemoryStore) remove(id string) {
+	if el, ok := s.entries[id]; ok {
+		s.order.Remove(el)
+		delete(s.entries, id)
+	}
+}
+
+func copyValues(values map[interface{}]interface{}) map[interface{}]interface{} {
+	c := make(map[interface{}]interface{}, len(values))
+	for k, v := range values {
+		c[k] = v
+	}
+	return c
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
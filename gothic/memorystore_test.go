@@ -0,0 +1,110 @@
+package gothic_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	. "github.com/markbates/goth/gothic"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// withCookies returns a request carrying the cookies set on res.
+func withCookies(url string, res *httptest.ResponseRecorder) *http.Request {
+	req, _ := http.NewRequest("GET", url, nil)
+	for _, c := range res.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+func Test_MemoryStore(t *testing.T) {
+	a := assert.New(t)
+
+	s := NewMemoryStore(2, time.Hour)
+	saved := map[string]*httptest.ResponseRecorder{}
+	for _, name := range []string{"a", "b", "c"} {
+		req, _ := http.NewRequest("GET", "/", nil)
+		sess, err := s.Get(req, SessionName)
+		a.NoError(err)
+		a.True(sess.IsNew)
+		sess.Values["name"] = name
+		res := httptest.NewRecorder()
+		a.NoError(sess.Save(req, res))
+		saved[name] = res
+	}
+
+	stats := s.Stats()
+	a.Equal(2, stats.Entries)
+	a.Equal(uint64(1), stats.Evicted)
+
+	// the oldest session was evicted
+	sess, err := s.Get(withCookies("/", saved["a"]), SessionName)
+	a.NoError(err)
+	a.True(sess.IsNew)
+	a.Empty(sess.Values)
+
+	req := withCookies("/", saved["c"])
+	sess, err = s.Get(req, SessionName)
+	a.NoError(err)
+	a.False(sess.IsNew)
+	a.Equal("c", sess.Values["name"])
+
+	// deleting the session removes it from the store
+	sess.Options.MaxAge = -1
+	a.NoError(sess.Save(req, httptest.NewRecorder()))
+	a.Equal(1, s.Stats().Entries)
+
+	stats = s.Stats()
+	a.Equal(uint64(1), stats.Hits)
+	a.Equal(uint64(1), stats.Misses)
+}
+
+func Test_MemoryStore_Expiry(t *testing.T) {
+	a := assert.New(t)
+
+	clock := gothtest.NewClock(time.Now())
+	goth.UseClock(clock)
+	defer goth.UseClock(nil)
+
+	s := NewMemoryStore(0, time.Minute)
+	req, _ := http.NewRequest("GET", "/", nil)
+	sess, _ := s.New(req, SessionName)
+	sess.Values["name"] = "a"
+	res := httptest.NewRecorder()
+	a.NoError(s.Save(req, res, sess))
+
+	clock.Advance(time.Minute + time.Second)
+	sess, _ = s.New(withCookies("/", res), SessionName)
+	a.True(sess.IsNew)
+	a.Equal(uint64(1), s.Stats().Expired)
+	a.Equal(0, s.Stats().Entries)
+}
+
+func Test_MemoryStore_Login(t *testing.T) {
+	a := assert.New(t)
+
+	store := Store
+	memory := NewMemoryStore(10, time.Minute)
+	Store = memory
+	defer func() { Store = store }()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth?provider=faux", nil)
+	BeginAuthHandler(res, req)
+	a.Equal(http.StatusTemporaryRedirect, res.Code)
+	a.Len(res.Result().Cookies(), 1)
+
+	authURL, err := url.Parse(res.Header().Get("Location"))
+	a.NoError(err)
+	callback := "/auth/callback?provider=faux&state=" + url.QueryEscape(authURL.Query().Get("state"))
+	_, err = CompleteUserAuth(httptest.NewRecorder(), withCookies(callback, res))
+	a.NoError(err)
+	a.NotZero(memory.Stats().Hits)
+	// the session is deleted once the login completed
+	a.Equal(0, memory.Stats().Entries)
+}
@@ -0,0 +1,141 @@
+package gothic
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/markbates/goth"
+)
+
+// ErrRateLimited is returned by GetAuthURL and CompleteUserAuth when the
+// configured Limiter rejects a request.
+var ErrRateLimited = errors.New("gothic: too many authentication attempts, try again later")
+
+// Limiter decides whether a request identified by key may proceed. Implementations
+// must be safe for concurrent use.
+type Limiter interface {
+	Allow(key string) bool
+}
+
+// BeginAuthLimiter, when set, throttles calls to BeginAuthHandler and GetAuthURL.
+// It is nil, i.e. unlimited, by default.
+var BeginAuthLimiter Limiter
+
+// CallbackLimiter, when set, throttles calls to CompleteUserAuth. It is nil,
+// i.e. unlimited, by default.
+var CallbackLimiter Limiter
+
+// RateLimitKey is used to get the key a request is throttled by. By default
+// requests are keyed by the client IP. Assign SessionRateLimitKey, or your own
+// function (e.g. one that understands X-Forwarded-For behind a trusted proxy),
+// to change this.
+var RateLimitKey = IPRateLimitKey
+
+// IPRateLimitKey keys a request by the IP address of the remote peer.
+func IPRateLimitKey(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// SessionRateLimitKey keys a request by its gothic session cookie, falling
+// back to the client IP for requests that do not carry one yet.
+func SessionRateLimitKey(req *http.Request) string {
+	c, err := req.Cookie(SessionName)
+	if err != nil || c.Value == "" {
+		return IPRateLimitKey(req)
+	}
+	sum := sha256.Sum256([]byte(c.Value))
+	return "session:" + hex.EncodeToString(sum[:])
+}
+
+func allowRequest(l Limiter, req *http.Request) error {
+	if l == nil {
+		return nil
+	}
+	if !l.Allow(RateLimitKey(req)) {
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// MemoryLimiter is an in-memory, per-key token bucket Limiter. It is suitable
+// for single instance deployments; use a shared implementation of Limiter when
+// running several instances behind a load balancer.
+type MemoryLimiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewMemoryLimiter returns a MemoryLimiter that allows up to burst requests
+// per key at once, refilled at a rate of one request every interval. It
+// panics if interval is not positive, as a zero interval would let every
+// request through.
+func NewMemoryLimiter(interval time.Duration, burst int) *MemoryLimiter {
+	if interval <= 0 {
+		panic("gothic: non-positive interval for NewMemoryLimiter")
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &MemoryLimiter{
+		rate:    1 / interval.Seconds(),
+		burst:   float64(burst),
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Allow reports whether a request for key may proceed, consuming a token if so.
+func (l *MemoryLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := goth.GetClock().Now()
+	l.calls++
+	if l.calls%1024 == 0 {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// prune drops buckets that have refilled completely, so abandoned keys don't
+// accumulate forever.
+func (l *MemoryLimiter) prune(now time.Time) {
+	for k, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, k)
+		}
+	}
+}
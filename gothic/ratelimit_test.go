@@ -0,0 +1,87 @@
+package gothic_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	. "github.com/markbates/goth/gothic"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryLimiter(t *testing.T) {
+	a := assert.New(t)
+
+	l := NewMemoryLimiter(time.Hour, 2)
+	a.True(l.Allow("a"))
+	a.True(l.Allow("a"))
+	a.False(l.Allow("a"))
+	a.True(l.Allow("b"))
+
+	clock := gothtest.NewClock(time.Now())
+	goth.UseClock(clock)
+	defer goth.UseClock(nil)
+
+	l = NewMemoryLimiter(time.Minute, 1)
+	a.True(l.Allow("a"))
+	a.False(l.Allow("a"))
+	clock.Advance(time.Minute)
+	a.True(l.Allow("a"))
+
+	a.Panics(func() { NewMemoryLimiter(0, 1) })
+	a.Panics(func() { NewMemoryLimiter(-time.Second, 1) })
+}
+
+func Test_BeginAuthHandlerRateLimited(t *testing.T) {
+	a := assert.New(t)
+
+	BeginAuthLimiter = NewMemoryLimiter(time.Hour, 1)
+	defer func() { BeginAuthLimiter = nil }()
+
+	req, err := http.NewRequest("GET", "/auth?provider=faux", nil)
+	a.NoError(err)
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	res := httptest.NewRecorder()
+	BeginAuthHandler(res, req)
+	a.Equal(http.StatusTemporaryRedirect, res.Code)
+
+	res = httptest.NewRecorder()
+	BeginAuthHandler(res, req)
+	a.Equal(http.StatusTooManyRequests, res.Code)
+
+	req.RemoteAddr = "10.0.0.2:1234"
+	res = httptest.NewRecorder()
+	BeginAuthHandler(res, req)
+	a.Equal(http.StatusTemporaryRedirect, res.Code)
+}
+
+func Test_CompleteUserAuthRateLimited(t *testing.T) {
+	a := assert.New(t)
+
+	CallbackLimiter = NewMemoryLimiter(time.Hour, 1)
+	defer func() { CallbackLimiter = nil }()
+
+	req, err := http.NewRequest("GET", "/auth/callback?provider=faux", nil)
+	a.NoError(err)
+	a.True(CallbackLimiter.Allow(RateLimitKey(req)))
+
+	_, err = CompleteUserAuth(httptest.NewRecorder(), req)
+	a.Equal(ErrRateLimited, err)
+}
+
+func Test_SessionRateLimitKey(t *testing.T) {
+	a := assert.New(t)
+
+	req, _ := http.NewRequest("GET", "/auth", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	a.Equal("10.0.0.1", SessionRateLimitKey(req))
+
+	req.AddCookie(&http.Cookie{Name: SessionName, Value: "abc"})
+	key := SessionRateLimitKey(req)
+	a.Contains(key, "session:")
+	a.NotContains(key, "abc")
+}
@@ -0,0 +1,45 @@
+package gothtest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a goth.Clock that only moves when told to, for simulating token
+// expiry and TTLs without sleeping:
+//
+//	clock := gothtest.NewClock(time.Now())
+//	goth.UseClock(clock)
+//	defer goth.UseClock(nil)
+//
+//	clock.Advance(gothtest.TokenLifetime + time.Second) // the tokens issued so far have expired
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a Clock set to now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// Set sets the clock to t.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
@@ -0,0 +1,154 @@
+package gothtest
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth"
+)
+
+// Fixtures configures RunProviderConformance.
+type Fixtures struct {
+	// State is passed to BeginAuth. It defaults to "gothtest-state".
+	State string
+	// AuthorizeParams, if set, are used to authorize a session, after which
+	// the user is fetched. Leave it nil for providers that can only be
+	// authorized against the real 3rd party.
+	AuthorizeParams goth.Params
+}
+
+// RunProviderConformance checks that provider behaves the way goth and gothic
+// expect of a provider: that its name can be changed, that it carries the
+// state through its auth URL, that its sessions survive a marshal round trip,
+// that it fails cleanly on unauthorized sessions and malformed session data,
+// and that RefreshTokenAvailable matches RefreshToken. The checks run as
+// subtests of t and don't make requests unless fixtures.AuthorizeParams is set.
+//
+//	func Test_Conformance(t *testing.T) {
+//		gothtest.RunProviderConformance(t, mycorp.New(key, secret, callback), gothtest.Fixtures{})
+//	}
+func RunProviderConformance(t *testing.T, provider goth.Provider, fixtures Fixtures) {
+	state := fixtures.State
+	if state == "" {
+		state = "gothtest-state"
+	}
+
+	t.Run("Name", func(t *testing.T) {
+		name := provider.Name()
+		if name == "" {
+			t.Fatal("Name() is empty")
+		}
+		defer provider.SetName(name)
+		provider.SetName("gothtest-renamed")
+		if got := provider.Name(); got != "gothtest-renamed" {
+			t.Errorf("Name() = %q after SetName(%q)", got, "gothtest-renamed")
+		}
+	})
+
+	t.Run("BeginAuth", func(t *testing.T) {
+		sess, err := provider.BeginAuth(state)
+		if err != nil {
+			t.Fatalf("BeginAuth: %v", err)
+		}
+		authURL, err := sess.GetAuthURL()
+		if err != nil {
+			t.Fatalf("GetAuthURL: %v", err)
+		}
+		u, err := url.Parse(authURL)
+		if err != nil || !u.IsAbs() {
+			t.Fatalf("GetAuthURL() = %q, want an absolute URL", authURL)
+		}
+		// gothic validates the state of the callback against the auth URL
+		if got := u.Query().Get("state"); got != state {
+			t.Errorf("auth URL state = %q, want %q", got, state)
+		}
+	})
+
+	t.Run("SessionRoundTrip", func(t *testing.T) {
+		sess, err := provider.BeginAuth(state)
+		if err != nil {
+			t.Fatalf("BeginAuth: %v", err)
+		}
+		data := sess.Marshal()
+		restored, err := provider.UnmarshalSession(data)
+		if err != nil {
+			t.Fatalf("UnmarshalSession(%q): %v", data, err)
+		}
+		if got := restored.Marshal(); got != data {
+			t.Errorf("Marshal() after round trip = %q, want %q", got, data)
+		}
+		want, _ := sess.GetAuthURL()
+		if got, _ := restored.GetAuthURL(); got != want {
+			t.Errorf("GetAuthURL() after round trip = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("MalformedSession", func(t *testing.T) {
+		noPanic(t, "UnmarshalSession", func() {
+			if _, err := provider.UnmarshalSession("{not json"); err == nil {
+				t.Error("UnmarshalSession of malformed data did not fail")
+			}
+		})
+	})
+
+	t.Run("FetchUserUnauthorized", func(t *testing.T) {
+		sess, err := provider.BeginAuth(state)
+		if err != nil {
+			t.Fatalf("BeginAuth: %v", err)
+		}
+		noPanic(t, "FetchUser", func() {
+			if _, err := provider.FetchUser(sess); err == nil {
+				t.Error("FetchUser of a session without an access token did not fail")
+			}
+		})
+	})
+
+	t.Run("RefreshToken", func(t *testing.T) {
+		if provider.RefreshTokenAvailable() {
+			t.Skip("refreshing needs a real refresh token")
+		}
+		noPanic(t, "RefreshToken", func() {
+			if _, err := provider.RefreshToken("gothtest-refresh-token"); err == nil {
+				t.Error("RefreshToken did not fail although RefreshTokenAvailable() is false")
+			}
+		})
+	})
+
+	if fixtures.AuthorizeParams == nil {
+		return
+	}
+	t.Run("Authorize", func(t *testing.T) {
+		sess, err := provider.BeginAuth(state)
+		if err != nil {
+			t.Fatalf("BeginAuth: %v", err)
+		}
+		token, err := sess.Authorize(provider, fixtures.AuthorizeParams)
+		if err != nil {
+			t.Fatalf("Authorize: %v", err)
+		}
+		if token == "" {
+			t.Error("Authorize returned an empty access token")
+		}
+
+		user, err := provider.FetchUser(sess)
+		if err != nil {
+			t.Fatalf("FetchUser: %v", err)
+		}
+		if user.Provider != provider.Name() {
+			t.Errorf("user.Provider = %q, want %q", user.Provider, provider.Name())
+		}
+		if user.AccessToken != token {
+			t.Errorf("user.AccessToken = %q, want %q", user.AccessToken, token)
+		}
+	})
+}
+
+func noPanic(t *testing.T, name string, f func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("%s panicked: %v", name, r)
+		}
+	}()
+	f()
+}
@@ -0,0 +1,16 @@
+package gothtest_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth/gothtest"
+)
+
+func Test_RunProviderConformance(t *testing.T) {
+	t.Parallel()
+
+	gothtest.RunProviderConformance(t, provider(), gothtest.Fixtures{
+		AuthorizeParams: url.Values{"code": {"homer"}},
+	})
+}
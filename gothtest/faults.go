@@ -0,0 +1,257 @@
+package gothtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// Fault describes a failure to inject, for chaos testing the login and
+// refresh error handling of an application. A fault first waits for Latency,
+// then fails the call if Timeout, Err, OAuthError or MalformedJSON is set.
+type Fault struct {
+	// Op restricts the fault to one provider operation. It is ignored by
+	// NewFaultTransport.
+	Op Op
+	// Match restricts the fault to matching requests in NewFaultTransport. It
+	// is ignored by WithFaults.
+	Match func(*http.Request) bool
+	// Rate is the fraction of calls the fault applies to. Zero means all.
+	Rate float64
+
+	// Latency delays the call.
+	Latency time.Duration
+	// Timeout makes the call fail as if it timed out: it waits until the
+	// context is done if it has a deadline, and returns
+	// context.DeadlineExceeded.
+	Timeout bool
+	// Err is returned instead of making the call.
+	Err error
+	// OAuthError is an OAuth2 error code, e.g. "invalid_grant", returned by
+	// the token endpoint instead of making the call.
+	OAuthError string
+	// MalformedJSON makes NewFaultTransport answer with an unparsable JSON
+	// body. It is ignored by WithFaults.
+	MalformedJSON bool
+}
+
+func (f Fault) hit() bool {
+	return f.Rate <= 0 || rand.Float64() < f.Rate
+}
+
+// wait applies the latency and timeout of the fault.
+func (f Fault) wait(ctx context.Context) error {
+	if f.Latency > 0 {
+		t := time.NewTimer(f.Latency)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+	if f.Timeout {
+		if _, ok := ctx.Deadline(); ok {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+func oauthErrorBody(code string) []byte {
+	b, _ := json.Marshal(map[string]string{
+		"error":             code,
+		"error_description": "injected by gothtest",
+	})
+	return b
+}
+
+// FaultyProvider wraps a provider, injecting faults into its operations.
+type FaultyProvider struct {
+	goth.Provider
+	faults []Fault
+}
+
+// WithFaults returns a provider that injects faults into the operations of p
+// and otherwise behaves like p.
+func WithFaults(p goth.Provider, faults ...Fault) *FaultyProvider {
+	return &FaultyProvider{Provider: p, faults: faults}
+}
+
+// Unwrap returns the underlying provider.
+func (p *FaultyProvider) Unwrap() goth.Provider {
+	return p.Provider
+}
+
+func (p *FaultyProvider) inject(ctx context.Context, op Op) error {
+	for _, f := range p.faults {
+		if (f.Op != "" && f.Op != op) || !f.hit() {
+			continue
+		}
+		if err := f.wait(ctx); err != nil {
+			return err
+		}
+		if f.Err != nil {
+			return f.Err
+		}
+		if f.OAuthError != "" {
+			return &oauth2.RetrieveError{
+				Response: &http.Response{StatusCode: http.StatusBadRequest, Status: "400 Bad Request"},
+				Body:     oauthErrorBody(f.OAuthError),
+			}
+		}
+	}
+	return nil
+}
+
+// BeginAuth injects faults into the wrapped provider's BeginAuth.
+func (p *FaultyProvider) BeginAuth(state string) (goth.Session, error) {
+	if err := p.inject(context.Background(), OpBeginAuth); err != nil {
+		return nil, err
+	}
+	sess, err := p.Provider.BeginAuth(state)
+	if err != nil {
+		return sess, err
+	}
+	return &FaultySession{Session: sess, provider: p}, nil
+}
+
+// UnmarshalSession returns a session whose token exchange can be faulted.
+func (p *FaultyProvider) UnmarshalSession(data string) (goth.Session, error) {
+	sess, err := p.Provider.UnmarshalSession(data)
+	if err != nil {
+		return sess, err
+	}
+	return &FaultySession{Session: sess, provider: p}, nil
+}
+
+// FetchUser injects faults into the wrapped provider's FetchUser.
+func (p *FaultyProvider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the faults and the call to ctx.
+func (p *FaultyProvider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	if err := p.inject(ctx, OpFetchUser); err != nil {
+		return goth.User{}, err
+	}
+	if s, ok := session.(*FaultySession); ok {
+		session = s.Session
+	}
+	return goth.FetchUser(ctx, p.Provider, session)
+}
+
+// RefreshToken injects faults into the wrapped provider's RefreshToken.
+func (p *FaultyProvider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the faults and the call to ctx.
+func (p *FaultyProvider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	if err := p.inject(ctx, OpRefreshToken); err != nil {
+		return nil, err
+	}
+	if cr, ok := p.Provider.(goth.ContextTokenRefresher); ok {
+		return cr.RefreshTokenContext(ctx, refreshToken)
+	}
+	return p.Provider.RefreshToken(refreshToken)
+}
+
+// RevokeToken injects faults into the wrapped provider's token revocation.
+func (p *FaultyProvider) RevokeToken(ctx context.Context, token string) error {
+	if err := p.inject(ctx, OpRevokeToken); err != nil {
+		return err
+	}
+	return goth.RevokeToken(ctx, p.Provider, token)
+}
+
+// FaultySession wraps the session of a FaultyProvider so that faults can be
+// injected into the token exchange.
+type FaultySession struct {
+	goth.Session
+	provider *FaultyProvider
+}
+
+// Authorize injects faults into the token exchange of the wrapped session.
+func (s *FaultySession) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the faults and the exchange to ctx.
+func (s *FaultySession) AuthorizeContext(ctx context.Context, _ goth.Provider, params goth.Params) (string, error) {
+	if err := s.provider.inject(ctx, OpAuthorize); err != nil {
+		return "", err
+	}
+	return goth.Authorize(ctx, s.Session, s.provider.Provider, params)
+}
+
+// Unwrap returns the underlying session.
+func (s *FaultySession) Unwrap() goth.Session {
+	return s.Session
+}
+
+type faultTransport struct {
+	next   http.RoundTripper
+	faults []Fault
+}
+
+// NewFaultTransport returns an http.RoundTripper injecting faults into the
+// requests made through it, for faults that need to happen on the wire such
+// as malformed responses. If next is nil http.DefaultTransport is used.
+func NewFaultTransport(next http.RoundTripper, faults ...Fault) http.RoundTripper {
+	return &faultTransport{next: next, faults: faults}
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for _, f := range t.faults {
+		if (f.Match != nil && !f.Match(req)) || !f.hit() {
+			continue
+		}
+		if err := f.wait(req.Context()); err != nil {
+			return nil, err
+		}
+		if f.Err != nil {
+			return nil, f.Err
+		}
+		if f.OAuthError != "" {
+			return fakeResponse(req, http.StatusBadRequest, jsonHeader(), oauthErrorBody(f.OAuthError)), nil
+		}
+		if f.MalformedJSON {
+			return fakeResponse(req, http.StatusOK, jsonHeader(), []byte(`{"access_token": "trunc`)), nil
+		}
+	}
+
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(req)
+}
+
+func fakeResponse(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func jsonHeader() http.Header {
+	return http.Header{"Content-Type": {"application/json"}}
+}
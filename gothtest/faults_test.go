@@ -0,0 +1,77 @@
+package gothtest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func Test_WithFaults(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	boom := errors.New("boom")
+	p := gothtest.WithFaults(provider(),
+		gothtest.Fault{Op: gothtest.OpAuthorize, Latency: 10 * time.Millisecond},
+		gothtest.Fault{Op: gothtest.OpFetchUser, Err: boom},
+		gothtest.Fault{Op: gothtest.OpRefreshToken, OAuthError: "invalid_grant"},
+	)
+	a.Implements((*goth.Provider)(nil), p)
+
+	sess, err := p.BeginAuth("state")
+	a.NoError(err)
+	start := time.Now()
+	_, err = sess.Authorize(p, url.Values{"code": {"homer"}})
+	a.NoError(err)
+	a.True(time.Since(start) >= 10*time.Millisecond)
+
+	_, err = p.FetchUser(sess)
+	a.Equal(boom, err)
+
+	_, err = p.RefreshToken(gothtest.RefreshToken("homer"))
+	a.IsType(&oauth2.RetrieveError{}, err)
+	a.Contains(err.Error(), "invalid_grant")
+}
+
+func Test_WithFaults_Timeout(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := gothtest.WithFaults(provider(), gothtest.Fault{Timeout: true})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err := goth.FetchUser(ctx, p, &gothtest.Session{AccessToken: gothtest.AccessToken("homer")})
+	a.Equal(context.DeadlineExceeded, err)
+
+	_, err = p.BeginAuth("state")
+	a.Equal(context.DeadlineExceeded, err)
+}
+
+func Test_NewFaultTransport(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	idp := gothtest.NewIdP(p)
+	defer idp.Close()
+
+	p.HTTPClient = &http.Client{Transport: gothtest.NewFaultTransport(nil, gothtest.Fault{
+		Match:         func(r *http.Request) bool { return r.URL.Path == "/token" },
+		MalformedJSON: true,
+	})}
+	_, err := (&gothtest.Session{}).Authorize(p, url.Values{"code": {"homer"}})
+	a.Error(err)
+
+	// other requests go through untouched
+	user, err := p.FetchUser(&gothtest.Session{AccessToken: gothtest.AccessToken("homer")})
+	a.NoError(err)
+	a.Equal("1", user.UserID)
+}
@@ -0,0 +1,52 @@
+//go:build go1.18
+// +build go1.18
+
+package gothtest_test
+
+import (
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/github"
+	"github.com/markbates/goth/providers/google"
+)
+
+func strict(f *testing.F) {
+	goth.StrictParsing = true
+	f.Cleanup(func() { goth.StrictParsing = false })
+}
+
+func FuzzUnmarshalSession(f *testing.F) {
+	strict(f)
+	providers := []goth.Provider{
+		provider(),
+		github.New("key", "secret", "/callback"),
+		google.New("key", "secret", "/callback"),
+	}
+	for _, p := range providers {
+		sess, _ := p.BeginAuth("state")
+		f.Add(0, []byte(sess.Marshal()))
+	}
+	f.Add(0, []byte(`{"AuthURL":"https://example.com","ExpiresAt":"9999-12-31T23:59:59Z"}`))
+	f.Add(1, []byte(`{"AuthURL":"https://example.com","Unknown":true}`))
+
+	f.Fuzz(func(t *testing.T, i int, data []byte) {
+		if i < 0 {
+			i = -i
+		}
+		gothtest.FuzzUnmarshalSession(providers[i%len(providers)], data)
+	})
+}
+
+func FuzzCallback(f *testing.F) {
+	strict(f)
+	f.Add([]byte("code=homer&state=state"))
+	f.Add([]byte("code=a&code=b&state=state"))
+	f.Add([]byte("error=access_denied&error_description=%ZZ"))
+	f.Add([]byte("state=%00%ff;code=1"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		gothtest.FuzzCallback(data)
+	})
+}
@@ -0,0 +1,181 @@
+package gothtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/markbates/goth"
+)
+
+// IdP is an in-memory OAuth2 identity provider serving the users of a fake
+// provider over HTTP, with these endpoints:
+//
+//	GET  /authorize  logs in the user added for the login_hint parameter (or
+//	                 the first one, by code), redirecting back with its code
+//	POST /token      exchanges codes and refresh tokens
+//	GET  /userinfo   returns the user a bearer access token was issued to
+//	POST /revoke     revokes the grant a token belongs to (RFC 7009)
+//
+// This exercises the redirects, token exchange and userinfo request of a real
+// login, e.g. by following the redirect of gothic.BeginAuthHandler.
+type IdP struct {
+	*httptest.Server
+	provider *Provider
+}
+
+// NewIdP starts an IdP serving the users of p and points p at it. Close it
+// when done.
+func NewIdP(p *Provider) *IdP {
+	idp := &IdP{provider: p}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/userinfo", idp.userInfo)
+	mux.HandleFunc("/revoke", idp.revoke)
+	idp.Server = httptest.NewServer(mux)
+	p.idp = idp
+	return idp
+}
+
+func (idp *IdP) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	params := redirect.Query()
+	params.Set("state", q.Get("state"))
+	if code, ok := idp.loginCode(q.Get("login_hint")); ok {
+		params.Set("code", code)
+	} else {
+		params.Set("error", "access_denied")
+	}
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (idp *IdP) loginCode(hint string) (string, bool) {
+	p := idp.provider
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if hint != "" {
+		_, ok := p.users[hint]
+		return hint, ok
+	}
+	codes := make([]string, 0, len(p.users))
+	for code := range p.users {
+		codes = append(codes, code)
+	}
+	if len(codes) == 0 {
+		return "", false
+	}
+	sort.Strings(codes)
+	return codes[0], true
+}
+
+func (idp *IdP) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.ParseForm()
+
+	var code string
+	var ok bool
+	switch r.Form.Get("grant_type") {
+	case "authorization_code":
+		code = r.Form.Get("code")
+		ok = idp.provider.grant(code)
+	case "refresh_token":
+		code = strings.TrimPrefix(r.Form.Get("refresh_token"), "refresh-")
+		_, ok = idp.provider.user(code)
+	default:
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+	if !ok {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	t := issueToken(code)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":  t.AccessToken,
+		"token_type":    t.TokenType,
+		"refresh_token": t.RefreshToken,
+		"expires_in":    int(TokenLifetime.Seconds()),
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func (idp *IdP) revoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.ParseForm()
+	// unknown tokens are not an error, see RFC 7009 section 2.2
+	idp.provider.revoke(r.Form.Get("token"))
+}
+
+func (idp *IdP) userInfo(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	user, ok := idp.provider.user(strings.TrimPrefix(token, "access-"))
+	if !ok || !strings.HasPrefix(token, "access-") {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userInfo{
+		Sub:         user.UserID,
+		Email:       user.Email,
+		Name:        user.Name,
+		GivenName:   user.FirstName,
+		FamilyName:  user.LastName,
+		Nickname:    user.NickName,
+		Picture:     user.AvatarURL,
+		Location:    user.Location,
+		Description: user.Description,
+	})
+}
+
+// userInfo is the document served by the userinfo endpoint.
+type userInfo struct {
+	Sub         string `json:"sub"`
+	Email       string `json:"email,omitempty"`
+	Name        string `json:"name,omitempty"`
+	GivenName   string `json:"given_name,omitempty"`
+	FamilyName  string `json:"family_name,omitempty"`
+	Nickname    string `json:"nickname,omitempty"`
+	Picture     string `json:"picture,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func (u userInfo) user() goth.User {
+	return goth.User{
+		UserID:      u.Sub,
+		Email:       u.Email,
+		Name:        u.Name,
+		FirstName:   u.GivenName,
+		LastName:    u.FamilyName,
+		NickName:    u.Nickname,
+		AvatarURL:   u.Picture,
+		Location:    u.Location,
+		Description: u.Description,
+	}
+}
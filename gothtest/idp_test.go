@@ -0,0 +1,71 @@
+package gothtest_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IdP(t *testing.T) {
+	a := assert.New(t)
+
+	p := provider()
+	p.AddUser("marge", goth.User{UserID: "2", Email: "marge@example.com"})
+	idp := gothtest.NewIdP(p)
+	defer idp.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth", gothic.BeginAuthHandler)
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		user, err := gothic.CompleteUserAuth(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, user.Email)
+	})
+	app := httptest.NewServer(mux)
+	defer app.Close()
+
+	p.CallbackURL = app.URL + "/callback?provider=fake"
+	goth.UseProviders(p)
+	defer goth.ClearProviders()
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+
+	// the IdP logs in the first user by code unless a login hint is given
+	res, err := client.Get(app.URL + "/auth?provider=fake")
+	a.NoError(err)
+	defer res.Body.Close()
+	a.Equal(http.StatusOK, res.StatusCode)
+	body, _ := ioutil.ReadAll(res.Body)
+	a.Equal("homer@example.com", string(body))
+
+	sess := &gothtest.Session{}
+	_, err = sess.Authorize(p, url.Values{"code": {"marge"}})
+	a.NoError(err)
+	user, err := p.FetchUser(sess)
+	a.NoError(err)
+	a.Equal("2", user.UserID)
+	a.NotEmpty(user.RawJSON)
+
+	_, err = p.RefreshToken(user.RefreshToken)
+	a.NoError(err)
+	_, err = p.RefreshToken("bogus")
+	a.Error(err)
+
+	a.NoError(goth.RevokeToken(context.Background(), p, user.AccessToken))
+	_, err = p.FetchUser(sess)
+	a.Error(err)
+}
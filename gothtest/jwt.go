@@ -0,0 +1,11 @@
+package gothtest
+
+import "encoding/base64"
+
+// UnsignedJWT returns a JWT with the given JSON claims and a placeholder
+// signature, for faking the id_token or access token of a token response to
+// providers that do not verify their signatures.
+func UnsignedJWT(claims string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"RS256"}`)) + "." + enc([]byte(claims)) + ".sig"
+}
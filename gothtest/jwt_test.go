@@ -0,0 +1,20 @@
+package gothtest_test
+
+import (
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_UnsignedJWT(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var c struct {
+		Subject string `json:"sub"`
+	}
+	a.NoError(goth.DecodeJWTClaims(gothtest.UnsignedJWT(`{"sub":"123"}`), &c))
+	a.Equal("123", c.Subject)
+}
@@ -0,0 +1,330 @@
+// Package gothtest provides a fake goth provider and an in-memory identity
+// provider for testing applications built on goth, without real credentials
+// or network access.
+//
+// Users are scripted by the authorization code that logs them in:
+//
+//	p := gothtest.New("fake", "http://localhost/auth/fake/callback")
+//	p.AddUser("homer", goth.User{UserID: "1", Email: "homer@example.com"})
+//	goth.UseProviders(p)
+//
+// A callback with code=homer then logs in as Homer. Sessions and tokens are
+// derived from the code, so they are the same on every run. To exercise the
+// HTTP side of the flow as well, start an IdP for the provider with NewIdP.
+package gothtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// Op identifies a provider operation a failure can be scripted for.
+type Op string
+
+const (
+	OpBeginAuth    Op = "BeginAuth"
+	OpAuthorize    Op = "Authorize"
+	OpFetchUser    Op = "FetchUser"
+	OpRefreshToken Op = "RefreshToken"
+	OpRevokeToken  Op = "RevokeToken"
+)
+
+// DefaultAuthURL is the authorization endpoint of providers without an IdP.
+const DefaultAuthURL = "https://gothtest.invalid/authorize"
+
+// TokenLifetime is how long the access tokens issued by the fake provider are valid.
+const TokenLifetime = time.Hour
+
+// ErrUnknownCode is returned when authorizing with a code no user was added for.
+var ErrUnknownCode = errors.New("gothtest: unknown authorization code")
+
+// ErrTokenExpired is returned when fetching a user with a session whose access
+// token has expired according to goth.GetClock. Use a Clock to expire tokens
+// in tests.
+var ErrTokenExpired = errors.New("gothtest: access token expired")
+
+// ErrUnknownToken is returned when fetching a user or refreshing with a token
+// that was not issued by the provider.
+var ErrUnknownToken = errors.New("gothtest: unknown token")
+
+// AccessToken returns the access token issued for code.
+func AccessToken(code string) string {
+	return "access-" + code
+}
+
+// RefreshToken returns the refresh token issued for code.
+func RefreshToken(code string) string {
+	return "refresh-" + code
+}
+
+// Provider is a fake goth.Provider serving scripted users.
+type Provider struct {
+	HTTPClient   *http.Client
+	CallbackURL  string
+	providerName string
+	idp          *IdP
+
+	mu       sync.Mutex
+	users    map[string]goth.User
+	revoked  map[string]bool
+	failures map[Op]error
+}
+
+// New creates a fake provider with the given name and no users.
+func New(name, callbackURL string) *Provider {
+	return &Provider{
+		CallbackURL:  callbackURL,
+		providerName: name,
+		users:        map[string]goth.User{},
+		revoked:      map[string]bool{},
+		failures:     map[Op]error{},
+	}
+}
+
+// AddUser makes the authorization code log in as user. The Provider,
+// AccessToken, RefreshToken and ExpiresAt fields of user are filled in by the
+// provider.
+func (p *Provider) AddUser(code string, user goth.User) {
+	p.mu.Lock()
+	p.users[code] = user
+	p.mu.Unlock()
+}
+
+// Fail makes every following call of op return err, until Fail is called
+// again for op with a nil error.
+func (p *Provider) Fail(op Op, err error) {
+	p.mu.Lock()
+	if err == nil {
+		delete(p.failures, op)
+	} else {
+		p.failures[op] = err
+	}
+	p.mu.Unlock()
+}
+
+func (p *Provider) failure(op Op) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.failures[op]
+}
+
+// user returns the user logged in by code, unless its tokens were revoked.
+func (p *Provider) user(code string) (goth.User, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	u, ok := p.users[code]
+	return u, ok && !p.revoked[code]
+}
+
+// grant reports whether a user was added for code, clearing any earlier
+// revocation of its tokens.
+func (p *Provider) grant(code string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.users[code]
+	if ok {
+		delete(p.revoked, code)
+	}
+	return ok
+}
+
+// revoke revokes the grant an access or refresh token belongs to.
+func (p *Provider) revoke(token string) {
+	code := strings.TrimPrefix(strings.TrimPrefix(token, "access-"), "refresh-")
+	p.mu.Lock()
+	p.revoked[code] = true
+	p.mu.Unlock()
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the HTTP client used to talk to the IdP.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the gothtest package.
+func (p *Provider) Debug(debug bool) {}
+
+func (p *Provider) config() *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     "gothtest",
+		ClientSecret: "gothtest",
+		RedirectURL:  p.CallbackURL,
+		Endpoint:     oauth2.Endpoint{AuthURL: DefaultAuthURL},
+	}
+	if p.idp != nil {
+		c.Endpoint = oauth2.Endpoint{
+			AuthURL:   p.idp.URL + "/authorize",
+			TokenURL:  p.idp.URL + "/token",
+			AuthStyle: oauth2.AuthStyleInHeader,
+		}
+	}
+	return c
+}
+
+// BeginAuth returns a session whose auth URL carries the state.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	if err := p.failure(OpBeginAuth); err != nil {
+		return nil, err
+	}
+	return &Session{AuthURL: p.config().AuthCodeURL(state)}, nil
+}
+
+// FetchUser returns the user the session's access token was issued to.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the userinfo request to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	if err := p.failure(OpFetchUser); err != nil {
+		return goth.User{}, err
+	}
+	if sess.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return goth.User{}, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+	if !sess.ExpiresAt.IsZero() && goth.GetClock().Now().After(sess.ExpiresAt) {
+		return goth.User{}, ErrTokenExpired
+	}
+
+	var user goth.User
+	if p.idp != nil {
+		var err error
+		if user, err = p.fetchUserInfo(ctx, sess.AccessToken); err != nil {
+			return user, err
+		}
+	} else {
+		var ok bool
+		if user, ok = p.user(strings.TrimPrefix(sess.AccessToken, "access-")); !ok {
+			return goth.User{}, ErrUnknownToken
+		}
+	}
+
+	user.Provider = p.Name()
+	user.AccessToken = sess.AccessToken
+	user.RefreshToken = sess.RefreshToken
+	user.ExpiresAt = sess.ExpiresAt
+	return user, nil
+}
+
+func (p *Provider) fetchUserInfo(ctx context.Context, accessToken string) (goth.User, error) {
+	user := goth.User{}
+	req, err := http.NewRequestWithContext(ctx, "GET", p.idp.URL+"/userinfo", nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	res, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, res.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return user, err
+	}
+	var info userInfo
+	if err := json.Unmarshal(bits, &info); err != nil {
+		return user, err
+	}
+	user = info.user()
+	return user, user.SetRawJSON(bits)
+}
+
+// UnmarshalSession will unmarshal a JSON string into a session.
+func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
+	sess := &Session{}
+	err := goth.DecodeSession(data, sess)
+	return sess, err
+}
+
+// RefreshTokenAvailable refresh token is provided by the fake provider
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	if err := p.failure(OpRefreshToken); err != nil {
+		return nil, err
+	}
+	if p.idp != nil {
+		token := &oauth2.Token{RefreshToken: refreshToken}
+		return p.config().TokenSource(goth.ContextWithClient(ctx, p.Client()), token).Token()
+	}
+
+	code := strings.TrimPrefix(refreshToken, "refresh-")
+	if _, ok := p.user(code); !ok {
+		return nil, ErrUnknownToken
+	}
+	return issueToken(code), nil
+}
+
+// RevokeToken revokes the grant an access or refresh token belongs to, so
+// that neither can be used any more. Authorizing with the code again grants
+// new tokens.
+func (p *Provider) RevokeToken(ctx context.Context, token string) error {
+	if err := p.failure(OpRevokeToken); err != nil {
+		return err
+	}
+	if p.idp == nil {
+		p.revoke(token)
+		return nil
+	}
+
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.idp.URL+"/revoke", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := p.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with a %d trying to revoke a token", p.providerName, res.StatusCode)
+	}
+	return nil
+}
+
+func issueToken(code string) *oauth2.Token {
+	return &oauth2.Token{
+		AccessToken:  AccessToken(code),
+		TokenType:    "Bearer",
+		RefreshToken: RefreshToken(code),
+		Expiry:       goth.GetClock().Now().Add(TokenLifetime),
+	}
+}
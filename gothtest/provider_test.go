@@ -0,0 +1,120 @@
+package gothtest_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func provider() *gothtest.Provider {
+	p := gothtest.New("fake", "http://localhost/auth/fake/callback")
+	p.AddUser("homer", goth.User{UserID: "1", Email: "homer@example.com", Name: "Homer Simpson"})
+	return p
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Implements((*goth.Provider)(nil), provider())
+	a.Implements((*goth.Session)(nil), &gothtest.Session{})
+}
+
+func Test_Login(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	sess, err := p.BeginAuth("state")
+	a.NoError(err)
+	authURL, _ := sess.GetAuthURL()
+	a.Contains(authURL, gothtest.DefaultAuthURL)
+	a.Contains(authURL, "state=state")
+
+	sess, err = p.UnmarshalSession(sess.Marshal())
+	a.NoError(err)
+	token, err := sess.Authorize(p, url.Values{"code": {"homer"}})
+	a.NoError(err)
+	a.Equal(gothtest.AccessToken("homer"), token)
+
+	user, err := p.FetchUser(sess)
+	a.NoError(err)
+	a.Equal("1", user.UserID)
+	a.Equal("fake", user.Provider)
+	a.Equal(gothtest.RefreshToken("homer"), user.RefreshToken)
+
+	refreshed, err := p.RefreshToken(user.RefreshToken)
+	a.NoError(err)
+	a.Equal(gothtest.AccessToken("homer"), refreshed.AccessToken)
+
+	_, err = (&gothtest.Session{}).Authorize(p, url.Values{"code": {"bart"}})
+	a.Equal(gothtest.ErrUnknownCode, err)
+	_, err = p.RefreshToken("bogus")
+	a.Equal(gothtest.ErrUnknownToken, err)
+}
+
+func Test_Fail(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	boom := errors.New("boom")
+	p.Fail(gothtest.OpFetchUser, boom)
+
+	sess := &gothtest.Session{}
+	_, err := sess.Authorize(p, url.Values{"code": {"homer"}})
+	a.NoError(err)
+	_, err = p.FetchUser(sess)
+	a.Equal(boom, err)
+
+	p.Fail(gothtest.OpFetchUser, nil)
+	_, err = p.FetchUser(sess)
+	a.NoError(err)
+}
+
+func Test_RevokeToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	sess := &gothtest.Session{}
+	_, err := sess.Authorize(p, url.Values{"code": {"homer"}})
+	a.NoError(err)
+
+	a.NoError(goth.RevokeToken(context.Background(), p, sess.RefreshToken))
+	_, err = p.FetchUser(sess)
+	a.Equal(gothtest.ErrUnknownToken, err)
+	_, err = p.RefreshToken(sess.RefreshToken)
+	a.Equal(gothtest.ErrUnknownToken, err)
+
+	_, err = sess.Authorize(p, url.Values{"code": {"homer"}})
+	a.NoError(err)
+	_, err = p.FetchUser(sess)
+	a.NoError(err)
+}
+
+func Test_TokenExpiry(t *testing.T) {
+	a := assert.New(t)
+
+	clock := gothtest.NewClock(time.Now())
+	goth.UseClock(clock)
+	defer goth.UseClock(nil)
+
+	p := provider()
+	sess := &gothtest.Session{}
+	_, err := sess.Authorize(p, url.Values{"code": {"homer"}})
+	a.NoError(err)
+	a.Equal(clock.Now().Add(gothtest.TokenLifetime), sess.ExpiresAt)
+
+	_, err = p.FetchUser(sess)
+	a.NoError(err)
+	clock.Advance(gothtest.TokenLifetime + time.Second)
+	_, err = p.FetchUser(sess)
+	a.Equal(gothtest.ErrTokenExpired, err)
+}
@@ -0,0 +1,57 @@
+package gothtest_test
+
+import (
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Recorder(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	cassette := filepath.Join(t.TempDir(), "testdata", "login.json")
+	p := provider()
+	idp := gothtest.NewIdP(p)
+
+	rec, err := gothtest.NewRecorder(cassette, gothtest.ModeRecord, nil)
+	a.NoError(err)
+	p.HTTPClient = rec.Client()
+	sess := &gothtest.Session{}
+	_, err = sess.Authorize(p, url.Values{"code": {"homer"}})
+	a.NoError(err)
+	user, err := p.FetchUser(sess)
+	a.NoError(err)
+	a.Equal("homer@example.com", user.Email)
+	a.NoError(rec.Stop())
+	idp.Close()
+
+	b, err := ioutil.ReadFile(cassette)
+	a.NoError(err)
+	a.Len(rec.Interactions(), 2)
+	a.NotContains(string(b), gothtest.AccessToken("homer"))
+	a.NotContains(string(b), gothtest.RefreshToken("homer"))
+	a.NotContains(string(b), "code=homer")
+
+	// the IdP is gone, so everything has to come from the cassette
+	rec, err = gothtest.NewRecorder(cassette, gothtest.ModeReplay, nil)
+	a.NoError(err)
+	p.HTTPClient = rec.Client()
+	sess = &gothtest.Session{}
+	_, err = sess.Authorize(p, url.Values{"code": {"marge"}})
+	a.NoError(err)
+	user, err = p.FetchUser(sess)
+	a.NoError(err)
+	a.Equal("homer@example.com", user.Email)
+
+	// each interaction is only replayed once
+	_, err = p.FetchUser(sess)
+	a.Error(err)
+
+	_, err = gothtest.NewRecorder(filepath.Join(t.TempDir(), "missing.json"), gothtest.ModeReplay, nil)
+	a.Error(err)
+}
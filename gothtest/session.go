@@ -0,0 +1,72 @@
+package gothtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// Session stores data during the auth process with the fake provider.
+type Session struct {
+	AuthURL      string
+	Code         string
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// GetAuthURL will return the URL set by calling the `BeginAuth` function on the fake provider.
+func (s Session) GetAuthURL() (string, error) {
+	if s.AuthURL == "" {
+		return "", errors.New(goth.NoAuthUrlErrorMessage)
+	}
+	return s.AuthURL, nil
+}
+
+// Authorize exchanges the code in params for the tokens of the user it was added for.
+func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
+	p := provider.(*Provider)
+	if err := p.failure(OpAuthorize); err != nil {
+		return "", err
+	}
+
+	code := params.Get("code")
+	var token *oauth2.Token
+	if p.idp != nil {
+		var err error
+		token, err = p.config().Exchange(goth.ContextWithClient(ctx, p.Client()), code)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		if !p.grant(code) {
+			return "", ErrUnknownCode
+		}
+		token = issueToken(code)
+	}
+
+	s.Code = code
+	s.AccessToken = token.AccessToken
+	s.RefreshToken = token.RefreshToken
+	s.ExpiresAt = token.Expiry
+	return s.AccessToken, nil
+}
+
+// Marshal the session into a string
+func (s Session) Marshal() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func (s Session) String() string {
+	return s.Marshal()
+}
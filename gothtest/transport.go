@@ -0,0 +1,18 @@
+package gothtest
+
+import "net/http"
+
+// RoundTripperFunc adapts a function to an http.RoundTripper, for faking the
+// responses of a provider's API in tests:
+//
+//	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+//		rec := httptest.NewRecorder()
+//		fmt.Fprint(rec, `{"id":"1"}`)
+//		return rec.Result(), nil
+//	})}
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,28 @@
+package gothtest_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RoundTripperFunc(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	client := &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, req.URL.Path)
+		return rec.Result(), nil
+	})}
+	res, err := client.Get("https://example.com/userinfo")
+	a.NoError(err)
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+	a.Equal("/userinfo", string(body))
+}
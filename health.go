@@ -0,0 +1,142 @@
+package goth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// HealthChecker can optionally be implemented by providers that know a cheap
+// way to check that the 3rd party is reachable and the provider is configured
+// correctly, e.g. by fetching its OpenID Connect discovery document.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// ErrUnhealthy is returned by HealthCheck when a provider's endpoint could not
+// be reached or answered with a server error.
+type ErrUnhealthy struct {
+	Provider string
+	Endpoint string
+	Err      error
+}
+
+func (e *ErrUnhealthy) Error() string {
+	return fmt.Sprintf("provider %s is unhealthy: %s: %v", e.Provider, e.Endpoint, e.Err)
+}
+
+func (e *ErrUnhealthy) Unwrap() error {
+	return e.Err
+}
+
+// HealthCheck checks that the provider's 3rd party is reachable. Providers
+// implementing HealthChecker are asked to check themselves; for all others the
+// authorization endpoint, taken from the auth URL of a new session, is probed
+// with a HEAD request.
+func HealthCheck(ctx context.Context, provider Provider) error {
+	if hc, ok := provider.(HealthChecker); ok {
+		return hc.HealthCheck(ctx)
+	}
+
+	sess, err := provider.BeginAuth("healthcheck")
+	if err != nil {
+		return &ErrUnhealthy{Provider: provider.Name(), Endpoint: "BeginAuth", Err: err}
+	}
+	authURL, err := sess.GetAuthURL()
+	if err != nil {
+		return &ErrUnhealthy{Provider: provider.Name(), Endpoint: "GetAuthURL", Err: err}
+	}
+	var client *http.Client
+	if c, ok := provider.(interface{ Client() *http.Client }); ok {
+		client = c.Client()
+	}
+	return ProbeEndpoint(ctx, provider.Name(), client, authURL)
+}
+
+// ProbeEndpoint sends a HEAD request to endpoint and reports it unhealthy if no
+// response is received or the response is a server error. Other responses,
+// such as 405 Method Not Allowed, show the endpoint is up and are accepted.
+// Providers can use it to implement HealthChecker.
+func ProbeEndpoint(ctx context.Context, provider string, client *http.Client, endpoint string) error {
+	// the query string of auth URLs holds the client ID and state, which don't
+	// belong in health check errors
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return &ErrUnhealthy{Provider: provider, Endpoint: endpoint, Err: err}
+	}
+	u.RawQuery = ""
+	endpoint = u.String()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+	if err != nil {
+		return &ErrUnhealthy{Provider: provider, Endpoint: endpoint, Err: err}
+	}
+	res, err := HTTPClientWithFallBack(client).Do(req)
+	if err != nil {
+		return &ErrUnhealthy{Provider: provider, Endpoint: endpoint, Err: err}
+	}
+	res.Body.Close()
+	if res.StatusCode >= 500 {
+		return &ErrUnhealthy{Provider: provider, Endpoint: endpoint, Err: fmt.Errorf("status %d", res.StatusCode)}
+	}
+	return nil
+}
+
+// HealthReport holds the outcome of HealthCheck for each provider, keyed by
+// provider name. Healthy providers map to nil.
+type HealthReport map[string]error
+
+// Healthy reports whether every provider passed its check.
+func (r HealthReport) Healthy() bool {
+	for _, err := range r {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Err returns an error listing the failed providers, or nil if all are healthy.
+func (r HealthReport) Err() error {
+	failed := []string{}
+	for _, err := range r {
+		if err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.Strings(failed)
+	return fmt.Errorf("%d unhealthy provider(s): %s", len(failed), strings.Join(failed, "; "))
+}
+
+// CheckProviders runs HealthCheck concurrently for every provider in use, for
+// readiness probes:
+//
+//	http.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+//		if err := goth.CheckProviders(r.Context()).Err(); err != nil {
+//			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//		}
+//	})
+func CheckProviders(ctx context.Context) HealthReport {
+	report := HealthReport{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, provider := range GetProviders() {
+		wg.Add(1)
+		go func(name string, provider Provider) {
+			defer wg.Done()
+			err := HealthCheck(ctx, provider)
+			mu.Lock()
+			report[name] = err
+			mu.Unlock()
+		}(name, provider)
+	}
+	wg.Wait()
+	return report
+}
@@ -0,0 +1,70 @@
+package goth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HealthCheck(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var method, query string
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, query = r.Method, r.URL.RawQuery
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	p := github.NewCustomisedURL("key", "secret", "/callback", up.URL+"/authorize", "", "", "")
+	a.NoError(goth.HealthCheck(context.Background(), p))
+	a.Equal(http.MethodHead, method)
+	a.Empty(query)
+
+	p = github.NewCustomisedURL("key", "secret", "/callback", down.URL+"/authorize", "", "", "")
+	err := goth.HealthCheck(context.Background(), p)
+	a.Error(err)
+	a.IsType(&goth.ErrUnhealthy{}, err)
+	a.NotContains(err.Error(), "client_id")
+}
+
+func Test_HealthReport(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	r := goth.HealthReport{"github": nil}
+	a.True(r.Healthy())
+	a.NoError(r.Err())
+
+	r["google"] = &goth.ErrUnhealthy{Provider: "google", Endpoint: "https://accounts.google.com", Err: context.DeadlineExceeded}
+	a.False(r.Healthy())
+	a.Contains(r.Err().Error(), "google")
+}
+
+func Test_HealthCheckStrict(t *testing.T) {
+	a := assert.New(t)
+	strictParsing(t)
+
+	// authorization endpoints serve the login page
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}))
+	defer up.Close()
+
+	p := github.NewCustomisedURL("key", "secret", "/callback", up.URL+"/authorize", "", "", "")
+	a.NoError(goth.HealthCheck(context.Background(), p))
+
+	// other requests serving HTML are still rejected
+	_, err := goth.HTTPClientWithFallBack(nil).Get(up.URL)
+	a.Error(err)
+}
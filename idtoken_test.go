@@ -0,0 +1,64 @@
+package goth_test
+
+import (
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DecodeJWTClaims(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var c struct {
+		Roles []string `json:"roles"`
+	}
+	a.NoError(goth.DecodeJWTClaims(gothtest.UnsignedJWT(`{"roles":["admin"]}`), &c))
+	a.Equal([]string{"admin"}, c.Roles)
+
+	a.Error(goth.DecodeJWTClaims("not-a-jwt", &c))
+	a.Error(goth.DecodeJWTClaims("a.!.c", &c))
+	a.Error(goth.DecodeJWTClaims(gothtest.UnsignedJWT(`{`), &c))
+}
+
+func Test_CheckIDToken(t *testing.T) {
+	t.Parallel()
+
+	const issuer = "https://idp.example.com"
+	for name, tc := range map[string]struct {
+		claims   string
+		issuer   string
+		mismatch bool
+		valid    bool
+	}{
+		"valid":           {claims: `{"iss":"https://idp.example.com","aud":"myapp"}`, issuer: issuer, valid: true},
+		"audienceArray":   {claims: `{"iss":"https://idp.example.com","aud":["account","myapp"]}`, issuer: issuer, valid: true},
+		"trailingSlash":   {claims: `{"iss":"https://idp.example.com/","aud":"myapp"}`, issuer: issuer, valid: true},
+		"issuerNotSet":    {claims: `{"iss":"https://other.example.com","aud":"myapp"}`, valid: true},
+		"otherIssuer":     {claims: `{"iss":"https://other.example.com","aud":"myapp"}`, issuer: issuer, mismatch: true},
+		"otherClient":     {claims: `{"iss":"https://idp.example.com","aud":"account"}`, issuer: issuer},
+		"otherClients":    {claims: `{"iss":"https://idp.example.com","aud":["account"]}`, issuer: issuer},
+		"missingAudience": {claims: `{"iss":"https://idp.example.com"}`, issuer: issuer},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			err := goth.CheckIDToken("idp", gothtest.UnsignedJWT(tc.claims), tc.issuer, "myapp")
+			switch {
+			case tc.valid:
+				a.NoError(err)
+			case tc.mismatch:
+				a.IsType(&goth.ErrIssuerMismatch{}, err)
+				a.Equal(`idp: id_token was issued by "https://other.example.com", not "https://idp.example.com"`, err.Error())
+			default:
+				a.EqualError(err, `idp: id_token was not issued for "myapp"`)
+			}
+		})
+	}
+	a := assert.New(t)
+	a.Error(goth.CheckIDToken("idp", "not-a-jwt", issuer, "myapp"))
+}
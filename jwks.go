@@ -0,0 +1,199 @@
+package goth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+)
+
+const (
+	// DefaultJWKSTTL is how long a fetched key set is used before it is refetched.
+	DefaultJWKSTTL = time.Hour
+	// DefaultJWKSMinRefreshInterval bounds how often a key set is refetched
+	// because a token was signed with an unknown key ID.
+	DefaultJWKSMinRefreshInterval = time.Minute
+)
+
+// ErrJWKSKeyNotFound is returned when no key with the requested ID exists in a key set.
+type ErrJWKSKeyNotFound struct {
+	URL string
+	KID string
+}
+
+func (e *ErrJWKSKeyNotFound) Error() string {
+	return fmt.Sprintf("no key with id %q found in %s", e.KID, e.URL)
+}
+
+// JWKSCache caches JSON Web Key Sets by URL so that validating an id_token
+// doesn't require fetching the provider's jwks_uri on every login.
+//
+// Sets are refetched once they are older than TTL, and at most once every
+// MinRefreshInterval when a key ID is not found, which picks up key rotations
+// without letting tokens with bogus key IDs hammer the provider. Concurrent
+// lookups of the same set share a single fetch. If a refetch fails the
+// previously fetched set keeps being used.
+type JWKSCache struct {
+	// Client is used to fetch key sets. goth.HTTPClientWithFallBack(nil) is used if it is nil.
+	Client *http.Client
+	// TTL defaults to DefaultJWKSTTL.
+	TTL time.Duration
+	// MinRefreshInterval defaults to DefaultJWKSMinRefreshInterval.
+	MinRefreshInterval time.Duration
+
+	mu   sync.Mutex
+	sets map[string]*jwksEntry
+}
+
+type jwksEntry struct {
+	mu          sync.Mutex
+	set         jwk.Set
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// NewJWKSCache returns a JWKSCache fetching key sets with client, which may be nil.
+func NewJWKSCache(client *http.Client) *JWKSCache {
+	return &JWKSCache{
+		Client:             client,
+		TTL:                DefaultJWKSTTL,
+		MinRefreshInterval: DefaultJWKSMinRefreshInterval,
+	}
+}
+
+func (c *JWKSCache) entry(url string) *jwksEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sets == nil {
+		c.sets = map[string]*jwksEntry{}
+	}
+	e, ok := c.sets[url]
+	if !ok {
+		e = &jwksEntry{}
+		c.sets[url] = e
+	}
+	return e
+}
+
+func (c *JWKSCache) ttl() time.Duration {
+	if c.TTL > 0 {
+		return c.TTL
+	}
+	return DefaultJWKSTTL
+}
+
+func (c *JWKSCache) minRefreshInterval() time.Duration {
+	if c.MinRefreshInterval > 0 {
+		return c.MinRefreshInterval
+	}
+	return DefaultJWKSMinRefreshInterval
+}
+
+// fetch refreshes the entry. It must be called with e.mu held.
+func (c *JWKSCache) fetch(ctx context.Context, url string, e *jwksEntry) error {
+	e.attemptedAt = GetClock().Now()
+	set, err := jwk.Fetch(ctx, url, jwk.WithHTTPClient(HTTPClientWithFallBack(c.Client)))
+	if err != nil {
+		GetLogger().Warn("failed to fetch JWKS", "url", url, "error", err)
+		return err
+	}
+	e.set = set
+	e.fetchedAt = e.attemptedAt
+	return nil
+}
+
+// Set returns the key set published at url, fetching it if it isn't cached or has expired.
+func (c *JWKSCache) Set(ctx context.Context, url string) (jwk.Set, error) {
+	e := c.entry(url)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.set == nil || GetClock().Now().Sub(e.fetchedAt) > c.ttl() {
+		if err := c.fetch(ctx, url, e); err != nil && e.set == nil {
+			return nil, err
+		}
+	}
+	return e.set, nil
+}
+
+// Key returns the key with the given ID from the key set published at url. If
+// the key is unknown the set is refetched, unless that happened recently.
+func (c *JWKSCache) Key(ctx context.Context, url, kid string) (jwk.Key, error) {
+	set, err := c.Set(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := set.LookupKeyID(kid); ok {
+		return key, nil
+	}
+
+	e := c.entry(url)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// another caller may have refetched the set while we were waiting
+	if key, ok := e.set.LookupKeyID(kid); ok {
+		return key, nil
+	}
+	if GetClock().Now().Sub(e.attemptedAt) >= c.minRefreshInterval() {
+		if err := c.fetch(ctx, url, e); err != nil {
+			return nil, err
+		}
+		if key, ok := e.set.LookupKeyID(kid); ok {
+			return key, nil
+		}
+	}
+	return nil, &ErrJWKSKeyNotFound{URL: url, KID: kid}
+}
+
+// PublicKey is like Key but returns the raw public key (e.g. an *rsa.PublicKey
+// or *ecdsa.PublicKey), as expected by most JWT libraries.
+func (c *JWKSCache) PublicKey(ctx context.Context, url, kid string) (interface{}, error) {
+	key, err := c.Key(ctx, url, kid)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err := key.Raw(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// StartAutoRefresh refreshes every cached key set in the background shortly
+// before it expires, so logins never wait on a fetch. It returns immediately
+// and stops when ctx is done.
+func (c *JWKSCache) StartAutoRefresh(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(c.ttl() / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.refreshExpiring(ctx)
+			}
+		}
+	}()
+}
+
+func (c *JWKSCache) refreshExpiring(ctx context.Context) {
+	c.mu.Lock()
+	entries := make(map[string]*jwksEntry, len(c.sets))
+	for url, e := range c.sets {
+		entries[url] = e
+	}
+	c.mu.Unlock()
+
+	for url, e := range entries {
+		e.mu.Lock()
+		if GetClock().Now().Sub(e.fetchedAt) > c.ttl()/2 {
+			c.fetch(ctx, url, e)
+		}
+		e.mu.Unlock()
+	}
+}
@@ -0,0 +1,107 @@
+package goth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/markbates/goth"
+	"github.com/stretchr/testify/assert"
+)
+
+func jwksServer(t *testing.T, kids *atomic.Value) (*httptest.Server, *int32) {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fetches int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		set := jwk.NewSet()
+		for _, kid := range kids.Load().([]string) {
+			key, _ := jwk.New(&priv.PublicKey)
+			key.Set(jwk.KeyIDKey, kid)
+			set.Add(key)
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	return ts, &fetches
+}
+
+func Test_JWKSCache(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	kids := &atomic.Value{}
+	kids.Store([]string{"one"})
+	ts, fetches := jwksServer(t, kids)
+	defer ts.Close()
+
+	c := goth.NewJWKSCache(nil)
+	c.MinRefreshInterval = 10 * time.Millisecond
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Key(context.Background(), ts.URL, "one")
+			a.NoError(err)
+		}()
+	}
+	wg.Wait()
+	a.Equal(int32(1), atomic.LoadInt32(fetches))
+
+	key, err := c.PublicKey(context.Background(), ts.URL, "one")
+	a.NoError(err)
+	a.IsType(&rsa.PublicKey{}, key)
+	a.Equal(int32(1), atomic.LoadInt32(fetches))
+
+	// unknown key IDs only trigger a refetch once per MinRefreshInterval
+	kids.Store([]string{"one", "two"})
+	time.Sleep(15 * time.Millisecond)
+	_, err = c.Key(context.Background(), ts.URL, "two")
+	a.NoError(err)
+	a.Equal(int32(2), atomic.LoadInt32(fetches))
+
+	_, err = c.Key(context.Background(), ts.URL, "bogus")
+	var notFound *goth.ErrJWKSKeyNotFound
+	a.True(errors.As(err, &notFound))
+	_, err = c.Key(context.Background(), ts.URL, "bogus")
+	a.Error(err)
+	a.Equal(int32(2), atomic.LoadInt32(fetches))
+}
+
+func Test_JWKSCache_Expiry(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	kids := &atomic.Value{}
+	kids.Store([]string{"one"})
+	ts, fetches := jwksServer(t, kids)
+
+	c := goth.NewJWKSCache(nil)
+	c.TTL = 5 * time.Millisecond
+	_, err := c.Set(context.Background(), ts.URL)
+	a.NoError(err)
+
+	time.Sleep(10 * time.Millisecond)
+	_, err = c.Set(context.Background(), ts.URL)
+	a.NoError(err)
+	a.Equal(int32(2), atomic.LoadInt32(fetches))
+
+	// a stale set keeps being served when the provider is unreachable
+	ts.Close()
+	time.Sleep(10 * time.Millisecond)
+	_, err = c.Key(context.Background(), ts.URL, "one")
+	a.NoError(err)
+}
@@ -0,0 +1,259 @@
+package goth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger is the structured logger goth and gothic write to. Arguments after the
+// message are alternating keys and values. A *slog.Logger satisfies this
+// interface, as do thin wrappers around most structured logging libraries.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+var loggerLock sync.RWMutex
+var logger Logger = stdLogger{}
+
+// UseLogger sets the Logger used by goth and gothic. By default warnings and
+// errors are written through the standard library's log package and all other
+// messages are discarded. Passing nil restores the default.
+func UseLogger(l Logger) {
+	if l == nil {
+		l = stdLogger{}
+	}
+	loggerLock.Lock()
+	logger = l
+	loggerLock.Unlock()
+}
+
+// GetLogger returns the Logger currently in use.
+func GetLogger() Logger {
+	loggerLock.RLock()
+	defer loggerLock.RUnlock()
+	return logger
+}
+
+// logProviderError logs a failed call to provider at debug level. The error is
+// returned to the caller as well, which decides whether it deserves more.
+func logProviderError(msg string, provider Provider, err error) {
+	if err != nil {
+		GetLogger().Debug(msg, "provider", provider.Name(), "error", err)
+	}
+}
+
+// stdLogger writes warnings and errors to the standard logger.
+type stdLogger struct{}
+
+func (stdLogger) Debug(string, ...interface{}) {}
+func (stdLogger) Info(string, ...interface{})  {}
+
+func (stdLogger) Warn(msg string, args ...interface{}) {
+	log.Print(formatLog("WARN", msg, args))
+}
+
+func (stdLogger) Error(msg string, args ...interface{}) {
+	log.Print(formatLog("ERROR", msg, args))
+}
+
+func formatLog(level, msg string, args []interface{}) string {
+	var b strings.Builder
+	b.WriteString("goth: ")
+	b.WriteString(level)
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", args[i])
+		}
+	}
+	return b.String()
+}
+
+// RedactedValue replaces secrets removed by the Redact functions.
+const RedactedValue = "REDACTED"
+
+// SensitiveParams lists the (lower case) parameter, header and JSON field names
+// whose values are treated as secrets by the Redact functions.
+var SensitiveParams = map[string]bool{
+	"access_token":        true,
+	"refresh_token":       true,
+	"id_token":            true,
+	"token":               true,
+	"code":                true,
+	"code_verifier":       true,
+	"client_secret":       true,
+	"client_assertion":    true,
+	"assertion":           true,
+	"password":            true,
+	"oauth_token":         true,
+	"oauth_token_secret":  true,
+	"oauth_verifier":      true,
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"x-api-key":           true,
+	"subject_token":       true,
+	"actor_token":         true,
+	"fb_exchange_token":   true,
+	"shared_secret":       true,
+	"openid.sig":          true,
+	"openid.assoc_handle": true,
+	"device_code":         true,
+}
+
+// IsSensitive reports whether the named parameter, header or field holds a secret.
+func IsSensitive(name string) bool {
+	return SensitiveParams[strings.ToLower(name)]
+}
+
+// RedactValues returns a copy of v with the values of sensitive keys replaced.
+func RedactValues(v url.Values) url.Values {
+	out := make(url.Values, len(v))
+	for k, vals := range v {
+		if IsSensitive(k) {
+			out[k] = []string{RedactedValue}
+			continue
+		}
+		out[k] = append([]string(nil), vals...)
+	}
+	return out
+}
+
+// RedactURL returns u as a string with the values of sensitive query
+// parameters and any user info replaced.
+func RedactURL(u *url.URL) string {
+	c := *u
+	if c.User != nil {
+		c.User = url.User(RedactedValue)
+	}
+	if c.RawQuery != "" {
+		c.RawQuery = RedactValues(c.Query()).Encode()
+	}
+	return c.String()
+}
+
+// RedactHeader returns a copy of h with the values of sensitive headers replaced.
+func RedactHeader(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, vals := range h {
+		if IsSensitive(k) {
+			out[k] = []string{RedactedValue}
+			continue
+		}
+		out[k] = append([]string(nil), vals...)
+	}
+	return out
+}
+
+// RedactJSON returns the JSON document b with the values of sensitive fields,
+// at any depth, replaced. b is returned unchanged if it isn't valid JSON.
+func RedactJSON(b []byte) []byte {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	// keep large numeric IDs intact
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return b
+	}
+	out, err := json.Marshal(redactJSONValue(v))
+	if err != nil {
+		return b
+	}
+	return out
+}
+
+// RedactBody returns the request or response body b with secrets redacted
+// according to its content type: sensitive parameters of form bodies and
+// sensitive fields of JSON bodies are replaced, and JWTs are replaced as a
+// whole. Bodies of other types are returned unchanged, unless they parse as a
+// form with a sensitive parameter, as some providers serve token responses
+// as text/plain.
+func RedactBody(contentType string, b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		if v, err := url.ParseQuery(string(b)); err == nil {
+			return []byte(RedactValues(v).Encode())
+		}
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return RedactJSON(b)
+	case mediaType == "application/jwt":
+		return []byte(RedactedValue)
+	default:
+		if v, err := url.ParseQuery(string(b)); err == nil {
+			for k := range v {
+				if IsSensitive(k) {
+					return []byte(RedactValues(v).Encode())
+				}
+			}
+		}
+	}
+	return b
+}
+
+func redactJSONValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if IsSensitive(k) {
+				v[k] = RedactedValue
+				continue
+			}
+			v[k] = redactJSONValue(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = redactJSONValue(val)
+		}
+	}
+	return v
+}
+
+type loggingTransport struct {
+	provider string
+	next     http.RoundTripper
+}
+
+// NewLoggingTransport returns an http.RoundTripper that logs every request made
+// through it, with secrets redacted, at debug level to the configured Logger.
+// If next is nil http.DefaultTransport is used.
+func NewLoggingTransport(provider string, next http.RoundTripper) http.RoundTripper {
+	return &loggingTransport{provider: provider, next: next}
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	start := time.Now()
+	res, err := next.RoundTrip(req)
+	l := GetLogger()
+	if err != nil {
+		l.Debug("provider request failed", "provider", t.provider, "method", req.Method,
+			"url", RedactURL(req.URL), "duration", time.Since(start), "error", err)
+		return res, err
+	}
+	l.Debug("provider request", "provider", t.provider, "method", req.Method,
+		"url", RedactURL(req.URL), "status", res.StatusCode, "duration", time.Since(start))
+	return res, err
+}
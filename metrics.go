@@ -0,0 +1,142 @@
+package goth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrorClass buckets authentication failures for reporting purposes.
+type ErrorClass string
+
+const (
+	// ErrorClassProvider is used when the requested provider could not be resolved.
+	ErrorClassProvider ErrorClass = "provider"
+	// ErrorClassSession is used when no, or an unreadable, session was found for the callback.
+	ErrorClassSession ErrorClass = "session"
+	// ErrorClassCallback is used when the callback request was malformed.
+	ErrorClassCallback ErrorClass = "callback"
+	// ErrorClassState is used when the state returned by the provider did not match.
+	ErrorClassState ErrorClass = "state"
+	// ErrorClassExchange is used when the authorization code could not be exchanged for a token.
+	ErrorClassExchange ErrorClass = "exchange"
+	// ErrorClassFetchUser is used when the user could not be fetched from the provider.
+	ErrorClassFetchUser ErrorClass = "fetch_user"
+	// ErrorClassRateLimited is used when a request was rejected by a rate limiter.
+	ErrorClassRateLimited ErrorClass = "rate_limited"
+	// ErrorClassInternal is used for everything else, e.g. failures persisting the session.
+	ErrorClassInternal ErrorClass = "internal"
+)
+
+// Metrics receives measurements about authentication activity. Implementations
+// must be safe for concurrent use. See the promgoth package for a Prometheus
+// implementation.
+type Metrics interface {
+	// AuthStarted is called when a user is sent to the provider to authenticate.
+	AuthStarted(provider string)
+	// AuthCompleted is called when a user returned from the provider and was fetched successfully.
+	AuthCompleted(provider string)
+	// AuthFailed is called when beginning or completing authentication failed.
+	AuthFailed(provider string, class ErrorClass)
+	// TokenRefreshed is called after every refresh attempt made through goth.RefreshToken.
+	TokenRefreshed(provider string, err error)
+	// ProviderRequest is called after every HTTP request made through a MetricsTransport.
+	// host is the host the request was sent to; the path is left out to keep
+	// the number of label values bounded. statusCode is 0 if no response was
+	// received.
+	ProviderRequest(provider, host string, statusCode int, duration time.Duration)
+}
+
+// NopMetrics is a Metrics implementation that discards everything. It is used
+// until UseMetrics is called.
+type NopMetrics struct{}
+
+func (NopMetrics) AuthStarted(string)                                 {}
+func (NopMetrics) AuthCompleted(string)                               {}
+func (NopMetrics) AuthFailed(string, ErrorClass)                      {}
+func (NopMetrics) TokenRefreshed(string, error)                       {}
+func (NopMetrics) ProviderRequest(string, string, int, time.Duration) {}
+
+var metricsLock sync.RWMutex
+var metrics Metrics = NopMetrics{}
+
+// UseMetrics sets the Metrics implementation goth and gothic report to.
+// Passing nil restores the default NopMetrics.
+func UseMetrics(m Metrics) {
+	if m == nil {
+		m = NopMetrics{}
+	}
+	metricsLock.Lock()
+	metrics = m
+	metricsLock.Unlock()
+}
+
+// GetMetrics returns the Metrics implementation currently in use.
+func GetMetrics() Metrics {
+	metricsLock.RLock()
+	defer metricsLock.RUnlock()
+	return metrics
+}
+
+// RefreshToken asks the provider for a new access token and reports the
+// attempt to the configured Metrics and AuditSink, logging failures at debug
+// level. Prefer it over calling Provider.RefreshToken directly.
+func RefreshToken(provider Provider, refreshToken string) (*oauth2.Token, error) {
+	return RefreshTokenContext(context.Background(), provider, refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken, passing ctx on to the provider if
+// it implements ContextTokenRefresher.
+//
+// Concurrent calls refreshing the same token with the same provider are
+// coalesced into a single request to the provider, whose result is shared
+// with every caller, and with later callers for RefreshReuseWindow. The
+// returned token must not be modified. The refresh keeps the values of ctx,
+// but is not cancelled with it, see RefreshTimeout.
+func RefreshTokenContext(ctx context.Context, provider Provider, refreshToken string) (*oauth2.Token, error) {
+	return refreshOnce(ctx, provider.Name(), refreshToken, func(ctx context.Context) (*oauth2.Token, error) {
+		var token *oauth2.Token
+		var err error
+		if cr, ok := provider.(ContextTokenRefresher); ok {
+			token, err = cr.RefreshTokenContext(ctx, refreshToken)
+		} else {
+			token, err = provider.RefreshToken(refreshToken)
+		}
+		logProviderError("failed to refresh token", provider, err)
+		GetMetrics().TokenRefreshed(provider.Name(), err)
+		Audit(ctx, AuditEvent{Type: AuditTokenRefreshed, Provider: provider.Name(), Error: errorString(err)})
+		return token, err
+	})
+}
+
+type metricsTransport struct {
+	provider string
+	next     http.RoundTripper
+}
+
+// NewMetricsTransport returns an http.RoundTripper that reports the latency and
+// status code of every request made through it to the configured Metrics.
+// Requests are reported by host; wrap next with NewLoggingTransport to log the
+// URLs requested. If next is nil http.DefaultTransport is used.
+func NewMetricsTransport(provider string, next http.RoundTripper) http.RoundTripper {
+	return &metricsTransport{provider: provider, next: next}
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	start := time.Now()
+	res, err := next.RoundTrip(req)
+	status := 0
+	if res != nil {
+		status = res.StatusCode
+	}
+	GetMetrics().ProviderRequest(t.provider, req.URL.Host, status, time.Since(start))
+	return res, err
+}
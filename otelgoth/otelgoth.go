@@ -0,0 +1,245 @@
+/*
+Package otelgoth instruments Goth providers with OpenTelemetry tracing.
+
+Wrap a provider before registering it to get spans for BeginAuth, the token
+exchange performed by Session.Authorize, RefreshToken and FetchUser. Spans are
+children of the span in the request context when called through gothic or the
+goth.Authorize, goth.FetchUser and goth.RefreshTokenContext helpers:
+
+	goth.UseProviders(otelgoth.WrapProvider(github.New(key, secret, callback)))
+
+Outbound HTTP calls can be traced as well by wrapping the transport of the
+provider's HTTP client:
+
+	p := github.New(key, secret, callback)
+	p.HTTPClient = &http.Client{Transport: otelgoth.NewTransport(p.Name(), nil)}
+*/
+package otelgoth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/markbates/goth"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/oauth2"
+)
+
+// InstrumentationName is the name spans created by this package are reported under.
+const InstrumentationName = "github.com/markbates/goth/otelgoth"
+
+// ProviderKey is the span attribute holding the name of the goth provider.
+const ProviderKey = attribute.Key("goth.provider")
+
+type config struct {
+	tracerProvider trace.TracerProvider
+}
+
+// Option configures the instrumentation.
+type Option func(*config)
+
+// WithTracerProvider sets the TracerProvider spans are created with. The
+// global TracerProvider is used by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+func newTracer(opts []Option) trace.Tracer {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.tracerProvider == nil {
+		c.tracerProvider = otel.GetTracerProvider()
+	}
+	return c.tracerProvider.Tracer(InstrumentationName)
+}
+
+// Provider is a goth.Provider that records a span for every operation of the
+// provider it wraps.
+type Provider struct {
+	goth.Provider
+	tracer trace.Tracer
+}
+
+// WrapProvider returns a goth.Provider that traces calls to p.
+func WrapProvider(p goth.Provider, opts ...Option) *Provider {
+	return &Provider{
+		Provider: p,
+		tracer:   newTracer(opts),
+	}
+}
+
+// Unwrap returns the underlying provider.
+func (p *Provider) Unwrap() goth.Provider {
+	return p.Provider
+}
+
+func (p *Provider) start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return p.tracer.Start(ctx, name, trace.WithAttributes(ProviderKey.String(p.Name())))
+}
+
+// BeginAuth traces the wrapped provider's BeginAuth.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	_, span := p.start(context.Background(), "goth.BeginAuth")
+	sess, err := p.Provider.BeginAuth(state)
+	endSpan(span, err)
+	if err != nil {
+		return sess, err
+	}
+	return &Session{Session: sess, provider: p}, nil
+}
+
+// UnmarshalSession returns a session whose token exchange is traced.
+func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
+	sess, err := p.Provider.UnmarshalSession(data)
+	if err != nil {
+		return sess, err
+	}
+	return &Session{Session: sess, provider: p}, nil
+}
+
+// FetchUser traces the wrapped provider's FetchUser.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext traces the wrapped provider's FetchUser as a child of the
+// span in ctx, if any.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	ctx, span := p.start(ctx, "goth.FetchUser")
+	user, err := goth.FetchUser(ctx, p.Provider, unwrapSession(session))
+	endSpan(span, err)
+	return user, err
+}
+
+// RefreshToken traces the wrapped provider's RefreshToken.
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext traces the wrapped provider's RefreshToken as a child
+// of the span in ctx, if any.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	ctx, span := p.start(ctx, "goth.RefreshToken")
+	var token *oauth2.Token
+	var err error
+	if cr, ok := p.Provider.(goth.ContextTokenRefresher); ok {
+		token, err = cr.RefreshTokenContext(ctx, refreshToken)
+	} else {
+		token, err = p.Provider.RefreshToken(refreshToken)
+	}
+	endSpan(span, err)
+	return token, err
+}
+
+// IntrospectToken traces bearer token introspection by the wrapped provider.
+func (p *Provider) IntrospectToken(ctx context.Context, accessToken string) (goth.User, error) {
+	ctx, span := p.start(ctx, "goth.IntrospectToken")
+	user, err := goth.IntrospectToken(ctx, p.Provider, accessToken)
+	endSpan(span, err)
+	return user, err
+}
+
+// RevokeToken traces token revocation by the wrapped provider.
+func (p *Provider) RevokeToken(ctx context.Context, token string) error {
+	ctx, span := p.start(ctx, "goth.RevokeToken")
+	err := goth.RevokeToken(ctx, p.Provider, token)
+	endSpan(span, err)
+	return err
+}
+
+// Session wraps the session of an instrumented provider so that
+// the token exchange is traced.
+type Session struct {
+	goth.Session
+	provider *Provider
+}
+
+// Authorize traces the token exchange of the wrapped session.
+func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext traces the token exchange of the wrapped session as a
+// child of the span in ctx, if any.
+func (s *Session) AuthorizeContext(ctx context.Context, _ goth.Provider, params goth.Params) (string, error) {
+	ctx, span := s.provider.start(ctx, "goth.Authorize")
+	token, err := goth.Authorize(ctx, s.Session, s.provider.Provider, params)
+	endSpan(span, err)
+	return token, err
+}
+
+// Unwrap returns the underlying session.
+func (s *Session) Unwrap() goth.Session {
+	return s.Session
+}
+
+func unwrapSession(session goth.Session) goth.Session {
+	if s, ok := session.(*Session); ok {
+		return s.Session
+	}
+	return session
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type transport struct {
+	provider string
+	next     http.RoundTripper
+	tracer   trace.Tracer
+}
+
+// NewTransport returns an http.RoundTripper that records a client span, carrying
+// the provider name, endpoint and status code, for every request made through it.
+// If next is nil http.DefaultTransport is used.
+func NewTransport(provider string, next http.RoundTripper, opts ...Option) http.RoundTripper {
+	return &transport{
+		provider: provider,
+		next:     next,
+		tracer:   newTracer(opts),
+	}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	// the query string is left out as it regularly carries tokens
+	endpoint := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	ctx, span := t.tracer.Start(req.Context(), "goth.HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			ProviderKey.String(t.provider),
+			attribute.String("http.method", req.Method),
+			attribute.String("http.url", endpoint),
+		),
+	)
+	defer span.End()
+
+	res, err := next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return res, err
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
+	if res.StatusCode >= 400 {
+		span.SetStatus(codes.Error, http.StatusText(res.StatusCode))
+	}
+	return res, nil
+}
@@ -0,0 +1,109 @@
+package otelgoth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/otelgoth"
+	"github.com/markbates/goth/providers/faux"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func tracerProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	sr := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)), sr
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Implements((*goth.Provider)(nil), otelgoth.WrapProvider(&faux.Provider{}))
+	a.Implements((*goth.Session)(nil), &otelgoth.Session{})
+}
+
+func Test_WrapProvider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	tp, sr := tracerProvider()
+	p := otelgoth.WrapProvider(&faux.Provider{}, otelgoth.WithTracerProvider(tp))
+	a.Equal("faux", p.Name())
+
+	sess, err := p.BeginAuth("state")
+	a.NoError(err)
+
+	sess, err = p.UnmarshalSession(sess.Marshal())
+	a.NoError(err)
+	_, err = sess.Authorize(p, url.Values{})
+	a.NoError(err)
+
+	user, err := p.FetchUser(sess)
+	a.NoError(err)
+	a.Equal("access", user.AccessToken)
+
+	_, err = p.RefreshToken("refresh")
+	a.NoError(err)
+
+	spans := sr.Ended()
+	a.Len(spans, 4)
+	names := []string{}
+	for _, s := range spans {
+		names = append(names, s.Name())
+		a.Contains(s.Attributes(), otelgoth.ProviderKey.String("faux"))
+	}
+	a.Equal([]string{"goth.BeginAuth", "goth.Authorize", "goth.FetchUser", "goth.RefreshToken"}, names)
+}
+
+func Test_WrapProvider_ParentSpan(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	tp, sr := tracerProvider()
+	p := otelgoth.WrapProvider(&faux.Provider{}, otelgoth.WithTracerProvider(tp))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	_, err := goth.FetchUser(ctx, p, &faux.Session{ID: "1", AccessToken: "access"})
+	a.NoError(err)
+	_, err = goth.RefreshTokenContext(ctx, p, "refresh")
+	a.NoError(err)
+	parent.End()
+
+	spans := sr.Ended()
+	a.Len(spans, 3)
+	for _, s := range spans[:2] {
+		a.Equal(parent.SpanContext().SpanID(), s.Parent().SpanID())
+	}
+}
+
+func Test_NewTransport(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	tp, sr := tracerProvider()
+	client := &http.Client{Transport: otelgoth.NewTransport("faux", nil, otelgoth.WithTracerProvider(tp))}
+	res, err := client.Get(ts.URL + "/userinfo?access_token=secret")
+	a.NoError(err)
+	res.Body.Close()
+
+	spans := sr.Ended()
+	a.Len(spans, 1)
+	attrs := map[string]interface{}{}
+	for _, kv := range spans[0].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	a.Equal("faux", attrs["goth.provider"])
+	a.Equal(ts.URL+"/userinfo", attrs["http.url"])
+	a.Equal(int64(http.StatusBadGateway), attrs["http.status_code"])
+}
@@ -0,0 +1,116 @@
+/*
+Package promgoth exposes Goth's authentication metrics to Prometheus.
+
+	c := promgoth.NewCollector("myapp")
+	prometheus.MustRegister(c)
+	goth.UseMetrics(c)
+
+Provider HTTP latency is only recorded for requests made through a
+goth.NewMetricsTransport.
+*/
+package promgoth
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector implements both goth.Metrics and prometheus.Collector.
+type Collector struct {
+	authStarted   *prometheus.CounterVec
+	authCompleted *prometheus.CounterVec
+	authFailed    *prometheus.CounterVec
+	refreshes     *prometheus.CounterVec
+	requests      *prometheus.HistogramVec
+}
+
+var _ goth.Metrics = &Collector{}
+var _ prometheus.Collector = &Collector{}
+
+// NewCollector creates a Collector whose metrics are prefixed with namespace,
+// which may be empty.
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		authStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "goth",
+			Name:      "auth_started_total",
+			Help:      "Number of users sent to a provider to authenticate.",
+		}, []string{"provider"}),
+		authCompleted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "goth",
+			Name:      "auth_completed_total",
+			Help:      "Number of successfully completed authentications.",
+		}, []string{"provider"}),
+		authFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "goth",
+			Name:      "auth_failed_total",
+			Help:      "Number of failed authentications by error class.",
+		}, []string{"provider", "class"}),
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "goth",
+			Name:      "token_refreshes_total",
+			Help:      "Number of token refresh attempts by result.",
+		}, []string{"provider", "result"}),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "goth",
+			Name:      "provider_request_duration_seconds",
+			Help:      "Latency of HTTP requests made to providers.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"provider", "host", "code"}),
+	}
+}
+
+// AuthStarted implements goth.Metrics.
+func (c *Collector) AuthStarted(provider string) {
+	c.authStarted.WithLabelValues(provider).Inc()
+}
+
+// AuthCompleted implements goth.Metrics.
+func (c *Collector) AuthCompleted(provider string) {
+	c.authCompleted.WithLabelValues(provider).Inc()
+}
+
+// AuthFailed implements goth.Metrics.
+func (c *Collector) AuthFailed(provider string, class goth.ErrorClass) {
+	c.authFailed.WithLabelValues(provider, string(class)).Inc()
+}
+
+// TokenRefreshed implements goth.Metrics.
+func (c *Collector) TokenRefreshed(provider string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	c.refreshes.WithLabelValues(provider, result).Inc()
+}
+
+// ProviderRequest implements goth.Metrics.
+func (c *Collector) ProviderRequest(provider, host string, statusCode int, duration time.Duration) {
+	c.requests.WithLabelValues(provider, host, strconv.Itoa(statusCode)).Observe(duration.Seconds())
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.authStarted.Describe(ch)
+	c.authCompleted.Describe(ch)
+	c.authFailed.Describe(ch)
+	c.refreshes.Describe(ch)
+	c.requests.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.authStarted.Collect(ch)
+	c.authCompleted.Collect(ch)
+	c.authFailed.Collect(ch)
+	c.refreshes.Collect(ch)
+	c.requests.Collect(ch)
+}
@@ -0,0 +1,82 @@
+package promgoth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/promgoth"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Collector(t *testing.T) {
+	a := assert.New(t)
+
+	c := promgoth.NewCollector("test")
+	reg := prometheus.NewPedanticRegistry()
+	a.NoError(reg.Register(c))
+
+	c.AuthStarted("github")
+	c.AuthStarted("github")
+	c.AuthCompleted("github")
+	c.AuthFailed("github", goth.ErrorClassState)
+	c.TokenRefreshed("google", nil)
+	c.TokenRefreshed("google", errors.New("boom"))
+	c.ProviderRequest("google", "example.com", 200, 10*time.Millisecond)
+
+	expected := `
+# HELP test_goth_auth_failed_total Number of failed authentications by error class.
+# TYPE test_goth_auth_failed_total counter
+test_goth_auth_failed_total{class="state",provider="github"} 1
+# HELP test_goth_auth_started_total Number of users sent to a provider to authenticate.
+# TYPE test_goth_auth_started_total counter
+test_goth_auth_started_total{provider="github"} 2
+# HELP test_goth_token_refreshes_total Number of token refresh attempts by result.
+# TYPE test_goth_token_refreshes_total counter
+test_goth_token_refreshes_total{provider="google",result="failure"} 1
+test_goth_token_refreshes_total{provider="google",result="success"} 1
+`
+	a.NoError(testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"test_goth_auth_started_total", "test_goth_auth_failed_total", "test_goth_token_refreshes_total"))
+
+	count, err := testutil.GatherAndCount(reg, "test_goth_provider_request_duration_seconds")
+	a.NoError(err)
+	a.Equal(1, count)
+}
+
+func Test_MetricsTransport(t *testing.T) {
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer ts.Close()
+
+	c := promgoth.NewCollector("transport")
+	reg := prometheus.NewPedanticRegistry()
+	a.NoError(reg.Register(c))
+	goth.UseMetrics(c)
+	defer goth.UseMetrics(nil)
+
+	client := &http.Client{Transport: goth.NewMetricsTransport("faux", nil)}
+	res, err := client.Get(ts.URL + "/me?access_token=secret")
+	a.NoError(err)
+	res.Body.Close()
+
+	mfs, err := reg.Gather()
+	a.NoError(err)
+	a.Len(mfs, 1)
+	labels := map[string]string{}
+	for _, l := range mfs[0].Metric[0].Label {
+		labels[l.GetName()] = l.GetValue()
+	}
+	a.Equal("faux", labels["provider"])
+	a.Equal("418", labels["code"])
+	a.Equal(strings.TrimPrefix(ts.URL, "http://"), labels["host"])
+}
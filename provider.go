@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"sync/atomic"
 
 	"golang.org/x/oauth2"
 )
@@ -25,18 +26,139 @@ type Provider interface {
 	BeginAuth(state string) (Session, error)
 	UnmarshalSession(string) (Session, error)
 	FetchUser(Session) (User, error)
+	// Deprecated: Debug only ever affected a handful of providers. Use UseLogger
+	// to receive structured, redacted log output from goth instead.
 	Debug(bool)
 	RefreshToken(refreshToken string) (*oauth2.Token, error) // Get new access token based on the refresh token
 	RefreshTokenAvailable() bool                             // Refresh token is provided by auth provider or not
 }
 
+// TokenIntrospector can optionally be implemented by providers that are able to
+// validate a bare access token with the 3rd party (e.g. via an introspection or
+// userinfo endpoint) and resolve the user it was issued to. It is used by
+// gothic to authenticate API clients presenting a bearer token.
+type TokenIntrospector interface {
+	IntrospectToken(ctx context.Context, accessToken string) (User, error)
+}
+
+// ErrTokenIntrospectionUnsupported is returned when bearer token resolution is
+// requested for a provider that does not implement TokenIntrospector.
+type ErrTokenIntrospectionUnsupported struct {
+	name string
+}
+
+func (e *ErrTokenIntrospectionUnsupported) Error() string {
+	return fmt.Sprintf("provider %s does not support bearer token introspection", e.name)
+}
+
+// ErrTokenAudience is returned by TokenIntrospector implementations for bearer
+// tokens that are valid, but were issued to another client than the
+// provider's. Accepting them would let any app the user has authorized log in
+// as the user.
+type ErrTokenAudience struct {
+	Provider string
+	// Audience is the client the token was issued to, if the provider said.
+	Audience string
+}
+
+func (e *ErrTokenAudience) Error() string {
+	if e.Audience == "" {
+		return fmt.Sprintf("%s: bearer token was not issued to this client", e.Provider)
+	}
+	return fmt.Sprintf("%s: bearer token was issued to client %q, not this one", e.Provider, e.Audience)
+}
+
+// IntrospectToken resolves the user an access token was issued to, provided
+// the provider implements TokenIntrospector. Failures are logged at debug
+// level.
+func IntrospectToken(ctx context.Context, provider Provider, accessToken string) (User, error) {
+	ti, ok := provider.(TokenIntrospector)
+	if !ok {
+		return User{}, &ErrTokenIntrospectionUnsupported{provider.Name()}
+	}
+	user, err := ti.IntrospectToken(ctx, accessToken)
+	logProviderError("failed to introspect token", provider, err)
+	return user, err
+}
+
+// TokenRevoker can optionally be implemented by providers that can revoke
+// access or refresh tokens, e.g. through an RFC 7009 revocation endpoint.
+type TokenRevoker interface {
+	RevokeToken(ctx context.Context, token string) error
+}
+
+// ErrTokenRevocationUnsupported is returned when token revocation is requested
+// for a provider that does not implement TokenRevoker.
+type ErrTokenRevocationUnsupported struct {
+	name string
+}
+
+func (e *ErrTokenRevocationUnsupported) Error() string {
+	return fmt.Sprintf("provider %s does not support token revocation", e.name)
+}
+
+// RevokeToken revokes the token, provided the provider implements TokenRevoker,
+// and reports the attempt to the configured AuditSink, logging failures at
+// debug level.
+func RevokeToken(ctx context.Context, provider Provider, token string) error {
+	tr, ok := provider.(TokenRevoker)
+	if !ok {
+		return &ErrTokenRevocationUnsupported{provider.Name()}
+	}
+	err := tr.RevokeToken(ctx, token)
+	logProviderError("failed to revoke token", provider, err)
+	Audit(ctx, AuditEvent{Type: AuditTokenRevoked, Provider: provider.Name(), Error: errorString(err)})
+	return err
+}
+
+// LogoutURLProvider can optionally be implemented by providers with an
+// endpoint that ends the user's session at the provider, so that logging out
+// of the application doesn't leave the user logged in to the provider.
+type LogoutURLProvider interface {
+	// LogoutURL returns the URL to send the user to, which redirects back to
+	// postLogoutRedirectURI once the provider session has ended.
+	LogoutURL(postLogoutRedirectURI string) string
+}
+
+// LogoutURL returns the provider's logout URL, and false if the provider
+// doesn't implement LogoutURLProvider.
+func LogoutURL(provider Provider, postLogoutRedirectURI string) (string, bool) {
+	lp, ok := provider.(LogoutURLProvider)
+	if !ok {
+		return "", false
+	}
+	return lp.LogoutURL(postLogoutRedirectURI), true
+}
+
 const NoAuthUrlErrorMessage = "an AuthURL has not been set"
 
 // Providers is list of known/available providers.
 type Providers map[string]Provider
 
-var providerLock sync.RWMutex
-var providers = Providers{}
+// providers holds the registered Providers. The map is never modified once
+// stored; writers, serialized by providerLock, store an updated copy instead,
+// so GetProvider can look providers up without taking a lock.
+var providerLock sync.Mutex
+var providers atomic.Value
+
+func init() {
+	providers.Store(Providers{})
+}
+
+func loadProviders() Providers {
+	return providers.Load().(Providers)
+}
+
+// copyProviders returns a copy of the registered providers with room for n
+// more. It must be called with providerLock held.
+func copyProviders(n int) Providers {
+	current := loadProviders()
+	c := make(Providers, len(current)+n)
+	for k, v := range current {
+		c[k] = v
+	}
+	return c
+}
 
 // UseProviders adds a list of available providers for use with Goth.
 // Can be called multiple times. If you pass the same provider more
@@ -45,18 +167,18 @@ func UseProviders(viders ...Provider) {
 	providerLock.Lock()
 	defer providerLock.Unlock()
 
+	updated := copyProviders(len(viders))
 	for _, provider := range viders {
-		providers[provider.Name()] = provider
+		updated[provider.Name()] = provider
 	}
+	providers.Store(updated)
 }
 
 // GetProviders returns a list of all the providers currently in use.
 func GetProviders() Providers {
-	providerLock.RLock()
-	defer providerLock.RUnlock()
-
-	providersCopy := Providers{}
-	for k, v := range providers {
+	current := loadProviders()
+	providersCopy := make(Providers, len(current))
+	for k, v := range current {
 		providersCopy[k] = v
 	}
 	return providersCopy
@@ -65,10 +187,7 @@ func GetProviders() Providers {
 // GetProvider returns a previously created provider. If Goth has not
 // been told to use the named provider it will return an error.
 func GetProvider(name string) (Provider, error) {
-	providerLock.RLock()
-	provider := providers[name]
-	providerLock.RUnlock()
-
+	provider := loadProviders()[name]
 	if provider == nil {
 		return nil, &ErrNoSuchProvider{name}
 	}
@@ -81,10 +200,12 @@ func RemoveProvider(name string) error {
 	providerLock.Lock()
 	defer providerLock.Unlock()
 
-	if _, ok := providers[name]; !ok {
+	if _, ok := loadProviders()[name]; !ok {
 		return &ErrNoSuchProvider{name}
 	}
-	delete(providers, name)
+	updated := copyProviders(0)
+	delete(updated, name)
+	providers.Store(updated)
 	return nil
 }
 
@@ -92,22 +213,23 @@ func RemoveProvider(name string) error {
 // This is useful, mostly, for testing purposes.
 func ClearProviders() {
 	providerLock.Lock()
-	providers = Providers{}
+	providers.Store(Providers{})
 	providerLock.Unlock()
 }
 
-// ContextForClient provides a context for use with oauth2.
+// ContextForClient provides a context for use with oauth2. Prefer
+// ContextWithClient, which keeps the deadline and cancellation of the
+// incoming request.
 func ContextForClient(h *http.Client) context.Context {
-	if h == nil {
-		return oauth2.NoContext
-	}
-	return context.WithValue(oauth2.NoContext, oauth2.HTTPClient, h)
+	return ContextWithClient(context.Background(), h)
 }
 
-// HTTPClientWithFallBack to be used in all fetch operations.
+// HTTPClientWithFallBack to be used in all fetch operations. It returns
+// DefaultHTTPClient if h is nil, wrapped with any middleware added with
+// UseMiddleware and, if StrictParsing is set, the strict response checks.
 func HTTPClientWithFallBack(h *http.Client) *http.Client {
-	if h != nil {
-		return h
+	if h == nil {
+		h = DefaultHTTPClient
 	}
-	return http.DefaultClient
+	return withGlobalMiddleware(h)
 }
@@ -51,3 +51,94 @@ func Test_RemoveProvider(t *testing.T) {
 
 	a.Equal(len(goth.GetProviders()), 0)
 }
+
+type refreshMetrics struct {
+	goth.NopMetrics
+	refreshed []string
+}
+
+func (m *refreshMetrics) TokenRefreshed(provider string, err error) {
+	m.refreshed = append(m.refreshed, provider)
+}
+
+func Test_RefreshToken(t *testing.T) {
+	a := assert.New(t)
+
+	m := &refreshMetrics{}
+	goth.UseMetrics(m)
+	defer goth.UseMetrics(nil)
+
+	_, err := goth.RefreshToken(&faux.Provider{}, "refresh")
+	a.NoError(err)
+	a.Equal([]string{"faux"}, m.refreshed)
+}
+
+func Test_GetProvider_Concurrent(t *testing.T) {
+	a := assert.New(t)
+	defer goth.ClearProviders()
+
+	provider := &faux.Provider{}
+	goth.UseProviders(provider)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			other := &faux.Provider{}
+			other.SetName("other")
+			goth.UseProviders(other)
+			goth.RemoveProvider("other")
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		p, err := goth.GetProvider(provider.Name())
+		a.NoError(err)
+		a.Equal(provider, p)
+	}
+	<-done
+	a.Len(goth.GetProviders(), 1)
+}
+
+func Benchmark_GetProvider(b *testing.B) {
+	defer goth.ClearProviders()
+	goth.UseProviders(&faux.Provider{})
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := goth.GetProvider("faux"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func Benchmark_GetProvider_WithWrites(b *testing.B) {
+	defer goth.ClearProviders()
+	goth.UseProviders(&faux.Provider{})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		other := &faux.Provider{}
+		other.SetName("other")
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				goth.UseProviders(other)
+			}
+		}
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := goth.GetProvider("faux"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
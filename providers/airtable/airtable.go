@@ -0,0 +1,226 @@
+// Package airtable implements the OAuth2 protocol, with PKCE, for
+// authenticating users through Airtable.
+package airtable
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL         string = "https://airtable.com/oauth2/v1/authorize"
+	tokenURL        string = "https://airtable.com/oauth2/v1/token"
+	endpointProfile string = "https://api.airtable.com/v0/meta/whoami"
+)
+
+// Airtable scopes. ScopeUserEmailRead is requested if no scopes are passed
+// to New, and is needed for the user's email address.
+const (
+	ScopeUserEmailRead    = "user.email:read"
+	ScopeDataRecordsRead  = "data.records:read"
+	ScopeDataRecordsWrite = "data.records:write"
+	ScopeSchemaBasesRead  = "schema.bases:read"
+	ScopeSchemaBasesWrite = "schema.bases:write"
+	ScopeWebhookManage    = "webhook:manage"
+)
+
+// ErrAuthorizationDenied is returned by Authorize when Airtable redirected
+// back with an error instead of a code, e.g. because the user declined access.
+type ErrAuthorizationDenied struct {
+	Code        string
+	Description string
+}
+
+func (e *ErrAuthorizationDenied) Error() string {
+	if e.Description == "" {
+		return "airtable: authorization failed: " + e.Code
+	}
+	return fmt.Sprintf("airtable: authorization failed: %s: %s", e.Code, e.Description)
+}
+
+// Provider is the implementation of `goth.Provider` for accessing Airtable.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+}
+
+// New creates a new Airtable provider, and sets up important connection details.
+// You should always call `airtable.New` to get a new provider.  Never try to
+// create one manually.
+//
+// callbackURL must be exactly one of the redirect URLs of the integration,
+// as Airtable rejects any other. Leave secret empty for an integration
+// without a client secret.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "airtable",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  authURL,
+			TokenURL: tokenURL,
+			// clients with a secret must authenticate with Basic auth
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+		Scopes: []string{},
+	}
+	if provider.Secret == "" {
+		c.Endpoint.AuthStyle = oauth2.AuthStyleInParams
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, ScopeUserEmailRead)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the airtable package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks Airtable for an authentication end-point. Airtable requires
+// PKCE; a code verifier is generated and kept in the session until the code
+// is exchanged.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(b)
+	challenge := sha256.Sum256([]byte(verifier))
+
+	url := p.config.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+	return &Session{
+		AuthURL:      url,
+		CodeVerifier: verifier,
+	}, nil
+}
+
+// FetchUser will go to Airtable and access basic information about the user.
+// Airtable only knows the user's ID and, with ScopeUserEmailRead, email
+// address; the scopes granted to the token are included in RawData.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointProfile, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	err = userFromReader(bytes.NewReader(bits), &user)
+	return user, err
+}
+
+func userFromReader(r io.Reader, user *goth.User) error {
+	u := struct {
+		ID    string `json:"id"`
+		Email string `json:"email"`
+	}{}
+	err := json.NewDecoder(r).Decode(&u)
+	if err != nil {
+		return err
+	}
+	user.UserID = u.ID
+	user.Email = u.Email
+	return nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token. Airtable
+// refresh tokens can only be used once, so the returned token's refresh
+// token must replace the old one.
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
@@ -0,0 +1,122 @@
+package airtable_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/airtable"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("AIRTABLE_KEY"))
+	a.Equal(p.Secret, os.Getenv("AIRTABLE_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	s := session.(*airtable.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "airtable.com/oauth2/v1/authorize")
+	a.Contains(s.AuthURL, "code_challenge_method=S256")
+	a.Contains(s.AuthURL, "scope=user.email%3Aread")
+	a.NotEmpty(s.CodeVerifier)
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := airtable.New("client", "secret", "https://example.com/callback")
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://airtable.com/oauth2/v1/token", req.URL.String())
+		id, secret, ok := req.BasicAuth()
+		a.True(ok)
+		a.Equal("client", id)
+		a.Equal("secret", secret)
+		a.NoError(req.ParseForm())
+		a.Equal("verifier", req.PostForm.Get("code_verifier"))
+		a.Equal("https://example.com/callback", req.PostForm.Get("redirect_uri"))
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(rec, `{"access_token":"1234567890","refresh_token":"refresh","token_type":"Bearer","scope":"user.email:read","expires_in":3600,"refresh_expires_in":5184000}`)
+		return rec.Result(), nil
+	})}
+
+	s := &airtable.Session{CodeVerifier: "verifier"}
+	token, err := s.Authorize(p, url.Values{"code": {"code"}})
+	a.NoError(err)
+	a.Equal("1234567890", token)
+	a.Equal("refresh", s.RefreshToken)
+	a.Empty(s.CodeVerifier)
+}
+
+func Test_Authorize_Errors(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	_, err := (&airtable.Session{CodeVerifier: "verifier"}).Authorize(provider(), url.Values{
+		"error":             {"access_denied"},
+		"error_description": {"The user denied the request"},
+	})
+	a.Equal(&airtable.ErrAuthorizationDenied{Code: "access_denied", Description: "The user denied the request"}, err)
+
+	_, err = (&airtable.Session{}).Authorize(provider(), url.Values{"code": {"code"}})
+	a.Error(err)
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://api.airtable.com/v0/meta/whoami", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"id":"usrL2PNC5o3H4lBEi","email":"jane@example.com","scopes":["user.email:read"]}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&airtable.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("usrL2PNC5o3H4lBEi", user.UserID)
+	a.Equal("jane@example.com", user.Email)
+	a.Len(user.RawData["scopes"], 1)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://airtable.com/oauth2/v1/authorize","AccessToken":"1234567890","CodeVerifier":"verifier"}`)
+	a.NoError(err)
+
+	s := session.(*airtable.Session)
+	a.Equal(s.AuthURL, "https://airtable.com/oauth2/v1/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+	a.Equal(s.CodeVerifier, "verifier")
+}
+
+func provider() *airtable.Provider {
+	return airtable.New(os.Getenv("AIRTABLE_KEY"), os.Getenv("AIRTABLE_SECRET"), "/foo")
+}
@@ -0,0 +1,81 @@
+package airtable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// Session stores data during the auth process with Airtable.
+type Session struct {
+	AuthURL      string
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+	// CodeVerifier is the PKCE code verifier of the pending authorization.
+	// It is cleared once the code has been exchanged.
+	CodeVerifier string `json:",omitempty"`
+}
+
+var _ goth.Session = &Session{}
+
+// GetAuthURL will return the URL set by calling the `BeginAuth` function on the Airtable provider.
+func (s Session) GetAuthURL() (string, error) {
+	if s.AuthURL == "" {
+		return "", errors.New(goth.NoAuthUrlErrorMessage)
+	}
+	return s.AuthURL, nil
+}
+
+// Authorize the session with Airtable and return the access token to be stored for future use.
+func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
+	p := provider.(*Provider)
+	if code := params.Get("error"); code != "" {
+		return "", &ErrAuthorizationDenied{Code: code, Description: params.Get("error_description")}
+	}
+	if s.CodeVerifier == "" {
+		return "", errors.New("airtable: session has no PKCE code verifier")
+	}
+
+	token, err := p.config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"),
+		oauth2.SetAuthURLParam("code_verifier", s.CodeVerifier))
+	if err != nil {
+		return "", err
+	}
+
+	if !token.Valid() {
+		return "", errors.New("Invalid token received from provider")
+	}
+
+	s.CodeVerifier = ""
+	s.AccessToken = token.AccessToken
+	s.RefreshToken = token.RefreshToken
+	s.ExpiresAt = token.Expiry
+	return token.AccessToken, err
+}
+
+// Marshal the session into a string
+func (s Session) Marshal() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func (s Session) String() string {
+	return s.Marshal()
+}
+
+// UnmarshalSession wil unmarshal a JSON string into a session.
+func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
+	s := &Session{}
+	err := goth.DecodeSession(data, s)
+	return s, err
+}
@@ -4,12 +4,12 @@ package amazon
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"net/url"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -21,6 +21,48 @@ const (
 	endpointProfile string = "https://api.amazon.com/user/profile"
 )
 
+// Scopes supported by Login with Amazon.
+// See: https://developer.amazon.com/docs/login-with-amazon/customer-profile.html
+const (
+	// ScopeProfile gives access to the user's name, email address and user ID.
+	ScopeProfile = "profile"
+	// ScopeProfileUserID gives access to the user ID only.
+	ScopeProfileUserID = "profile:user_id"
+	// ScopePostalCode gives access to the postal code of the user's default
+	// shipping address. It must be requested along with one of the profile scopes.
+	ScopePostalCode = "postal_code"
+)
+
+// Region selects the regional Login with Amazon endpoints. Users are
+// authenticated against the marketplace of the region they shop in.
+type Region struct {
+	AuthURL    string
+	TokenURL   string
+	ProfileURL string
+}
+
+// The regions Login with Amazon is available in.
+var (
+	// RegionNA is North America, the default.
+	RegionNA = Region{
+		AuthURL:    authURL,
+		TokenURL:   tokenURL,
+		ProfileURL: endpointProfile,
+	}
+	// RegionEU is Europe.
+	RegionEU = Region{
+		AuthURL:    "https://eu.account.amazon.com/ap/oa",
+		TokenURL:   "https://api.amazon.co.uk/auth/o2/token",
+		ProfileURL: "https://api.amazon.co.uk/user/profile",
+	}
+	// RegionFE is the Far East.
+	RegionFE = Region{
+		AuthURL:    "https://apac.account.amazon.com/ap/oa",
+		TokenURL:   "https://api.amazon.co.jp/auth/o2/token",
+		ProfileURL: "https://api.amazon.co.jp/user/profile",
+	}
+)
+
 // Provider is the implementation of `goth.Provider` for accessing Amazon.
 type Provider struct {
 	ClientKey    string
@@ -29,19 +71,26 @@ type Provider struct {
 	HTTPClient   *http.Client
 	config       *oauth2.Config
 	providerName string
+	profileURL   string
 }
 
 // New creates a new Amazon provider and sets up important connection details.
 // You should always call `amazon.New` to get a new provider.  Never try to
 // create one manually.
 func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	return NewWithRegion(clientKey, secret, callbackURL, RegionNA, scopes...)
+}
+
+// NewWithRegion is like New but uses the endpoints of region.
+func NewWithRegion(clientKey, secret, callbackURL string, region Region, scopes ...string) *Provider {
 	p := &Provider{
 		ClientKey:    clientKey,
 		Secret:       secret,
 		CallbackURL:  callbackURL,
 		providerName: "amazon",
+		profileURL:   region.ProfileURL,
 	}
-	p.config = newConfig(p, scopes)
+	p.config = newConfig(p, region, scopes)
 	return p
 }
 
@@ -71,6 +120,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Amazon and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken:  sess.AccessToken,
@@ -84,8 +138,12 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	response, err := goth.HTTPClientWithFallBack(p.Client()).Get(endpointProfile + "?access_token=" + url.QueryEscape(sess.AccessToken))
-
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
 	if err != nil {
 		return user, err
 	}
@@ -100,7 +158,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, err
 	}
 
-	err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
+	err = user.SetRawJSON(bits)
 	if err != nil {
 		return user, err
 	}
@@ -109,24 +167,37 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	return user, err
 }
 
-func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+func newConfig(provider *Provider, region Region, scopes []string) *oauth2.Config {
 	c := &oauth2.Config{
 		ClientID:     provider.ClientKey,
 		ClientSecret: provider.Secret,
 		RedirectURL:  provider.CallbackURL,
 		Endpoint: oauth2.Endpoint{
-			AuthURL:  authURL,
-			TokenURL: tokenURL,
+			AuthURL:  region.AuthURL,
+			TokenURL: region.TokenURL,
 		},
 		Scopes: []string{},
 	}
 
 	if len(scopes) > 0 {
+		hasProfile := false
 		for _, scope := range scopes {
 			c.Scopes = append(c.Scopes, scope)
+			if scope == ScopeProfile || scope == ScopeProfileUserID {
+				hasProfile = true
+			}
+		}
+		// Amazon rejects postal_code on its own, the user ID is the least that can go with it
+		if !hasProfile {
+			for _, scope := range scopes {
+				if scope == ScopePostalCode {
+					c.Scopes = append(c.Scopes, ScopeProfileUserID)
+					break
+				}
+			}
 		}
 	} else {
-		c.Scopes = append(c.Scopes, "profile", "postal_code")
+		c.Scopes = append(c.Scopes, ScopeProfile, ScopePostalCode)
 	}
 	return c
 }
@@ -142,6 +213,8 @@ func userFromReader(r io.Reader, user *goth.User) error {
 	if err != nil {
 		return err
 	}
+	// with profile:user_id only the user ID is returned, and the postal code
+	// only if postal_code was granted
 	user.Email = u.Email
 	user.Name = u.Name
 	user.NickName = u.Name
@@ -157,8 +230,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -1,10 +1,14 @@
 package amazon_test
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/amazon"
 	"github.com/stretchr/testify/assert"
 )
@@ -48,6 +52,38 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal(s.AccessToken, "1234567890")
 }
 
+func Test_BeginAuth_Scopes(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := amazon.New(os.Getenv("AMAZON_KEY"), os.Getenv("AMAZON_SECRET"), "/foo", amazon.ScopePostalCode)
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	a.Contains(session.(*amazon.Session).AuthURL, "scope=postal_code+profile%3Auser_id")
+}
+
+func Test_NewWithRegion(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := amazon.NewWithRegion(os.Getenv("AMAZON_KEY"), os.Getenv("AMAZON_SECRET"), "/foo", amazon.RegionEU, amazon.ScopeProfileUserID)
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	a.Contains(session.(*amazon.Session).AuthURL, "eu.account.amazon.com/ap/oa")
+
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://api.amazon.co.uk/user/profile", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"user_id":"amzn1.account.K2LI23KL2LK2"}`)
+		return rec.Result(), nil
+	})}
+	user, err := p.FetchUser(&amazon.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("amzn1.account.K2LI23KL2LK2", user.UserID)
+	a.Empty(user.Email)
+}
+
 func provider() *amazon.Provider {
 	return amazon.New(os.Getenv("AMAZON_KEY"), os.Getenv("AMAZON_SECRET"), "/foo")
 }
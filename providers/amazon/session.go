@@ -1,9 +1,9 @@
 package amazon
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"strings"
 	"time"
 
 	"github.com/markbates/goth"
@@ -29,8 +29,13 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with Amazon and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.config.Exchange(goth.ContextForClient(p.Client()), params.Get("code"))
+	token, err := p.config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"))
 	if err != nil {
 		return "", err
 	}
@@ -58,6 +63,6 @@ func (s Session) String() string {
 // UnmarshalSession wil unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(s)
+	err := goth.DecodeSession(data, s)
 	return s, err
 }
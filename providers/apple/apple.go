@@ -3,10 +3,7 @@
 package apple
 
 import (
-	"crypto/x509"
-	"encoding/json"
-	"encoding/pem"
-	"errors"
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -37,6 +34,8 @@ type Provider struct {
 	httpClient           *http.Client
 	formPostResponseMode bool
 	timeNowFn            func() time.Time
+	jwks                 *goth.JWKSCache
+	signer               *secretSigner
 }
 
 func New(clientId, secret, redirectURL string, httpClient *http.Client, scopes ...string) *Provider {
@@ -48,9 +47,23 @@ func New(clientId, secret, redirectURL string, httpClient *http.Client, scopes .
 	}
 	p.configure(scopes)
 	p.httpClient = httpClient
+	p.jwks = goth.NewJWKSCache(httpClient)
 	return p
 }
 
+// NewWithSigningKey is like New but generates the client secret from key
+// instead of taking a pre-generated one. A new secret is generated shortly
+// before the current one expires, so it never needs to be rotated by hand.
+func NewWithSigningKey(clientId string, key SigningKey, redirectURL string, httpClient *http.Client, scopes ...string) (*Provider, error) {
+	signer, err := newSecretSigner(clientId, key)
+	if err != nil {
+		return nil, err
+	}
+	p := New(clientId, "", redirectURL, httpClient, scopes...)
+	p.signer = signer
+	return p, nil
+}
+
 func (p Provider) Name() string {
 	return p.providerName
 }
@@ -69,11 +82,7 @@ type SecretParams struct {
 }
 
 func MakeSecret(sp SecretParams) (*string, error) {
-	block, rest := pem.Decode([]byte(strings.TrimSpace(sp.PKCS8PrivateKey)))
-	if block == nil || len(rest) > 0 {
-		return nil, errors.New("invalid private key")
-	}
-	pk, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	pk, err := parsePrivateKey(sp.PKCS8PrivateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -89,8 +98,30 @@ func MakeSecret(sp SecretParams) (*string, error) {
 	return &ss, err
 }
 
+// Secret returns the client secret. For providers created with
+// NewWithSigningKey it is the current generated secret, or empty if it
+// cannot be generated.
 func (p Provider) Secret() string {
-	return p.secret
+	secret, _ := p.clientSecret()
+	return secret
+}
+
+func (p Provider) clientSecret() (string, error) {
+	if p.signer != nil {
+		return p.signer.Secret()
+	}
+	return p.secret, nil
+}
+
+// oauthConfig returns the provider's config with the current client secret.
+func (p Provider) oauthConfig() (*oauth2.Config, error) {
+	secret, err := p.clientSecret()
+	if err != nil {
+		return nil, err
+	}
+	c := *p.config
+	c.ClientSecret = secret
+	return &c, nil
 }
 
 func (p Provider) RedirectURL() string {
@@ -117,15 +148,17 @@ func (p Provider) BeginAuth(state string) (goth.Session, error) {
 
 func (Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(s)
+	err := goth.DecodeSession(data, s)
 	return s, err
 }
 
 // Apple doesn't seem to provide a user profile endpoint like all the other providers do.
 // Therefore this will return a User with the unique identifier obtained through authorization
 // as the only identifying attribute.
-// A full name and email can be obtained from the form post response (parameter 'user')
-// to the redirect page following authentication, if the name and email scopes are requested.
+// A full name is taken from the form post response (parameter 'user') to the
+// redirect page following the first authentication, if the name scope is
+// requested. Apple only sends it once, so store it with the user. The email in
+// that parameter is not signed and is ignored.
 // Additionally, if the response type is form_post and the email scope is requested, the email
 // will be encoded into the ID token in the email claim.
 func (p Provider) FetchUser(session goth.Session) (goth.User, error) {
@@ -137,6 +170,9 @@ func (p Provider) FetchUser(session goth.Session) (goth.User, error) {
 		Provider:     p.Name(),
 		UserID:       s.ID.Sub,
 		Email:        s.ID.Email,
+		Name:         strings.TrimSpace(s.FirstName + " " + s.LastName),
+		FirstName:    s.FirstName,
+		LastName:     s.LastName,
 		AccessToken:  s.AccessToken,
 		RefreshToken: s.RefreshToken,
 		ExpiresAt:    s.ExpiresAt,
@@ -150,9 +186,26 @@ func (p Provider) Client() *http.Client {
 	return goth.HTTPClientWithFallBack(p.httpClient)
 }
 
+// verificationKeys returns the cache of Apple's identity token signing keys.
+func (p Provider) verificationKeys() *goth.JWKSCache {
+	if p.jwks != nil {
+		return p.jwks
+	}
+	return goth.NewJWKSCache(p.Client())
+}
+
 func (p Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	config, err := p.oauthConfig()
+	if err != nil {
+		return nil, err
+	}
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -0,0 +1,109 @@
+package apple
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/markbates/goth"
+)
+
+const (
+	// MaxSecretLifetime is the longest lifetime Apple accepts for a client secret, about six months.
+	MaxSecretLifetime = 15777000 * time.Second
+	// secretRefreshMargin is how long before its expiry a generated client secret is replaced.
+	secretRefreshMargin = 24 * time.Hour
+)
+
+// SigningKey is the key used to sign client secrets, as created under
+// Certificates, Identifiers & Profiles > Keys in the Apple developer account.
+type SigningKey struct {
+	TeamId string
+	KeyId  string
+	// PKCS8PrivateKey is the PEM-encoded contents of the downloaded .p8 file.
+	PKCS8PrivateKey string
+	// Lifetime of the generated secrets, MaxSecretLifetime if zero.
+	Lifetime time.Duration
+}
+
+// secretSigner generates client secrets and regenerates them shortly before
+// they expire.
+type secretSigner struct {
+	key      *ecdsa.PrivateKey
+	teamId   string
+	keyId    string
+	clientId string
+	lifetime time.Duration
+
+	mu      sync.Mutex
+	secret  string
+	expires time.Time
+}
+
+func newSecretSigner(clientId string, sk SigningKey) (*secretSigner, error) {
+	key, err := parsePrivateKey(sk.PKCS8PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	lifetime := sk.Lifetime
+	if lifetime <= 0 || lifetime > MaxSecretLifetime {
+		lifetime = MaxSecretLifetime
+	}
+	return &secretSigner{
+		key:      key,
+		teamId:   sk.TeamId,
+		keyId:    sk.KeyId,
+		clientId: clientId,
+		lifetime: lifetime,
+	}, nil
+}
+
+func parsePrivateKey(pemKey string) (*ecdsa.PrivateKey, error) {
+	block, rest := pem.Decode([]byte(strings.TrimSpace(pemKey)))
+	if block == nil || len(rest) > 0 {
+		return nil, errors.New("invalid private key")
+	}
+	pk, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pk.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an ECDSA key")
+	}
+	return key, nil
+}
+
+// Secret returns the current client secret, generating a new one if there is
+// none yet or the current one is about to expire.
+func (s *secretSigner) Secret() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := goth.GetClock().Now()
+	if s.secret != "" && now.Add(secretRefreshMargin).Before(s.expires) {
+		return s.secret, nil
+	}
+
+	expires := now.Add(s.lifetime)
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": s.teamId,
+		"iat": now.Unix(),
+		"exp": expires.Unix(),
+		"aud": AppleAudOrIss,
+		"sub": s.clientId,
+	})
+	token.Header["kid"] = s.keyId
+	ss, err := token.SignedString(s.key)
+	if err != nil {
+		return "", err
+	}
+	s.secret = ss
+	s.expires = expires
+	return ss, nil
+}
@@ -11,7 +11,6 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
-	"github.com/lestrrat-go/jwx/jwk"
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
 )
@@ -32,6 +31,20 @@ type Session struct {
 	RefreshToken string
 	ExpiresAt    time.Time
 	ID
+	// FirstName and LastName are only known after the user's first
+	// authorization, when Apple posts them along with the code.
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+}
+
+// formUser is the user parameter Apple posts to the redirect URL the first
+// time a user authorizes the app, if the name or email scope was requested.
+// It is never sent again, so it must be captured then.
+type formUser struct {
+	Name struct {
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+	} `json:"name"`
 }
 
 func (s Session) GetAuthURL() (string, error) {
@@ -55,13 +68,22 @@ type IDTokenClaims struct {
 }
 
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
+	config, err := p.oauthConfig()
+	if err != nil {
+		return "", err
+	}
 	opts := []oauth2.AuthCodeOption{
 		// Apple requires client id & secret as headers
 		oauth2.SetAuthURLParam("client_id", p.clientId),
-		oauth2.SetAuthURLParam("client_secret", p.secret),
+		oauth2.SetAuthURLParam("client_secret", config.ClientSecret),
 	}
-	token, err := p.config.Exchange(context.Background(), params.Get("code"), opts...)
+	token, err := config.Exchange(ctx, params.Get("code"), opts...)
 	if err != nil {
 		return "", err
 	}
@@ -102,14 +124,10 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 			}
 
 			// get the public key for verifying the identity token signature
-			set, err := jwk.Fetch(context.Background(), idTokenVerificationKeyEndpoint, jwk.WithHTTPClient(p.Client()))
+			selectedKey, err := p.verificationKeys().Key(ctx, idTokenVerificationKeyEndpoint, kid)
 			if err != nil {
 				return nil, err
 			}
-			selectedKey, found := set.LookupKeyID(kid)
-			if !found {
-				return nil, errors.New("could not find matching public key")
-			}
 			pubKey := &rsa.PublicKey{}
 			err = selectedKey.Raw(pubKey)
 			if err != nil {
@@ -127,9 +145,29 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 		}
 	}
 
+	if err := s.mergeFormUser(params.Get("user")); err != nil {
+		return "", err
+	}
+
 	return token.AccessToken, err
 }
 
+// mergeFormUser adds the name from the user parameter to the session. The
+// parameter is not signed, so its email is ignored; the email only comes from
+// the verified identity token.
+func (s *Session) mergeFormUser(data string) error {
+	if data == "" {
+		return nil
+	}
+	var u formUser
+	if err := json.Unmarshal([]byte(data), &u); err != nil {
+		return fmt.Errorf("invalid user parameter: %v", err)
+	}
+	s.FirstName = u.Name.FirstName
+	s.LastName = u.Name.LastName
+	return nil
+}
+
 func (s Session) String() string {
 	return s.Marshal()
 }
@@ -45,3 +45,27 @@ func Test_String(t *testing.T) {
 
 	a.Equal(s.String(), s.Marshal())
 }
+
+func Test_MergeFormUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// the user parameter is not signed, so its email is never used
+	s := &Session{AccessToken: "1234567890", ID: ID{Sub: "user-1"}}
+	a.NoError(s.mergeFormUser(`{"name":{"firstName":"John","lastName":"Doe"},"email":"attacker@example.com"}`))
+	a.Empty(s.Email)
+
+	s.Email = "john@example.com"
+	a.NoError(s.mergeFormUser(`{"name":{"firstName":"John","lastName":"Doe"},"email":"other@example.com"}`))
+	a.Equal("john@example.com", s.Email)
+
+	user, err := provider().FetchUser(s)
+	a.NoError(err)
+	a.Equal("John Doe", user.Name)
+	a.Equal("John", user.FirstName)
+	a.Equal("Doe", user.LastName)
+	a.Equal("john@example.com", user.Email)
+
+	a.NoError(s.mergeFormUser(""))
+	a.Error(s.mergeFormUser("{"))
+}
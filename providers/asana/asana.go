@@ -0,0 +1,198 @@
+// Package asana implements the OAuth2 protocol for authenticating users
+// through Asana.
+package asana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL         string = "https://app.asana.com/-/oauth_authorize"
+	tokenURL        string = "https://app.asana.com/-/oauth_token"
+	endpointProfile string = "https://app.asana.com/api/1.0/users/me?opt_fields=gid,name,email,photo.image_128x128,workspaces.name"
+
+	// IssuerURL is the issuer of Asana's id_tokens.
+	IssuerURL string = "https://app.asana.com"
+)
+
+// Asana scopes. Apps that request no scopes are granted ScopeDefault, full
+// access to the user's data. ScopeOpenID makes Asana issue an id_token.
+const (
+	ScopeDefault        = "default"
+	ScopeOpenID         = "openid"
+	ScopeEmail          = "email"
+	ScopeProfile        = "profile"
+	ScopeUsersRead      = "users:read"
+	ScopeWorkspacesRead = "workspaces:read"
+)
+
+// RawDataWorkspaces is the key of User.RawData holding the workspaces the
+// user is a member of, as a list of objects with a gid and a name.
+const RawDataWorkspaces = "workspaces"
+
+// Provider is the implementation of `goth.Provider` for accessing Asana.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+}
+
+// New creates a new Asana provider, and sets up important connection details.
+// You should always call `asana.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "asana",
+	}
+	p.config = &oauth2.Config{
+		ClientID:     p.ClientKey,
+		ClientSecret: p.Secret,
+		RedirectURL:  p.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: scopes,
+	}
+	return p
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the asana package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks Asana for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to Asana and access basic information about the user.
+// The user's workspaces are stored in RawData under RawDataWorkspaces.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointProfile, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	// the user is wrapped in a data envelope, like all Asana API responses
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(bits, &envelope); err != nil {
+		return user, err
+	}
+	if len(envelope.Data) == 0 {
+		return user, fmt.Errorf("%s did not return the user", p.providerName)
+	}
+
+	err = user.SetRawJSON(envelope.Data)
+	if err != nil {
+		return user, err
+	}
+
+	err = userFromJSON(envelope.Data, &user)
+	return user, err
+}
+
+func userFromJSON(data []byte, user *goth.User) error {
+	u := struct {
+		GID   string `json:"gid"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+		Photo *struct {
+			Image128 string `json:"image_128x128"`
+		} `json:"photo"`
+	}{}
+	err := json.Unmarshal(data, &u)
+	if err != nil {
+		return err
+	}
+	user.UserID = u.GID
+	user.Name = u.Name
+	user.Email = u.Email
+	if u.Photo != nil {
+		user.AvatarURL = u.Photo.Image128
+	}
+	return nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
@@ -0,0 +1,117 @@
+package asana_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/asana"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("ASANA_KEY"))
+	a.Equal(p.Secret, os.Getenv("ASANA_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := asana.New(os.Getenv("ASANA_KEY"), os.Getenv("ASANA_SECRET"), "/foo", asana.ScopeOpenID, asana.ScopeEmail)
+	session, err := p.BeginAuth("test_state")
+	s := session.(*asana.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "app.asana.com/-/oauth_authorize")
+	a.Contains(s.AuthURL, "scope=openid+email")
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+
+	for name, claims := range map[string]string{
+		"valid":       `{"iss":"https://app.asana.com","aud":"myapp","sub":"1201"}`,
+		"otherIssuer": `{"iss":"https://example.com","aud":"myapp"}`,
+	} {
+		name, claims := name, claims
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := asana.New("myapp", "secret", "/foo", asana.ScopeOpenID)
+			p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				rec := httptest.NewRecorder()
+				rec.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(rec, `{"access_token":"1234567890","token_type":"bearer","expires_in":3600,"refresh_token":"refresh",
+					"data":{"id":1201,"gid":"1201","name":"Jane Doe","email":"jane@example.com"},"id_token":%q}`, gothtest.UnsignedJWT(claims))
+				return rec.Result(), nil
+			})}
+
+			s := &asana.Session{}
+			_, err := s.Authorize(p, url.Values{"code": {"code"}})
+			if name == "valid" {
+				a.NoError(err)
+				a.Equal(gothtest.UnsignedJWT(claims), s.IDToken)
+				return
+			}
+			a.IsType(&goth.ErrIssuerMismatch{}, err)
+		})
+	}
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("/api/1.0/users/me", req.URL.Path)
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"data":{"gid":"1201","name":"Jane Doe","email":"jane@example.com",
+			"photo":{"image_128x128":"https://example.com/jane_128.png"},
+			"workspaces":[{"gid":"1337","name":"Acme","resource_type":"workspace"}]}}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&asana.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("1201", user.UserID)
+	a.Equal("Jane Doe", user.Name)
+	a.Equal("jane@example.com", user.Email)
+	a.Equal("https://example.com/jane_128.png", user.AvatarURL)
+	workspaces := user.RawData[asana.RawDataWorkspaces].([]interface{})
+	a.Len(workspaces, 1)
+	a.Equal("Acme", workspaces[0].(map[string]interface{})["name"])
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://app.asana.com/-/oauth_authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*asana.Session)
+	a.Equal(s.AuthURL, "https://app.asana.com/-/oauth_authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *asana.Provider {
+	return asana.New(os.Getenv("ASANA_KEY"), os.Getenv("ASANA_SECRET"), "/foo")
+}
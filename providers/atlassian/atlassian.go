@@ -0,0 +1,233 @@
+// Package atlassian implements the OAuth 2.0 (3LO) protocol for
+// authenticating users through Atlassian, giving access to the Jira and
+// Confluence Cloud sites they have authorized the app for.
+package atlassian
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL           string = "https://auth.atlassian.com/authorize"
+	tokenURL          string = "https://auth.atlassian.com/oauth/token"
+	endpointProfile   string = "https://api.atlassian.com/me"
+	endpointResources string = "https://api.atlassian.com/oauth/token/accessible-resources"
+)
+
+// Atlassian scopes. ScopeReadMe and ScopeOfflineAccess are requested if no
+// scopes are passed to New, ScopeReadMe is needed to fetch the user.
+const (
+	ScopeReadMe                = "read:me"
+	ScopeOfflineAccess         = "offline_access"
+	ScopeReadAccount           = "read:account"
+	ScopeReadJiraUser          = "read:jira-user"
+	ScopeReadJiraWork          = "read:jira-work"
+	ScopeWriteJiraWork         = "write:jira-work"
+	ScopeReadConfluenceUser    = "read:confluence-user"
+	ScopeReadConfluenceSpace   = "read:confluence-space.summary"
+	ScopeReadConfluenceContent = "read:confluence-content.all"
+)
+
+// RawDataResources is the key of User.RawData holding the sites the user
+// authorized the app for, as a list of objects with an id (the cloud ID used
+// in https://api.atlassian.com/ex/jira/{id} API URLs), url, name, scopes and
+// avatarUrl.
+const RawDataResources = "accessible_resources"
+
+// Provider is the implementation of `goth.Provider` for accessing Atlassian.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+}
+
+// New creates a new Atlassian provider, and sets up important connection details.
+// You should always call `atlassian.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "atlassian",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, ScopeReadMe, ScopeOfflineAccess)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the atlassian package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks Atlassian for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	url := p.config.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("audience", "api.atlassian.com"),
+		oauth2.SetAuthURLParam("prompt", "consent"),
+	)
+	return &Session{
+		AuthURL: url,
+	}, nil
+}
+
+// FetchUser will go to Atlassian and access basic information about the
+// user. The sites the user authorized the app for are stored in RawData
+// under RawDataResources, so that their cloud IDs can be used to call the
+// Jira and Confluence APIs.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	bits, err := p.get(ctx, endpointProfile, sess.AccessToken)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	err = userFromReader(bytes.NewReader(bits), &user)
+	if err != nil {
+		return user, err
+	}
+
+	bits, err = p.get(ctx, endpointResources, sess.AccessToken)
+	if err != nil {
+		return user, err
+	}
+	var resources []interface{}
+	if err := json.Unmarshal(bits, &resources); err != nil {
+		return user, err
+	}
+	user.SetRawData(RawDataResources, resources)
+	return user, nil
+}
+
+// get GETs url with the access token and returns the response body.
+func (p *Provider) get(ctx context.Context, url, accessToken string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+	return ioutil.ReadAll(response.Body)
+}
+
+func userFromReader(r io.Reader, user *goth.User) error {
+	u := struct {
+		AccountID       string `json:"account_id"`
+		Email           string `json:"email"`
+		EmailVerified   bool   `json:"email_verified"`
+		Name            string `json:"name"`
+		Nickname        string `json:"nickname"`
+		Picture         string `json:"picture"`
+		ExtendedProfile struct {
+			Location string `json:"location"`
+		} `json:"extended_profile"`
+	}{}
+	err := json.NewDecoder(r).Decode(&u)
+	if err != nil {
+		return err
+	}
+	user.UserID = u.AccountID
+	user.Email = u.Email
+	user.EmailVerified = u.EmailVerified
+	user.Name = u.Name
+	user.NickName = u.Nickname
+	user.AvatarURL = u.Picture
+	user.Location = u.ExtendedProfile.Location
+	return nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token. Refresh
+// tokens are only issued for ScopeOfflineAccess, and are rotated: the
+// returned token's refresh token must replace the old one.
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
@@ -0,0 +1,125 @@
+package atlassian_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/atlassian"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("ATLASSIAN_KEY"))
+	a.Equal(p.Secret, os.Getenv("ATLASSIAN_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	s := session.(*atlassian.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "auth.atlassian.com/authorize")
+	a.Contains(s.AuthURL, "audience=api.atlassian.com")
+	a.Contains(s.AuthURL, "prompt=consent")
+	a.Contains(s.AuthURL, "scope=read%3Ame+offline_access")
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		switch req.URL.String() {
+		case "https://api.atlassian.com/me":
+			fmt.Fprint(rec, `{"account_type":"atlassian","account_id":"112233aa-bb11-cc22-33dd-445566abcabc",
+				"email":"jane@example.com","email_verified":true,"name":"Jane Doe","picture":"https://example.com/jane.png",
+				"account_status":"active","nickname":"jane","locale":"en_US",
+				"extended_profile":{"job_title":"Engineer","organization":"Acme","location":"Sydney"}}`)
+		case "https://api.atlassian.com/oauth/token/accessible-resources":
+			fmt.Fprint(rec, `[{"id":"1324a887-45db-1bf4-1e99-ef0ff456d421","name":"acme","url":"https://acme.atlassian.net",
+				"scopes":["read:jira-work"],"avatarUrl":"https://site-admin-avatar-cdn.prod.public.atl-paas.net/avatars/240/flag.png"}]`)
+		default:
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&atlassian.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("112233aa-bb11-cc22-33dd-445566abcabc", user.UserID)
+	a.Equal("jane@example.com", user.Email)
+	a.True(user.EmailVerified)
+	a.Equal("Jane Doe", user.Name)
+	a.Equal("jane", user.NickName)
+	a.Equal("Sydney", user.Location)
+	resources := user.RawData[atlassian.RawDataResources].([]interface{})
+	a.Len(resources, 1)
+	a.Equal("1324a887-45db-1bf4-1e99-ef0ff456d421", resources[0].(map[string]interface{})["id"])
+}
+
+func Test_FetchUser_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		switch req.URL.String() {
+		case "https://api.atlassian.com/me":
+			fmt.Fprint(rec, `{"account_id":"112233aa-bb11-cc22-33dd-445566abcabc","nickname":"jane"}`)
+		case "https://api.atlassian.com/oauth/token/accessible-resources":
+			fmt.Fprint(rec, `[{"id":"1324a887-45db-1bf4-1e99-ef0ff456d421","name":"acme"}]`)
+		default:
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&atlassian.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("jane", raw["nickname"])
+	resources := raw[atlassian.RawDataResources].([]interface{})
+	a.Len(resources, 1)
+	a.Equal("1324a887-45db-1bf4-1e99-ef0ff456d421", resources[0].(map[string]interface{})["id"])
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://auth.atlassian.com/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*atlassian.Session)
+	a.Equal(s.AuthURL, "https://auth.atlassian.com/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *atlassian.Provider {
+	return atlassian.New(os.Getenv("ATLASSIAN_KEY"), os.Getenv("ATLASSIAN_SECRET"), "/foo")
+}
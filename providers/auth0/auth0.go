@@ -4,10 +4,12 @@ package auth0
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -29,14 +31,30 @@ type Provider struct {
 	HTTPClient   *http.Client
 	config       *oauth2.Config
 	providerName string
+	organization string
 }
 
 type auth0UserResp struct {
-	Name      string `json:"name"`
-	NickName  string `json:"nickname"`
-	Email     string `json:"email"`
-	UserID    string `json:"sub"`
-	AvatarURL string `json:"picture"`
+	Name          string `json:"name"`
+	NickName      string `json:"nickname"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	UserID        string `json:"sub"`
+	AvatarURL     string `json:"picture"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	OrgID         string `json:"org_id"`
+}
+
+// ErrOrganizationMismatch is returned by FetchUser when the user did not log
+// in to the organization the session was started with.
+type ErrOrganizationMismatch struct {
+	Want string
+	Got  string
+}
+
+func (e *ErrOrganizationMismatch) Error() string {
+	return fmt.Sprintf("auth0: user logged in to organization %q, not %q", e.Got, e.Want)
 }
 
 // New creates a new Auth0 provider and sets up important connection details.
@@ -71,10 +89,32 @@ func (p *Provider) Client() *http.Client {
 // Debug is a no-op for the auth0 package.
 func (p *Provider) Debug(debug bool) {}
 
+// SetOrganization makes BeginAuth log users in to the Auth0 organization
+// with the given ID (org_...) or name.
+func (p *Provider) SetOrganization(organization string) {
+	p.organization = organization
+}
+
 // BeginAuth asks Auth0 for an authentication end-point.
 func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return p.BeginAuthWithOrganization(state, p.organization, "")
+}
+
+// BeginAuthWithOrganization is like BeginAuth but logs the user in to the
+// given organization, if not empty. invitation is the ticket ID of an
+// organization invitation, which Auth0 passes to the application's login
+// route along with the organization when the user follows the invitation link.
+func (p *Provider) BeginAuthWithOrganization(state, organization, invitation string) (goth.Session, error) {
+	var opts []oauth2.AuthCodeOption
+	if organization != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("organization", organization))
+	}
+	if invitation != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("invitation", invitation))
+	}
 	return &Session{
-		AuthURL: p.config.AuthCodeURL(state),
+		AuthURL:      p.config.AuthCodeURL(state, opts...),
+		Organization: organization,
 	}, nil
 }
 
@@ -83,6 +123,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 // https://auth0.com/docs/api/authentication#get-user-info
 
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	s := session.(*Session)
 	user := goth.User{
 		AccessToken:  s.AccessToken,
@@ -97,7 +142,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	}
 
 	userProfileURL := protocol + p.Domain + endpointProfile
-	req, err := http.NewRequest("GET", userProfileURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", userProfileURL, nil)
 	if err != nil {
 		return user, err
 	}
@@ -116,7 +161,32 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	}
 
 	err = userFromReader(resp.Body, &user)
-	return user, err
+	if err != nil {
+		return user, err
+	}
+	if err := checkOrganization(s.Organization, user.RawData); err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// checkOrganization verifies the org_id claim if the session was started
+// with an organization. Organization names are only included in org_name,
+// and only if the tenant is configured to.
+func checkOrganization(organization string, claims map[string]interface{}) error {
+	if organization == "" {
+		return nil
+	}
+	claim := "org_id"
+	if !strings.HasPrefix(organization, "org_") {
+		claim = "org_name"
+		organization = strings.ToLower(organization)
+	}
+	got, _ := claims[claim].(string)
+	if got != organization {
+		return &ErrOrganizationMismatch{Want: organization, Got: got}
+	}
+	return nil
 }
 
 func newConfig(provider *Provider, scopes []string) *oauth2.Config {
@@ -158,14 +228,50 @@ func userFromReader(r io.Reader, user *goth.User) error {
 		return err
 	}
 	user.Email = u.Email
+	user.EmailVerified = u.EmailVerified
 	user.Name = u.Name
 	user.NickName = u.NickName
+	user.FirstName = u.GivenName
+	user.LastName = u.FamilyName
 	user.UserID = u.UserID
 	user.AvatarURL = u.AvatarURL
 	user.RawData = rawData
+	mapCustomClaims(user)
 	return nil
 }
 
+// mapCustomClaims adds custom claims, which Auth0 Actions have to give
+// namespaced names like "https://myapp.example.com/roles", to RawData under
+// their name without the namespace, unless a claim of that name exists. The
+// roles and groups claims are mapped to User.Roles and User.Groups.
+func mapCustomClaims(user *goth.User) {
+	for key, value := range user.RawData {
+		if !strings.HasPrefix(key, "https://") && !strings.HasPrefix(key, "http://") {
+			continue
+		}
+		name := key[strings.LastIndex(key, "/")+1:]
+		if name == "" {
+			continue
+		}
+		if _, ok := user.RawData[name]; !ok {
+			user.RawData[name] = value
+		}
+	}
+	user.Roles = stringsClaim(user.RawData["roles"])
+	user.Groups = stringsClaim(user.RawData["groups"])
+}
+
+func stringsClaim(v interface{}) []string {
+	values, _ := v.([]interface{})
+	var s []string
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			s = append(s, str)
+		}
+	}
+	return s
+}
+
 // RefreshTokenAvailable refresh token is provided by auth provider or not
 func (p *Provider) RefreshTokenAvailable() bool {
 	return true
@@ -173,8 +279,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(oauth2.NoContext, token)
+	ts := p.config.TokenSource(ctx, token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -99,3 +99,54 @@ func Test_FetchUser(t *testing.T) {
 func provider() *auth0.Provider {
 	return auth0.New(os.Getenv("AUTH0_KEY"), os.Getenv("AUTH0_SECRET"), "/foo", os.Getenv("AUTH0_DOMAIN"))
 }
+
+func Test_BeginAuthWithOrganization(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+	p.SetOrganization("org_default")
+
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*auth0.Session)
+	a.Contains(s.AuthURL, "organization=org_default")
+	a.Equal("org_default", s.Organization)
+
+	session, err = p.BeginAuthWithOrganization("test_state", "org_W30ZDq", "inv_1234")
+	a.NoError(err)
+	s = session.(*auth0.Session)
+	a.Contains(s.AuthURL, "organization=org_W30ZDq")
+	a.Contains(s.AuthURL, "invitation=inv_1234")
+	a.Equal("org_W30ZDq", s.Organization)
+}
+
+func Test_FetchUserCustomClaims(t *testing.T) {
+	a := assert.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://example.auth0.com/userinfo", httpmock.NewStringResponder(200, `{
+		"sub": "auth0|58454...",
+		"email": "test.account@userinfo.com",
+		"email_verified": true,
+		"org_id": "org_W30ZDq",
+		"https://myapp.example.com/roles": ["admin", "editor"],
+		"https://myapp.example.com/groups": ["staff"],
+		"https://myapp.example.com/plan": "pro",
+		"https://myapp.example.com/email": "other@example.com"
+	}`))
+
+	p := auth0.New("key", "secret", "/foo", "example.auth0.com")
+	u, err := p.FetchUser(&auth0.Session{AccessToken: "token", Organization: "org_W30ZDq"})
+	a.NoError(err)
+	a.True(u.EmailVerified)
+	a.Equal([]string{"admin", "editor"}, u.Roles)
+	a.Equal([]string{"staff"}, u.Groups)
+	a.Equal("pro", u.RawData["plan"])
+	// standard claims are not overwritten by custom ones
+	a.Equal("test.account@userinfo.com", u.RawData["email"])
+
+	_, err = p.FetchUser(&auth0.Session{AccessToken: "token", Organization: "org_other"})
+	a.IsType(&auth0.ErrOrganizationMismatch{}, err)
+}
@@ -1,13 +1,12 @@
 package auth0
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"strings"
 	"time"
 
 	"github.com/markbates/goth"
-	"golang.org/x/oauth2"
 )
 
 // Session stores data during the auth process with Auth0.
@@ -16,6 +15,8 @@ type Session struct {
 	AccessToken  string
 	RefreshToken string
 	ExpiresAt    time.Time
+	// Organization is the organization the session was started with, if any.
+	Organization string `json:",omitempty"`
 }
 
 var _ goth.Session = &Session{}
@@ -30,8 +31,13 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with Auth0 and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.config.Exchange(oauth2.NoContext, params.Get("code"))
+	token, err := p.config.Exchange(ctx, params.Get("code"))
 	if err != nil {
 		return "", err
 	}
@@ -59,6 +65,6 @@ func (s Session) String() string {
 // UnmarshalSession wil unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(s)
+	err := goth.DecodeSession(data, s)
 	return s, err
 }
@@ -0,0 +1,291 @@
+// Package authelia implements the OpenID Connect protocol for authenticating
+// users through a self-hosted Authelia instance.
+package authelia
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// Scopes supported by Authelia in addition to openid, profile and email.
+const (
+	ScopeGroups = "groups"
+	// ScopeOfflineAccess makes Authelia issue a refresh token, for clients
+	// allowed the refresh_token grant type.
+	ScopeOfflineAccess = "offline_access"
+)
+
+// ConsentMode is the consent_mode an Authelia client is configured with.
+type ConsentMode string
+
+// Authelia's consent modes.
+const (
+	// ConsentModeExplicit asks the user for consent on every login.
+	ConsentModeExplicit ConsentMode = "explicit"
+	// ConsentModeImplicit never asks the user for consent.
+	ConsentModeImplicit ConsentMode = "implicit"
+	// ConsentModePreConfigured lets the user remember their consent for the
+	// client's pre_configured_consent_duration.
+	ConsentModePreConfigured ConsentMode = "pre-configured"
+)
+
+// ErrAuthorizationDenied is returned when Authelia redirects back with an
+// error instead of a code, e.g. access_denied when the user rejects the
+// consent screen or consent_required when consent has expired.
+type ErrAuthorizationDenied struct {
+	Code        string
+	Description string
+}
+
+func (e *ErrAuthorizationDenied) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("authelia: authorization failed: %s: %s", e.Code, e.Description)
+	}
+	return fmt.Sprintf("authelia: authorization failed: %s", e.Code)
+}
+
+// Provider is the implementation of `goth.Provider` for accessing Authelia.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	issuerURL    string
+	profileURL   string
+	revokeURL    string
+	consentMode  ConsentMode
+}
+
+// New creates a new Authelia provider for the instance at issuerURL, e.g.
+// https://auth.example.com, and sets up important connection details. The
+// openid, profile, email and groups scopes are requested unless other scopes
+// are passed.
+// You should always call `authelia.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, issuerURL string, scopes ...string) *Provider {
+	issuerURL = strings.TrimSuffix(issuerURL, "/")
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "authelia",
+		issuerURL:    issuerURL,
+		profileURL:   issuerURL + "/api/oidc/userinfo",
+		revokeURL:    issuerURL + "/api/oidc/revocation",
+		consentMode:  ConsentModeExplicit,
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.issuerURL + "/api/oidc/authorization",
+			TokenURL: provider.issuerURL + "/api/oidc/token",
+			// client_secret_basic is Authelia's default token_endpoint_auth_method
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, "openid", "profile", "email", ScopeGroups)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the authelia package.
+func (p *Provider) Debug(debug bool) {}
+
+// IssuerURL returns the issuer of the provider's instance.
+func (p *Provider) IssuerURL() string {
+	return p.issuerURL
+}
+
+// SetConsentMode tells the provider the consent_mode of the Authelia client,
+// ConsentModeExplicit by default (Authelia's "auto" mode is explicit unless
+// pre_configured_consent_duration is set).
+//
+// Authelia only issues a refresh token for the offline_access scope when the
+// user was asked for consent, so BeginAuth adds prompt=consent when that
+// scope is requested in explicit mode. It doesn't in pre-configured mode, as
+// forcing the consent screen would disregard the consent the user chose to
+// remember.
+func (p *Provider) SetConsentMode(mode ConsentMode) {
+	p.consentMode = mode
+}
+
+// BeginAuth asks Authelia for an authentication end-point. PKCE is always
+// used, as Authelia can be configured to enforce it for all clients; the code
+// verifier is kept in the session until the code is exchanged.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(b)
+	challenge := sha256.Sum256([]byte(verifier))
+
+	opts := []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	}
+	if p.consentMode == ConsentModeExplicit && p.hasScope(ScopeOfflineAccess) {
+		opts = append(opts, oauth2.SetAuthURLParam("prompt", "consent"))
+	}
+	return &Session{
+		AuthURL:      p.config.AuthCodeURL(state, opts...),
+		CodeVerifier: verifier,
+	}, nil
+}
+
+func (p *Provider) hasScope(scope string) bool {
+	for _, s := range p.config.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchUser will go to Authelia and access basic information about the user.
+// Claims of scopes the user didn't consent to are missing from the userinfo
+// response, so the corresponding fields are left empty.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(bytes.NewReader(bits)).Decode(&claims); err != nil {
+		return user, err
+	}
+	user.UserID, _ = claims["sub"].(string)
+	user.Email, _ = claims["email"].(string)
+	user.EmailVerified, _ = claims["email_verified"].(bool)
+	user.Name, _ = claims["name"].(string)
+	user.NickName, _ = claims["preferred_username"].(string)
+	user.FirstName, _ = claims["given_name"].(string)
+	user.LastName, _ = claims["family_name"].(string)
+	user.AvatarURL, _ = claims["picture"].(string)
+	if groups, ok := claims["groups"].([]interface{}); ok {
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				user.Groups = append(user.Groups, s)
+			}
+		}
+	}
+	return user, nil
+}
+
+// RevokeToken revokes an access or refresh token at Authelia's revocation endpoint.
+func (p *Provider) RevokeToken(ctx context.Context, token string) error {
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.revokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.ClientKey), url.QueryEscape(p.Secret))
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with a %d trying to revoke a token", p.providerName, response.StatusCode)
+	}
+	return nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
@@ -0,0 +1,164 @@
+package authelia_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/authelia"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "authelia")
+	a.Equal(p.ClientKey, "myapp")
+	a.Equal(p.Secret, "secret")
+	a.Equal(p.CallbackURL, "/foo")
+	a.Equal("https://auth.example.com", p.IssuerURL())
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+	a.Implements((*goth.TokenRevoker)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*authelia.Session)
+
+	u, err := url.Parse(s.AuthURL)
+	a.NoError(err)
+	a.Equal("/api/oidc/authorization", u.Path)
+	a.Equal("openid profile email groups", u.Query().Get("scope"))
+	a.Equal("S256", u.Query().Get("code_challenge_method"))
+	challenge := sha256.Sum256([]byte(s.CodeVerifier))
+	a.Equal(base64.RawURLEncoding.EncodeToString(challenge[:]), u.Query().Get("code_challenge"))
+	a.Empty(u.Query().Get("prompt"))
+}
+
+func Test_BeginAuth_OfflineAccess(t *testing.T) {
+	t.Parallel()
+
+	for mode, prompt := range map[authelia.ConsentMode]string{
+		authelia.ConsentModeExplicit:      "consent",
+		authelia.ConsentModeImplicit:      "",
+		authelia.ConsentModePreConfigured: "",
+	} {
+		p := authelia.New("myapp", "secret", "/foo", "https://auth.example.com", "openid", authelia.ScopeOfflineAccess)
+		p.SetConsentMode(mode)
+		session, err := p.BeginAuth("test_state")
+		assert.NoError(t, err)
+		u, _ := url.Parse(session.(*authelia.Session).AuthURL)
+		assert.Equal(t, prompt, u.Query().Get("prompt"), string(mode))
+	}
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	session, _ := p.BeginAuth("test_state")
+	s := session.(*authelia.Session)
+	verifier := s.CodeVerifier
+
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://auth.example.com/api/oidc/token", req.URL.String())
+		a.NoError(req.ParseForm())
+		a.Equal(verifier, req.PostForm.Get("code_verifier"))
+		user, _, _ := req.BasicAuth()
+		a.Equal("myapp", user)
+
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(rec, `{"access_token":"1234567890","token_type":"bearer","expires_in":3599,"id_token":%q}`,
+			gothtest.UnsignedJWT(`{"iss":"https://auth.example.com","aud":["myapp"]}`))
+		return rec.Result(), nil
+	})}
+	_, err := s.Authorize(p, url.Values{"code": {"code"}})
+	a.NoError(err)
+	a.Equal("1234567890", s.AccessToken)
+	a.Empty(s.CodeVerifier)
+}
+
+func Test_Authorize_ConsentDenied(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	session, _ := p.BeginAuth("test_state")
+	_, err := session.Authorize(p, url.Values{
+		"error":             {"access_denied"},
+		"error_description": {"The resource owner denied the request."},
+	})
+	a.Equal(&authelia.ErrAuthorizationDenied{Code: "access_denied", Description: "The resource owner denied the request."}, err)
+}
+
+func Test_AuthorizeChecksIssuer(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(rec, `{"access_token":"1234567890","token_type":"bearer","expires_in":3599,"id_token":%q}`,
+			gothtest.UnsignedJWT(`{"iss":"https://other.example.com","aud":"myapp"}`))
+		return rec.Result(), nil
+	})}
+	session, _ := p.BeginAuth("test_state")
+	_, err := session.Authorize(p, url.Values{"code": {"code"}})
+	a.IsType(&goth.ErrIssuerMismatch{}, err)
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://auth.example.com/api/oidc/userinfo", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"sub":"3b1a4c2e-8a4f-4a2b-9c3d-6e2f1a0b7c5d","email":"jdoe@example.com","email_verified":true,
+			"name":"John Doe","preferred_username":"jdoe","groups":["admins","dev"]}`)
+		return rec.Result(), nil
+	})}
+	user, err := p.FetchUser(&authelia.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("3b1a4c2e-8a4f-4a2b-9c3d-6e2f1a0b7c5d", user.UserID)
+	a.Equal("jdoe", user.NickName)
+	a.True(user.EmailVerified)
+	a.Equal([]string{"admins", "dev"}, user.Groups)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://auth.example.com/api/oidc/authorization","CodeVerifier":"verifier"}`)
+	a.NoError(err)
+
+	s := session.(*authelia.Session)
+	a.Equal(s.AuthURL, "https://auth.example.com/api/oidc/authorization")
+	a.Equal(s.CodeVerifier, "verifier")
+}
+
+func provider() *authelia.Provider {
+	return authelia.New("myapp", "secret", "/foo", "https://auth.example.com/")
+}
@@ -0,0 +1,228 @@
+// Package authentik implements the OpenID Connect protocol for authenticating
+// users through a self-hosted Authentik instance.
+package authentik
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// Provider is the implementation of `goth.Provider` for accessing Authentik.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	issuerURL    string
+	profileURL   string
+	revokeURL    string
+	groupsClaim  string
+}
+
+// New creates a new Authentik provider for the application with the given
+// slug on the instance at baseURL, e.g. https://authentik.example.com, and
+// sets up important connection details. The openid, profile and email scopes
+// are requested unless other scopes are passed; the profile scope includes
+// the user's groups.
+// You should always call `authentik.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, baseURL, slug string, scopes ...string) *Provider {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "authentik",
+		issuerURL:    baseURL + "/application/o/" + slug + "/",
+		profileURL:   baseURL + "/application/o/userinfo/",
+		revokeURL:    baseURL + "/application/o/revoke/",
+		groupsClaim:  "groups",
+	}
+	p.config = newConfig(p, baseURL, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, baseURL string, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  baseURL + "/application/o/authorize/",
+			TokenURL: baseURL + "/application/o/token/",
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, "openid", "profile", "email")
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the authentik package.
+func (p *Provider) Debug(debug bool) {}
+
+// IssuerURL returns the issuer of the provider's application.
+func (p *Provider) IssuerURL() string {
+	return p.issuerURL
+}
+
+// SetIssuerURL sets the issuer id_tokens are checked against, for OAuth2
+// providers whose issuer mode is set to "Same identifier is used for all
+// providers" rather than the per-application default.
+func (p *Provider) SetIssuerURL(issuerURL string) {
+	p.issuerURL = issuerURL
+}
+
+// SetGroupsClaim sets the claim User.Groups is filled in from, "groups" by
+// default, e.g. for a custom scope mapping.
+func (p *Provider) SetGroupsClaim(claim string) {
+	p.groupsClaim = claim
+}
+
+// BeginAuth asks Authentik for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to Authentik and access basic information about the user.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(bytes.NewReader(bits)).Decode(&claims); err != nil {
+		return user, err
+	}
+	user.UserID, _ = claims["sub"].(string)
+	user.Email, _ = claims["email"].(string)
+	user.EmailVerified, _ = claims["email_verified"].(bool)
+	user.Name, _ = claims["name"].(string)
+	user.NickName, _ = claims["preferred_username"].(string)
+	user.FirstName, _ = claims["given_name"].(string)
+	user.LastName, _ = claims["family_name"].(string)
+	user.AvatarURL, _ = claims["picture"].(string)
+	user.Groups = stringList(claims[p.groupsClaim])
+	return user, nil
+}
+
+// stringList returns the values of a claim holding a list of strings.
+func stringList(claim interface{}) []string {
+	list, _ := claim.([]interface{})
+	var values []string
+	for _, v := range list {
+		if s, ok := v.(string); ok && s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// RevokeToken revokes an access or refresh token at Authentik's revocation endpoint.
+func (p *Provider) RevokeToken(ctx context.Context, token string) error {
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.revokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.ClientKey), url.QueryEscape(p.Secret))
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with a %d trying to revoke a token", p.providerName, response.StatusCode)
+	}
+	return nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
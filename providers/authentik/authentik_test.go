@@ -0,0 +1,111 @@
+package authentik_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/authentik"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "authentik")
+	a.Equal(p.ClientKey, "myapp")
+	a.Equal(p.Secret, "secret")
+	a.Equal(p.CallbackURL, "/foo")
+	a.Equal("https://authentik.example.com/application/o/grafana/", p.IssuerURL())
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+	a.Implements((*goth.TokenRevoker)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*authentik.Session)
+	a.Contains(s.AuthURL, "https://authentik.example.com/application/o/authorize/")
+	a.Contains(s.AuthURL, "scope=openid+profile+email")
+}
+
+func Test_AuthorizeChecksIssuer(t *testing.T) {
+	t.Parallel()
+
+	for name, claims := range map[string]string{
+		"valid":            `{"iss":"https://authentik.example.com/application/o/grafana/","aud":"myapp"}`,
+		"otherApplication": `{"iss":"https://authentik.example.com/application/o/gitea/","aud":"myapp"}`,
+	} {
+		name, claims := name, claims
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := provider()
+			p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				a.Equal("https://authentik.example.com/application/o/token/", req.URL.String())
+				rec := httptest.NewRecorder()
+				rec.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(rec, `{"access_token":"1234567890","token_type":"Bearer","expires_in":3600,"id_token":%q}`, gothtest.UnsignedJWT(claims))
+				return rec.Result(), nil
+			})}
+			session, _ := p.BeginAuth("test_state")
+			_, err := session.Authorize(p, url.Values{"code": {"code"}})
+			if name == "valid" {
+				a.NoError(err)
+			} else {
+				a.IsType(&goth.ErrIssuerMismatch{}, err)
+			}
+		})
+	}
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://authentik.example.com/application/o/userinfo/", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"sub":"a1b2c3","email":"jdoe@example.com","email_verified":true,"name":"John Doe",
+			"given_name":"John Doe","preferred_username":"jdoe","nickname":"jdoe","groups":["authentik Admins","media"]}`)
+		return rec.Result(), nil
+	})}
+	user, err := p.FetchUser(&authentik.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("a1b2c3", user.UserID)
+	a.Equal("jdoe", user.NickName)
+	a.True(user.EmailVerified)
+	a.Equal([]string{"authentik Admins", "media"}, user.Groups)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://authentik.example.com/application/o/authorize/","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*authentik.Session)
+	a.Equal(s.AuthURL, "https://authentik.example.com/application/o/authorize/")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *authentik.Provider {
+	return authentik.New("myapp", "secret", "/foo", "https://authentik.example.com/", "grafana")
+}
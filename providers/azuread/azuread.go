@@ -4,6 +4,7 @@
 package azuread
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -84,6 +85,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to AzureAD and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	msSession := session.(*Session)
 	user := goth.User{
 		AccessToken: msSession.AccessToken,
@@ -95,7 +101,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	req, err := http.NewRequest("GET", endpointProfile, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointProfile, nil)
 	if err != nil {
 		return user, err
 	}
@@ -123,8 +129,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
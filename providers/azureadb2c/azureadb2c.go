@@ -0,0 +1,217 @@
+// Package azureadb2c implements the OpenID Connect protocol for
+// authenticating users through Azure AD B2C user flows and custom policies.
+package azureadb2c
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// ProviderOptions are the collection of optional configuration to provide when constructing a Provider
+type ProviderOptions struct {
+	// Scopes are requested in addition to openid and offline_access. Add the
+	// scopes of your own APIs here; if there are none the app's client ID is
+	// requested, which makes B2C issue an access token for the app itself.
+	Scopes []string
+	// Domain is the host users sign in on. It defaults to
+	// "<tenant>.b2clogin.com"; set it when using a custom domain.
+	Domain string
+}
+
+// Provider is the implementation of `goth.Provider` for accessing Azure AD B2C.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	baseURL      string
+	policy       string
+}
+
+// New creates a new Azure AD B2C provider for tenant, e.g. "contoso", running
+// the user flow or custom policy named policy, e.g. "B2C_1_signupsignin",
+// unless another one is passed to BeginAuthWithPolicy.
+func New(clientKey, secret, callbackURL, tenant, policy string, opts ProviderOptions) *Provider {
+	domain := opts.Domain
+	if domain == "" {
+		domain = tenant + ".b2clogin.com"
+	}
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+	if !strings.Contains(tenant, ".") {
+		tenant += ".onmicrosoft.com"
+	}
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "azureadb2c",
+		baseURL:      strings.TrimSuffix(domain, "/") + "/" + tenant,
+		policy:       policy,
+	}
+	p.config = newConfig(p, opts)
+	return p
+}
+
+func newConfig(provider *Provider, opts ProviderOptions) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint:     provider.endpoint(provider.policy),
+		Scopes:       []string{"openid", "offline_access"},
+	}
+	if len(opts.Scopes) > 0 {
+		c.Scopes = append(c.Scopes, opts.Scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, provider.ClientKey)
+	}
+	return c
+}
+
+// endpoint returns the authorize and token endpoints of policy. Each user
+// flow and custom policy has endpoints of its own.
+func (p *Provider) endpoint(policy string) oauth2.Endpoint {
+	return oauth2.Endpoint{
+		AuthURL:  p.baseURL + "/" + policy + "/oauth2/v2.0/authorize",
+		TokenURL: p.baseURL + "/" + policy + "/oauth2/v2.0/token",
+	}
+}
+
+// configFor returns the provider's config using the endpoints of policy.
+func (p *Provider) configFor(policy string) *oauth2.Config {
+	if policy == "" || policy == p.policy {
+		return p.config
+	}
+	c := *p.config
+	c.Endpoint = p.endpoint(policy)
+	return &c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client is HTTP client to be used in all fetch operations.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the package
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks for an authentication end-point running the provider's policy.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return p.BeginAuthWithPolicy(state, p.policy)
+}
+
+// BeginAuthWithPolicy is like BeginAuth but runs another user flow or custom
+// policy, e.g. a password reset or profile editing flow. The session
+// remembers the policy so that the code is redeemed at its token endpoint.
+func (p *Provider) BeginAuthWithPolicy(state, policy string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.configFor(policy).AuthCodeURL(state),
+		Policy:  policy,
+	}, nil
+}
+
+// FetchUser returns the user described by the session's id_token. B2C
+// tokens cannot be used with Microsoft Graph, so no request is made.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser. It makes no requests.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if sess.IDToken == "" {
+		return user, fmt.Errorf("%s cannot get user information without idToken", p.providerName)
+	}
+
+	var payload json.RawMessage
+	if err := goth.DecodeJWTClaims(sess.IDToken, &payload); err != nil {
+		return user, err
+	}
+	if err := user.SetRawJSON(payload); err != nil {
+		return user, err
+	}
+	err := userFromClaims(payload, &user)
+	return user, err
+}
+
+// b2cClaims are the claims B2C issues, some of which are named differently
+// than in other OpenID Connect providers.
+type b2cClaims struct {
+	Subject    string   `json:"sub"`
+	ObjectID   string   `json:"oid"`
+	Name       string   `json:"name"`
+	GivenName  string   `json:"given_name"`
+	FamilyName string   `json:"family_name"`
+	Email      string   `json:"email"`
+	Emails     []string `json:"emails"`
+	City       string   `json:"city"`
+	Country    string   `json:"country"`
+}
+
+func userFromClaims(payload []byte, user *goth.User) error {
+	var c b2cClaims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return err
+	}
+	// sub is only the object ID if the policy is configured that way
+	user.UserID = c.ObjectID
+	if user.UserID == "" {
+		user.UserID = c.Subject
+	}
+	user.Email = c.Email
+	if user.Email == "" && len(c.Emails) > 0 {
+		user.Email = c.Emails[0]
+	}
+	user.Name = c.Name
+	user.NickName = c.Name
+	user.FirstName = c.GivenName
+	user.LastName = c.FamilyName
+	user.Location = strings.Trim(c.City+", "+c.Country, ", ")
+	return nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token, using the
+// provider's policy.
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
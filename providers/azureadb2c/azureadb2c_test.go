@@ -0,0 +1,122 @@
+package azureadb2c_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/azureadb2c"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	applicationID = "6731de76-14a6-49ae-97bc-6eba6914391e"
+	secret        = "foo"
+	redirectUri   = "https://localhost:3000"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	provider := b2cProvider()
+
+	a.Equal(provider.Name(), "azureadb2c")
+	a.Equal(provider.ClientKey, applicationID)
+	a.Equal(provider.Secret, secret)
+	a.Equal(provider.CallbackURL, redirectUri)
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), b2cProvider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := b2cProvider().BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*azureadb2c.Session)
+	a.Contains(s.AuthURL, "https://contoso.b2clogin.com/contoso.onmicrosoft.com/B2C_1_signupsignin/oauth2/v2.0/authorize")
+	a.Contains(s.AuthURL, "scope=openid+offline_access+"+applicationID)
+	a.Equal("B2C_1_signupsignin", s.Policy)
+}
+
+func Test_BeginAuthWithPolicy(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	provider := azureadb2c.New(applicationID, secret, redirectUri, "contoso", "B2C_1_signupsignin", azureadb2c.ProviderOptions{
+		Domain: "login.contoso.com",
+		Scopes: []string{"https://contoso.onmicrosoft.com/api/read"},
+	})
+	session, err := provider.BeginAuthWithPolicy("test_state", "B2C_1A_passwordreset")
+	a.NoError(err)
+	s := session.(*azureadb2c.Session)
+	a.Contains(s.AuthURL, "https://login.contoso.com/contoso.onmicrosoft.com/B2C_1A_passwordreset/oauth2/v2.0/authorize")
+	a.Contains(s.AuthURL, "scope=openid+offline_access+https%3A%2F%2Fcontoso.onmicrosoft.com%2Fapi%2Fread")
+	a.Equal("B2C_1A_passwordreset", s.Policy)
+}
+
+func Test_AuthorizeAndFetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	enc := base64.RawURLEncoding.EncodeToString
+	idToken := enc([]byte(`{"alg":"RS256"}`)) + "." + enc([]byte(`{
+		"sub":"sub-1","oid":"object-1","name":"John Doe","given_name":"John","family_name":"Doe",
+		"emails":["john@example.com"],"city":"Seattle","country":"US","tfp":"B2C_1A_passwordreset"}`)) + ".sig"
+
+	var tokenPath string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"1234567890","token_type":"Bearer","expires_in":3600,"refresh_token":"refresh","id_token":%q}`, idToken)
+	}))
+	defer ts.Close()
+
+	provider := azureadb2c.New(applicationID, secret, redirectUri, "contoso", "B2C_1_signupsignin", azureadb2c.ProviderOptions{Domain: ts.URL})
+	session, err := provider.BeginAuthWithPolicy("test_state", "B2C_1A_passwordreset")
+	a.NoError(err)
+
+	_, err = session.Authorize(provider, url.Values{"code": {"code"}})
+	a.NoError(err)
+	a.Equal("/contoso.onmicrosoft.com/B2C_1A_passwordreset/oauth2/v2.0/token", tokenPath)
+
+	user, err := provider.FetchUser(session)
+	a.NoError(err)
+	a.Equal("object-1", user.UserID)
+	a.Equal("john@example.com", user.Email)
+	a.Equal("John Doe", user.Name)
+	a.Equal("John", user.FirstName)
+	a.Equal("Doe", user.LastName)
+	a.Equal("Seattle, US", user.Location)
+	a.Equal("refresh", user.RefreshToken)
+	a.Equal("B2C_1A_passwordreset", user.RawData["tfp"])
+}
+
+func Test_FetchUserWithoutIDToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	_, err := b2cProvider().FetchUser(&azureadb2c.Session{AccessToken: "1234567890"})
+	a.Error(err)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	s, err := b2cProvider().UnmarshalSession(`{"AuthURL":"https://contoso.b2clogin.com/contoso.onmicrosoft.com/B2C_1_signupsignin/oauth2/v2.0/authorize","Policy":"B2C_1_signupsignin","AccessToken":"1234567890"}`)
+	a.NoError(err)
+	session := s.(*azureadb2c.Session)
+	a.Equal("B2C_1_signupsignin", session.Policy)
+	a.Equal("1234567890", session.AccessToken)
+}
+
+func b2cProvider() *azureadb2c.Provider {
+	return azureadb2c.New(applicationID, secret, redirectUri, "contoso", "B2C_1_signupsignin", azureadb2c.ProviderOptions{})
+}
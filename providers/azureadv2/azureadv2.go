@@ -1,11 +1,13 @@
 package azureadv2
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -13,11 +15,35 @@ import (
 
 // also https://docs.microsoft.com/en-us/azure/active-directory/develop/active-directory-v2-protocols#endpoints
 const (
-	authURLTemplate  string = "https://login.microsoftonline.com/%s/oauth2/v2.0/authorize"
-	tokenURLTemplate string = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
-	graphAPIResource string = "https://graph.microsoft.com/v1.0/"
+	authURLTemplate  string = "%s/%s/oauth2/v2.0/authorize"
+	tokenURLTemplate string = "%s/%s/oauth2/v2.0/token"
 )
 
+// These are the authorities and Microsoft Graph endpoints of the national
+// clouds, to be used in ProviderOptions.
+//
+// See also https://learn.microsoft.com/en-us/azure/active-directory/develop/authentication-national-cloud
+const (
+	AuthorityPublic       = "https://login.microsoftonline.com"
+	AuthorityUSGovernment = "https://login.microsoftonline.us"
+	AuthorityChina        = "https://login.chinacloudapi.cn"
+
+	GraphPublic          = "https://graph.microsoft.com"
+	GraphUSGovernment    = "https://graph.microsoft.us"
+	GraphUSGovernmentDoD = "https://dod-graph.microsoft.us"
+	GraphChina           = "https://microsoftgraph.chinacloudapi.cn"
+)
+
+// ErrTenantNotAllowed is returned by Authorize when the user signed in with
+// a tenant that is not one of ProviderOptions.AllowedTenants.
+type ErrTenantNotAllowed struct {
+	TenantID string
+}
+
+func (e *ErrTenantNotAllowed) Error() string {
+	return fmt.Sprintf("azureadv2: tenant %q is not allowed", e.TenantID)
+}
+
 type (
 	// TenantType are the well known tenant types to scope the users that can authenticate. TenantType is not an
 	// exclusive list of Azure Tenants which can be used. A consumer can also use their own Tenant ID to scope
@@ -28,18 +54,37 @@ type (
 
 	// Provider is the implementation of `goth.Provider` for accessing AzureAD V2.
 	Provider struct {
-		ClientKey    string
-		Secret       string
-		CallbackURL  string
-		HTTPClient   *http.Client
-		config       *oauth2.Config
-		providerName string
+		ClientKey      string
+		Secret         string
+		CallbackURL    string
+		HTTPClient     *http.Client
+		config         *oauth2.Config
+		providerName   string
+		graphURL       string
+		allowedTenants []string
+		resolveOverage bool
 	}
 
 	// ProviderOptions are the collection of optional configuration to provide when constructing a Provider
 	ProviderOptions struct {
 		Scopes []ScopeType
 		Tenant TenantType
+		// Authority is the login endpoint of the cloud to use. It defaults to
+		// AuthorityPublic.
+		Authority string
+		// GraphEndpoint is the Microsoft Graph endpoint of the cloud to use. It
+		// defaults to GraphPublic.
+		GraphEndpoint string
+		// AllowedTenants, if set, are the IDs of the only tenants whose users
+		// may sign in, checked against the tid claim of the id_token. Use it
+		// with the common and organizations tenants to admit a known set of
+		// organizations only.
+		AllowedTenants []string
+		// ResolveGroupOverage makes FetchUser look up the user's groups with
+		// Microsoft Graph when the id_token signals that the user is in too
+		// many groups for them to be included as claims. It requires the
+		// GroupMember.Read.All scope.
+		ResolveGroupOverage bool
 	}
 )
 
@@ -63,10 +108,16 @@ const (
 // one manually.
 func New(clientKey, secret, callbackURL string, opts ProviderOptions) *Provider {
 	p := &Provider{
-		ClientKey:    clientKey,
-		Secret:       secret,
-		CallbackURL:  callbackURL,
-		providerName: "azureadv2",
+		ClientKey:      clientKey,
+		Secret:         secret,
+		CallbackURL:    callbackURL,
+		providerName:   "azureadv2",
+		graphURL:       strings.TrimSuffix(opts.GraphEndpoint, "/"),
+		allowedTenants: opts.AllowedTenants,
+		resolveOverage: opts.ResolveGroupOverage,
+	}
+	if p.graphURL == "" {
+		p.graphURL = GraphPublic
 	}
 
 	p.config = newConfig(p, opts)
@@ -78,14 +129,18 @@ func newConfig(provider *Provider, opts ProviderOptions) *oauth2.Config {
 	if tenant == "" {
 		tenant = CommonTenant
 	}
+	authority := strings.TrimSuffix(opts.Authority, "/")
+	if authority == "" {
+		authority = AuthorityPublic
+	}
 
 	c := &oauth2.Config{
 		ClientID:     provider.ClientKey,
 		ClientSecret: provider.Secret,
 		RedirectURL:  provider.CallbackURL,
 		Endpoint: oauth2.Endpoint{
-			AuthURL:  fmt.Sprintf(authURLTemplate, tenant),
-			TokenURL: fmt.Sprintf(tokenURLTemplate, tenant),
+			AuthURL:  fmt.Sprintf(authURLTemplate, authority, tenant),
+			TokenURL: fmt.Sprintf(tokenURLTemplate, authority, tenant),
 		},
 		Scopes: []string{},
 	}
@@ -129,6 +184,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to AzureAD and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	msSession := session.(*Session)
 	user := goth.User{
 		AccessToken: msSession.AccessToken,
@@ -140,7 +200,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	req, err := http.NewRequest("GET", graphAPIResource+"me", nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", p.graphURL+"/v1.0/me", nil)
 	if err != nil {
 		return user, err
 	}
@@ -157,14 +217,93 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
 	}
 
-	err = userFromReader(response.Body, &user)
+	err = userFromReader(response.Body, p.graphURL, &user)
 	user.AccessToken = msSession.AccessToken
 	user.IDToken = msSession.IDToken
 	user.RefreshToken = msSession.RefreshToken
 	user.ExpiresAt = msSession.ExpiresAt
+	if err != nil || msSession.IDToken == "" {
+		return user, err
+	}
+	err = p.groupsAndRoles(ctx, msSession, &user)
 	return user, err
 }
 
+// groupsAndRoles fills in the user's groups and app roles from the groups
+// and roles claims of the id_token. The groups claim holds group object IDs
+// and must be enabled in the app registration. If the user is in more groups
+// than fit in a token, Azure AD leaves the claim out and signals an overage
+// instead, in which case the groups are fetched if the provider has been
+// told to.
+func (p *Provider) groupsAndRoles(ctx context.Context, session *Session, user *goth.User) error {
+	var claims map[string]interface{}
+	err := goth.DecodeJWTClaims(session.IDToken, &claims)
+	if err != nil {
+		return err
+	}
+	user.Groups = stringsClaim(claims["groups"])
+	user.Roles = stringsClaim(claims["roles"])
+
+	names, _ := claims["_claim_names"].(map[string]interface{})
+	_, overage := names["groups"]
+	if hasGroups, _ := claims["hasgroups"].(bool); hasGroups {
+		overage = true
+	}
+	if !overage || !p.resolveOverage {
+		return nil
+	}
+	user.Groups, err = p.memberGroups(ctx, session)
+	return err
+}
+
+// memberGroups returns the object IDs of all groups the user is a direct or
+// transitive member of, following all result pages.
+func (p *Provider) memberGroups(ctx context.Context, session *Session) ([]string, error) {
+	var groups []string
+	next := p.graphURL + "/v1.0/me/transitiveMemberOf/microsoft.graph.group?$select=id&$top=999"
+	for next != "" {
+		req, err := http.NewRequestWithContext(ctx, "GET", next, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(authorizationHeader(session))
+		response, err := p.Client().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Value []struct {
+				ID string `json:"id"`
+			} `json:"value"`
+			NextLink string `json:"@odata.nextLink"`
+		}
+		err = json.NewDecoder(response.Body).Decode(&page)
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s responded with a %d trying to fetch group memberships", p.providerName, response.StatusCode)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range page.Value {
+			groups = append(groups, g.ID)
+		}
+		next = page.NextLink
+	}
+	return groups, nil
+}
+
+func stringsClaim(v interface{}) []string {
+	values, _ := v.([]interface{})
+	var strs []string
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
 // RefreshTokenAvailable refresh token is provided by auth provider or not
 func (p *Provider) RefreshTokenAvailable() bool {
 	return true
@@ -172,8 +311,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -181,11 +325,33 @@ func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
 	return newToken, err
 }
 
+// checkTenant verifies that the tenant the id_token was issued for is one of
+// the allowed tenants. The id_token's signature is not checked, as it was
+// received from the token endpoint over TLS.
+func (p *Provider) checkTenant(idToken string) error {
+	if len(p.allowedTenants) == 0 {
+		return nil
+	}
+	var claims struct {
+		TenantID string `json:"tid"`
+	}
+	if err := goth.DecodeJWTClaims(idToken, &claims); err != nil {
+		return err
+	}
+	tid := claims.TenantID
+	for _, allowed := range p.allowedTenants {
+		if tid != "" && strings.EqualFold(tid, allowed) {
+			return nil
+		}
+	}
+	return &ErrTenantNotAllowed{TenantID: tid}
+}
+
 func authorizationHeader(session *Session) (string, string) {
 	return "Authorization", fmt.Sprintf("Bearer %s", session.AccessToken)
 }
 
-func userFromReader(r io.Reader, user *goth.User) error {
+func userFromReader(r io.Reader, graphURL string, user *goth.User) error {
 	u := struct {
 		ID                string   `json:"id"`                // The unique identifier for the user.
 		BusinessPhones    []string `json:"businessPhones"`    // The user's phone numbers.
@@ -216,7 +382,7 @@ func userFromReader(r io.Reader, user *goth.User) error {
 	user.NickName = u.DisplayName
 	user.Location = u.OfficeLocation
 	user.UserID = u.ID
-	user.AvatarURL = graphAPIResource + fmt.Sprintf("users/%s/photo/$value", u.ID)
+	user.AvatarURL = graphURL + fmt.Sprintf("/v1.0/users/%s/photo/$value", u.ID)
 	// Make sure all the information returned is available via RawData
 	if err := json.Unmarshal(userBytes, &user.RawData); err != nil {
 		return err
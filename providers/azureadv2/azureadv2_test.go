@@ -1,6 +1,12 @@
 package azureadv2_test
 
 import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/markbates/goth"
@@ -44,6 +50,111 @@ func Test_BeginAuth(t *testing.T) {
 	a.Contains(s.AuthURL, "scope=openid+profile+email")
 }
 
+func Test_BeginAuthWithAuthority(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	provider := azureadv2.New(applicationID, secret, redirectUri, azureadv2.ProviderOptions{
+		Tenant:    "contoso.onmicrosoft.us",
+		Authority: azureadv2.AuthorityUSGovernment,
+	})
+	session, err := provider.BeginAuth("test_state")
+	a.NoError(err)
+	a.Contains(session.(*azureadv2.Session).AuthURL, "https://login.microsoftonline.us/contoso.onmicrosoft.us/oauth2/v2.0/authorize")
+}
+
+func idToken(claims string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"RS256"}`)) + "." + enc([]byte(claims)) + ".sig"
+}
+
+// tokenServer serves the token endpoint of the tenant, returning idToken,
+// and the Microsoft Graph /me endpoint.
+func tokenServer(idToken string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token"):
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"1234567890","token_type":"Bearer","expires_in":3600,"id_token":%q}`, idToken)
+		case r.URL.Path == "/v1.0/me":
+			fmt.Fprint(w, `{"id":"user-1","displayName":"John Doe","mail":"john@contoso.com"}`)
+		case r.URL.Path == "/v1.0/me/transitiveMemberOf/microsoft.graph.group":
+			if r.URL.Query().Get("$skiptoken") == "" {
+				fmt.Fprintf(w, `{"value":[{"id":"group-1"}],"@odata.nextLink":"http://%s/v1.0/me/transitiveMemberOf/microsoft.graph.group?$skiptoken=2"}`, r.Host)
+				return
+			}
+			fmt.Fprint(w, `{"value":[{"id":"group-2"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func Test_AllowedTenants(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	authorize := func(tid string) (*azureadv2.Session, error) {
+		ts := tokenServer(idToken(fmt.Sprintf(`{"sub":"1","tid":%q}`, tid)))
+		defer ts.Close()
+		provider := azureadv2.New(applicationID, secret, redirectUri, azureadv2.ProviderOptions{
+			Tenant:         azureadv2.OrganizationsTenant,
+			Authority:      ts.URL,
+			GraphEndpoint:  ts.URL,
+			AllowedTenants: []string{"72f988bf-86f1-41af-91ab-2d7cd011db47"},
+		})
+		session, _ := provider.BeginAuth("test_state")
+		s := session.(*azureadv2.Session)
+		_, err := s.Authorize(provider, url.Values{"code": {"code"}})
+		if err == nil {
+			var user goth.User
+			user, err = provider.FetchUser(s)
+			a.Equal("user-1", user.UserID)
+			a.Equal(ts.URL+"/v1.0/users/user-1/photo/$value", user.AvatarURL)
+		}
+		return s, err
+	}
+
+	s, err := authorize("72F988BF-86F1-41AF-91AB-2D7CD011DB47")
+	a.NoError(err)
+	a.Equal("1234567890", s.AccessToken)
+
+	s, err = authorize("9188040d-6c67-4c5b-b112-36a304b66dad")
+	a.Equal(&azureadv2.ErrTenantNotAllowed{TenantID: "9188040d-6c67-4c5b-b112-36a304b66dad"}, err)
+	a.Empty(s.AccessToken)
+}
+
+func Test_GroupsAndRoles(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	fetch := func(claims string, resolve bool) goth.User {
+		ts := tokenServer(idToken(claims))
+		defer ts.Close()
+		provider := azureadv2.New(applicationID, secret, redirectUri, azureadv2.ProviderOptions{
+			Authority:           ts.URL,
+			GraphEndpoint:       ts.URL,
+			ResolveGroupOverage: resolve,
+		})
+		session, _ := provider.BeginAuth("test_state")
+		_, err := session.Authorize(provider, url.Values{"code": {"code"}})
+		a.NoError(err)
+		user, err := provider.FetchUser(session)
+		a.NoError(err)
+		return user
+	}
+
+	user := fetch(`{"sub":"1","groups":["group-a","group-b"],"roles":["Admin"]}`, true)
+	a.Equal([]string{"group-a", "group-b"}, user.Groups)
+	a.Equal([]string{"Admin"}, user.Roles)
+
+	overage := `{"sub":"1","roles":["Reader"],"_claim_names":{"groups":"src1"},"_claim_sources":{"src1":{"endpoint":"https://graph.windows.net/"}}}`
+	user = fetch(overage, false)
+	a.Empty(user.Groups)
+	a.Equal([]string{"Reader"}, user.Roles)
+	user = fetch(overage, true)
+	a.Equal([]string{"group-1", "group-2"}, user.Groups)
+}
+
 func Test_SessionFromJSON(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
@@ -1,9 +1,9 @@
 package azureadv2
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"strings"
 	"time"
 
 	"github.com/markbates/goth"
@@ -29,8 +29,13 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with AzureAD and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.config.Exchange(goth.ContextForClient(p.Client()), params.Get("code"))
+	token, err := p.config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"))
 	if err != nil {
 		return "", err
 	}
@@ -39,11 +44,16 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 		return "", errors.New("invalid token received from provider")
 	}
 
+	idToken, _ := token.Extra("id_token").(string)
+	if err := p.checkTenant(idToken); err != nil {
+		return "", err
+	}
+
 	s.AccessToken = token.AccessToken
 	s.RefreshToken = token.RefreshToken
 	s.ExpiresAt = token.Expiry
-	if idTok, ok := token.Extra("id_token").(string); ok {
-		s.IDToken = idTok
+	if idToken != "" {
+		s.IDToken = idToken
 	}
 
 	return token.AccessToken, err
@@ -62,6 +72,6 @@ func (s Session) String() string {
 // UnmarshalSession wil unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	session := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(session)
+	err := goth.DecodeSession(data, session)
 	return session, err
 }
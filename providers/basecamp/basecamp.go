@@ -0,0 +1,263 @@
+// Package basecamp implements the OAuth2 protocol for authenticating users
+// through 37signals Launchpad, the identity service of Basecamp and the other
+// 37signals products.
+package basecamp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL         string = "https://launchpad.37signals.com/authorization/new"
+	tokenURL        string = "https://launchpad.37signals.com/authorization/token"
+	endpointProfile string = "https://launchpad.37signals.com/authorization.json"
+)
+
+// Products of the accounts returned by AccountsOf.
+const (
+	// ProductBasecamp is Basecamp 3 and later, whose API is at
+	// https://3.basecampapi.com.
+	ProductBasecamp        = "bc3"
+	ProductBasecamp2       = "bcx"
+	ProductBasecampClassic = "basecamp"
+	ProductCampfire        = "campfire"
+	ProductHighrise        = "highrise"
+)
+
+// RawDataAccounts is the key of User.RawData holding the accounts the user
+// can access.
+const RawDataAccounts = "accounts"
+
+// Account is a 37signals account the user can access.
+type Account struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Product string `json:"product"`
+	// HRef is the base URL of the account's API.
+	HRef string `json:"href"`
+	// AppHRef is the URL of the account in the browser.
+	AppHRef string `json:"app_href"`
+}
+
+// AccountsOf returns the accounts of a user returned by FetchUser, optionally
+// only those of the given products, e.g. ProductBasecamp.
+func AccountsOf(user goth.User, products ...string) ([]Account, error) {
+	var a struct {
+		Accounts []Account `json:"accounts"`
+	}
+	if err := user.DecodeRawJSON(&a); err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return a.Accounts, nil
+	}
+	var accounts []Account
+	for _, account := range a.Accounts {
+		for _, product := range products {
+			if account.Product == product {
+				accounts = append(accounts, account)
+				break
+			}
+		}
+	}
+	return accounts, nil
+}
+
+// Provider is the implementation of `goth.Provider` for accessing Basecamp.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+}
+
+// New creates a new Basecamp provider, and sets up important connection details.
+// Launchpad has no scopes; tokens can access all of the user's accounts.
+// You should always call `basecamp.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "basecamp",
+	}
+	p.config = &oauth2.Config{
+		ClientID:     p.ClientKey,
+		ClientSecret: p.Secret,
+		RedirectURL:  p.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+	return p
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the basecamp package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks Launchpad for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state, oauth2.SetAuthURLParam("type", "web_server")),
+	}, nil
+}
+
+// FetchUser will go to Launchpad and access the identity of the user. The
+// accounts the user can access are stored in RawData under RawDataAccounts;
+// use AccountsOf to read them.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointProfile, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	u := struct {
+		Identity struct {
+			ID           int    `json:"id"`
+			FirstName    string `json:"first_name"`
+			LastName     string `json:"last_name"`
+			EmailAddress string `json:"email_address"`
+		} `json:"identity"`
+	}{}
+	if err := json.Unmarshal(bits, &u); err != nil {
+		return user, err
+	}
+	user.UserID = strconv.Itoa(u.Identity.ID)
+	user.FirstName = u.Identity.FirstName
+	user.LastName = u.Identity.LastName
+	user.Name = strings.TrimSpace(u.Identity.FirstName + " " + u.Identity.LastName)
+	user.Email = u.Identity.EmailAddress
+	return user, nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+// Launchpad wants type=refresh instead of a refresh_token grant, and keeps the
+// refresh token unchanged.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	form := url.Values{
+		"type":          {"refresh"},
+		"refresh_token": {refreshToken},
+		"client_id":     {p.ClientKey},
+		"client_secret": {p.Secret},
+		"redirect_uri":  {p.CallbackURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with a %d trying to refresh the token", p.providerName, resp.StatusCode)
+	}
+
+	r := struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+	if r.AccessToken == "" {
+		return nil, errors.New("Invalid token received from provider")
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  r.AccessToken,
+		TokenType:    "Bearer",
+		RefreshToken: r.RefreshToken,
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	if r.ExpiresIn > 0 {
+		token.Expiry = goth.GetClock().Now().Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -0,0 +1,138 @@
+package basecamp_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/basecamp"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("BASECAMP_KEY"))
+	a.Equal(p.Secret, os.Getenv("BASECAMP_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	s := session.(*basecamp.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "launchpad.37signals.com/authorization/new")
+	a.Contains(s.AuthURL, "type=web_server")
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://launchpad.37signals.com/authorization/token", req.URL.String())
+		a.NoError(req.ParseForm())
+		a.Equal("web_server", req.PostForm.Get("type"))
+		a.Equal("code", req.PostForm.Get("code"))
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(rec, `{"access_token":"1234567890","expires_in":1209600,"refresh_token":"refresh"}`)
+		return rec.Result(), nil
+	})}
+
+	s := &basecamp.Session{}
+	_, err := s.Authorize(p, url.Values{"code": {"code"}})
+	a.NoError(err)
+	a.Equal("1234567890", s.AccessToken)
+	a.Equal("refresh", s.RefreshToken)
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://launchpad.37signals.com/authorization.json", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"expires_at":"2026-10-28T00:00:00Z",
+			"identity":{"id":9999999,"first_name":"Jane","last_name":"Doe","email_address":"jane@example.com"},
+			"accounts":[
+				{"product":"bc3","id":88888888,"name":"Acme","href":"https://3.basecampapi.com/88888888","app_href":"https://3.basecamp.com/88888888"},
+				{"product":"campfire","id":77777777,"name":"Acme Chat","href":"https://acme.campfirenow.com","app_href":"https://acme.campfirenow.com"}]}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&basecamp.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("9999999", user.UserID)
+	a.Equal("Jane Doe", user.Name)
+	a.Equal("Jane", user.FirstName)
+	a.Equal("jane@example.com", user.Email)
+	a.Len(user.RawData[basecamp.RawDataAccounts], 2)
+
+	accounts, err := basecamp.AccountsOf(user, basecamp.ProductBasecamp)
+	a.NoError(err)
+	a.Equal([]basecamp.Account{{
+		ID:      88888888,
+		Name:    "Acme",
+		Product: "bc3",
+		HRef:    "https://3.basecampapi.com/88888888",
+		AppHRef: "https://3.basecamp.com/88888888",
+	}}, accounts)
+}
+
+func Test_RefreshToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.NoError(req.ParseForm())
+		a.Equal("refresh", req.PostForm.Get("type"))
+		a.Equal("old", req.PostForm.Get("refresh_token"))
+		a.Empty(req.PostForm.Get("grant_type"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"access_token":"new","expires_in":1209600}`)
+		return rec.Result(), nil
+	})}
+
+	token, err := p.RefreshToken("old")
+	a.NoError(err)
+	a.Equal("new", token.AccessToken)
+	a.Equal("old", token.RefreshToken)
+	a.False(token.Expiry.IsZero())
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://launchpad.37signals.com/authorization/new","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*basecamp.Session)
+	a.Equal(s.AuthURL, "https://launchpad.37signals.com/authorization/new")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *basecamp.Provider {
+	return basecamp.New(os.Getenv("BASECAMP_KEY"), os.Getenv("BASECAMP_SECRET"), "/foo")
+}
@@ -4,6 +4,7 @@ package battlenet
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -69,6 +70,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Battle.net and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken:  sess.AccessToken,
@@ -84,7 +90,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 
 	// Get the userID, battlenet needs userID in order to get user profile info
 	c := p.Client()
-	req, err := http.NewRequest("GET", endpointUser, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointUser, nil)
 	if err != nil {
 		return user, err
 	}
@@ -3,6 +3,7 @@ package bitbucket
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -71,6 +72,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Bitbucket and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken:  sess.AccessToken,
@@ -84,19 +90,19 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	if err := p.getUserInfo(&user, sess); err != nil {
+	if err := p.getUserInfo(ctx, &user, sess); err != nil {
 		return user, err
 	}
 
-	if err := p.getEmail(&user, sess); err != nil {
+	if err := p.getEmail(ctx, &user, sess); err != nil {
 		return user, err
 	}
 
 	return user, nil
 }
 
-func (p *Provider) getUserInfo(user *goth.User, sess *Session) error {
-	req, err := http.NewRequest("GET", endpointProfile, nil)
+func (p *Provider) getUserInfo(ctx context.Context, user *goth.User, sess *Session) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointProfile, nil)
 	if err != nil {
 		return err
 	}
@@ -116,7 +122,7 @@ func (p *Provider) getUserInfo(user *goth.User, sess *Session) error {
 		return err
 	}
 
-	err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
+	err = user.SetRawJSON(bits)
 	if err != nil {
 		return err
 	}
@@ -146,8 +152,8 @@ func (p *Provider) getUserInfo(user *goth.User, sess *Session) error {
 	return nil
 }
 
-func (p *Provider) getEmail(user *goth.User, sess *Session) error {
-	req, err := http.NewRequest("GET", endpointEmail, nil)
+func (p *Provider) getEmail(ctx context.Context, user *goth.User, sess *Session) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointEmail, nil)
 	if err != nil {
 		return err
 	}
@@ -210,8 +216,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
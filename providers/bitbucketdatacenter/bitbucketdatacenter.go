@@ -0,0 +1,211 @@
+// Package bitbucketdatacenter implements the OAuth2 protocol for
+// authenticating users through a self-hosted Bitbucket Data Center (or
+// Bitbucket Server 8.5+) instance. Use the bitbucket package for Bitbucket Cloud.
+package bitbucketdatacenter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// Bitbucket Data Center scopes. ScopePublicRepos is requested if no scopes
+// are passed to New; any scope allows reading the user's profile.
+const (
+	ScopePublicRepos  = "PUBLIC_REPOS"
+	ScopeRepoRead     = "REPO_READ"
+	ScopeRepoWrite    = "REPO_WRITE"
+	ScopeRepoAdmin    = "REPO_ADMIN"
+	ScopeProjectAdmin = "PROJECT_ADMIN"
+)
+
+// Provider is the implementation of `goth.Provider` for accessing Bitbucket Data Center.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	baseURL      string
+}
+
+// New creates a new Bitbucket Data Center provider for the instance at
+// baseURL, e.g. https://bitbucket.example.com, using the client ID and secret
+// of an incoming application link, and sets up important connection details.
+// You should always call `bitbucketdatacenter.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, baseURL string, scopes ...string) *Provider {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "bitbucketdatacenter",
+		baseURL:      baseURL,
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   provider.baseURL + "/rest/oauth2/latest/authorize",
+			TokenURL:  provider.baseURL + "/rest/oauth2/latest/token",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, ScopePublicRepos)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the bitbucketdatacenter package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks Bitbucket Data Center for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to Bitbucket Data Center and access basic information
+// about the user. The REST API has no endpoint for the current user, so the
+// username is looked up with the application links whoami servlet first.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	bits, err := p.get(ctx, "/plugins/servlet/applinks/whoami", sess.AccessToken)
+	if err != nil {
+		return user, err
+	}
+	username := strings.TrimSpace(string(bits))
+	if username == "" {
+		return user, fmt.Errorf("%s did not return the user's name", p.providerName)
+	}
+
+	bits, err = p.get(ctx, "/rest/api/latest/users/"+url.PathEscape(username)+"?avatarSize=128", sess.AccessToken)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	err = userFromReader(bytes.NewReader(bits), &user, p.baseURL)
+	return user, err
+}
+
+// get GETs path from the instance and returns the response body.
+func (p *Provider) get(ctx context.Context, path, accessToken string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+	return ioutil.ReadAll(response.Body)
+}
+
+func userFromReader(r io.Reader, user *goth.User, baseURL string) error {
+	u := struct {
+		ID           int    `json:"id"`
+		Name         string `json:"name"`
+		Slug         string `json:"slug"`
+		EmailAddress string `json:"emailAddress"`
+		DisplayName  string `json:"displayName"`
+		AvatarURL    string `json:"avatarUrl"`
+	}{}
+	err := json.NewDecoder(r).Decode(&u)
+	if err != nil {
+		return err
+	}
+	user.UserID = strconv.Itoa(u.ID)
+	user.NickName = u.Name
+	user.Name = u.DisplayName
+	user.Email = u.EmailAddress
+	user.AvatarURL = u.AvatarURL
+	// avatar URLs are relative to the instance unless they point to Gravatar
+	if strings.HasPrefix(user.AvatarURL, "/") {
+		user.AvatarURL = baseURL + user.AvatarURL
+	}
+	return nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
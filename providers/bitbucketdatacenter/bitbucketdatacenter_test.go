@@ -0,0 +1,87 @@
+package bitbucketdatacenter_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/bitbucketdatacenter"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "bitbucketdatacenter")
+	a.Equal(p.ClientKey, os.Getenv("BITBUCKET_DC_KEY"))
+	a.Equal(p.Secret, os.Getenv("BITBUCKET_DC_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	s := session.(*bitbucketdatacenter.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "https://bitbucket.example.com/rest/oauth2/latest/authorize")
+	a.Contains(s.AuthURL, "scope=PUBLIC_REPOS")
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		switch req.URL.Path {
+		case "/plugins/servlet/applinks/whoami":
+			fmt.Fprint(rec, "john.doe@corp")
+		case "/rest/api/latest/users/john.doe@corp":
+			fmt.Fprint(rec, `{"name":"john.doe@corp","emailAddress":"jdoe@example.com","active":true,"displayName":"John Doe",
+				"id":101,"slug":"john.doe_corp","type":"NORMAL","avatarUrl":"/users/john.doe_corp/avatar.png?s=128"}`)
+		default:
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&bitbucketdatacenter.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("101", user.UserID)
+	a.Equal("john.doe@corp", user.NickName)
+	a.Equal("John Doe", user.Name)
+	a.Equal("jdoe@example.com", user.Email)
+	a.Equal("https://bitbucket.example.com/users/john.doe_corp/avatar.png?s=128", user.AvatarURL)
+	a.Equal("john.doe_corp", user.RawData["slug"])
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://bitbucket.example.com/rest/oauth2/latest/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*bitbucketdatacenter.Session)
+	a.Equal(s.AuthURL, "https://bitbucket.example.com/rest/oauth2/latest/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *bitbucketdatacenter.Provider {
+	return bitbucketdatacenter.New(os.Getenv("BITBUCKET_DC_KEY"), os.Getenv("BITBUCKET_DC_SECRET"), "/foo", "https://bitbucket.example.com/")
+}
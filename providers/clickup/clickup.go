@@ -0,0 +1,211 @@
+// Package clickup implements the OAuth2 protocol for authenticating users
+// through ClickUp.
+package clickup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL       string = "https://app.clickup.com/api"
+	tokenURL      string = "https://api.clickup.com/api/v2/oauth/token"
+	endpointUser  string = "https://api.clickup.com/api/v2/user"
+	endpointTeams string = "https://api.clickup.com/api/v2/team"
+)
+
+// RawDataTeams is the key of User.RawData holding the teams (workspaces) the
+// user authorized the app for, as a list of objects with an id, name, color
+// and avatar.
+const RawDataTeams = "teams"
+
+// Provider is the implementation of `goth.Provider` for accessing ClickUp.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+}
+
+// New creates a new ClickUp provider, and sets up important connection details.
+// ClickUp has no scopes; users pick the teams the app can access when
+// authorizing it.
+// You should always call `clickup.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "clickup",
+	}
+	p.config = &oauth2.Config{
+		ClientID:     p.ClientKey,
+		ClientSecret: p.Secret,
+		RedirectURL:  p.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+	return p
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the clickup package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks ClickUp for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to ClickUp and access basic information about the user.
+// The teams the user authorized the app for are stored in RawData under
+// RawDataTeams.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken: sess.AccessToken,
+		Provider:    p.Name(),
+		ExpiresAt:   sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	bits, err := p.get(ctx, endpointUser, sess.AccessToken)
+	if err != nil {
+		return user, err
+	}
+	var envelope struct {
+		User json.RawMessage `json:"user"`
+	}
+	if err := json.Unmarshal(bits, &envelope); err != nil {
+		return user, err
+	}
+	if len(envelope.User) == 0 {
+		return user, errors.New("clickup: no user information returned")
+	}
+
+	err = user.SetRawJSON(envelope.User)
+	if err != nil {
+		return user, err
+	}
+
+	u := struct {
+		ID             int    `json:"id"`
+		Username       string `json:"username"`
+		Email          string `json:"email"`
+		ProfilePicture string `json:"profilePicture"`
+	}{}
+	if err := json.Unmarshal(envelope.User, &u); err != nil {
+		return user, err
+	}
+	user.UserID = strconv.Itoa(u.ID)
+	user.Name = u.Username
+	user.NickName = u.Username
+	user.Email = u.Email
+	user.AvatarURL = u.ProfilePicture
+
+	teams, err := p.fetchTeams(ctx, sess.AccessToken)
+	if err != nil {
+		return user, err
+	}
+	user.SetRawData(RawDataTeams, teams)
+	return user, nil
+}
+
+// fetchTeams returns the teams the token is authorized for, without their
+// member lists.
+func (p *Provider) fetchTeams(ctx context.Context, accessToken string) ([]interface{}, error) {
+	bits, err := p.get(ctx, endpointTeams, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	var r struct {
+		Teams []struct {
+			ID     string `json:"id"`
+			Name   string `json:"name"`
+			Color  string `json:"color"`
+			Avatar string `json:"avatar"`
+		} `json:"teams"`
+	}
+	if err := json.Unmarshal(bits, &r); err != nil {
+		return nil, err
+	}
+	teams := make([]interface{}, 0, len(r.Teams))
+	for _, t := range r.Teams {
+		teams = append(teams, map[string]interface{}{
+			"id":     t.ID,
+			"name":   t.Name,
+			"color":  t.Color,
+			"avatar": t.Avatar,
+		})
+	}
+	return teams, nil
+}
+
+// get GETs url with the access token and returns the response body.
+func (p *Provider) get(ctx context.Context, url, accessToken string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// ClickUp takes the bare token, without a Bearer prefix
+	req.Header.Set("Authorization", accessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+	return ioutil.ReadAll(response.Body)
+}
+
+// RefreshTokenAvailable refresh token is not provided by ClickUp
+func (p *Provider) RefreshTokenAvailable() bool {
+	return false
+}
+
+// RefreshToken refresh token is not provided by ClickUp
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return nil, errors.New("Refresh token is not provided by ClickUp")
+}
@@ -0,0 +1,115 @@
+package clickup_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/clickup"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("CLICKUP_KEY"))
+	a.Equal(p.Secret, os.Getenv("CLICKUP_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	s := session.(*clickup.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "app.clickup.com/api")
+	a.Contains(s.AuthURL, "state=test_state")
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		switch req.URL.String() {
+		case "https://api.clickup.com/api/v2/user":
+			fmt.Fprint(rec, `{"user":{"id":123,"username":"Jane Doe","email":"jane@example.com","color":"#000000",
+				"profilePicture":"https://example.com/jane.png","initials":"JD","week_start_day":0,"timezone":"Europe/London"}}`)
+		case "https://api.clickup.com/api/v2/team":
+			fmt.Fprint(rec, `{"teams":[{"id":"1234","name":"Acme","color":"#8C9FA1","avatar":null,
+				"members":[{"user":{"id":123,"username":"Jane Doe"}}]}]}`)
+		default:
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&clickup.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("123", user.UserID)
+	a.Equal("Jane Doe", user.Name)
+	a.Equal("jane@example.com", user.Email)
+	a.Equal("https://example.com/jane.png", user.AvatarURL)
+	a.Equal([]interface{}{map[string]interface{}{"id": "1234", "name": "Acme", "color": "#8C9FA1", "avatar": ""}}, user.RawData[clickup.RawDataTeams])
+}
+
+func Test_FetchUser_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		switch req.URL.String() {
+		case "https://api.clickup.com/api/v2/user":
+			fmt.Fprint(rec, `{"user":{"id":123,"username":"Jane Doe"}}`)
+		case "https://api.clickup.com/api/v2/team":
+			fmt.Fprint(rec, `{"teams":[{"id":"1234","name":"Acme","color":"#8C9FA1","avatar":null}]}`)
+		default:
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&clickup.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("Jane Doe", raw["username"])
+	a.Equal([]interface{}{map[string]interface{}{"id": "1234", "name": "Acme", "color": "#8C9FA1", "avatar": ""}}, raw[clickup.RawDataTeams])
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://app.clickup.com/api","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*clickup.Session)
+	a.Equal(s.AuthURL, "https://app.clickup.com/api")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *clickup.Provider {
+	return clickup.New(os.Getenv("CLICKUP_KEY"), os.Getenv("CLICKUP_SECRET"), "/foo")
+}
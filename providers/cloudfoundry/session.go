@@ -4,11 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"strings"
 	"time"
 
 	"github.com/markbates/goth"
-	"golang.org/x/oauth2"
 )
 
 // Session stores data during the auth process with Cloud Foundry.
@@ -31,8 +29,13 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with Cloud Foundry and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	ctx := context.WithValue(goth.ContextForClient(p.Client()), oauth2.HTTPClient, p.Client())
+	ctx = goth.ContextWithClient(ctx, p.Client())
 	token, err := p.config.Exchange(ctx, params.Get("code"))
 	if err != nil {
 		return "", err
@@ -61,6 +64,6 @@ func (s Session) String() string {
 // UnmarshalSession wil unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(s)
+	err := goth.DecodeSession(data, s)
 	return s, err
 }
@@ -0,0 +1,224 @@
+// Package cognito implements the OAuth2 protocol for authenticating users
+// through the hosted UI of an Amazon Cognito user pool.
+package cognito
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// Provider is the implementation of `goth.Provider` for accessing Amazon Cognito.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	domain       string
+}
+
+// New creates a new Cognito provider using the hosted UI at domain, which is
+// either the prefix domain of the user pool, like
+// myapp.auth.us-east-1.amazoncognito.com, or a custom domain. The openid,
+// profile and email scopes are requested unless other scopes are passed.
+// You should always call `cognito.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, domain string, scopes ...string) *Provider {
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "cognito",
+		domain:       strings.TrimSuffix(domain, "/"),
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.domain + "/oauth2/authorize",
+			TokenURL: provider.domain + "/oauth2/token",
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, "openid", "profile", "email")
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the cognito package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks Cognito for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// LogoutURL returns the URL of the hosted UI's logout endpoint, which ends
+// the user's Cognito session and redirects to logoutURI. logoutURI must be
+// one of the sign-out URLs configured for the app client.
+func (p *Provider) LogoutURL(logoutURI string) string {
+	return p.domain + "/logout?" + url.Values{
+		"client_id":  {p.ClientKey},
+		"logout_uri": {logoutURI},
+	}.Encode()
+}
+
+// FetchUser will go to Cognito and access basic information about the user.
+// User.Groups is filled in from the cognito:groups claim of the id_token, or
+// of the access token if there is none. Custom attributes are added to
+// RawData under their name without the "custom:" prefix, unless a standard
+// attribute of that name exists.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.domain+"/oauth2/userInfo", nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	claims := user.RawData
+	if claims == nil {
+		// goth.LazyRawData is set
+		if err := json.Unmarshal(bits, &claims); err != nil {
+			return user, err
+		}
+	}
+	userFromClaims(claims, &user)
+
+	token := sess.IDToken
+	if token == "" {
+		token = sess.AccessToken
+	}
+	var c struct {
+		Groups []string `json:"cognito:groups"`
+	}
+	if goth.DecodeJWTClaims(token, &c) == nil {
+		user.Groups = c.Groups
+	}
+	return user, nil
+}
+
+func userFromClaims(claims map[string]interface{}, user *goth.User) {
+	for key, value := range claims {
+		if name := strings.TrimPrefix(key, "custom:"); name != key {
+			if _, ok := claims[name]; !ok {
+				claims[name] = value
+			}
+		}
+	}
+
+	str := func(claim string) string {
+		s, _ := claims[claim].(string)
+		return s
+	}
+	user.UserID = str("sub")
+	user.Email = str("email")
+	user.Name = str("name")
+	user.FirstName = str("given_name")
+	user.LastName = str("family_name")
+	user.NickName = str("nickname")
+	if user.NickName == "" {
+		user.NickName = str("username")
+	}
+	user.AvatarURL = str("picture")
+	// Cognito returns booleans of the userinfo endpoint as strings
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		user.EmailVerified = v
+	case string:
+		user.EmailVerified = v == "true"
+	}
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
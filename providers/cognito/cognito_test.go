@@ -0,0 +1,95 @@
+package cognito_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/cognito"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "cognito")
+	a.Equal(p.ClientKey, "client")
+	a.Equal(p.Secret, "secret")
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*cognito.Session)
+	a.Contains(s.AuthURL, "https://myapp.auth.us-east-1.amazoncognito.com/oauth2/authorize")
+	a.Contains(s.AuthURL, "scope=openid+profile+email")
+
+	session, err = cognito.New("client", "secret", "/foo", "https://auth.example.com/").BeginAuth("test_state")
+	a.NoError(err)
+	a.Contains(session.(*cognito.Session).AuthURL, "https://auth.example.com/oauth2/authorize")
+}
+
+func Test_LogoutURL(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Equal("https://myapp.auth.us-east-1.amazoncognito.com/logout?client_id=client&logout_uri=https%3A%2F%2Fexample.com%2Fsigned-out",
+		provider().LogoutURL("https://example.com/signed-out"))
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("/oauth2/userInfo", r.URL.Path)
+		a.Equal("Bearer 1234567890", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"sub":"user-1","username":"jdoe","email":"jdoe@example.com","email_verified":"true",
+			"given_name":"John","family_name":"Doe","custom:tenant":"acme","custom:email":"other@example.com"}`)
+	}))
+	defer ts.Close()
+
+	idToken := gothtest.UnsignedJWT(`{"sub":"user-1","cognito:groups":["admins","staff"]}`)
+
+	p := cognito.New("client", "secret", "/foo", ts.URL)
+	user, err := p.FetchUser(&cognito.Session{AccessToken: "1234567890", IDToken: idToken})
+	a.NoError(err)
+	a.Equal("user-1", user.UserID)
+	a.Equal("jdoe", user.NickName)
+	a.Equal("jdoe@example.com", user.Email)
+	a.True(user.EmailVerified)
+	a.Equal("John", user.FirstName)
+	a.Equal("Doe", user.LastName)
+	a.Equal([]string{"admins", "staff"}, user.Groups)
+	a.Equal("acme", user.RawData["tenant"])
+	a.Equal("jdoe@example.com", user.RawData["email"])
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://myapp.auth.us-east-1.amazoncognito.com/oauth2/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*cognito.Session)
+	a.Equal(s.AuthURL, "https://myapp.auth.us-east-1.amazoncognito.com/oauth2/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *cognito.Provider {
+	return cognito.New("client", "secret", "/foo", "myapp.auth.us-east-1.amazoncognito.com")
+}
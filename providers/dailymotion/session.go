@@ -1,13 +1,12 @@
 package dailymotion
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"strings"
 	"time"
 
 	"github.com/markbates/goth"
-	"golang.org/x/oauth2"
 )
 
 // Session stores data during the auth process with Dailymotion.
@@ -28,8 +27,13 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with Dailymotion and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.config.Exchange(oauth2.NoContext, params.Get("code"))
+	token, err := p.config.Exchange(ctx, params.Get("code"))
 	if err != nil {
 		return "", err
 	}
@@ -57,6 +61,6 @@ func (s Session) String() string {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	sess := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(sess)
+	err := goth.DecodeSession(data, sess)
 	return sess, err
 }
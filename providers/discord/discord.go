@@ -4,6 +4,7 @@ package discord
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,6 +19,7 @@ const (
 	authURL      string = "https://discord.com/api/oauth2/authorize"
 	tokenURL     string = "https://discord.com/api/oauth2/token"
 	userEndpoint string = "https://discord.com/api/users/@me"
+	apiURL       string = "https://discord.com/api"
 )
 
 const (
@@ -50,11 +52,39 @@ func New(clientKey string, secret string, callbackURL string, scopes ...string)
 		Secret:       secret,
 		CallbackURL:  callbackURL,
 		providerName: "discord",
+		apiURL:       apiURL,
 	}
 	p.config = newConfig(p, scopes)
 	return p
 }
 
+// ErrNotGuildMember is returned by FetchUser when the user is not a member of
+// the guild set with SetRequiredGuild.
+type ErrNotGuildMember struct {
+	GuildID string
+}
+
+func (e *ErrNotGuildMember) Error() string {
+	return fmt.Sprintf("discord: user is not a member of guild %s", e.GuildID)
+}
+
+// Guild is a guild the user is a member of, as returned by /users/@me/guilds.
+type Guild struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Icon        string `json:"icon"`
+	Owner       bool   `json:"owner"`
+	Permissions string `json:"permissions"`
+}
+
+// GuildMember is the user's membership of a guild, as returned by
+// /users/@me/guilds/{guild.id}/member.
+type GuildMember struct {
+	Nick     string   `json:"nick"`
+	Roles    []string `json:"roles"`
+	JoinedAt string   `json:"joined_at"`
+}
+
 // Provider is the implementation of `goth.Provider` for accessing Discord
 type Provider struct {
 	ClientKey    string
@@ -63,6 +93,48 @@ type Provider struct {
 	HTTPClient   *http.Client
 	config       *oauth2.Config
 	providerName string
+	apiURL       string
+	fetchGuilds  bool
+	guildID      string
+	requireGuild bool
+}
+
+// SetFetchGuilds makes FetchUser fill in User.Groups with the IDs of the
+// user's guilds, and RawData["guilds"] with the guilds as []Guild.
+// ScopeGuilds is added to the requested scopes.
+func (p *Provider) SetFetchGuilds(fetch bool) {
+	p.fetchGuilds = fetch
+	if fetch {
+		p.addScope(ScopeGuilds)
+	}
+}
+
+// SetGuild makes FetchUser fill in User.Roles with the IDs of the user's roles
+// in the guild with the given ID, and RawData["guild_member"] with the
+// user's GuildMember. Users that are not a member of the guild get no roles.
+// ScopeReadGuilds is added to the requested scopes.
+func (p *Provider) SetGuild(guildID string) {
+	p.guildID = guildID
+	p.requireGuild = false
+	if guildID != "" {
+		p.addScope(ScopeReadGuilds)
+	}
+}
+
+// SetRequiredGuild is like SetGuild, but FetchUser fails with
+// ErrNotGuildMember for users that are not a member of the guild.
+func (p *Provider) SetRequiredGuild(guildID string) {
+	p.SetGuild(guildID)
+	p.requireGuild = guildID != ""
+}
+
+func (p *Provider) addScope(scope string) {
+	for _, s := range p.config.Scopes {
+		if s == scope {
+			return
+		}
+	}
+	p.config.Scopes = append(p.config.Scopes, scope)
 }
 
 // Name gets the name used to retrieve this provider.
@@ -98,6 +170,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Discord and access basic info about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	s := session.(*Session)
 
 	user := goth.User{
@@ -112,7 +189,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	req, err := http.NewRequest("GET", userEndpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", userEndpoint, nil)
 	if err != nil {
 		return user, err
 	}
@@ -136,7 +213,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, err
 	}
 
-	err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
+	err = user.SetRawJSON(bits)
 	if err != nil {
 		return user, err
 	}
@@ -146,9 +223,92 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, err
 	}
 
+	if p.fetchGuilds {
+		guilds, err := p.guilds(ctx, s.AccessToken)
+		if err != nil {
+			return user, err
+		}
+		user.Groups = make([]string, 0, len(guilds))
+		for _, g := range guilds {
+			user.Groups = append(user.Groups, g.ID)
+		}
+		user.SetRawData("guilds", guilds)
+	}
+
+	if p.guildID != "" {
+		member, err := p.guildMember(ctx, s.AccessToken)
+		if err != nil {
+			return user, err
+		}
+		if member == nil {
+			if p.requireGuild {
+				return user, &ErrNotGuildMember{GuildID: p.guildID}
+			}
+			return user, nil
+		}
+		user.Roles = member.Roles
+		user.SetRawData("guild_member", member)
+	}
+
 	return user, err
 }
 
+// get decodes the response of the API endpoint at path into v. It returns
+// false if the endpoint responded with a 404.
+func (p *Provider) get(ctx context.Context, path, accessToken string, v interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.apiURL+path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := p.Client().Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%s responded with a %d trying to fetch %s", p.providerName, resp.StatusCode, req.URL.Path)
+	}
+	return true, json.NewDecoder(resp.Body).Decode(v)
+}
+
+// guilds returns all of the user's guilds, which are returned at most 200 at a time.
+func (p *Provider) guilds(ctx context.Context, accessToken string) ([]Guild, error) {
+	var guilds []Guild
+	after := ""
+	for {
+		var page []Guild
+		path := "/users/@me/guilds?limit=200"
+		if after != "" {
+			path += "&after=" + after
+		}
+		if _, err := p.get(ctx, path, accessToken, &page); err != nil {
+			return nil, err
+		}
+		guilds = append(guilds, page...)
+		if len(page) < 200 {
+			return guilds, nil
+		}
+		after = page[len(page)-1].ID
+	}
+}
+
+// guildMember returns the user's membership of the configured guild, or nil
+// if the user is not a member.
+func (p *Provider) guildMember(ctx context.Context, accessToken string) (*GuildMember, error) {
+	member := &GuildMember{}
+	found, err := p.get(ctx, "/users/@me/guilds/"+p.guildID+"/member", accessToken, member)
+	if err != nil || !found {
+		return nil, err
+	}
+	return member, nil
+}
+
 func userFromReader(r io.Reader, user *goth.User) error {
 	u := struct {
 		Name          string `json:"username"`
@@ -215,8 +375,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(oauth2.NoContext, token)
+	ts := p.config.TokenSource(ctx, token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -1,10 +1,15 @@
 package discord
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -52,3 +57,117 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal(s.AuthURL, "https://discord.com/api/oauth2/authorize")
 	a.Equal(s.AccessToken, "1234567890")
 }
+
+// discordClient serves the Discord API with handler.
+func discordClient(handler http.HandlerFunc) *http.Client {
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Result(), nil
+	})}
+}
+
+func Test_FetchGuilds(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	page := func(from, n int) string {
+		guilds := make([]string, n)
+		for i := range guilds {
+			guilds[i] = fmt.Sprintf(`{"id":"%d","name":"Guild %d"}`, from+i, from+i)
+		}
+		return "[" + strings.Join(guilds, ",") + "]"
+	}
+
+	p := New("key", "secret", "/foo", ScopeIdentify)
+	p.SetFetchGuilds(true)
+	p.SetGuild("1000")
+	p.HTTPClient = discordClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/users/@me":
+			fmt.Fprint(w, `{"id":"42","username":"jdoe"}`)
+		case "/api/users/@me/guilds":
+			switch r.URL.Query().Get("after") {
+			case "":
+				fmt.Fprint(w, page(1000, 200))
+			case "1199":
+				fmt.Fprint(w, page(1200, 3))
+			default:
+				t.Errorf("unexpected after %q", r.URL.Query().Get("after"))
+			}
+		case "/api/users/@me/guilds/1000/member":
+			fmt.Fprint(w, `{"nick":"J","roles":["111","222"],"joined_at":"2021-01-01T00:00:00Z"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	a.Contains(session.(*Session).AuthURL, "scope=identify+guilds+guilds.members.read")
+
+	user, err := p.FetchUser(&Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Len(user.Groups, 203)
+	a.Equal("1202", user.Groups[202])
+	a.Equal([]string{"111", "222"}, user.Roles)
+	a.Equal("J", user.RawData["guild_member"].(*GuildMember).Nick)
+}
+
+func Test_FetchGuilds_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	p := New("key", "secret", "/foo", ScopeIdentify)
+	p.SetFetchGuilds(true)
+	p.SetGuild("1000")
+	p.HTTPClient = discordClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/users/@me":
+			fmt.Fprint(w, `{"id":"42","username":"jdoe"}`)
+		case "/api/users/@me/guilds":
+			fmt.Fprint(w, `[{"id":"1000","name":"Guild 1000"}]`)
+		case "/api/users/@me/guilds/1000/member":
+			fmt.Fprint(w, `{"nick":"J","roles":["111"]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	user, err := p.FetchUser(&Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("jdoe", raw["username"])
+	a.Equal([]Guild{{ID: "1000", Name: "Guild 1000"}}, raw["guilds"])
+	a.Equal("J", raw["guild_member"].(*GuildMember).Nick)
+}
+
+func Test_RequiredGuild(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/users/@me":
+			fmt.Fprint(w, `{"id":"42","username":"jdoe"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Unknown Guild","code":10004}`)
+		}
+	}
+
+	p := New("key", "secret", "/foo")
+	p.HTTPClient = discordClient(handler)
+	p.SetGuild("1000")
+	user, err := p.FetchUser(&Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Empty(user.Roles)
+
+	p.SetRequiredGuild("1000")
+	_, err = p.FetchUser(&Session{AccessToken: "1234567890"})
+	a.Equal(&ErrNotGuildMember{GuildID: "1000"}, err)
+}
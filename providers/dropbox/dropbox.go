@@ -3,6 +3,10 @@ package dropbox
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +14,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -36,6 +41,12 @@ type Provider struct {
 type Session struct {
 	AuthURL string
 	Token   string
+	// CodeVerifier is the PKCE code verifier of the pending authorization.
+	// It is cleared once the code has been exchanged.
+	CodeVerifier string `json:",omitempty"`
+	// RefreshToken is used to get new short-lived access tokens.
+	RefreshToken string `json:",omitempty"`
+	ExpiresAt    time.Time
 }
 
 // New creates a new Dropbox provider and sets up important connection details.
@@ -70,19 +81,42 @@ func (p *Provider) Client() *http.Client {
 // Debug is a no-op for the dropbox package.
 func (p *Provider) Debug(debug bool) {}
 
-// BeginAuth asks Dropbox for an authentication end-point.
+// BeginAuth asks Dropbox for an authentication end-point. Offline access is
+// requested, so that a refresh token is issued along with the short-lived
+// access token, and a PKCE code verifier is kept in the session until the code
+// is exchanged.
 func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(b)
+	challenge := sha256.Sum256([]byte(verifier))
+
+	url := p.config.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("token_access_type", "offline"),
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
 	return &Session{
-		AuthURL: p.config.AuthCodeURL(state),
+		AuthURL:      url,
+		CodeVerifier: verifier,
 	}, nil
 }
 
 // FetchUser will go to Dropbox and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	s := session.(*Session)
 	user := goth.User{
-		AccessToken: s.Token,
-		Provider:    p.Name(),
+		AccessToken:  s.Token,
+		RefreshToken: s.RefreshToken,
+		ExpiresAt:    s.ExpiresAt,
+		Provider:     p.Name(),
 	}
 
 	if user.AccessToken == "" {
@@ -90,7 +124,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	req, err := http.NewRequest("POST", p.AccountURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "POST", p.AccountURL, nil)
 	if err != nil {
 		return user, err
 	}
@@ -110,7 +144,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, err
 	}
 
-	err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
+	err = user.SetRawJSON(bits)
 	if err != nil {
 		return user, err
 	}
@@ -122,7 +156,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 // UnmarshalSession wil unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(s)
+	err := goth.DecodeSession(data, s)
 	return s, err
 }
 
@@ -136,8 +170,18 @@ func (s *Session) GetAuthURL() (string, error) {
 
 // Authorize the session with Dropbox and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.config.Exchange(goth.ContextForClient(p.Client()), params.Get("code"))
+	var opts []oauth2.AuthCodeOption
+	// sessions started before PKCE was used have no verifier
+	if s.CodeVerifier != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("code_verifier", s.CodeVerifier))
+	}
+	token, err := p.config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"), opts...)
 	if err != nil {
 		return "", err
 	}
@@ -146,7 +190,10 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 		return "", errors.New("Invalid token received from provider")
 	}
 
+	s.CodeVerifier = ""
 	s.Token = token.AccessToken
+	s.RefreshToken = token.RefreshToken
+	s.ExpiresAt = token.Expiry
 	return token.AccessToken, nil
 }
 
@@ -183,6 +230,7 @@ func userFromReader(r io.Reader, user *goth.User) error {
 		} `json:"name"`
 		Country         string `json:"country"`
 		Email           string `json:"email"`
+		EmailVerified   bool   `json:"email_verified"`
 		ProfilePhotoURL string `json:"profile_photo_url"`
 	}{}
 	err := json.NewDecoder(r).Decode(&u)
@@ -195,18 +243,31 @@ func userFromReader(r io.Reader, user *goth.User) error {
 	user.Name = strings.TrimSpace(fmt.Sprintf("%s %s", u.Name.GivenName, u.Name.Surname))
 	user.Description = u.Name.DisplayName // Full name plus parenthetical team name
 	user.Email = u.Email
+	user.EmailVerified = u.EmailVerified
 	user.NickName = u.Email // Email is the dropbox username
 	user.Location = u.Country
 	user.AvatarURL = u.ProfilePhotoURL // May be blank
 	return nil
 }
 
-// RefreshToken refresh token is not provided by dropbox
+// RefreshToken get new access token based on the refresh token. Dropbox
+// doesn't rotate refresh tokens, so the one passed in stays valid.
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
-	return nil, errors.New("Refresh token is not provided by dropbox")
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	newToken, err := ts.Token()
+	if err != nil {
+		return nil, err
+	}
+	return newToken, err
 }
 
-// RefreshTokenAvailable refresh token is not provided by dropbox
+// RefreshTokenAvailable refresh token is provided by dropbox
 func (p *Provider) RefreshTokenAvailable() bool {
-	return false
+	return true
 }
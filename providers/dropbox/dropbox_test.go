@@ -3,10 +3,12 @@ package dropbox
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,6 +47,31 @@ func Test_BeginAuth(t *testing.T) {
 	s := session.(*Session)
 	a.NoError(err)
 	a.Contains(s.AuthURL, "www.dropbox.com/oauth2/authorize")
+	a.Contains(s.AuthURL, "token_access_type=offline")
+	a.Contains(s.AuthURL, "code_challenge_method=S256")
+	a.NotEmpty(s.CodeVerifier)
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		a.NoError(r.ParseForm())
+		a.Equal("verifier", r.PostForm.Get("code_verifier"))
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		rec.WriteString(`{"access_token":"sl.1234567890","expires_in":14400,"token_type":"bearer","refresh_token":"refresh","account_id":"dbid:AAH4f99T0taONIb-OurWxbNQ6ywGRopQngc"}`)
+		return rec.Result(), nil
+	})}
+
+	s := &Session{AuthURL: "/foo", CodeVerifier: "verifier"}
+	token, err := s.Authorize(p, url.Values{"code": {"code"}})
+	a.NoError(err)
+	a.Equal("sl.1234567890", token)
+	a.Equal("refresh", s.RefreshToken)
+	a.False(s.ExpiresAt.IsZero())
+	a.Empty(s.CodeVerifier)
 }
 
 func Test_FetchUser(t *testing.T) {
@@ -79,6 +106,7 @@ func Test_FetchUser(t *testing.T) {
 	a.Equal(user.AccessTokenSecret, "")
 	a.Equal(user.AvatarURL, "https://dl-web.dropbox.com/account_photo/get/dbid%3AAAH4f99T0taONIb-OurWxbNQ6ywGRopQngc?vers=1453416673259\u0026size=128x128")
 	a.Equal(user.Provider, "dropbox")
+	a.True(user.EmailVerified)
 	a.Len(user.RawData, 14)
 }
 
@@ -101,7 +129,7 @@ func Test_SessionToJSON(t *testing.T) {
 	s := &Session{}
 
 	data := s.Marshal()
-	a.Equal(data, `{"AuthURL":"","Token":""}`)
+	a.Equal(data, `{"AuthURL":"","Token":"","ExpiresAt":"0001-01-01T00:00:00Z"}`)
 }
 
 func Test_GetAuthURL(t *testing.T) {
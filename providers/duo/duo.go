@@ -0,0 +1,396 @@
+// Package duo implements the OpenID Connect based Duo Universal Prompt for
+// adding Duo two-factor authentication to a login.
+//
+// Duo authenticates a user the application already knows the username of, so
+// sessions are started with BeginAuthForUser rather than BeginAuth.
+package duo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// assertionLifetime is how long the JWTs sent to Duo are valid for.
+const assertionLifetime = 5 * time.Minute
+
+// ErrUsernameRequired is returned by BeginAuth, as Duo needs to know which
+// user to authenticate.
+var ErrUsernameRequired = errors.New("duo: a username is required, use BeginAuthForUser")
+
+// ErrHealthCheck is returned when Duo's health check fails, e.g. because Duo
+// is unavailable or the client credentials are wrong. Applications may let
+// the user continue without two-factor authentication ("fail open") or refuse
+// the login when they get this error.
+type ErrHealthCheck struct {
+	Code    int
+	Message string
+	Detail  string
+}
+
+func (e *ErrHealthCheck) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("duo: health check failed (%d): %s: %s", e.Code, e.Message, e.Detail)
+	}
+	return fmt.Sprintf("duo: health check failed (%d): %s", e.Code, e.Message)
+}
+
+// ErrDenied is returned when Duo did not allow the authentication.
+type ErrDenied struct {
+	Result string
+	Status string
+}
+
+func (e *ErrDenied) Error() string {
+	return fmt.Sprintf("duo: authentication was not allowed: %s (%s)", e.Result, e.Status)
+}
+
+// ErrUsernameMismatch is returned when Duo authenticated another user than
+// the one the session was started for.
+type ErrUsernameMismatch struct {
+	Want string
+	Got  string
+}
+
+func (e *ErrUsernameMismatch) Error() string {
+	return fmt.Sprintf("duo: authenticated %q, not %q", e.Got, e.Want)
+}
+
+// Provider is the implementation of `goth.Provider` for accessing Duo.
+type Provider struct {
+	ClientKey      string
+	Secret         string
+	CallbackURL    string
+	HTTPClient     *http.Client
+	providerName   string
+	apiHost        string
+	authURL        string
+	tokenURL       string
+	healthCheckURL string
+}
+
+// New creates a new Duo provider for the Web SDK application with the given
+// client ID and secret, on apiHost, e.g. "api-XXXXXXXX.duosecurity.com".
+// You should always call `duo.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, apiHost, callbackURL string) *Provider {
+	return &Provider{
+		ClientKey:      clientKey,
+		Secret:         secret,
+		CallbackURL:    callbackURL,
+		providerName:   "duo",
+		apiHost:        apiHost,
+		authURL:        "https://" + apiHost + "/oauth/v1/authorize",
+		tokenURL:       "https://" + apiHost + "/oauth/v1/token",
+		healthCheckURL: "https://" + apiHost + "/oauth/v1/health_check",
+	}
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the duo package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth always returns ErrUsernameRequired.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return nil, ErrUsernameRequired
+}
+
+// BeginAuthForUser checks that Duo is available and returns a session whose
+// auth URL sends username to the Universal Prompt.
+func (p *Provider) BeginAuthForUser(state, username string) (goth.Session, error) {
+	return p.BeginAuthForUserContext(context.Background(), state, username)
+}
+
+// BeginAuthForUserContext is like BeginAuthForUser but binds the health check to ctx.
+func (p *Provider) BeginAuthForUserContext(ctx context.Context, state, username string) (goth.Session, error) {
+	if username == "" {
+		return nil, ErrUsernameRequired
+	}
+	if err := p.HealthCheck(ctx); err != nil {
+		return nil, err
+	}
+
+	nonce, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	now := goth.GetClock().Now()
+	request, err := p.sign(jwt.MapClaims{
+		"iss":                    p.ClientKey,
+		"aud":                    "https://" + p.apiHost,
+		"exp":                    now.Add(assertionLifetime).Unix(),
+		"client_id":              p.ClientKey,
+		"response_type":          "code",
+		"scope":                  "openid",
+		"redirect_uri":           p.CallbackURL,
+		"state":                  state,
+		"duo_uname":              username,
+		"nonce":                  nonce,
+		"use_duo_code_attribute": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	v := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.ClientKey},
+		"request":       {request},
+	}
+	return &Session{
+		AuthURL:  p.authURL + "?" + v.Encode(),
+		Username: username,
+		Nonce:    nonce,
+	}, nil
+}
+
+// HealthCheck asks Duo whether it is available to authenticate users.
+// It returns an *ErrHealthCheck if Duo responds that it isn't.
+func (p *Provider) HealthCheck(ctx context.Context) error {
+	assertion, err := p.clientAssertion(p.healthCheckURL)
+	if err != nil {
+		return err
+	}
+	form := url.Values{
+		"client_id":        {p.ClientKey},
+		"client_assertion": {assertion},
+	}
+	var result struct {
+		Stat          string `json:"stat"`
+		Code          int    `json:"code"`
+		Message       string `json:"message"`
+		MessageDetail string `json:"message_detail"`
+	}
+	status, err := p.post(ctx, p.healthCheckURL, form, &result)
+	if err != nil {
+		return err
+	}
+	if result.Stat != "OK" {
+		if result.Code == 0 {
+			result.Code = status
+		}
+		return &ErrHealthCheck{Code: result.Code, Message: result.Message, Detail: result.MessageDetail}
+	}
+	return nil
+}
+
+// tokenResponse is the response of Duo's token endpoint.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchange trades the code Duo redirected back with for the tokens.
+func (p *Provider) exchange(ctx context.Context, code string) (*tokenResponse, error) {
+	assertion, err := p.clientAssertion(p.tokenURL)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":            {"authorization_code"},
+		"code":                  {code},
+		"redirect_uri":          {p.CallbackURL},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {assertion},
+	}
+	token := &tokenResponse{}
+	status, err := p.post(ctx, p.tokenURL, form, token)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("%s responded with a %d trying to exchange the code: %s %s", p.providerName, status, token.Error, token.ErrorDescription)
+	}
+	return token, nil
+}
+
+// idClaims are the claims of the id_token Duo returns.
+type idClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	PreferredUsername string `json:"preferred_username"`
+	Email             string `json:"email"`
+	AuthResult        struct {
+		Result    string `json:"result"`
+		Status    string `json:"status"`
+		StatusMsg string `json:"status_msg"`
+	} `json:"auth_result"`
+	AuthContext struct {
+		Factor string `json:"factor"`
+		Result string `json:"result"`
+		User   struct {
+			Key    string   `json:"key"`
+			Name   string   `json:"name"`
+			Groups []string `json:"groups"`
+		} `json:"user"`
+	} `json:"auth_context"`
+}
+
+// checkIDToken verifies the signature and claims of an id_token returned for
+// the session.
+func (p *Provider) checkIDToken(idToken string, s *Session) error {
+	claims := &idClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS512 {
+			return nil, fmt.Errorf("duo: unexpected id_token signing method %v", t.Header["alg"])
+		}
+		return []byte(p.Secret), nil
+	})
+	if err != nil {
+		return err
+	}
+	if claims.Issuer != p.tokenURL {
+		return fmt.Errorf("duo: id_token was issued by %q, not %q", claims.Issuer, p.tokenURL)
+	}
+	if !claims.VerifyAudience(p.ClientKey, true) {
+		return fmt.Errorf("duo: id_token was not issued for %q", p.ClientKey)
+	}
+	if s.Nonce == "" || claims.Nonce != s.Nonce {
+		return errors.New("duo: id_token nonce does not match the session")
+	}
+	if claims.PreferredUsername != s.Username {
+		return &ErrUsernameMismatch{Want: s.Username, Got: claims.PreferredUsername}
+	}
+	if claims.AuthResult.Result != "allow" {
+		return &ErrDenied{Result: claims.AuthResult.Result, Status: claims.AuthResult.Status}
+	}
+	return nil
+}
+
+// FetchUser returns the user Duo authenticated, from the claims of the
+// session's id_token; Duo has no userinfo endpoint. User.UserID is the Duo
+// user key, User.NickName the username and User.Groups the names of the Duo
+// groups the user is in. RawData holds all the id_token claims, including the
+// auth_context describing the factor and device used.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken: sess.AccessToken,
+		Provider:    p.Name(),
+		ExpiresAt:   sess.ExpiresAt,
+		IDToken:     sess.IDToken,
+	}
+
+	if user.AccessToken == "" || sess.IDToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	// the id_token was verified when the session was authorized
+	var payload json.RawMessage
+	if err := goth.DecodeJWTClaims(sess.IDToken, &payload); err != nil {
+		return user, err
+	}
+	if err := user.SetRawJSON(payload); err != nil {
+		return user, err
+	}
+
+	claims := &idClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return user, err
+	}
+	user.UserID = claims.AuthContext.User.Key
+	if user.UserID == "" {
+		user.UserID = claims.Subject
+	}
+	user.NickName = claims.PreferredUsername
+	user.Name = claims.AuthContext.User.Name
+	user.Email = claims.Email
+	user.Groups = claims.AuthContext.User.Groups
+	return user, nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return false
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return nil, errors.New("Refresh token is not provided by duo")
+}
+
+// clientAssertion returns the JWT authenticating the client to the endpoint at aud.
+func (p *Provider) clientAssertion(aud string) (string, error) {
+	jti, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	now := goth.GetClock().Now()
+	return p.sign(jwt.MapClaims{
+		"iss": p.ClientKey,
+		"sub": p.ClientKey,
+		"aud": aud,
+		"iat": now.Unix(),
+		"exp": now.Add(assertionLifetime).Unix(),
+		"jti": jti,
+	})
+}
+
+// sign signs claims with the client secret, as Duo requires.
+func (p *Provider) sign(claims jwt.MapClaims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString([]byte(p.Secret))
+}
+
+// post posts form to u and decodes the JSON response into v, returning the
+// response's status code.
+func (p *Provider) post(ctx context.Context, u string, form url.Values, v interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return response.StatusCode, err
+	}
+	if err := json.Unmarshal(bits, v); err != nil {
+		return response.StatusCode, fmt.Errorf("%s responded with a %d: %s", p.providerName, response.StatusCode, bits)
+	}
+	return response.StatusCode, nil
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,187 @@
+package duo_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/duo"
+	"github.com/stretchr/testify/assert"
+)
+
+const apiHost = "api-12345678.duosecurity.com"
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "duo")
+	a.Equal(p.ClientKey, "DIXXXXXXXXXXXXXXXXXX")
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	_, err := provider().BeginAuth("test_state")
+	a.Equal(duo.ErrUsernameRequired, err)
+}
+
+// fakeDuo answers the health check with healthCheck and the token endpoint
+// with an id_token holding claims.
+func fakeDuo(t *testing.T, healthCheck string, claims func() jwt.MapClaims) *http.Client {
+	a := assert.New(t)
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.NoError(req.ParseForm())
+		assertion, err := jwt.Parse(req.PostForm.Get("client_assertion"), func(*jwt.Token) (interface{}, error) {
+			return []byte(secret), nil
+		})
+		a.NoError(err)
+		a.Equal("https://"+apiHost+req.URL.Path, assertion.Claims.(jwt.MapClaims)["aud"])
+
+		rec := httptest.NewRecorder()
+		switch req.URL.Path {
+		case "/oauth/v1/health_check":
+			fmt.Fprint(rec, healthCheck)
+		case "/oauth/v1/token":
+			a.Equal("duo-code", req.PostForm.Get("code"))
+			a.Equal("/foo", req.PostForm.Get("redirect_uri"))
+			idToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS512, claims()).SignedString([]byte(secret))
+			fmt.Fprintf(rec, `{"access_token":"1234567890","id_token":%q,"expires_in":3600,"token_type":"Bearer"}`, idToken)
+		default:
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		return rec.Result(), nil
+	})}
+}
+
+func idClaims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":                "https://" + apiHost + "/oauth/v1/token",
+		"aud":                "DIXXXXXXXXXXXXXXXXXX",
+		"sub":                "jdoe",
+		"exp":                time.Now().Add(time.Minute).Unix(),
+		"nonce":              nonce,
+		"preferred_username": "jdoe",
+		"auth_result":        map[string]interface{}{"result": "allow", "status": "allow", "status_msg": "Login Successful"},
+		"auth_context": map[string]interface{}{
+			"factor": "duo_push",
+			"user":   map[string]interface{}{"key": "DU3RP9I2WOC59VZX672N", "name": "jdoe", "groups": []string{"Admins"}},
+		},
+	}
+}
+
+const healthy = `{"stat":"OK","response":{"timestamp":1629837896}}`
+
+func Test_Login(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = fakeDuo(t, healthy, nil)
+	session, err := p.BeginAuthForUser("test_state_1234567890", "jdoe")
+	a.NoError(err)
+
+	u, err := url.Parse(session.(*duo.Session).AuthURL)
+	a.NoError(err)
+	a.Equal(apiHost, u.Host)
+	a.Equal("/oauth/v1/authorize", u.Path)
+	request := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(u.Query().Get("request"), request, func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	})
+	a.NoError(err)
+	a.Equal("jdoe", request["duo_uname"])
+	a.Equal("test_state_1234567890", request["state"])
+	a.Equal(session.(*duo.Session).Nonce, request["nonce"])
+
+	p.HTTPClient = fakeDuo(t, healthy, func() jwt.MapClaims {
+		return idClaims(request["nonce"].(string))
+	})
+	_, err = session.Authorize(p, url.Values{"duo_code": {"duo-code"}, "state": {"test_state_1234567890"}})
+	a.NoError(err)
+
+	user, err := p.FetchUser(session)
+	a.NoError(err)
+	a.Equal("DU3RP9I2WOC59VZX672N", user.UserID)
+	a.Equal("jdoe", user.NickName)
+	a.Equal([]string{"Admins"}, user.Groups)
+	a.Equal("duo_push", user.RawData["auth_context"].(map[string]interface{})["factor"])
+}
+
+func Test_HealthCheckFailure(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = fakeDuo(t, `{"stat":"FAIL","code":40002,"message":"invalid_client","message_detail":"The provided client_assertion was invalid."}`, nil)
+	_, err := p.BeginAuthForUser("test_state_1234567890", "jdoe")
+	a.Equal(&duo.ErrHealthCheck{Code: 40002, Message: "invalid_client", Detail: "The provided client_assertion was invalid."}, err)
+}
+
+func Test_AuthorizeChecksIDToken(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		modify func(jwt.MapClaims)
+		err    error
+	}{
+		"denied":        {func(c jwt.MapClaims) { c["auth_result"] = map[string]interface{}{"result": "deny", "status": "fraud"} }, &duo.ErrDenied{Result: "deny", Status: "fraud"}},
+		"otherUser":     {func(c jwt.MapClaims) { c["preferred_username"] = "other" }, &duo.ErrUsernameMismatch{Want: "jdoe", Got: "other"}},
+		"otherNonce":    {func(c jwt.MapClaims) { c["nonce"] = "other" }, nil},
+		"otherAudience": {func(c jwt.MapClaims) { c["aud"] = "other" }, nil},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := provider()
+			p.HTTPClient = fakeDuo(t, healthy, func() jwt.MapClaims {
+				c := idClaims("nonce")
+				tc.modify(c)
+				return c
+			})
+			s := &duo.Session{AuthURL: "/auth", Username: "jdoe", Nonce: "nonce"}
+			_, err := s.Authorize(p, url.Values{"duo_code": {"duo-code"}})
+			if tc.err != nil {
+				a.Equal(tc.err, err)
+			} else {
+				a.Error(err)
+			}
+			a.Empty(s.AccessToken)
+		})
+	}
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://api-12345678.duosecurity.com/oauth/v1/authorize","Username":"jdoe","Nonce":"nonce"}`)
+	a.NoError(err)
+
+	s := session.(*duo.Session)
+	a.Equal(s.AuthURL, "https://api-12345678.duosecurity.com/oauth/v1/authorize")
+	a.Equal(s.Username, "jdoe")
+	a.Equal(s.Nonce, "nonce")
+}
+
+const secret = "0123456789012345678901234567890123456789"
+
+func provider() *duo.Provider {
+	return duo.New("DIXXXXXXXXXXXXXXXXXX", secret, apiHost, "/foo")
+}
@@ -0,0 +1,84 @@
+package duo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/markbates/goth"
+)
+
+// Session stores data during the auth process with Duo.
+type Session struct {
+	AuthURL     string
+	AccessToken string
+	ExpiresAt   time.Time
+	IDToken     string
+	// Username is the user passed to BeginAuthForUser.
+	Username string
+	// Nonce is checked against the nonce claim of the id_token.
+	Nonce string
+}
+
+var _ goth.Session = &Session{}
+
+// GetAuthURL will return the URL set by calling the `BeginAuthForUser` function on the Duo provider.
+func (s Session) GetAuthURL() (string, error) {
+	if s.AuthURL == "" {
+		return "", errors.New(goth.NoAuthUrlErrorMessage)
+	}
+	return s.AuthURL, nil
+}
+
+// Authorize the session with Duo and return the access token to be stored for future use.
+func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
+	p := provider.(*Provider)
+	code := params.Get("duo_code")
+	if code == "" {
+		code = params.Get("code")
+	}
+	if code == "" {
+		return "", errors.New("duo: no code was returned")
+	}
+
+	token, err := p.exchange(ctx, code)
+	if err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("Invalid token received from provider")
+	}
+	if err := p.checkIDToken(token.IDToken, s); err != nil {
+		return "", err
+	}
+
+	s.AccessToken = token.AccessToken
+	s.IDToken = token.IDToken
+	if token.ExpiresIn > 0 {
+		s.ExpiresAt = goth.GetClock().Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token.AccessToken, nil
+}
+
+// Marshal the session into a string
+func (s Session) Marshal() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func (s Session) String() string {
+	return s.Marshal()
+}
+
+// UnmarshalSession wil unmarshal a JSON string into a session.
+func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
+	s := &Session{}
+	err := goth.DecodeSession(data, s)
+	return s, err
+}
@@ -4,6 +4,7 @@ package facebook
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
@@ -15,15 +16,18 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
 )
 
 const (
-	authURL         string = "https://www.facebook.com/dialog/oauth"
-	tokenURL        string = "https://graph.facebook.com/oauth/access_token"
-	endpointProfile string = "https://graph.facebook.com/me?fields="
+	authURL             string = "https://www.facebook.com/dialog/oauth"
+	tokenURL            string = "https://graph.facebook.com/oauth/access_token"
+	endpointProfile     string = "https://graph.facebook.com/me?fields="
+	endpointPermissions string = "https://graph.facebook.com/me/permissions"
 )
 
 // New creates a new Facebook provider, and sets up important connection details.
@@ -43,13 +47,17 @@ func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
 
 // Provider is the implementation of `goth.Provider` for accessing Facebook.
 type Provider struct {
-	ClientKey    string
-	Secret       string
-	CallbackURL  string
-	HTTPClient   *http.Client
-	Fields       string
-	config       *oauth2.Config
-	providerName string
+	ClientKey        string
+	Secret           string
+	CallbackURL      string
+	HTTPClient       *http.Client
+	Fields           string
+	config           *oauth2.Config
+	providerName     string
+	authCodeOptions  []oauth2.AuthCodeOption
+	fetchPermissions bool
+	jwksOnce         sync.Once
+	jwks             *goth.JWKSCache
 }
 
 // Name is the name used to retrieve this provider later.
@@ -81,7 +89,7 @@ func (p *Provider) Debug(debug bool) {}
 
 // BeginAuth asks Facebook for an authentication end-point.
 func (p *Provider) BeginAuth(state string) (goth.Session, error) {
-	authUrl := p.config.AuthCodeURL(state)
+	authUrl := p.config.AuthCodeURL(state, p.authCodeOptions...)
 	session := &Session{
 		AuthURL: authUrl,
 	}
@@ -90,6 +98,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Facebook and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken: sess.AccessToken,
@@ -97,27 +110,31 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		ExpiresAt:   sess.ExpiresAt,
 	}
 
+	if user.AccessToken == "" && sess.IDToken != "" {
+		claims, err := p.verifyLimitedLoginToken(ctx, sess.IDToken)
+		if err != nil {
+			return user, err
+		}
+		return p.userFromLimitedLoginToken(sess, claims)
+	}
 	if user.AccessToken == "" {
 		// data is not yet retrieved since accessToken is still empty
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	// always add appsecretProof to make calls more protected
-	// https://github.com/markbates/goth/issues/96
-	// https://developers.facebook.com/docs/graph-api/securing-requests
-	hash := hmac.New(sha256.New, []byte(p.Secret))
-	hash.Write([]byte(sess.AccessToken))
-	appsecretProof := hex.EncodeToString(hash.Sum(nil))
-
 	reqUrl := fmt.Sprint(
 		endpointProfile,
 		p.Fields,
 		"&access_token=",
 		url.QueryEscape(sess.AccessToken),
 		"&appsecret_proof=",
-		appsecretProof,
+		p.appsecretProof(sess.AccessToken),
 	)
-	response, err := p.Client().Get(reqUrl)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqUrl, nil)
+	if err != nil {
+		return user, err
+	}
+	response, err := p.Client().Do(req)
 	if err != nil {
 		return user, err
 	}
@@ -132,7 +149,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, err
 	}
 
-	err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
+	err = user.SetRawJSON(bits)
 	if err != nil {
 		return user, err
 	}
@@ -141,6 +158,118 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	return user, err
 }
 
+// appsecretProof is added to every Graph API call to make it more protected.
+// https://github.com/markbates/goth/issues/96
+// https://developers.facebook.com/docs/graph-api/securing-requests
+func (p *Provider) appsecretProof(accessToken string) string {
+	hash := hmac.New(sha256.New, []byte(p.Secret))
+	hash.Write([]byte(accessToken))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// ExchangeLongLivedToken exchanges the short-lived access token of the
+// session, as issued at login, for a long-lived one valid for about 60 days,
+// and updates the session. Use it before storing a session for background
+// use of the Graph API.
+// See https://developers.facebook.com/docs/facebook-login/guides/access-tokens/get-long-lived
+func (p *Provider) ExchangeLongLivedToken(ctx context.Context, sess *Session) error {
+	if sess.AccessToken == "" {
+		return fmt.Errorf("%s cannot exchange a session without accessToken", p.providerName)
+	}
+	params := url.Values{
+		"grant_type":        {"fb_exchange_token"},
+		"client_id":         {p.ClientKey},
+		"client_secret":     {p.Secret},
+		"fb_exchange_token": {sess.AccessToken},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", tokenURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with a %d trying to exchange a long-lived token", p.providerName, response.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return err
+	}
+	if token.AccessToken == "" {
+		return errors.New("Invalid token received from provider")
+	}
+	sess.AccessToken = token.AccessToken
+	sess.ExpiresAt = time.Time{}
+	if token.ExpiresIn > 0 {
+		sess.ExpiresAt = goth.GetClock().Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return nil
+}
+
+// SetAuthType sets the auth_type parameter of the login dialog. Use
+// "rerequest" to ask the user again for permissions they declined before;
+// Facebook does not show them otherwise.
+// See https://developers.facebook.com/docs/facebook-login/guides/advanced/re-request
+func (p *Provider) SetAuthType(authType string) {
+	if authType == "" {
+		return
+	}
+	p.authCodeOptions = append(p.authCodeOptions, oauth2.SetAuthURLParam("auth_type", authType))
+}
+
+// SetFetchPermissions makes Authorize look up which of the requested
+// permissions the user granted and declined, and store them in the session's
+// GrantedScopes and DeclinedScopes.
+func (p *Provider) SetFetchPermissions(fetch bool) {
+	p.fetchPermissions = fetch
+}
+
+// Permissions returns the permissions the user has granted to and declined
+// for the app.
+func (p *Provider) Permissions(ctx context.Context, accessToken string) (granted, declined []string, err error) {
+	reqUrl := endpointPermissions + "?access_token=" + url.QueryEscape(accessToken) + "&appsecret_proof=" + p.appsecretProof(accessToken)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqUrl, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s responded with a %d trying to fetch permissions", p.providerName, response.StatusCode)
+	}
+
+	var permissions struct {
+		Data []struct {
+			Permission string `json:"permission"`
+			Status     string `json:"status"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&permissions); err != nil {
+		return nil, nil, err
+	}
+	for _, perm := range permissions.Data {
+		switch perm.Status {
+		case "granted":
+			granted = append(granted, perm.Permission)
+		case "declined":
+			declined = append(declined, perm.Permission)
+		}
+	}
+	return granted, declined, nil
+}
+
 func userFromReader(reader io.Reader, user *goth.User) error {
 	u := struct {
 		ID        string `json:"id"`
@@ -1,12 +1,18 @@
 package facebook_test
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/facebook"
 	"github.com/stretchr/testify/assert"
 )
@@ -42,6 +48,77 @@ func Test_BeginAuth(t *testing.T) {
 	a.Contains(s.AuthURL, "scope=email")
 }
 
+// graphClient answers requests to the Graph API with responses by path.
+func graphClient(responses map[string]string) *http.Client {
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, ok := responses[req.URL.Path]
+		status := http.StatusOK
+		if !ok {
+			status = http.StatusNotFound
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+}
+
+func Test_BeginAuthWithAuthType(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := facebookProvider()
+	provider.SetAuthType("rerequest")
+	session, err := provider.BeginAuth("test_state")
+	a.NoError(err)
+	a.Contains(session.(*facebook.Session).AuthURL, "auth_type=rerequest")
+}
+
+func Test_AuthorizeWithPermissions(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := facebook.New(os.Getenv("FACEBOOK_KEY"), os.Getenv("FACEBOOK_SECRET"), "/foo", "email", "user_friends")
+	provider.SetFetchPermissions(true)
+	provider.HTTPClient = graphClient(map[string]string{
+		"/oauth/access_token": `{"access_token":"1234567890","token_type":"bearer","expires_in":5183944}`,
+		"/me/permissions":     `{"data":[{"permission":"email","status":"granted"},{"permission":"user_friends","status":"declined"},{"permission":"public_profile","status":"granted"}]}`,
+	})
+
+	session, _ := provider.BeginAuth("test_state")
+	_, err := session.Authorize(provider, url.Values{"code": {"code"}})
+	a.NoError(err)
+	s := session.(*facebook.Session)
+	a.Equal([]string{"email", "public_profile"}, s.GrantedScopes)
+	a.Equal([]string{"user_friends"}, s.DeclinedScopes)
+}
+
+func Test_ExchangeLongLivedToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := facebookProvider()
+	var exchanged string
+	client := graphClient(map[string]string{
+		"/oauth/access_token": `{"access_token":"long-lived","token_type":"bearer","expires_in":5183944}`,
+	})
+	provider.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("fb_exchange_token", req.URL.Query().Get("grant_type"))
+		exchanged = req.URL.Query().Get("fb_exchange_token")
+		return client.Transport.RoundTrip(req)
+	})}
+
+	s := &facebook.Session{AccessToken: "short-lived", ExpiresAt: time.Now().Add(time.Hour)}
+	a.NoError(provider.ExchangeLongLivedToken(context.Background(), s))
+	a.Equal("short-lived", exchanged)
+	a.Equal("long-lived", s.AccessToken)
+	a.True(s.ExpiresAt.After(time.Now().Add(59 * 24 * time.Hour)))
+
+	a.Error(provider.ExchangeLongLivedToken(context.Background(), &facebook.Session{}))
+}
+
 func Test_SessionFromJSON(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
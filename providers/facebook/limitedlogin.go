@@ -0,0 +1,105 @@
+package facebook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/markbates/goth"
+)
+
+const (
+	limitedLoginIssuer string = "https://www.facebook.com"
+	endpointJWKS       string = "https://limited.facebook.com/.well-known/oauth/openid/jwks/"
+)
+
+type limitedLoginClaims struct {
+	jwt.RegisteredClaims
+	Nonce      string `json:"nonce"`
+	Email      string `json:"email"`
+	Name       string `json:"name"`
+	GivenName  string `json:"given_name"`
+	FamilyName string `json:"family_name"`
+	Picture    string `json:"picture"`
+}
+
+// VerifyLimitedLoginToken validates the authentication token returned by
+// Facebook Limited Login, an OpenID Connect id_token, against Facebook's
+// signing keys, the provider's app ID and the nonce the app passed to the
+// login request, and returns the user it was issued to. Limited Login does
+// not issue Graph API access tokens; the returned session holds the token as
+// its IDToken instead, and FetchUser reads the user from it, verifying it
+// again except for the nonce.
+// See https://developers.facebook.com/docs/facebook-login/limited-login/token/validating
+func (p *Provider) VerifyLimitedLoginToken(ctx context.Context, token, nonce string) (goth.User, *Session, error) {
+	claims, err := p.verifyLimitedLoginToken(ctx, token)
+	if err != nil {
+		return goth.User{}, nil, err
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return goth.User{}, nil, errors.New("facebook: token nonce does not match")
+	}
+
+	sess := &Session{IDToken: token, ExpiresAt: claims.ExpiresAt.Time}
+	user, err := p.userFromLimitedLoginToken(sess, claims)
+	return user, sess, err
+}
+
+// verifyLimitedLoginToken validates the signature, audience, issuer and expiry
+// of a Limited Login token and returns its claims.
+func (p *Provider) verifyLimitedLoginToken(ctx context.Context, token string) (*limitedLoginClaims, error) {
+	claims := &limitedLoginClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256"}), jwt.WithoutClaimsValidation())
+	_, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.verificationKeys().PublicKey(ctx, endpointJWKS, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !claims.VerifyAudience(p.ClientKey, true):
+		return nil, errors.New("facebook: token was issued to another app")
+	case !claims.VerifyIssuer(limitedLoginIssuer, true):
+		return nil, fmt.Errorf("facebook: token has unexpected issuer %q", claims.Issuer)
+	case !claims.VerifyExpiresAt(goth.GetClock().Now(), true):
+		return nil, errors.New("facebook: token has expired")
+	}
+	return claims, nil
+}
+
+// userFromLimitedLoginToken returns the user of the session's Limited Login
+// token, whose verified claims are claims.
+func (p *Provider) userFromLimitedLoginToken(sess *Session, claims *limitedLoginClaims) (goth.User, error) {
+	user := goth.User{
+		Provider:  p.Name(),
+		ExpiresAt: sess.ExpiresAt,
+		IDToken:   sess.IDToken,
+	}
+	var raw json.RawMessage
+	if err := goth.DecodeJWTClaims(sess.IDToken, &raw); err != nil {
+		return user, err
+	}
+	if err := user.SetRawJSON(raw); err != nil {
+		return user, err
+	}
+	user.UserID = claims.Subject
+	user.Email = claims.Email
+	user.Name = claims.Name
+	user.NickName = claims.Name
+	user.FirstName = claims.GivenName
+	user.LastName = claims.FamilyName
+	user.AvatarURL = claims.Picture
+	return user, nil
+}
+
+// verificationKeys returns the cache of Facebook's Limited Login signing keys.
+func (p *Provider) verificationKeys() *goth.JWKSCache {
+	p.jwksOnce.Do(func() {
+		p.jwks = goth.NewJWKSCache(p.HTTPClient)
+	})
+	return p.jwks
+}
@@ -0,0 +1,91 @@
+package facebook_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/facebook"
+	"github.com/stretchr/testify/assert"
+)
+
+func limitedLoginToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "key-1"
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func Test_VerifyLimitedLoginToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	k, _ := jwk.New(&key.PublicKey)
+	k.Set(jwk.KeyIDKey, "key-1")
+	set := jwk.NewSet()
+	set.Add(k)
+	jwks, _ := json.Marshal(set)
+
+	provider := facebook.New("app-id", "secret", "/foo")
+	provider.HTTPClient = graphClient(map[string]string{"/.well-known/oauth/openid/jwks/": string(jwks)})
+
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   "https://www.facebook.com",
+			"aud":   "app-id",
+			"sub":   "1234",
+			"nonce": "nonce-1",
+			"name":  "John Doe",
+			"email": "john@example.com",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	user, sess, err := provider.VerifyLimitedLoginToken(context.Background(), limitedLoginToken(t, key, claims()), "nonce-1")
+	a.NoError(err)
+	a.Equal("1234", user.UserID)
+	a.Equal("john@example.com", user.Email)
+	a.Equal("John Doe", user.Name)
+
+	// the stored session resolves the same user without a Graph access token
+	stored, err := provider.UnmarshalSession(sess.Marshal())
+	a.NoError(err)
+	fetched, err := provider.FetchUser(stored)
+	a.NoError(err)
+	a.Equal(user.UserID, fetched.UserID)
+
+	// a session holding a token that isn't signed by Facebook yields no user
+	forged := &facebook.Session{IDToken: gothtest.UnsignedJWT(`{"iss":"https://www.facebook.com","aud":"app-id","sub":"1"}`)}
+	_, err = provider.FetchUser(forged)
+	a.Error(err)
+	forged.IDToken = limitedLoginToken(t, key, jwt.MapClaims{"iss": "https://www.facebook.com", "aud": "other-app", "sub": "1"})
+	_, err = provider.FetchUser(forged)
+	a.Error(err)
+
+	_, _, err = provider.VerifyLimitedLoginToken(context.Background(), limitedLoginToken(t, key, claims()), "nonce-2")
+	a.Error(err)
+	for name, mutate := range map[string]func(jwt.MapClaims){
+		"audience": func(c jwt.MapClaims) { c["aud"] = "other-app" },
+		"issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"expired":  func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+	} {
+		c := claims()
+		mutate(c)
+		_, _, err := provider.VerifyLimitedLoginToken(context.Background(), limitedLoginToken(t, key, c), "nonce-1")
+		a.Error(err, name)
+	}
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, _, err = provider.VerifyLimitedLoginToken(context.Background(), limitedLoginToken(t, other, claims()), "nonce-1")
+	a.Error(err)
+}
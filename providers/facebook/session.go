@@ -1,9 +1,9 @@
 package facebook
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"strings"
 	"time"
 
 	"github.com/markbates/goth"
@@ -14,6 +14,12 @@ type Session struct {
 	AuthURL     string
 	AccessToken string
 	ExpiresAt   time.Time
+	// GrantedScopes and DeclinedScopes are only set if the provider has been
+	// told to fetch them with SetFetchPermissions.
+	GrantedScopes  []string `json:",omitempty"`
+	DeclinedScopes []string `json:",omitempty"`
+	// IDToken is the Limited Login token, see VerifyLimitedLoginToken.
+	IDToken string `json:",omitempty"`
 }
 
 // GetAuthURL will return the URL set by calling the `BeginAuth` function on the Facebook provider.
@@ -26,8 +32,13 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with Facebook and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.config.Exchange(goth.ContextForClient(p.Client()), params.Get("code"))
+	token, err := p.config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"))
 	if err != nil {
 		return "", err
 	}
@@ -38,6 +49,11 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 
 	s.AccessToken = token.AccessToken
 	s.ExpiresAt = token.Expiry
+	if p.fetchPermissions {
+		if s.GrantedScopes, s.DeclinedScopes, err = p.Permissions(ctx, s.AccessToken); err != nil {
+			return "", err
+		}
+	}
 	return token.AccessToken, err
 }
 
@@ -54,6 +70,6 @@ func (s Session) String() string {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	sess := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(sess)
+	err := goth.DecodeSession(data, sess)
 	return sess, err
 }
@@ -3,10 +3,10 @@
 package faux
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -68,10 +68,15 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	return user, nil
 }
 
+// IntrospectToken is used only for testing.
+func (p *Provider) IntrospectToken(ctx context.Context, accessToken string) (goth.User, error) {
+	return p.FetchUser(&Session{ID: "id", AccessToken: accessToken})
+}
+
 // UnmarshalSession is used only for testing.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	sess := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(sess)
+	err := goth.DecodeSession(data, sess)
 	return sess, err
 }
 
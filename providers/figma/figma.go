@@ -0,0 +1,231 @@
+// Package figma implements the OAuth2 protocol for authenticating users
+// through Figma, e.g. for the companion app of a Figma plugin.
+package figma
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL         string = "https://www.figma.com/oauth"
+	tokenURL        string = "https://api.figma.com/v1/oauth/token"
+	refreshURL      string = "https://api.figma.com/v1/oauth/refresh"
+	endpointProfile string = "https://api.figma.com/v1/me"
+)
+
+// Figma scopes. ScopeCurrentUserRead is needed to fetch the user and is
+// always requested.
+const (
+	ScopeCurrentUserRead   = "current_user:read"
+	ScopeFileContentRead   = "file_content:read"
+	ScopeFileMetadataRead  = "file_metadata:read"
+	ScopeFileCommentsRead  = "file_comments:read"
+	ScopeFileCommentsWrite = "file_comments:write"
+	ScopeProjectsRead      = "projects:read"
+	ScopeWebhooksWrite     = "webhooks:write"
+)
+
+// Provider is the implementation of `goth.Provider` for accessing Figma.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+}
+
+// New creates a new Figma provider, and sets up important connection details.
+// You should always call `figma.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "figma",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+		Scopes: []string{ScopeCurrentUserRead},
+	}
+
+	for _, scope := range scopes {
+		if scope != ScopeCurrentUserRead {
+			c.Scopes = append(c.Scopes, scope)
+		}
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the figma package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks Figma for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to Figma and access basic information about the user.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointProfile, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	err = userFromReader(bytes.NewReader(bits), &user)
+	return user, err
+}
+
+func userFromReader(r io.Reader, user *goth.User) error {
+	u := struct {
+		ID     string `json:"id"`
+		Email  string `json:"email"`
+		Handle string `json:"handle"`
+		ImgURL string `json:"img_url"`
+	}{}
+	err := json.NewDecoder(r).Decode(&u)
+	if err != nil {
+		return err
+	}
+	user.UserID = u.ID
+	user.Email = u.Email
+	user.Name = u.Handle
+	user.NickName = u.Handle
+	user.AvatarURL = u.ImgURL
+	return nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+// Figma refreshes tokens at its own endpoint rather than with a
+// refresh_token grant, and keeps the refresh token unchanged.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	form := url.Values{"refresh_token": {refreshToken}}
+	req, err := http.NewRequestWithContext(ctx, "POST", refreshURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(url.QueryEscape(p.ClientKey), url.QueryEscape(p.Secret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with a %d trying to refresh the token", p.providerName, resp.StatusCode)
+	}
+
+	r := struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+	if r.AccessToken == "" {
+		return nil, errors.New("Invalid token received from provider")
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  r.AccessToken,
+		TokenType:    r.TokenType,
+		RefreshToken: refreshToken,
+	}
+	if r.ExpiresIn > 0 {
+		token.Expiry = goth.GetClock().Now().Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
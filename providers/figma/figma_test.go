@@ -0,0 +1,103 @@
+package figma_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/figma"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("FIGMA_KEY"))
+	a.Equal(p.Secret, os.Getenv("FIGMA_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := figma.New(os.Getenv("FIGMA_KEY"), os.Getenv("FIGMA_SECRET"), "/foo", figma.ScopeFileContentRead)
+	session, err := p.BeginAuth("test_state")
+	s := session.(*figma.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "www.figma.com/oauth")
+	a.Contains(s.AuthURL, "scope=current_user%3Aread+file_content%3Aread")
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://api.figma.com/v1/me", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"id":"1234","email":"jane@example.com","handle":"Jane Doe","img_url":"https://example.com/jane.png"}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&figma.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("1234", user.UserID)
+	a.Equal("jane@example.com", user.Email)
+	a.Equal("Jane Doe", user.NickName)
+	a.Equal("https://example.com/jane.png", user.AvatarURL)
+}
+
+func Test_RefreshToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := figma.New("client", "secret", "/foo")
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://api.figma.com/v1/oauth/refresh", req.URL.String())
+		id, secret, _ := req.BasicAuth()
+		a.Equal("client", id)
+		a.Equal("secret", secret)
+		a.NoError(req.ParseForm())
+		a.Equal(url.Values{"refresh_token": {"refresh"}}, req.PostForm)
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"access_token":"new","token_type":"bearer","expires_in":7776000}`)
+		return rec.Result(), nil
+	})}
+
+	token, err := p.RefreshToken("refresh")
+	a.NoError(err)
+	a.Equal("new", token.AccessToken)
+	a.Equal("refresh", token.RefreshToken)
+	a.False(token.Expiry.IsZero())
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://www.figma.com/oauth","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*figma.Session)
+	a.Equal(s.AuthURL, "https://www.figma.com/oauth")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *figma.Provider {
+	return figma.New(os.Getenv("FIGMA_KEY"), os.Getenv("FIGMA_SECRET"), "/foo")
+}
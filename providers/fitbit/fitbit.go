@@ -3,10 +3,13 @@
 package fitbit
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -37,8 +40,55 @@ const (
 	ScopeSocial = "social"
 	// ScopeWeight includes weight and related information, such as body mass index, body fat percentage, and goals
 	ScopeWeight = "weight"
+	// ScopeCardioFitness includes the maximum or estimated maximum volume of oxygen uptake (VO2 Max)
+	ScopeCardioFitness = "cardio_fitness"
+	// ScopeElectrocardiogram includes electrocardiogram readings
+	ScopeElectrocardiogram = "electrocardiogram"
+	// ScopeIrregularRhythmNotifications includes the results of the irregular rhythm notifications feature
+	ScopeIrregularRhythmNotifications = "irregular_rhythm_notifications"
+	// ScopeOxygenSaturation includes SpO2 data
+	ScopeOxygenSaturation = "oxygen_saturation"
+	// ScopeRespiratoryRate includes breathing rate data
+	ScopeRespiratoryRate = "respiratory_rate"
+	// ScopeTemperature includes skin and core temperature data
+	ScopeTemperature = "temperature"
 )
 
+// RateLimit is the state of the user's API rate limit, as reported by Fitbit
+// in the Fitbit-Rate-Limit-* response headers.
+type RateLimit struct {
+	// Limit is the number of requests allowed per hour.
+	Limit int
+	// Remaining is the number of requests left until Reset.
+	Remaining int
+	// Reset is when the limit is reset, at the top of the hour.
+	Reset time.Time
+}
+
+// rateLimitFromHeader returns the rate limit reported in h, or nil if it has no rate limit headers.
+func rateLimitFromHeader(h http.Header) *RateLimit {
+	if h.Get("Fitbit-Rate-Limit-Limit") == "" {
+		return nil
+	}
+	limit, _ := strconv.Atoi(h.Get("Fitbit-Rate-Limit-Limit"))
+	remaining, _ := strconv.Atoi(h.Get("Fitbit-Rate-Limit-Remaining"))
+	reset, _ := strconv.Atoi(h.Get("Fitbit-Rate-Limit-Reset"))
+	return &RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     goth.GetClock().Now().Add(time.Duration(reset) * time.Second),
+	}
+}
+
+// ErrRateLimited is returned by FetchUser when the user has exceeded their API rate limit.
+type ErrRateLimited struct {
+	RateLimit RateLimit
+}
+
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("fitbit: rate limit of %d requests exceeded, resets at %s", e.RateLimit.Limit, e.RateLimit.Reset.Format(time.RFC3339))
+}
+
 // New creates a new Fitbit provider, and sets up important connection details.
 // You should always call `fitbit.New` to get a new Provider. Never try to create
 // one manually.
@@ -91,6 +141,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Fitbit and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	s := session.(*Session)
 	user := goth.User{
 		AccessToken:  s.AccessToken,
@@ -105,7 +160,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	req, err := http.NewRequest("GET", endpointProfile, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointProfile, nil)
 	if err != nil {
 		return user, err
 	}
@@ -119,6 +174,10 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	}
 	defer resp.Body.Close()
 
+	s.RateLimit = rateLimitFromHeader(resp.Header)
+	if resp.StatusCode == http.StatusTooManyRequests && s.RateLimit != nil {
+		return user, &ErrRateLimited{RateLimit: *s.RateLimit}
+	}
 	if resp.StatusCode != http.StatusOK {
 		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, resp.StatusCode)
 	}
@@ -159,6 +218,8 @@ func newConfig(provider *Provider, scopes []string) *oauth2.Config {
 		Endpoint: oauth2.Endpoint{
 			AuthURL:  authURL,
 			TokenURL: tokenURL,
+			// Fitbit requires the client credentials in a Basic Authorization header
+			AuthStyle: oauth2.AuthStyleInHeader,
 		},
 		Scopes: []string{
 			ScopeProfile,
@@ -178,10 +239,16 @@ func newConfig(provider *Provider, scopes []string) *oauth2.Config {
 	return c
 }
 
-// RefreshToken get new access token based on the refresh token
+// RefreshToken get new access token based on the refresh token. Fitbit refresh
+// tokens can only be used once: the returned token's RefreshToken replaces it.
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(oauth2.NoContext, token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -1,10 +1,15 @@
 package fitbit_test
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/fitbit"
 	"github.com/stretchr/testify/assert"
 )
@@ -53,3 +58,49 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal(s.AccessToken, "1234567890")
 	a.Equal(s.UserID, "abc")
 }
+
+func Test_FetchUser_RateLimited(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Fitbit-Rate-Limit-Limit", "150")
+		rec.Header().Set("Fitbit-Rate-Limit-Remaining", "0")
+		rec.Header().Set("Fitbit-Rate-Limit-Reset", "1200")
+		rec.WriteHeader(http.StatusTooManyRequests)
+		return rec.Result(), nil
+	})}
+
+	s := &fitbit.Session{AccessToken: "1234567890"}
+	_, err := p.FetchUser(s)
+	a.IsType(&fitbit.ErrRateLimited{}, err)
+	rl := err.(*fitbit.ErrRateLimited).RateLimit
+	a.Equal(150, rl.Limit)
+	a.Equal(0, rl.Remaining)
+	a.Equal(s.RateLimit.Reset, rl.Reset)
+}
+
+func Test_RefreshToken_BasicAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := fitbit.New("key", "secret", "/foo")
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		user, pass, ok := req.BasicAuth()
+		a.True(ok)
+		a.Equal("key", user)
+		a.Equal("secret", pass)
+		a.NoError(req.ParseForm())
+		a.Empty(req.PostForm.Get("client_secret"))
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(rec, `{"access_token":"new-access","refresh_token":"new-refresh","expires_in":28800,"token_type":"Bearer","user_id":"26FWFL"}`)
+		return rec.Result(), nil
+	})}
+
+	token, err := p.RefreshTokenContext(context.Background(), "old-refresh")
+	a.NoError(err)
+	a.Equal("new-refresh", token.RefreshToken)
+}
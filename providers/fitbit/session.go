@@ -1,12 +1,12 @@
 package fitbit
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"time"
 
 	"github.com/markbates/goth"
-	"golang.org/x/oauth2"
 )
 
 // Session stores data during the auth process with Fitbit.
@@ -16,6 +16,8 @@ type Session struct {
 	RefreshToken string
 	ExpiresAt    time.Time
 	UserID       string
+	// RateLimit is the user's API rate limit as of the last FetchUser, if Fitbit reported it.
+	RateLimit *RateLimit `json:"-"`
 }
 
 // GetAuthURL will return the URL set by calling the `BeginAuth` function on the
@@ -30,15 +32,20 @@ func (s Session) GetAuthURL() (string, error) {
 // Authorize completes the authorization with Fitbit and returns the access
 // token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.config.Exchange(oauth2.NoContext, params.Get("code"))
+	token, err := p.config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"))
 	if err != nil {
 		return "", err
 	}
 	s.AccessToken = token.AccessToken
 	s.RefreshToken = token.RefreshToken
 	s.ExpiresAt = token.Expiry
-	s.UserID = token.Extra("user_id").(string)
+	s.UserID, _ = token.Extra("user_id").(string)
 	return token.AccessToken, err
 }
 
@@ -56,6 +63,6 @@ func (s Session) String() string {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := Session{}
-	err := json.Unmarshal([]byte(data), &s)
+	err := goth.DecodeSession(data, &s)
 	return &s, err
 }
@@ -0,0 +1,258 @@
+// Package fusionauth implements the OpenID Connect protocol for authenticating
+// users through FusionAuth.
+package fusionauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// ErrTenantMismatch is returned when the user belongs to another tenant than
+// the one the provider is configured for.
+type ErrTenantMismatch struct {
+	Want string
+	Got  string
+}
+
+func (e *ErrTenantMismatch) Error() string {
+	return fmt.Sprintf("fusionauth: user belongs to tenant %q, not %q", e.Got, e.Want)
+}
+
+// Provider is the implementation of `goth.Provider` for accessing FusionAuth.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	baseURL      string
+	profileURL   string
+	tenantID     string
+}
+
+// New creates a new FusionAuth provider for the application whose client ID
+// (the application ID) is clientKey, on the instance at baseURL, e.g.
+// https://auth.example.com, and sets up important connection details. The
+// openid, profile, email and offline_access scopes are requested unless other
+// scopes are passed.
+// You should always call `fusionauth.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, baseURL string, scopes ...string) *Provider {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "fusionauth",
+		baseURL:      baseURL,
+		profileURL:   baseURL + "/oauth2/userinfo",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.baseURL + "/oauth2/authorize",
+			TokenURL: provider.baseURL + "/oauth2/token",
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, "openid", "profile", "email", "offline_access")
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the fusionauth package.
+func (p *Provider) Debug(debug bool) {}
+
+// SetTenantID sets the tenant of the application, for instances with more
+// than one tenant. It is passed to the hosted login and logout pages so that
+// they use the tenant's theme, and FetchUser checks that users belong to it.
+func (p *Provider) SetTenantID(tenantID string) {
+	p.tenantID = tenantID
+}
+
+// BeginAuth asks FusionAuth for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	var opts []oauth2.AuthCodeOption
+	if p.tenantID != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("tenantId", p.tenantID))
+	}
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state, opts...),
+	}, nil
+}
+
+// LogoutURL returns the URL of FusionAuth's logout endpoint, which ends the
+// user's FusionAuth SSO session and redirects to postLogoutRedirectURI, which
+// must be one of the application's authorized redirect URLs. It implements
+// goth.LogoutURLProvider, so gothic.LogoutAndRedirect uses it.
+func (p *Provider) LogoutURL(postLogoutRedirectURI string) string {
+	v := url.Values{"client_id": {p.ClientKey}}
+	if postLogoutRedirectURI != "" {
+		v.Set("post_logout_redirect_uri", postLogoutRedirectURI)
+	}
+	if p.tenantID != "" {
+		v.Set("tenantId", p.tenantID)
+	}
+	return p.baseURL + "/oauth2/logout?" + v.Encode()
+}
+
+// FetchUser will go to FusionAuth and access basic information about the user.
+// User.Roles is filled in from the roles of the user's registration for the
+// application, which FusionAuth puts in the roles claim of the tokens it
+// issues. It is empty for users who aren't registered for the application.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(bytes.NewReader(bits)).Decode(&claims); err != nil {
+		return user, err
+	}
+	if tid, ok := claims["tid"].(string); ok && p.tenantID != "" && tid != p.tenantID {
+		return user, &ErrTenantMismatch{Want: p.tenantID, Got: tid}
+	}
+
+	user.UserID, _ = claims["sub"].(string)
+	user.Email, _ = claims["email"].(string)
+	user.EmailVerified, _ = claims["email_verified"].(bool)
+	user.Name, _ = claims["name"].(string)
+	user.NickName, _ = claims["preferred_username"].(string)
+	user.FirstName, _ = claims["given_name"].(string)
+	user.LastName, _ = claims["family_name"].(string)
+	user.AvatarURL, _ = claims["picture"].(string)
+	user.Roles = stringList(claims["roles"])
+
+	// older FusionAuth versions only include the roles in the tokens
+	for _, token := range []string{sess.IDToken, sess.AccessToken} {
+		if len(user.Roles) > 0 {
+			break
+		}
+		var tokenClaims map[string]interface{}
+		if goth.DecodeJWTClaims(token, &tokenClaims) == nil {
+			user.Roles = stringList(tokenClaims["roles"])
+		}
+	}
+	return user, nil
+}
+
+// stringList returns the values of a claim holding a list of strings.
+func stringList(claim interface{}) []string {
+	list, _ := claim.([]interface{})
+	var values []string
+	for _, v := range list {
+		if s, ok := v.(string); ok && s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// checkIDToken verifies that idToken was issued for the provider's
+// application. FusionAuth's issuer is a per-tenant setting that needn't match
+// the instance URL, so it isn't checked.
+func (p *Provider) checkIDToken(idToken string) error {
+	var c struct {
+		TenantID string `json:"tid"`
+	}
+	if err := goth.DecodeJWTClaims(idToken, &c); err != nil {
+		return err
+	}
+	if p.tenantID != "" && c.TenantID != "" && c.TenantID != p.tenantID {
+		return &ErrTenantMismatch{Want: p.tenantID, Got: c.TenantID}
+	}
+	return goth.CheckIDToken(p.Name(), idToken, "", p.ClientKey)
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
@@ -0,0 +1,133 @@
+package fusionauth_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/fusionauth"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	appID    = "85a03867-dccf-4882-adde-1a79aeec50df"
+	tenantID = "d7d09513-a3f5-401c-9685-34ab6c552453"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "fusionauth")
+	a.Equal(p.ClientKey, appID)
+	a.Equal(p.Secret, "secret")
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+	a.Implements((*goth.LogoutURLProvider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*fusionauth.Session)
+	a.Contains(s.AuthURL, "https://auth.example.com/oauth2/authorize")
+	a.Contains(s.AuthURL, "scope=openid+profile+email+offline_access")
+	a.Contains(s.AuthURL, "tenantId="+tenantID)
+}
+
+func Test_LogoutURL(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	u, ok := goth.LogoutURL(provider(), "https://app.example.com/signed-out")
+	a.True(ok)
+	a.Equal("https://auth.example.com/oauth2/logout?client_id="+appID+
+		"&post_logout_redirect_uri=https%3A%2F%2Fapp.example.com%2Fsigned-out&tenantId="+tenantID, u)
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+
+	for name, claims := range map[string]string{
+		"valid":       `{"iss":"acme.com","aud":"` + appID + `","tid":"` + tenantID + `"}`,
+		"otherTenant": `{"iss":"acme.com","aud":"` + appID + `","tid":"other"}`,
+	} {
+		name, claims := name, claims
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := provider()
+			p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				a.Equal("https://auth.example.com/oauth2/token", req.URL.String())
+				rec := httptest.NewRecorder()
+				rec.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(rec, `{"access_token":"1234567890","token_type":"Bearer","expires_in":3600,"id_token":%q}`, gothtest.UnsignedJWT(claims))
+				return rec.Result(), nil
+			})}
+			session, _ := p.BeginAuth("test_state")
+			_, err := session.Authorize(p, url.Values{"code": {"code"}})
+			if name == "valid" {
+				a.NoError(err)
+			} else {
+				a.Equal(&fusionauth.ErrTenantMismatch{Want: tenantID, Got: "other"}, err)
+			}
+		})
+	}
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://auth.example.com/oauth2/userinfo", req.URL.String())
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"sub":"00000000-0000-0001-0000-000000000000","email":"jdoe@example.com","email_verified":true,
+			"given_name":"John","family_name":"Doe","applicationId":"`+appID+`","tid":"`+tenantID+`"}`)
+		return rec.Result(), nil
+	})}
+
+	accessToken := gothtest.UnsignedJWT(`{"aud":"` + appID + `","applicationId":"` + appID + `","roles":["admin","editor"]}`)
+	user, err := p.FetchUser(&fusionauth.Session{AccessToken: accessToken})
+	a.NoError(err)
+	a.Equal("00000000-0000-0001-0000-000000000000", user.UserID)
+	a.Equal("jdoe@example.com", user.Email)
+	a.True(user.EmailVerified)
+	a.Equal([]string{"admin", "editor"}, user.Roles)
+
+	p.SetTenantID("other")
+	_, err = p.FetchUser(&fusionauth.Session{AccessToken: accessToken})
+	a.IsType(&fusionauth.ErrTenantMismatch{}, err)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://auth.example.com/oauth2/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*fusionauth.Session)
+	a.Equal(s.AuthURL, "https://auth.example.com/oauth2/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *fusionauth.Provider {
+	p := fusionauth.New(appID, "secret", "/foo", "https://auth.example.com")
+	p.SetTenantID(tenantID)
+	return p
+}
@@ -1,9 +1,12 @@
 // Package gitea implements the OAuth2 protocol for authenticating users through gitea.
+// It also works with Forgejo, which shares Gitea's API; use NewWithBaseURL for
+// self-hosted instances of either.
 // This package can be used as a reference implementation of an OAuth2 provider for Goth.
 package gitea
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,6 +14,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -29,6 +33,16 @@ var (
 	ProfileURL = "https://gitea.com/api/v1/user"
 )
 
+// Scopes for Gitea 1.19+ and Forgejo, which OAuth2 applications are limited to
+// when they request any.
+const (
+	ScopeReadUser         = "read:user"
+	ScopeReadOrganization = "read:organization"
+)
+
+// orgsPageSize is the number of organizations fetched per request.
+const orgsPageSize = 50
+
 // Provider is the implementation of `goth.Provider` for accessing Gitea.
 type Provider struct {
 	ClientKey    string
@@ -40,6 +54,7 @@ type Provider struct {
 	authURL      string
 	tokenURL     string
 	profileURL   string
+	fetchOrgs    bool
 }
 
 // New creates a new Gitea provider and sets up important connection details.
@@ -62,6 +77,14 @@ func NewCustomisedURL(clientKey, secret, callbackURL, authURL, tokenURL, profile
 	return p
 }
 
+// NewWithBaseURL is like New but for the Gitea or Forgejo instance at baseURL,
+// e.g. https://codeberg.org.
+func NewWithBaseURL(clientKey, secret, callbackURL, baseURL string, scopes ...string) *Provider {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return NewCustomisedURL(clientKey, secret, callbackURL,
+		baseURL+"/login/oauth/authorize", baseURL+"/login/oauth/access_token", baseURL+"/api/v1/user", scopes...)
+}
+
 // Name is the name used to retrieve this provider later.
 func (p *Provider) Name() string {
 	return p.providerName
@@ -79,6 +102,13 @@ func (p *Provider) Client() *http.Client {
 // Debug is a no-op for the gitea package.
 func (p *Provider) Debug(debug bool) {}
 
+// SetFetchOrganizations makes FetchUser fill in User.Groups with the names of
+// the organizations the user is a member of, from <profile URL>/orgs. When
+// scopes are requested this needs ScopeReadOrganization.
+func (p *Provider) SetFetchOrganizations(fetch bool) {
+	p.fetchOrgs = fetch
+}
+
 // BeginAuth asks Gitea for an authentication end-point.
 func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 	return &Session{
@@ -88,6 +118,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Gitea and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken:  sess.AccessToken,
@@ -101,7 +136,13 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	response, err := p.Client().Get(p.profileURL + "?access_token=" + url.QueryEscape(sess.AccessToken))
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
+	if err != nil {
+		return user, err
+	}
+	// the access_token query parameter was removed in Gitea 1.23
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
 	if err != nil {
 		if response != nil {
 			response.Body.Close()
@@ -120,16 +161,60 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, err
 	}
 
-	err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
+	err = user.SetRawJSON(bits)
 	if err != nil {
 		return user, err
 	}
 
 	err = userFromReader(bytes.NewReader(bits), &user)
+	if err != nil || !p.fetchOrgs {
+		return user, err
+	}
 
+	user.Groups, err = p.organizations(ctx, sess.AccessToken)
 	return user, err
 }
 
+// organizations returns the names of the organizations the user is a member of.
+func (p *Provider) organizations(ctx context.Context, accessToken string) ([]string, error) {
+	var names []string
+	for page := 1; ; page++ {
+		u := p.profileURL + "/orgs?" + url.Values{
+			"page":  {strconv.Itoa(page)},
+			"limit": {strconv.Itoa(orgsPageSize)},
+		}.Encode()
+		req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		response, err := p.Client().Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var orgs []struct {
+			Name string `json:"username"`
+		}
+		if response.StatusCode == http.StatusOK {
+			err = json.NewDecoder(response.Body).Decode(&orgs)
+		} else {
+			err = fmt.Errorf("%s responded with a %d trying to fetch organizations", p.providerName, response.StatusCode)
+		}
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, org := range orgs {
+			names = append(names, org.Name)
+		}
+		if len(orgs) < orgsPageSize {
+			return names, nil
+		}
+	}
+}
+
 func newConfig(provider *Provider, authURL, tokenURL string, scopes []string) *oauth2.Config {
 	c := &oauth2.Config{
 		ClientID:     provider.ClientKey,
@@ -152,11 +237,13 @@ func newConfig(provider *Provider, authURL, tokenURL string, scopes []string) *o
 
 func userFromReader(r io.Reader, user *goth.User) error {
 	u := struct {
-		Name      string `json:"full_name"`
-		Email     string `json:"email"`
-		NickName  string `json:"login"`
-		ID        int    `json:"id"`
-		AvatarURL string `json:"avatar_url"`
+		Name        string `json:"full_name"`
+		Email       string `json:"email"`
+		NickName    string `json:"login"`
+		ID          int    `json:"id"`
+		AvatarURL   string `json:"avatar_url"`
+		Location    string `json:"location"`
+		Description string `json:"description"`
 	}{}
 	err := json.NewDecoder(r).Decode(&u)
 	if err != nil {
@@ -167,6 +254,8 @@ func userFromReader(r io.Reader, user *goth.User) error {
 	user.NickName = u.NickName
 	user.UserID = strconv.Itoa(u.ID)
 	user.AvatarURL = u.AvatarURL
+	user.Location = u.Location
+	user.Description = u.Description
 	return nil
 }
 
@@ -177,8 +266,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -1,10 +1,14 @@
 package gitea_test
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/gitea"
 	"github.com/stretchr/testify/assert"
 )
@@ -45,6 +49,59 @@ func Test_BeginAuth(t *testing.T) {
 	a.Contains(s.AuthURL, "gitea.com/login/oauth/authorize")
 }
 
+func Test_NewWithBaseURL(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := gitea.NewWithBaseURL(os.Getenv("GITEA_KEY"), os.Getenv("GITEA_SECRET"), "/foo", "https://codeberg.org/")
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	a.Contains(session.(*gitea.Session).AuthURL, "https://codeberg.org/login/oauth/authorize")
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := gitea.NewWithBaseURL(os.Getenv("GITEA_KEY"), os.Getenv("GITEA_SECRET"), "/foo", "https://codeberg.org", gitea.ScopeReadUser, gitea.ScopeReadOrganization)
+	p.SetFetchOrganizations(true)
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		switch req.URL.Path {
+		case "/api/v1/user":
+			fmt.Fprint(rec, `{"id":42,"login":"jdoe","full_name":"John Doe","email":"jdoe@example.com",
+				"avatar_url":"https://codeberg.org/avatars/42","location":"Berlin","description":"Gopher"}`)
+		case "/api/v1/user/orgs":
+			a.Equal("50", req.URL.Query().Get("limit"))
+			if req.URL.Query().Get("page") == "1" {
+				fmt.Fprint(rec, "[")
+				for i := 0; i < 50; i++ {
+					if i > 0 {
+						fmt.Fprint(rec, ",")
+					}
+					fmt.Fprintf(rec, `{"id":%d,"username":"org%d"}`, i, i)
+				}
+				fmt.Fprint(rec, "]")
+			} else {
+				fmt.Fprint(rec, `[{"id":50,"username":"forgejo"}]`)
+			}
+		default:
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&gitea.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("42", user.UserID)
+	a.Equal("jdoe", user.NickName)
+	a.Equal("Berlin", user.Location)
+	a.Equal("Gopher", user.Description)
+	a.Len(user.Groups, 51)
+	a.Equal("org0", user.Groups[0])
+	a.Equal("forgejo", user.Groups[50])
+}
+
 func Test_SessionFromJSON(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
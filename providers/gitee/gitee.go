@@ -0,0 +1,252 @@
+// Package gitee implements the OAuth2 protocol for authenticating users through Gitee.
+package gitee
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL    = "https://gitee.com/oauth/authorize"
+	tokenURL   = "https://gitee.com/oauth/token"
+	profileURL = "https://gitee.com/api/v5/user"
+	emailURL   = "https://gitee.com/api/v5/emails"
+)
+
+// Gitee scopes. ScopeUserInfo is requested if no scopes are passed to New.
+const (
+	ScopeUserInfo = "user_info"
+	ScopeEmails   = "emails"
+	ScopeProjects = "projects"
+	ScopeGroups   = "groups"
+)
+
+// Provider is the implementation of `goth.Provider` for accessing Gitee.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	profileURL   string
+	emailURL     string
+}
+
+// New creates a new Gitee provider, and sets up important connection details.
+// Request ScopeEmails to get the addresses of users who keep theirs private.
+// You should always call `gitee.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "gitee",
+		profileURL:   profileURL,
+		emailURL:     emailURL,
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  authURL,
+			TokenURL: tokenURL,
+			// Gitee only reads the client credentials from the request body
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, ScopeUserInfo)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the gitee package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks Gitee for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to Gitee and access basic information about the user.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	response, err := p.get(ctx, p.profileURL, sess.AccessToken)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	err = userFromReader(bytes.NewReader(bits), &user)
+	if err != nil {
+		return user, err
+	}
+
+	if user.Email == "" && p.hasScope(ScopeEmails) {
+		err = p.fetchEmail(ctx, sess.AccessToken, &user)
+	}
+	return user, err
+}
+
+// get requests u from Gitee's v5 API, which takes the token as a parameter.
+func (p *Provider) get(ctx context.Context, u, accessToken string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u+"?access_token="+url.QueryEscape(accessToken), nil)
+	if err != nil {
+		return nil, err
+	}
+	return p.Client().Do(req)
+}
+
+func (p *Provider) hasScope(scope string) bool {
+	for _, s := range p.config.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchEmail fills in the user's primary address, preferring a confirmed one.
+func (p *Provider) fetchEmail(ctx context.Context, accessToken string, user *goth.User) error {
+	response, err := p.get(ctx, p.emailURL, accessToken)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with a %d trying to fetch user emails", p.providerName, response.StatusCode)
+	}
+
+	var emails []struct {
+		Email string   `json:"email"`
+		State string   `json:"state"`
+		Scope []string `json:"scope"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&emails); err != nil {
+		return err
+	}
+	for _, e := range emails {
+		confirmed := e.State == "confirmed"
+		primary := false
+		for _, s := range e.Scope {
+			if s == "primary" {
+				primary = true
+			}
+		}
+		if primary && (confirmed || user.Email == "") {
+			user.Email = e.Email
+			user.EmailVerified = confirmed
+		}
+	}
+	return nil
+}
+
+func userFromReader(r io.Reader, user *goth.User) error {
+	u := struct {
+		ID        int    `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		Email     string `json:"email"`
+		AvatarURL string `json:"avatar_url"`
+		Bio       string `json:"bio"`
+	}{}
+	err := json.NewDecoder(r).Decode(&u)
+	if err != nil {
+		return err
+	}
+	user.UserID = strconv.Itoa(u.ID)
+	user.NickName = u.Login
+	user.Name = u.Name
+	user.Email = u.Email
+	user.AvatarURL = u.AvatarURL
+	user.Description = u.Bio
+	return nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
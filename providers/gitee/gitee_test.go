@@ -0,0 +1,88 @@
+package gitee_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/gitee"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("GITEE_KEY"))
+	a.Equal(p.Secret, os.Getenv("GITEE_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+	session, err := p.BeginAuth("test_state")
+	s := session.(*gitee.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "gitee.com/oauth/authorize")
+	a.Contains(s.AuthURL, "scope=user_info")
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := gitee.New(os.Getenv("GITEE_KEY"), os.Getenv("GITEE_SECRET"), "/foo", gitee.ScopeUserInfo, gitee.ScopeEmails)
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("1234567890", req.URL.Query().Get("access_token"))
+		rec := httptest.NewRecorder()
+		switch req.URL.Path {
+		case "/api/v5/user":
+			fmt.Fprint(rec, `{"id":1234567,"login":"zhangsan","name":"张三","avatar_url":"https://gitee.com/assets/no_portrait.png","bio":"Gopher","email":null}`)
+		case "/api/v5/emails":
+			fmt.Fprint(rec, `[{"email":"old@example.com","state":"unconfirmed","scope":["committed"]},
+				{"email":"zhangsan@example.com","state":"confirmed","scope":["primary","security"]}]`)
+		default:
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&gitee.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("1234567", user.UserID)
+	a.Equal("zhangsan", user.NickName)
+	a.Equal("张三", user.Name)
+	a.Equal("Gopher", user.Description)
+	a.Equal("zhangsan@example.com", user.Email)
+	a.True(user.EmailVerified)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	session, err := p.UnmarshalSession(`{"AuthURL":"https://gitee.com/oauth/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*gitee.Session)
+	a.Equal(s.AuthURL, "https://gitee.com/oauth/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *gitee.Provider {
+	return gitee.New(os.Getenv("GITEE_KEY"), os.Getenv("GITEE_SECRET"), "/foo")
+}
@@ -4,12 +4,14 @@ package github
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -18,7 +20,8 @@ import (
 )
 
 // These vars define the Authentication, Token, and API URLS for GitHub. If
-// using GitHub enterprise you should change these values before calling New.
+// using GitHub Enterprise Server you should change these values before calling
+// New, or use NewEnterprise.
 //
 // Examples:
 //
@@ -40,6 +43,20 @@ func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
 	return NewCustomisedURL(clientKey, secret, callbackURL, AuthURL, TokenURL, ProfileURL, EmailURL, scopes...)
 }
 
+// NewEnterprise is similar to New(...) but connects to the GitHub Enterprise
+// Server at baseURL, e.g. "https://github.acme.com", whose REST API is served
+// under /api/v3.
+func NewEnterprise(clientKey, secret, callbackURL, baseURL string, scopes ...string) *Provider {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	apiURL := baseURL + "/api/v3"
+	return NewCustomisedURL(clientKey, secret, callbackURL,
+		baseURL+"/login/oauth/authorize",
+		baseURL+"/login/oauth/access_token",
+		apiURL+"/user",
+		apiURL+"/user/emails",
+		scopes...)
+}
+
 // NewCustomisedURL is similar to New(...) but can be used to set custom URLs to connect to
 func NewCustomisedURL(clientKey, secret, callbackURL, authURL, tokenURL, profileURL, emailURL string, scopes ...string) *Provider {
 	p := &Provider{
@@ -49,6 +66,8 @@ func NewCustomisedURL(clientKey, secret, callbackURL, authURL, tokenURL, profile
 		providerName: "github",
 		profileURL:   profileURL,
 		emailURL:     emailURL,
+		apiURL:       strings.TrimSuffix(profileURL, "/user"),
+		emailPolicy:  EmailPrimaryVerified,
 	}
 	p.config = newConfig(p, authURL, tokenURL, scopes)
 	return p
@@ -64,6 +83,21 @@ type Provider struct {
 	providerName string
 	profileURL   string
 	emailURL     string
+	apiURL       string
+	emailPolicy  EmailPolicy
+	githubApp    bool
+	fetchOrgs    bool
+	requiredOrgs []string
+}
+
+// ErrNotOrganizationMember is returned by FetchUser when the user is not a
+// member of any of the organizations set with SetRequiredOrganizations.
+type ErrNotOrganizationMember struct {
+	Organizations []string
+}
+
+func (e *ErrNotOrganizationMember) Error() string {
+	return fmt.Sprintf("github: user is not a member of %s", strings.Join(e.Organizations, " or "))
 }
 
 // Name is the name used to retrieve this provider later.
@@ -94,10 +128,17 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Github and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
-		AccessToken: sess.AccessToken,
-		Provider:    p.Name(),
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
 	}
 
 	if user.AccessToken == "" {
@@ -105,7 +146,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	req, err := http.NewRequest("GET", p.profileURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
 	if err != nil {
 		return user, err
 	}
@@ -126,7 +167,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, err
 	}
 
-	err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
+	err = user.SetRawJSON(bits)
 	if err != nil {
 		return user, err
 	}
@@ -139,17 +180,208 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	if user.Email == "" {
 		for _, scope := range p.config.Scopes {
 			if strings.TrimSpace(scope) == "user" || strings.TrimSpace(scope) == "user:email" {
-				user.Email, err = getPrivateMail(p, sess)
+				email, err := getPrivateMail(ctx, p, sess)
 				if err != nil {
 					return user, err
 				}
+				user.Email = email.Email
+				user.EmailVerified = email.Verified
 				break
 			}
 		}
 	}
+
+	if p.fetchOrgs || len(p.requiredOrgs) > 0 {
+		if err := p.fetchMemberships(ctx, sess.AccessToken, &user); err != nil {
+			return user, err
+		}
+	}
 	return user, err
 }
 
+// SetFetchOrganizations makes FetchUser fill in User.Groups with the logins
+// of the organizations the user is a member of, followed by their teams as
+// "org/team-slug". They are also stored in RawData as "organizations" and
+// "teams". The read:org scope is added to the requested scopes.
+func (p *Provider) SetFetchOrganizations(fetch bool) {
+	p.fetchOrgs = fetch
+	if fetch {
+		p.addScope("read:org")
+	}
+}
+
+// SetRequiredOrganizations makes FetchUser fail with an
+// *ErrNotOrganizationMember unless the user is a member of at least one of
+// orgs. The read:org scope is added to the requested scopes.
+func (p *Provider) SetRequiredOrganizations(orgs ...string) {
+	p.requiredOrgs = orgs
+	if len(orgs) > 0 {
+		p.addScope("read:org")
+	}
+}
+
+func (p *Provider) addScope(scope string) {
+	for _, s := range p.config.Scopes {
+		if strings.TrimSpace(s) == scope {
+			return
+		}
+	}
+	p.config.Scopes = append(p.config.Scopes, scope)
+}
+
+// fetchMemberships fills in the user's organizations and teams, and checks
+// the required organizations.
+func (p *Provider) fetchMemberships(ctx context.Context, accessToken string, user *goth.User) error {
+	var orgs []string
+	err := p.getPages(ctx, p.apiURL+"/user/orgs?per_page=100", accessToken, func(r io.Reader) error {
+		var page []struct {
+			Login string `json:"login"`
+		}
+		if err := json.NewDecoder(r).Decode(&page); err != nil {
+			return err
+		}
+		for _, o := range page {
+			orgs = append(orgs, o.Login)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(p.requiredOrgs) > 0 && !containsAnyFold(orgs, p.requiredOrgs) {
+		return &ErrNotOrganizationMember{Organizations: p.requiredOrgs}
+	}
+	if !p.fetchOrgs {
+		return nil
+	}
+
+	var teams []string
+	err = p.getPages(ctx, p.apiURL+"/user/teams?per_page=100", accessToken, func(r io.Reader) error {
+		var page []struct {
+			Slug         string `json:"slug"`
+			Organization struct {
+				Login string `json:"login"`
+			} `json:"organization"`
+		}
+		if err := json.NewDecoder(r).Decode(&page); err != nil {
+			return err
+		}
+		for _, t := range page {
+			teams = append(teams, t.Organization.Login+"/"+t.Slug)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	user.Groups = append(append([]string{}, orgs...), teams...)
+	user.SetRawData("organizations", orgs)
+	user.SetRawData("teams", teams)
+	return nil
+}
+
+// getPages GETs url and every following page linked to with rel="next" in the
+// Link header, passing each response body to fn.
+func (p *Provider) getPages(ctx context.Context, url, accessToken string, fn func(io.Reader) error) error {
+	for url != "" {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Add("Authorization", "Bearer "+accessToken)
+		response, err := p.Client().Do(req)
+		if err != nil {
+			return err
+		}
+		if response.StatusCode != http.StatusOK {
+			response.Body.Close()
+			return fmt.Errorf("GitHub API responded with a %d trying to fetch %s", response.StatusCode, req.URL.Path)
+		}
+		err = fn(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return err
+		}
+		url = nextPage(response.Header.Get("Link"))
+	}
+	return nil
+}
+
+// nextPage returns the URL of the rel="next" link of a Link header.
+func nextPage(link string) string {
+	for _, l := range strings.Split(link, ",") {
+		parts := strings.Split(l, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+func containsAnyFold(have, want []string) bool {
+	for _, h := range have {
+		for _, w := range want {
+			if strings.EqualFold(h, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IntrospectToken validates a bare access token with GitHub's check a token
+// endpoint, which only knows tokens issued to the provider's client, and
+// fetches the user it was issued to. Other tokens are rejected with an
+// *goth.ErrTokenAudience.
+// See https://docs.github.com/en/rest/apps/oauth-applications#check-a-token
+func (p *Provider) IntrospectToken(ctx context.Context, accessToken string) (goth.User, error) {
+	body, err := json.Marshal(map[string]string{"access_token": accessToken})
+	if err != nil {
+		return goth.User{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiURL+"/applications/"+url.PathEscape(p.ClientKey)+"/token", bytes.NewReader(body))
+	if err != nil {
+		return goth.User{}, err
+	}
+	req.SetBasicAuth(p.ClientKey, p.Secret)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return goth.User{}, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusUnprocessableEntity:
+		// the token is invalid, or belongs to another app
+		return goth.User{}, &goth.ErrTokenAudience{Provider: p.providerName}
+	default:
+		return goth.User{}, fmt.Errorf("%s responded with a %d trying to validate a token", p.providerName, response.StatusCode)
+	}
+
+	var check struct {
+		App struct {
+			ClientID string `json:"client_id"`
+		} `json:"app"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&check); err != nil {
+		return goth.User{}, err
+	}
+	if check.App.ClientID != p.ClientKey {
+		return goth.User{}, &goth.ErrTokenAudience{Provider: p.providerName, Audience: check.App.ClientID}
+	}
+	return p.FetchUserContext(ctx, &Session{AccessToken: accessToken})
+}
+
 func userFromReader(reader io.Reader, user *goth.User) error {
 	u := struct {
 		ID       int    `json:"id"`
@@ -177,37 +409,79 @@ func userFromReader(reader io.Reader, user *goth.User) error {
 	return err
 }
 
-func getPrivateMail(p *Provider, sess *Session) (email string, err error) {
-	req, err := http.NewRequest("GET", p.emailURL, nil)
-	req.Header.Add("Authorization", "Bearer "+sess.AccessToken)
-	response, err := p.Client().Do(req)
-	if err != nil {
-		if response != nil {
-			response.Body.Close()
-		}
-		return email, err
-	}
-	defer response.Body.Close()
+// EmailPolicy selects which of the user's email addresses FetchUser uses
+// when the user has not made one public.
+type EmailPolicy int
+
+const (
+	// EmailPrimaryVerified only accepts the primary address, if it is verified.
+	EmailPrimaryVerified EmailPolicy = iota
+	// EmailVerified prefers the primary address but accepts any verified one.
+	EmailVerified
+	// EmailAny prefers verified addresses and the primary one, but accepts
+	// any address. Check User.EmailVerified before trusting it.
+	EmailAny
+)
 
-	if response.StatusCode != http.StatusOK {
-		return email, fmt.Errorf("GitHub API responded with a %d trying to fetch user email", response.StatusCode)
-	}
+// SetEmailPolicy sets how FetchUser picks an address from the user's emails
+// when the user has not made one public. The default is EmailPrimaryVerified.
+// Only loosen it if the app does not use the email to identify or link
+// accounts.
+func (p *Provider) SetEmailPolicy(policy EmailPolicy) {
+	p.emailPolicy = policy
+}
 
-	var mailList []struct {
-		Email    string `json:"email"`
-		Primary  bool   `json:"primary"`
-		Verified bool   `json:"verified"`
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func getPrivateMail(ctx context.Context, p *Provider, sess *Session) (email githubEmail, err error) {
+	url := p.emailURL
+	if !strings.Contains(url, "?") {
+		url += "?per_page=100"
 	}
-	err = json.NewDecoder(response.Body).Decode(&mailList)
+	var mailList []githubEmail
+	err = p.getPages(ctx, url, sess.AccessToken, func(r io.Reader) error {
+		var page []githubEmail
+		if err := json.NewDecoder(r).Decode(&page); err != nil {
+			return err
+		}
+		mailList = append(mailList, page...)
+		return nil
+	})
 	if err != nil {
 		return email, err
 	}
-	for _, v := range mailList {
-		if v.Primary && v.Verified {
-			return v.Email, nil
+
+	// rank the addresses from most to least preferred, stopping at what the policy accepts
+	rank := func(e githubEmail) int {
+		switch {
+		case e.Primary && e.Verified:
+			return 0
+		case e.Verified:
+			return 1
+		case e.Primary:
+			return 2
+		default:
+			return 3
 		}
 	}
-	return email, fmt.Errorf("The user does not have a verified, primary email address on GitHub")
+	worst := map[EmailPolicy]int{EmailPrimaryVerified: 0, EmailVerified: 1, EmailAny: 3}[p.emailPolicy]
+	best := -1
+	for i, e := range mailList {
+		if r := rank(e); r <= worst && (best < 0 || r < rank(mailList[best])) {
+			best = i
+		}
+	}
+	if best < 0 && p.emailPolicy == EmailPrimaryVerified {
+		return email, fmt.Errorf("The user does not have a verified, primary email address on GitHub")
+	}
+	if best < 0 {
+		return email, fmt.Errorf("The user does not have a suitable email address on GitHub")
+	}
+	return mailList[best], nil
 }
 
 func newConfig(provider *Provider, authURL, tokenURL string, scopes []string) *oauth2.Config {
@@ -229,12 +503,65 @@ func newConfig(provider *Provider, authURL, tokenURL string, scopes []string) *o
 	return c
 }
 
-// RefreshToken refresh token is not provided by github
+// SetGitHubApp marks the provider as authorizing a GitHub App rather than an
+// OAuth App. GitHub Apps can issue expiring user tokens, which come with a
+// refresh token that RefreshToken can use.
+// See https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/refreshing-user-access-tokens
+func (p *Provider) SetGitHubApp(app bool) {
+	p.githubApp = app
+}
+
+// RefreshToken gets a new access token for a GitHub App; the refresh token
+// is not provided by OAuth Apps.
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
-	return nil, errors.New("Refresh token is not provided by github")
+	return p.RefreshTokenContext(context.Background(), refreshToken)
 }
 
-// RefreshTokenAvailable refresh token is not provided by github
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	if !p.githubApp {
+		return nil, errors.New("Refresh token is not provided by github")
+	}
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
+
+// RefreshTokenAvailable refresh token is only provided by github for GitHub Apps
 func (p *Provider) RefreshTokenAvailable() bool {
-	return false
+	return p.githubApp
+}
+
+// Installation is an installation of a GitHub App the user can access.
+type Installation struct {
+	ID      int64  `json:"id"`
+	AppID   int64  `json:"app_id"`
+	Account string `json:"-"`
+	// TargetType is "User" or "Organization".
+	TargetType string `json:"target_type"`
+}
+
+// Installations lists the installations of the GitHub App that the user
+// with the given user token can access.
+func (p *Provider) Installations(ctx context.Context, accessToken string) ([]Installation, error) {
+	var installations []Installation
+	err := p.getPages(ctx, p.apiURL+"/user/installations?per_page=100", accessToken, func(r io.Reader) error {
+		var page struct {
+			Installations []struct {
+				Installation
+				Account struct {
+					Login string `json:"login"`
+				} `json:"account"`
+			} `json:"installations"`
+		}
+		if err := json.NewDecoder(r).Decode(&page); err != nil {
+			return err
+		}
+		for _, i := range page.Installations {
+			i.Installation.Account = i.Account.Login
+			installations = append(installations, i.Installation)
+		}
+		return nil
+	})
+	return installations, err
 }
@@ -1,11 +1,18 @@
 package github_test
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/github"
 	"github.com/stretchr/testify/assert"
 )
@@ -30,6 +37,155 @@ func Test_NewCustomisedURL(t *testing.T) {
 	a.Contains(s.AuthURL, "http://authURL")
 }
 
+func Test_NewEnterprise(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/user":
+			fmt.Fprint(w, `{"id":1,"login":"octocat"}`)
+		case "/api/v3/user/emails":
+			fmt.Fprint(w, `[{"email":"octocat@acme.com","primary":true,"verified":true}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	p := github.NewEnterprise(os.Getenv("GITHUB_KEY"), os.Getenv("GITHUB_SECRET"), "/foo", ts.URL+"/", "user:email")
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	a.Contains(session.(*github.Session).AuthURL, ts.URL+"/login/oauth/authorize?")
+
+	user, err := p.FetchUser(&github.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("octocat", user.NickName)
+	a.Equal("octocat@acme.com", user.Email)
+}
+
+func Test_FetchUserOrganizations(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case "/user?":
+			fmt.Fprint(w, `{"id":1,"login":"octocat","email":"octocat@github.com"}`)
+		case "/user/orgs?per_page=100":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/user/orgs?page=2>; rel="next", <%s/user/orgs?page=2>; rel="last"`, ts.URL, ts.URL))
+			fmt.Fprint(w, `[{"login":"acme"}]`)
+		case "/user/orgs?page=2":
+			fmt.Fprint(w, `[{"login":"Initech"}]`)
+		case "/user/teams?per_page=100":
+			fmt.Fprint(w, `[{"slug":"admins","organization":{"login":"acme"}}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	p := github.NewCustomisedURL(os.Getenv("GITHUB_KEY"), os.Getenv("GITHUB_SECRET"), "/foo", ts.URL+"/authorize", ts.URL+"/token", ts.URL+"/user", ts.URL+"/user/emails", "user")
+	p.SetFetchOrganizations(true)
+	session, _ := p.BeginAuth("test_state")
+	a.Contains(session.(*github.Session).AuthURL, "scope=user+read%3Aorg")
+
+	user, err := p.FetchUser(&github.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal([]string{"acme", "Initech", "acme/admins"}, user.Groups)
+	a.Equal([]string{"acme", "Initech"}, user.RawData["organizations"])
+
+	p.SetRequiredOrganizations("initech")
+	_, err = p.FetchUser(&github.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+
+	p.SetRequiredOrganizations("globex", "hooli")
+	_, err = p.FetchUser(&github.Session{AccessToken: "1234567890"})
+	a.Equal(&github.ErrNotOrganizationMember{Organizations: []string{"globex", "hooli"}}, err)
+}
+
+func Test_FetchUserPrivateEmail(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case "/user?":
+			fmt.Fprint(w, `{"id":1,"login":"octocat","email":null}`)
+		case "/user/emails?per_page=100":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/user/emails?page=2>; rel="next"`, ts.URL))
+			fmt.Fprint(w, `[{"email":"primary@github.com","primary":true,"verified":false}]`)
+		case "/user/emails?page=2":
+			fmt.Fprint(w, `[{"email":"other@github.com","primary":false,"verified":false},{"email":"verified@github.com","primary":false,"verified":true}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	p := github.NewCustomisedURL(os.Getenv("GITHUB_KEY"), os.Getenv("GITHUB_SECRET"), "/foo", ts.URL+"/authorize", ts.URL+"/token", ts.URL+"/user", ts.URL+"/user/emails", "user:email")
+	// by default only a verified primary address is accepted
+	_, err := p.FetchUser(&github.Session{AccessToken: "1234567890"})
+	a.Error(err)
+
+	p.SetEmailPolicy(github.EmailVerified)
+	user, err := p.FetchUser(&github.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("verified@github.com", user.Email)
+	a.True(user.EmailVerified)
+
+	p.SetEmailPolicy(github.EmailAny)
+	user, err = p.FetchUser(&github.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("verified@github.com", user.Email)
+}
+
+func Test_GitHubApp(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			r.ParseForm()
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"ghu_%s","expires_in":28800,"refresh_token":"ghr_new","refresh_token_expires_in":15897600,"token_type":"bearer","scope":""}`, r.Form.Get("grant_type"))
+		case "/user/installations":
+			fmt.Fprint(w, `{"total_count":1,"installations":[{"id":42,"app_id":7,"target_type":"Organization","account":{"login":"acme"}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	p := github.NewCustomisedURL(os.Getenv("GITHUB_KEY"), os.Getenv("GITHUB_SECRET"), "/foo", ts.URL+"/authorize", ts.URL+"/token", ts.URL+"/user", ts.URL+"/user/emails")
+	a.False(p.RefreshTokenAvailable())
+	_, err := p.RefreshToken("ghr_old")
+	a.Error(err)
+
+	p.SetGitHubApp(true)
+	a.True(p.RefreshTokenAvailable())
+
+	s := &github.Session{}
+	_, err = s.Authorize(p, url.Values{"code": {"code"}, "installation_id": {"42"}, "setup_action": {"install"}})
+	a.NoError(err)
+	a.Equal("ghu_authorization_code", s.AccessToken)
+	a.Equal("ghr_new", s.RefreshToken)
+	a.True(s.ExpiresAt.After(time.Now().Add(7 * time.Hour)))
+	a.True(s.RefreshExpiresAt.After(time.Now().Add(180 * 24 * time.Hour)))
+	a.Equal(int64(42), s.InstallationID)
+
+	token, err := p.RefreshToken(s.RefreshToken)
+	a.NoError(err)
+	a.Equal("ghu_refresh_token", token.AccessToken)
+
+	installations, err := p.Installations(context.Background(), s.AccessToken)
+	a.NoError(err)
+	a.Equal([]github.Installation{{ID: 42, AppID: 7, Account: "acme", TargetType: "Organization"}}, installations)
+}
+
 func Test_Implements_Provider(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
@@ -71,3 +227,79 @@ func githubProvider() *github.Provider {
 func urlCustomisedURLProvider() *github.Provider {
 	return github.NewCustomisedURL(os.Getenv("GITHUB_KEY"), os.Getenv("GITHUB_SECRET"), "/foo", "http://authURL", "http://tokenURL", "http://profileURL", "http://emailURL")
 }
+
+func Test_Conformance(t *testing.T) {
+	t.Parallel()
+	gothtest.RunProviderConformance(t, githubProvider(), gothtest.Fixtures{})
+}
+
+func Test_IntrospectToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/applications/myapp/token":
+			a.Equal("POST", r.Method)
+			user, pass, _ := r.BasicAuth()
+			a.Equal("myapp", user)
+			a.Equal("secret", pass)
+			var body struct {
+				AccessToken string `json:"access_token"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.AccessToken != "1234567890" {
+				// GitHub does not know tokens of other apps
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, `{"token":"1234567890","app":{"client_id":"myapp"},"user":{"id":1,"login":"octocat"}}`)
+		case "/user":
+			fmt.Fprint(w, `{"id":1,"login":"octocat","email":"octocat@github.com"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	p := github.NewCustomisedURL("myapp", "secret", "/foo", ts.URL+"/authorize", ts.URL+"/token", ts.URL+"/user", ts.URL+"/user/emails")
+	user, err := p.IntrospectToken(context.Background(), "1234567890")
+	a.NoError(err)
+	a.Equal("1", user.UserID)
+	a.Equal("octocat", user.NickName)
+
+	_, err = p.IntrospectToken(context.Background(), "other-app-token")
+	a.IsType(&goth.ErrTokenAudience{}, err)
+}
+
+func Test_FetchUserOrganizations_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			fmt.Fprint(w, `{"id":1,"login":"octocat","email":"octocat@github.com"}`)
+		case "/user/orgs":
+			fmt.Fprint(w, `[{"login":"acme"}]`)
+		case "/user/teams":
+			fmt.Fprint(w, `[{"slug":"admins","organization":{"login":"acme"}}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	p := github.NewCustomisedURL(os.Getenv("GITHUB_KEY"), os.Getenv("GITHUB_SECRET"), "/foo", ts.URL+"/authorize", ts.URL+"/token", ts.URL+"/user", ts.URL+"/user/emails", "user")
+	p.SetFetchOrganizations(true)
+	user, err := p.FetchUser(&github.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("octocat", raw["login"])
+	a.Equal([]string{"acme"}, raw["organizations"])
+	a.Equal([]string{"acme/admins"}, raw["teams"])
+}
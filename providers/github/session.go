@@ -1,17 +1,28 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
 )
 
 // Session stores data during the auth process with GitHub.
 type Session struct {
 	AuthURL     string
 	AccessToken string
+	// RefreshToken, ExpiresAt and RefreshExpiresAt are only set for the
+	// expiring user tokens of GitHub Apps.
+	RefreshToken     string
+	ExpiresAt        time.Time
+	RefreshExpiresAt time.Time
+	// InstallationID is set when the user installed the GitHub App while
+	// authorizing it.
+	InstallationID int64
 }
 
 // GetAuthURL will return the URL set by calling the `BeginAuth` function on the GitHub provider.
@@ -24,8 +35,13 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with GitHub and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.config.Exchange(goth.ContextForClient(p.Client()), params.Get("code"))
+	token, err := p.config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"))
 	if err != nil {
 		return "", err
 	}
@@ -35,9 +51,31 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 	}
 
 	s.AccessToken = token.AccessToken
+	s.RefreshToken = token.RefreshToken
+	s.ExpiresAt = token.Expiry
+	s.RefreshExpiresAt = refreshExpiry(token)
+	if id := params.Get("installation_id"); id != "" {
+		s.InstallationID, _ = strconv.ParseInt(id, 10, 64)
+	}
 	return token.AccessToken, err
 }
 
+// refreshExpiry returns when the refresh token of a GitHub App user token
+// expires, or the zero time if it does not.
+func refreshExpiry(token *oauth2.Token) time.Time {
+	var seconds int64
+	switch v := token.Extra("refresh_token_expires_in").(type) {
+	case float64:
+		seconds = int64(v)
+	case string:
+		seconds, _ = strconv.ParseInt(v, 10, 64)
+	}
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return goth.GetClock().Now().Add(time.Duration(seconds) * time.Second)
+}
+
 // Marshal the session into a string
 func (s Session) Marshal() string {
 	b, _ := json.Marshal(s)
@@ -51,6 +89,6 @@ func (s Session) String() string {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	sess := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(sess)
+	err := goth.DecodeSession(data, sess)
 	return sess, err
 }
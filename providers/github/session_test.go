@@ -36,7 +36,7 @@ func Test_ToJSON(t *testing.T) {
 	s := &github.Session{}
 
 	data := s.Marshal()
-	a.Equal(data, `{"AuthURL":"","AccessToken":""}`)
+	a.Equal(data, `{"AuthURL":"","AccessToken":"","RefreshToken":"","ExpiresAt":"0001-01-01T00:00:00Z","RefreshExpiresAt":"0001-01-01T00:00:00Z","InstallationID":0}`)
 }
 
 func Test_String(t *testing.T) {
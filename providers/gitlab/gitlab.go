@@ -4,6 +4,7 @@ package gitlab
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,13 +12,15 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
 )
 
 // These vars define the Authentication, Token, and Profile URLS for Gitlab. If
-// using Gitlab CE or EE, you should change these values before calling New.
+// using Gitlab CE or EE, you should change these values before calling New,
+// or use NewWithBaseURL.
 //
 // Examples:
 //
@@ -41,6 +44,44 @@ type Provider struct {
 	authURL      string
 	tokenURL     string
 	profileURL   string
+	apiURL       string
+	fetchGroups  bool
+}
+
+// ScopeReadAPI is the scope needed to fetch the user's groups, see SetFetchGroups.
+const ScopeReadAPI = "read_api"
+
+// AccessLevel is the role a user has in a GitLab group.
+type AccessLevel int
+
+// Access levels, see https://docs.gitlab.com/ee/api/members.html#roles
+const (
+	AccessLevelGuest      AccessLevel = 10
+	AccessLevelReporter   AccessLevel = 20
+	AccessLevelDeveloper  AccessLevel = 30
+	AccessLevelMaintainer AccessLevel = 40
+	AccessLevelOwner      AccessLevel = 50
+)
+
+var accessLevelNames = map[AccessLevel]string{
+	AccessLevelGuest:      "guest",
+	AccessLevelReporter:   "reporter",
+	AccessLevelDeveloper:  "developer",
+	AccessLevelMaintainer: "maintainer",
+	AccessLevelOwner:      "owner",
+}
+
+func (l AccessLevel) String() string {
+	if name, ok := accessLevelNames[l]; ok {
+		return name
+	}
+	return strconv.Itoa(int(l))
+}
+
+// GroupMembership is a group the user is a member of, with the user's access level.
+type GroupMembership struct {
+	Path        string      `json:"path"`
+	AccessLevel AccessLevel `json:"access_level"`
 }
 
 // New creates a new Gitlab provider and sets up important connection details.
@@ -50,6 +91,13 @@ func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
 	return NewCustomisedURL(clientKey, secret, callbackURL, AuthURL, TokenURL, ProfileURL, scopes...)
 }
 
+// NewWithBaseURL is like New but for a self-managed GitLab instance at
+// baseURL, e.g. https://gitlab.acme.com.
+func NewWithBaseURL(clientKey, secret, callbackURL, baseURL string, scopes ...string) *Provider {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return NewCustomisedURL(clientKey, secret, callbackURL, baseURL+"/oauth/authorize", baseURL+"/oauth/token", baseURL+"/api/v4/user", scopes...)
+}
+
 // NewCustomisedURL is similar to New(...) but can be used to set custom URLs to connect to
 func NewCustomisedURL(clientKey, secret, callbackURL, authURL, tokenURL, profileURL string, scopes ...string) *Provider {
 	p := &Provider{
@@ -58,6 +106,7 @@ func NewCustomisedURL(clientKey, secret, callbackURL, authURL, tokenURL, profile
 		CallbackURL:  callbackURL,
 		providerName: "gitlab",
 		profileURL:   profileURL,
+		apiURL:       strings.TrimSuffix(profileURL, "/user"),
 	}
 	p.config = newConfig(p, authURL, tokenURL, scopes)
 	return p
@@ -80,6 +129,24 @@ func (p *Provider) Client() *http.Client {
 // Debug is a no-op for the gitlab package.
 func (p *Provider) Debug(debug bool) {}
 
+// SetFetchGroups makes FetchUser fill in User.Groups with the full paths of
+// the groups the user is a member of, suffixed with the user's access level,
+// e.g. "acme/platform:maintainer". The memberships are also stored in
+// RawData["group_memberships"] as []GroupMembership. ScopeReadAPI is added to
+// the requested scopes unless the api scope is requested.
+func (p *Provider) SetFetchGroups(fetch bool) {
+	p.fetchGroups = fetch
+	if !fetch {
+		return
+	}
+	for _, scope := range p.config.Scopes {
+		if scope == ScopeReadAPI || scope == "api" {
+			return
+		}
+	}
+	p.config.Scopes = append(p.config.Scopes, ScopeReadAPI)
+}
+
 // BeginAuth asks Gitlab for an authentication end-point.
 func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 	return &Session{
@@ -89,6 +156,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Gitlab and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken:  sess.AccessToken,
@@ -102,7 +174,11 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	response, err := p.Client().Get(p.profileURL + "?access_token=" + url.QueryEscape(sess.AccessToken))
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL+"?access_token="+url.QueryEscape(sess.AccessToken), nil)
+	if err != nil {
+		return user, err
+	}
+	response, err := p.Client().Do(req)
 	if err != nil {
 		if response != nil {
 			response.Body.Close()
@@ -121,14 +197,99 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, err
 	}
 
-	err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
+	err = user.SetRawJSON(bits)
 	if err != nil {
 		return user, err
 	}
 
 	err = userFromReader(bytes.NewReader(bits), &user)
+	if err != nil || !p.fetchGroups {
+		return user, err
+	}
 
-	return user, err
+	memberships, err := p.groupMemberships(ctx, sess.AccessToken)
+	if err != nil {
+		return user, err
+	}
+	user.Groups = make([]string, 0, len(memberships))
+	for _, m := range memberships {
+		user.Groups = append(user.Groups, m.Path+":"+m.AccessLevel.String())
+	}
+	user.SetRawData("group_memberships", memberships)
+	return user, nil
+}
+
+// groupMemberships returns the groups the user is a member of. The groups API
+// doesn't return the user's access level, so the groups are listed once per
+// access level, highest first, with min_access_level set.
+func (p *Provider) groupMemberships(ctx context.Context, accessToken string) ([]GroupMembership, error) {
+	var memberships []GroupMembership
+	seen := map[string]bool{}
+	for _, level := range []AccessLevel{AccessLevelOwner, AccessLevelMaintainer, AccessLevelDeveloper, AccessLevelReporter, AccessLevelGuest} {
+		url := fmt.Sprintf("%s/groups?min_access_level=%d&per_page=100", p.apiURL, level)
+		err := p.getPages(ctx, url, accessToken, func(r io.Reader) error {
+			var groups []struct {
+				FullPath string `json:"full_path"`
+			}
+			if err := json.NewDecoder(r).Decode(&groups); err != nil {
+				return err
+			}
+			for _, g := range groups {
+				if !seen[g.FullPath] {
+					seen[g.FullPath] = true
+					memberships = append(memberships, GroupMembership{Path: g.FullPath, AccessLevel: level})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return memberships, nil
+}
+
+// getPages calls fn with the body of every page of the paginated API
+// response at url.
+func (p *Provider) getPages(ctx context.Context, url, accessToken string, fn func(io.Reader) error) error {
+	for url != "" {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Add("Authorization", "Bearer "+accessToken)
+		response, err := p.Client().Do(req)
+		if err != nil {
+			return err
+		}
+		if response.StatusCode != http.StatusOK {
+			response.Body.Close()
+			return fmt.Errorf("%s responded with a %d trying to fetch %s", p.providerName, response.StatusCode, req.URL.Path)
+		}
+		err = fn(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return err
+		}
+		url = nextPage(response.Header.Get("Link"))
+	}
+	return nil
+}
+
+// nextPage returns the URL of the rel="next" link of a Link header.
+func nextPage(link string) string {
+	for _, l := range strings.Split(link, ",") {
+		parts := strings.Split(l, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			}
+		}
+	}
+	return ""
 }
 
 func newConfig(provider *Provider, authURL, tokenURL string, scopes []string) *oauth2.Config {
@@ -178,8 +339,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
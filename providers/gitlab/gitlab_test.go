@@ -1,6 +1,9 @@
 package gitlab_test
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -65,3 +68,87 @@ func provider() *gitlab.Provider {
 func urlCustomisedURLProvider() *gitlab.Provider {
 	return gitlab.NewCustomisedURL(os.Getenv("GITLAB_KEY"), os.Getenv("GITLAB_SECRET"), "/foo", "http://authURL", "http://tokenURL", "http://profileURL")
 }
+
+func Test_NewWithBaseURL(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := gitlab.NewWithBaseURL("key", "secret", "/foo", "https://gitlab.acme.com/", "read_user")
+	p.SetFetchGroups(true)
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*gitlab.Session)
+	a.Contains(s.AuthURL, "https://gitlab.acme.com/oauth/authorize")
+	a.Contains(s.AuthURL, "scope=read_user+read_api")
+}
+
+func Test_FetchGroups(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/user":
+			fmt.Fprint(w, `{"id":1,"username":"jdoe","name":"John Doe","email":"jdoe@acme.com"}`)
+		case "/api/v4/groups":
+			a.Equal("Bearer 1234567890", r.Header.Get("Authorization"))
+			switch r.URL.Query().Get("min_access_level") + "/" + r.URL.Query().Get("page") {
+			case "50/":
+				fmt.Fprint(w, `[]`)
+			case "40/":
+				fmt.Fprint(w, `[{"full_path":"acme/platform"}]`)
+			case "30/":
+				w.Header().Set("Link", fmt.Sprintf(`<%s/api/v4/groups?min_access_level=30&page=2>; rel="next"`, ts.URL))
+				fmt.Fprint(w, `[{"full_path":"acme/platform"}]`)
+			case "30/2":
+				fmt.Fprint(w, `[{"full_path":"acme"}]`)
+			default:
+				fmt.Fprint(w, `[{"full_path":"acme/platform"},{"full_path":"acme"},{"full_path":"oss"}]`)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	p := gitlab.NewWithBaseURL("key", "secret", "/foo", ts.URL)
+	p.SetFetchGroups(true)
+	user, err := p.FetchUser(&gitlab.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("1", user.UserID)
+	a.Equal([]string{"acme/platform:maintainer", "acme:developer", "oss:reporter"}, user.Groups)
+	a.Equal([]gitlab.GroupMembership{
+		{Path: "acme/platform", AccessLevel: gitlab.AccessLevelMaintainer},
+		{Path: "acme", AccessLevel: gitlab.AccessLevelDeveloper},
+		{Path: "oss", AccessLevel: gitlab.AccessLevelReporter},
+	}, user.RawData["group_memberships"])
+}
+
+func Test_FetchGroups_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/user":
+			fmt.Fprint(w, `{"id":1,"username":"jdoe","name":"John Doe","email":"jdoe@acme.com"}`)
+		case "/api/v4/groups":
+			fmt.Fprint(w, `[{"full_path":"acme"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	p := gitlab.NewWithBaseURL("key", "secret", "/foo", ts.URL)
+	p.SetFetchGroups(true)
+	user, err := p.FetchUser(&gitlab.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("jdoe", raw["username"])
+	a.Equal([]gitlab.GroupMembership{{Path: "acme", AccessLevel: gitlab.AccessLevelOwner}}, raw["group_memberships"])
+}
@@ -3,18 +3,27 @@
 package google
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
 )
 
 const endpointProfile string = "https://www.googleapis.com/oauth2/v2/userinfo"
+const endpointRevoke string = "https://oauth2.googleapis.com/revoke"
+const endpointTokenInfo string = "https://oauth2.googleapis.com/tokeninfo"
+const endpointGroups string = "https://cloudidentity.googleapis.com/v1/groups/-/memberships:searchDirectGroups"
+
+// ScopeGroups is the scope needed to fetch the user's groups, see SetFetchGroups.
+const ScopeGroups = "https://www.googleapis.com/auth/cloud-identity.groups.readonly"
 
 // New creates a new Google provider, and sets up important connection details.
 // You should always call `google.New` to get a new Provider. Never try to create
@@ -45,6 +54,24 @@ type Provider struct {
 	config          *oauth2.Config
 	authCodeOptions []oauth2.AuthCodeOption
 	providerName    string
+	hostedDomain    string
+	jwksOnce        sync.Once
+	jwks            *goth.JWKSCache
+	fetchGroups     bool
+}
+
+// ErrHostedDomain is returned by FetchUser when the user does not belong to
+// the hosted domain set with SetHostedDomain.
+type ErrHostedDomain struct {
+	Want string
+	Got  string
+}
+
+func (e *ErrHostedDomain) Error() string {
+	if e.Got == "" {
+		return fmt.Sprintf("google: user does not belong to the hosted domain %s", e.Want)
+	}
+	return fmt.Sprintf("google: user belongs to the hosted domain %s, not %s", e.Got, e.Want)
 }
 
 // Name is the name used to retrieve this provider later.
@@ -74,18 +101,40 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 	return session, nil
 }
 
+// BeginAuthWithScopes is like BeginAuth but asks the user to grant scopes in
+// addition to those already granted to the application, for incremental
+// authorization. The resulting session's Scopes hold all granted scopes.
+//
+// With gothic, which always calls BeginAuth, the same is achieved by
+// registering a second provider under another name, created with the
+// additional scopes and SetIncludeGrantedScopes(true).
+// See https://developers.google.com/identity/protocols/oauth2/web-server#incrementalAuth
+func (p *Provider) BeginAuthWithScopes(state string, scopes ...string) (goth.Session, error) {
+	c := *p.config
+	c.Scopes = scopes
+	opts := append(p.authCodeOptions[:len(p.authCodeOptions):len(p.authCodeOptions)], includeGrantedScopes)
+	return &Session{AuthURL: c.AuthCodeURL(state, opts...)}, nil
+}
+
 type googleUser struct {
 	ID        string `json:"id"`
 	Email     string `json:"email"`
+	Verified  bool   `json:"verified_email"`
 	Name      string `json:"name"`
 	FirstName string `json:"given_name"`
 	LastName  string `json:"family_name"`
 	Link      string `json:"link"`
 	Picture   string `json:"picture"`
+	HD        string `json:"hd"`
 }
 
 // FetchUser will go to Google and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken:  sess.AccessToken,
@@ -100,7 +149,11 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	response, err := p.Client().Get(endpointProfile + "?access_token=" + url.QueryEscape(sess.AccessToken))
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointProfile+"?access_token="+url.QueryEscape(sess.AccessToken), nil)
+	if err != nil {
+		return user, err
+	}
+	response, err := p.Client().Do(req)
 	if err != nil {
 		return user, err
 	}
@@ -119,6 +172,14 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	if err := json.Unmarshal(responseBytes, &u); err != nil {
 		return user, err
 	}
+	if err := p.checkHostedDomain(sess.IDToken, u.HD); err != nil {
+		return user, err
+	}
+	if p.fetchGroups {
+		if user.Groups, err = p.groups(ctx, sess.AccessToken, u.Email); err != nil {
+			return user, err
+		}
+	}
 
 	// Extract the user data we got from Google into our goth.User.
 	user.Name = u.Name
@@ -126,16 +187,140 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	user.LastName = u.LastName
 	user.NickName = u.Name
 	user.Email = u.Email
+	user.EmailVerified = u.Verified
 	user.AvatarURL = u.Picture
 	user.UserID = u.ID
 	// Google provides other useful fields such as 'hd'; get them from RawData
-	if err := json.Unmarshal(responseBytes, &user.RawData); err != nil {
+	if err := user.SetRawJSON(responseBytes); err != nil {
 		return user, err
 	}
 
 	return user, nil
 }
 
+// RevokeToken revokes an access or refresh token, together with the grant it belongs to.
+func (p *Provider) RevokeToken(ctx context.Context, token string) error {
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpointRevoke, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with a %d trying to revoke a token", p.providerName, response.StatusCode)
+	}
+	return nil
+}
+
+// IntrospectToken validates a bare access token with Google's tokeninfo
+// endpoint, and fetches the user it was issued to. Tokens issued to other
+// clients are rejected with an *goth.ErrTokenAudience.
+func (p *Provider) IntrospectToken(ctx context.Context, accessToken string) (goth.User, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointTokenInfo+"?access_token="+url.QueryEscape(accessToken), nil)
+	if err != nil {
+		return goth.User{}, err
+	}
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return goth.User{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return goth.User{}, fmt.Errorf("%s responded with a %d trying to validate a token", p.providerName, response.StatusCode)
+	}
+
+	var info struct {
+		Audience        string `json:"aud"`
+		AuthorizedParty string `json:"azp"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&info); err != nil {
+		return goth.User{}, err
+	}
+	if info.Audience != p.ClientKey && info.AuthorizedParty != p.ClientKey {
+		return goth.User{}, &goth.ErrTokenAudience{Provider: p.providerName, Audience: info.Audience}
+	}
+	return p.FetchUserContext(ctx, &Session{AccessToken: accessToken})
+}
+
+type groupsResponse struct {
+	Memberships []struct {
+		GroupKey struct {
+			ID string `json:"id"`
+		} `json:"groupKey"`
+	} `json:"memberships"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// groups returns the email addresses of the Workspace groups email is a
+// direct member of, following all result pages.
+func (p *Provider) groups(ctx context.Context, accessToken, email string) ([]string, error) {
+	var groups []string
+	pageToken := ""
+	for {
+		q := url.Values{
+			"query":    {fmt.Sprintf("member_key_id == %s && 'cloudidentity.googleapis.com/groups.discussion_forum' in labels", strconv.Quote(email))},
+			"pageSize": {"500"},
+		}
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", endpointGroups+"?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		response, err := p.Client().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var page groupsResponse
+		err = json.NewDecoder(response.Body).Decode(&page)
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s responded with a %d trying to fetch groups", p.providerName, response.StatusCode)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range page.Memberships {
+			groups = append(groups, m.GroupKey.ID)
+		}
+		if page.NextPageToken == "" || page.NextPageToken == pageToken {
+			return groups, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// checkHostedDomain verifies that the user belongs to the hosted domain set
+// with SetHostedDomain. The hd claim of the id_token is used if there is one,
+// otherwise the hd field of the userinfo response. The id_token's signature is
+// not checked, as it was received from Google's token endpoint over TLS.
+func (p *Provider) checkHostedDomain(idToken, userinfoHD string) error {
+	if p.hostedDomain == "" {
+		return nil
+	}
+	hd := userinfoHD
+	if idToken != "" {
+		var claims struct {
+			HostedDomain string `json:"hd"`
+		}
+		if err := goth.DecodeJWTClaims(idToken, &claims); err != nil {
+			return err
+		}
+		hd = claims.HostedDomain
+	}
+	if hd != "" && (p.hostedDomain == "*" || strings.EqualFold(hd, p.hostedDomain)) {
+		return nil
+	}
+	return &ErrHostedDomain{Want: p.hostedDomain, Got: hd}
+}
+
 func newConfig(provider *Provider, scopes []string) *oauth2.Config {
 	c := &oauth2.Config{
 		ClientID:     provider.ClientKey,
@@ -160,8 +345,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -182,14 +372,49 @@ func (p *Provider) SetPrompt(prompt ...string) {
 
 // SetHostedDomain sets the hd parameter for google OAuth call.
 // Use this to force user to pick user from specific hosted domain.
+// FetchUser then fails with an *ErrHostedDomain for users outside of it, as
+// the parameter alone can be removed from the authentication URL. Pass "*" to
+// accept users of any Workspace domain, but no consumer accounts.
 // See https://developers.google.com/identity/protocols/oauth2/openid-connect#hd-param
 func (p *Provider) SetHostedDomain(hd string) {
 	if hd == "" {
 		return
 	}
+	p.hostedDomain = hd
 	p.authCodeOptions = append(p.authCodeOptions, oauth2.SetAuthURLParam("hd", hd))
 }
 
+var includeGrantedScopes = oauth2.SetAuthURLParam("include_granted_scopes", "true")
+
+// SetIncludeGrantedScopes sets the include_granted_scopes parameter for the
+// Google OAuth call. The resulting access token then covers the scopes
+// granted to the application before as well as the requested ones.
+// See https://developers.google.com/identity/protocols/oauth2/web-server#incrementalAuth
+func (p *Provider) SetIncludeGrantedScopes(include bool) {
+	if !include {
+		return
+	}
+	p.authCodeOptions = append(p.authCodeOptions, includeGrantedScopes)
+}
+
+// SetFetchGroups makes FetchUser fill in User.Groups with the email
+// addresses of the Workspace groups the user is a direct member of, using the
+// Cloud Identity API. ScopeGroups is added to the requested scopes; the API
+// must be enabled for the application's Cloud project.
+// See https://cloud.google.com/identity/docs/how-to/query-memberships
+func (p *Provider) SetFetchGroups(fetch bool) {
+	p.fetchGroups = fetch
+	if !fetch {
+		return
+	}
+	for _, scope := range p.config.Scopes {
+		if scope == ScopeGroups {
+			return
+		}
+	}
+	p.config.Scopes = append(p.config.Scopes, ScopeGroups)
+}
+
 // SetLoginHint sets the login_hint parameter for the Google OAuth call.
 // Use this to prompt the user to log in with a specific account.
 // See https://developers.google.com/identity/protocols/oauth2/openid-connect#login-hint
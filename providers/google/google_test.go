@@ -1,11 +1,18 @@
 package google_test
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/google"
 	"github.com/stretchr/testify/assert"
 )
@@ -75,6 +82,160 @@ func Test_BeginAuthWithHostedDomain(t *testing.T) {
 	a.Contains(s.AuthURL, "hd=example.com")
 }
 
+// jsonClient serves body for every request, standing in for Google's endpoints.
+func jsonClient(body string) *http.Client {
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+}
+
+func idToken(claims string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"RS256"}`)) + "." + enc([]byte(claims)) + ".sig"
+}
+
+func Test_FetchUserWithHostedDomain(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	fetch := func(hd string, sess *google.Session, userinfo string) error {
+		provider := googleProvider()
+		provider.SetHostedDomain(hd)
+		provider.HTTPClient = jsonClient(userinfo)
+		sess.AccessToken = "1234567890"
+		_, err := provider.FetchUser(sess)
+		return err
+	}
+
+	a.NoError(fetch("example.com", &google.Session{IDToken: idToken(`{"sub":"1","hd":"example.com"}`)}, `{"id":"1"}`))
+	a.NoError(fetch("*", &google.Session{IDToken: idToken(`{"sub":"1","hd":"example.com"}`)}, `{"id":"1"}`))
+	// bearer tokens have no id_token, so the userinfo response is used
+	a.NoError(fetch("example.com", &google.Session{}, `{"id":"1","hd":"example.com"}`))
+
+	err := fetch("example.com", &google.Session{IDToken: idToken(`{"sub":"1","hd":"evil.com"}`)}, `{"id":"1","hd":"example.com"}`)
+	a.Equal(&google.ErrHostedDomain{Want: "example.com", Got: "evil.com"}, err)
+	err = fetch("*", &google.Session{IDToken: idToken(`{"sub":"1"}`)}, `{"id":"1"}`)
+	a.IsType(&google.ErrHostedDomain{}, err)
+	a.Error(fetch("example.com", &google.Session{}, `{"id":"1"}`))
+	a.Error(fetch("example.com", &google.Session{IDToken: "garbage"}, `{"id":"1","hd":"example.com"}`))
+
+	// without a hosted domain any user is accepted
+	a.NoError(fetch("", &google.Session{}, `{"id":"1"}`))
+}
+
+func Test_FetchUserEmailVerified(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := googleProvider()
+	provider.HTTPClient = jsonClient(`{"id":"1","email":"john@example.com","verified_email":true}`)
+	user, err := provider.FetchUser(&google.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("john@example.com", user.Email)
+	a.True(user.EmailVerified)
+}
+
+func Test_BeginAuthWithScopes(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := googleProvider()
+	session, err := provider.BeginAuthWithScopes("test_state", "https://www.googleapis.com/auth/drive.file")
+	a.NoError(err)
+	s := session.(*google.Session)
+	a.Contains(s.AuthURL, "scope=https%3A%2F%2Fwww.googleapis.com%2Fauth%2Fdrive.file")
+	a.NotContains(s.AuthURL, "scope=email")
+	a.Contains(s.AuthURL, "include_granted_scopes=true")
+	a.Contains(s.AuthURL, "access_type=offline")
+
+	// the provider's own options are left alone
+	session, _ = provider.BeginAuth("test_state")
+	a.NotContains(session.(*google.Session).AuthURL, "include_granted_scopes")
+
+	provider.SetIncludeGrantedScopes(true)
+	session, _ = provider.BeginAuth("test_state")
+	a.Contains(session.(*google.Session).AuthURL, "include_granted_scopes=true")
+}
+
+func Test_AuthorizeGrantedScopes(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := googleProvider()
+	provider.HTTPClient = jsonClient(`{"access_token":"1234567890","token_type":"Bearer","expires_in":3600,"scope":"email https://www.googleapis.com/auth/drive.file"}`)
+	session, _ := provider.BeginAuthWithScopes("test_state", "https://www.googleapis.com/auth/drive.file")
+	_, err := session.Authorize(provider, url.Values{"code": {"code"}})
+	a.NoError(err)
+
+	s := session.(*google.Session)
+	a.Equal([]string{"email", "https://www.googleapis.com/auth/drive.file"}, s.Scopes)
+	a.True(s.HasScopes("https://www.googleapis.com/auth/drive.file"))
+	a.True(s.HasScopes("email", "https://www.googleapis.com/auth/drive.file"))
+	a.False(s.HasScopes("https://www.googleapis.com/auth/drive"))
+}
+
+func Test_FetchUserWithGroups(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := googleProvider()
+	provider.SetFetchGroups(true)
+	provider.SetFetchGroups(true)
+	session, _ := provider.BeginAuth("test_state")
+	a.Equal(1, strings.Count(session.(*google.Session).AuthURL, "cloud-identity.groups.readonly"))
+
+	pages := map[string]string{
+		"":   `{"memberships":[{"groupKey":{"id":"eng@example.com"}}],"nextPageToken":"p2"}`,
+		"p2": `{"memberships":[{"groupKey":{"id":"admins@example.com"}}]}`,
+	}
+	provider.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body := `{"id":"1","email":"john@example.com"}`
+		if strings.HasPrefix(req.URL.Host, "cloudidentity") {
+			a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+			a.Contains(req.URL.Query().Get("query"), `member_key_id == "john@example.com"`)
+			body = pages[req.URL.Query().Get("pageToken")]
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+
+	user, err := provider.FetchUser(&google.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal([]string{"eng@example.com", "admins@example.com"}, user.Groups)
+}
+
+func Test_FetchUserWithGroupsQuotesEmail(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := googleProvider()
+	provider.SetFetchGroups(true)
+	provider.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body := `{"id":"1","email":"\"x' || true || '\"@example.com"}`
+		if strings.HasPrefix(req.URL.Host, "cloudidentity") {
+			a.Equal(`member_key_id == "\"x' || true || '\"@example.com" && 'cloudidentity.googleapis.com/groups.discussion_forum' in labels`,
+				req.URL.Query().Get("query"))
+			body = `{}`
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+
+	_, err := provider.FetchUser(&google.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+}
+
 func Test_BeginAuthWithLoginHint(t *testing.T) {
 	// This exists because there was a panic caused by the oauth2 package when
 	// the AuthCodeOption passed was nil. This test uses it, Test_BeginAuth does
@@ -118,3 +279,39 @@ func Test_SessionFromJSON(t *testing.T) {
 func googleProvider() *google.Provider {
 	return google.New(os.Getenv("GOOGLE_KEY"), os.Getenv("GOOGEL_SECRET"), "/foo")
 }
+
+func Test_Conformance(t *testing.T) {
+	t.Parallel()
+	gothtest.RunProviderConformance(t, googleProvider(), gothtest.Fixtures{})
+}
+
+func Test_IntrospectToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	introspect := func(tokeninfo string) (goth.User, error) {
+		provider := google.New("myapp.apps.googleusercontent.com", "secret", "/foo")
+		provider.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body := `{"id":"1","email":"john@example.com"}`
+			if req.URL.Path == "/tokeninfo" {
+				a.Equal("1234567890", req.URL.Query().Get("access_token"))
+				body = tokeninfo
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+				Request:    req,
+			}, nil
+		})}
+		return provider.IntrospectToken(context.Background(), "1234567890")
+	}
+
+	user, err := introspect(`{"aud":"myapp.apps.googleusercontent.com","azp":"myapp.apps.googleusercontent.com","sub":"1"}`)
+	a.NoError(err)
+	a.Equal("1", user.UserID)
+	a.Equal("1234567890", user.AccessToken)
+
+	// tokens of another app the user has authorized are rejected
+	_, err = introspect(`{"aud":"otherapp.apps.googleusercontent.com","azp":"otherapp.apps.googleusercontent.com","sub":"1"}`)
+	a.Equal(&goth.ErrTokenAudience{Provider: "google", Audience: "otherapp.apps.googleusercontent.com"}, err)
+}
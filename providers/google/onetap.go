@@ -0,0 +1,107 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/markbates/goth"
+)
+
+const endpointCerts string = "https://www.googleapis.com/oauth2/v3/certs"
+
+// ErrCSRFToken is returned by CredentialFromRequest when the double-submit
+// g_csrf_token cookie and form field sent by Google Identity Services are
+// missing or differ.
+var ErrCSRFToken = errors.New("google: g_csrf_token cookie and form field do not match")
+
+type credentialClaims struct {
+	jwt.RegisteredClaims
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Picture       string `json:"picture"`
+}
+
+// CredentialFromRequest returns the credential posted to the login_uri of a
+// Google One Tap or Sign In With Google button, after checking its CSRF token.
+func CredentialFromRequest(req *http.Request) (string, error) {
+	cookie, err := req.Cookie("g_csrf_token")
+	if err != nil || cookie.Value == "" || cookie.Value != req.PostFormValue("g_csrf_token") {
+		return "", ErrCSRFToken
+	}
+	credential := req.PostFormValue("credential")
+	if credential == "" {
+		return "", errors.New("google: no credential posted")
+	}
+	return credential, nil
+}
+
+// VerifyCredential validates a Google One Tap or Sign In With Google
+// credential, an id_token, against Google's signing keys and the provider's
+// client ID, and returns the user it was issued to. The returned session
+// holds the credential as its IDToken and can be stored like the session of
+// the redirect flow; it has no access token, so the user is returned here
+// rather than by FetchUser.
+//
+// If a hosted domain is set with SetHostedDomain, users outside of it are
+// rejected with an *ErrHostedDomain.
+// See https://developers.google.com/identity/gsi/web/guides/verify-google-id-token
+func (p *Provider) VerifyCredential(ctx context.Context, credential string) (goth.User, *Session, error) {
+	claims := &credentialClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256"}), jwt.WithoutClaimsValidation())
+	_, err := parser.ParseWithClaims(credential, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.verificationKeys().PublicKey(ctx, endpointCerts, kid)
+	})
+	if err != nil {
+		return goth.User{}, nil, err
+	}
+
+	now := goth.GetClock().Now()
+	switch {
+	case !claims.VerifyAudience(p.ClientKey, true):
+		return goth.User{}, nil, errors.New("google: credential was issued to another client")
+	case !claims.VerifyIssuer("accounts.google.com", true) && !claims.VerifyIssuer("https://accounts.google.com", true):
+		return goth.User{}, nil, fmt.Errorf("google: credential has unexpected issuer %q", claims.Issuer)
+	case !claims.VerifyExpiresAt(now, true):
+		return goth.User{}, nil, errors.New("google: credential has expired")
+	}
+	if err := p.checkHostedDomain(credential, ""); err != nil {
+		return goth.User{}, nil, err
+	}
+
+	sess := &Session{IDToken: credential, ExpiresAt: claims.ExpiresAt.Time}
+	user := goth.User{
+		Provider:      p.Name(),
+		UserID:        claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+		FirstName:     claims.GivenName,
+		LastName:      claims.FamilyName,
+		NickName:      claims.Name,
+		AvatarURL:     claims.Picture,
+		ExpiresAt:     claims.ExpiresAt.Time,
+		IDToken:       credential,
+	}
+	var raw json.RawMessage
+	if err := goth.DecodeJWTClaims(credential, &raw); err != nil {
+		return user, sess, err
+	}
+	err = user.SetRawJSON(raw)
+	return user, sess, err
+}
+
+// verificationKeys returns the cache of Google's id_token signing keys.
+func (p *Provider) verificationKeys() *goth.JWKSCache {
+	p.jwksOnce.Do(func() {
+		p.jwks = goth.NewJWKSCache(p.HTTPClient)
+	})
+	return p.jwks
+}
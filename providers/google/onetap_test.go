@@ -0,0 +1,129 @@
+package google_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/google"
+	"github.com/stretchr/testify/assert"
+)
+
+// certsClient serves a key set holding key for every request, standing in
+// for Google's certs endpoint.
+func certsClient(t *testing.T, key *rsa.PrivateKey) *http.Client {
+	k, err := jwk.New(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Set(jwk.KeyIDKey, "key-1")
+	k.Set(jwk.AlgorithmKey, "RS256")
+	set := jwk.NewSet()
+	set.Add(k)
+	b, _ := json.Marshal(set)
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(string(b))),
+			Request:    req,
+		}, nil
+	})}
+}
+
+func credential(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "key-1"
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func Test_VerifyCredential(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	provider := google.New("client-id", "secret", "/foo")
+	provider.HTTPClient = certsClient(t, key)
+
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":            "https://accounts.google.com",
+			"aud":            "client-id",
+			"sub":            "1234",
+			"email":          "john@example.com",
+			"email_verified": true,
+			"name":           "John Doe",
+			"hd":             "example.com",
+			"exp":            time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	user, sess, err := provider.VerifyCredential(context.Background(), credential(t, key, claims()))
+	a.NoError(err)
+	a.Equal("1234", user.UserID)
+	a.Equal("john@example.com", user.Email)
+	a.True(user.EmailVerified)
+	a.Equal("John Doe", user.Name)
+	a.Equal("example.com", user.RawData["hd"])
+	a.Equal(user.IDToken, sess.IDToken)
+	a.False(sess.ExpiresAt.IsZero())
+
+	for name, mutate := range map[string]func(jwt.MapClaims){
+		"audience": func(c jwt.MapClaims) { c["aud"] = "other-client" },
+		"issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"expired":  func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+	} {
+		c := claims()
+		mutate(c)
+		_, _, err := provider.VerifyCredential(context.Background(), credential(t, key, c))
+		a.Error(err, name)
+	}
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, _, err = provider.VerifyCredential(context.Background(), credential(t, other, claims()))
+	a.Error(err)
+
+	provider.SetHostedDomain("other.com")
+	_, _, err = provider.VerifyCredential(context.Background(), credential(t, key, claims()))
+	a.IsType(&google.ErrHostedDomain{}, err)
+}
+
+func Test_CredentialFromRequest(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	post := func(cookie string, form url.Values) *http.Request {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "g_csrf_token", Value: cookie})
+		}
+		return req
+	}
+
+	credential, err := google.CredentialFromRequest(post("csrf", url.Values{"g_csrf_token": {"csrf"}, "credential": {"jwt"}}))
+	a.NoError(err)
+	a.Equal("jwt", credential)
+
+	_, err = google.CredentialFromRequest(post("csrf", url.Values{"g_csrf_token": {"other"}, "credential": {"jwt"}}))
+	a.Equal(google.ErrCSRFToken, err)
+	_, err = google.CredentialFromRequest(post("", url.Values{"g_csrf_token": {""}, "credential": {"jwt"}}))
+	a.Equal(google.ErrCSRFToken, err)
+	_, err = google.CredentialFromRequest(post("csrf", url.Values{"g_csrf_token": {"csrf"}}))
+	a.Error(err)
+}
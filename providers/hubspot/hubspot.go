@@ -0,0 +1,240 @@
+// Package hubspot implements the OAuth2 protocol for authenticating users
+// through HubSpot.
+package hubspot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL           string = "https://app.hubspot.com/oauth/authorize"
+	tokenURL          string = "https://api.hubapi.com/oauth/v1/token"
+	endpointTokenInfo string = "https://api.hubapi.com/oauth/v1/access-tokens/"
+)
+
+// HubSpot scopes. ScopeOAuth is required by HubSpot and always requested.
+const (
+	ScopeOAuth               = "oauth"
+	ScopeContactsRead        = "crm.objects.contacts.read"
+	ScopeContactsWrite       = "crm.objects.contacts.write"
+	ScopeCompaniesRead       = "crm.objects.companies.read"
+	ScopeCompaniesWrite      = "crm.objects.companies.write"
+	ScopeDealsRead           = "crm.objects.deals.read"
+	ScopeDealsWrite          = "crm.objects.deals.write"
+	ScopeOwnersRead          = "crm.objects.owners.read"
+	ScopeSchemasContactsRead = "crm.schemas.contacts.read"
+	ScopeSettingsUsersRead   = "settings.users.read"
+	ScopeContent             = "content"
+	ScopeTickets             = "tickets"
+	ScopeAutomation          = "automation"
+	ScopeTransactionalEmail  = "transactional-email"
+	ScopeFormsUploadedFiles  = "forms-uploaded-files"
+)
+
+// Keys of User.RawData set by FetchUser, in addition to the rest of the
+// access token information.
+const (
+	// RawDataHubID holds the ID of the HubSpot account (portal) that installed
+	// the app, as a string.
+	RawDataHubID = "hub_id"
+	// RawDataHubDomain holds the domain of the HubSpot account.
+	RawDataHubDomain = "hub_domain"
+	// RawDataScopes holds the scopes granted to the access token, as a
+	// []interface{} of strings.
+	RawDataScopes = "scopes"
+)
+
+// Provider is the implementation of `goth.Provider` for accessing HubSpot.
+type Provider struct {
+	ClientKey      string
+	Secret         string
+	CallbackURL    string
+	HTTPClient     *http.Client
+	config         *oauth2.Config
+	providerName   string
+	optionalScopes []string
+}
+
+// New creates a new HubSpot provider and sets up important connection details.
+// You should always call `hubspot.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "hubspot",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: []string{ScopeOAuth},
+	}
+
+	for _, scope := range scopes {
+		if scope != ScopeOAuth {
+			c.Scopes = append(c.Scopes, scope)
+		}
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the hubspot package.
+func (p *Provider) Debug(debug bool) {}
+
+// SetOptionalScopes sets scopes the app can do without. HubSpot lets the app
+// be installed in accounts that lack them; the scopes actually granted can be
+// found with TokenInfo, or in the RawDataScopes of the user.
+func (p *Provider) SetOptionalScopes(scopes ...string) {
+	p.optionalScopes = scopes
+}
+
+// BeginAuth asks HubSpot for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	var opts []oauth2.AuthCodeOption
+	if len(p.optionalScopes) > 0 {
+		opts = append(opts, oauth2.SetAuthURLParam("optional_scope", strings.Join(p.optionalScopes, " ")))
+	}
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state, opts...),
+	}, nil
+}
+
+// TokenInfo is the information HubSpot has about an access token.
+// See https://developers.hubspot.com/docs/api/oauth/tokens
+type TokenInfo struct {
+	User      string   `json:"user"`
+	UserID    int64    `json:"user_id"`
+	HubID     int64    `json:"hub_id"`
+	HubDomain string   `json:"hub_domain"`
+	AppID     int64    `json:"app_id"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn int      `json:"expires_in"`
+	TokenType string   `json:"token_type"`
+}
+
+// TokenInfo looks up the user, the HubSpot account and the granted scopes of
+// accessToken.
+func (p *Provider) TokenInfo(ctx context.Context, accessToken string) (*TokenInfo, error) {
+	_, info, err := p.tokenInfo(ctx, accessToken)
+	return info, err
+}
+
+func (p *Provider) tokenInfo(ctx context.Context, accessToken string) ([]byte, *TokenInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointTokenInfo+url.PathEscape(accessToken), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s responded with a %d trying to fetch token information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := &TokenInfo{}
+	if err := json.Unmarshal(bits, info); err != nil {
+		return nil, nil, err
+	}
+	return bits, info, nil
+}
+
+// FetchUser will go to HubSpot and access basic information about the user
+// and the account the app was installed in.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	bits, info, err := p.tokenInfo(ctx, sess.AccessToken)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	user.UserID = strconv.FormatInt(info.UserID, 10)
+	user.Email = info.User
+	// hub IDs are returned as numbers, which would decode as float64
+	user.SetRawData(RawDataHubID, strconv.FormatInt(info.HubID, 10))
+	return user, nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token. HubSpot may
+// rotate refresh tokens, so the RefreshToken of the returned token must be
+// stored in place of the old one.
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
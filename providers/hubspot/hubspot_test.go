@@ -0,0 +1,125 @@
+package hubspot_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/hubspot"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("HUBSPOT_KEY"))
+	a.Equal(p.Secret, os.Getenv("HUBSPOT_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := hubspot.New(os.Getenv("HUBSPOT_KEY"), os.Getenv("HUBSPOT_SECRET"), "/foo", hubspot.ScopeContactsRead)
+	p.SetOptionalScopes(hubspot.ScopeDealsRead)
+	session, err := p.BeginAuth("test_state")
+	s := session.(*hubspot.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "https://app.hubspot.com/oauth/authorize")
+	a.Contains(s.AuthURL, "scope=oauth+crm.objects.contacts.read")
+	a.Contains(s.AuthURL, "optional_scope=crm.objects.deals.read")
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://api.hubapi.com/oauth/v1/access-tokens/1234567890", req.URL.String())
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"token":"1234567890","user":"jane@example.com","hub_domain":"demo.hubspot.com",
+			"scopes":["oauth","crm.objects.contacts.read"],"hub_id":62515,"app_id":456,
+			"expires_in":1754,"user_id":123,"token_type":"access"}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&hubspot.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("123", user.UserID)
+	a.Equal("jane@example.com", user.Email)
+	a.Equal("62515", user.RawData[hubspot.RawDataHubID])
+	a.Equal("demo.hubspot.com", user.RawData[hubspot.RawDataHubDomain])
+	a.Equal([]interface{}{"oauth", "crm.objects.contacts.read"}, user.RawData[hubspot.RawDataScopes])
+}
+
+func Test_FetchUser_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"token":"1234567890","user":"jane@example.com","hub_id":62515,"user_id":123}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&hubspot.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("62515", raw[hubspot.RawDataHubID])
+}
+
+func Test_RefreshToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://api.hubapi.com/oauth/v1/token", req.URL.String())
+		body, _ := ioutil.ReadAll(req.Body)
+		a.Contains(string(body), "refresh_token=old")
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(rec, `{"token_type":"bearer","access_token":"1234567890","refresh_token":"new","expires_in":1800}`)
+		return rec.Result(), nil
+	})}
+
+	token, err := p.RefreshToken("old")
+	a.NoError(err)
+	a.Equal("1234567890", token.AccessToken)
+	a.Equal("new", token.RefreshToken)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://app.hubspot.com/oauth/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*hubspot.Session)
+	a.Equal(s.AuthURL, "https://app.hubspot.com/oauth/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *hubspot.Provider {
+	return hubspot.New(os.Getenv("HUBSPOT_KEY"), os.Getenv("HUBSPOT_SECRET"), "/foo")
+}
@@ -1,9 +1,9 @@
 package influxcloud
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"strings"
 
 	"github.com/markbates/goth"
 )
@@ -24,9 +24,14 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with Influxcloud and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
 
-	token, err := p.Config.Exchange(goth.ContextForClient(p.Client()), params.Get("code"))
+	token, err := p.Config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"))
 
 	if err != nil {
 		return "", err
@@ -53,6 +58,6 @@ func (s Session) String() string {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	sess := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(sess)
+	err := goth.DecodeSession(data, sess)
 	return sess, err
 }
@@ -4,6 +4,7 @@ package instagram
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,18 +12,28 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
 )
 
+// The endpoints of the Instagram platform, see
+// https://developers.facebook.com/docs/instagram-platform/instagram-api-with-instagram-login
 var (
-	authURL         = "https://api.instagram.com/oauth/authorize/"
+	authURL         = "https://www.instagram.com/oauth/authorize"
 	tokenURL        = "https://api.instagram.com/oauth/access_token"
-	endPointProfile = "https://api.instagram.com/v1/users/self/"
+	graphURL        = "https://graph.instagram.com"
+	endPointProfile = graphURL + "/me?fields=id,user_id,username,name,account_type,profile_picture_url"
 )
 
+// ScopeBusinessBasic gives access to the profile of the user's professional account.
+const ScopeBusinessBasic = "instagram_business_basic"
+
 // New creates a new Instagram provider, and sets up important connection details.
+// The short-lived token issued on login is exchanged for a long-lived one,
+// valid for 60 days, which can be extended with RefreshLongLivedToken.
 // You should always call `instagram.New` to get a new Provider. Never try to craete
 // one manually.
 func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
@@ -75,10 +86,16 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Instagram and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken: sess.AccessToken,
 		Provider:    p.Name(),
+		ExpiresAt:   sess.ExpiresAt,
 	}
 
 	if user.AccessToken == "" {
@@ -86,7 +103,11 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	response, err := p.Client().Get(endPointProfile + "?access_token=" + url.QueryEscape(sess.AccessToken))
+	req, err := http.NewRequestWithContext(ctx, "GET", endPointProfile+"&access_token="+url.QueryEscape(sess.AccessToken), nil)
+	if err != nil {
+		return user, err
+	}
+	response, err := p.Client().Do(req)
 
 	if err != nil {
 		return user, err
@@ -101,7 +122,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	if err != nil {
 		return user, err
 	}
-	err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
+	err = user.SetRawJSON(bits)
 	if err != nil {
 		return user, err
 	}
@@ -111,29 +132,22 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 
 func userFromReader(reader io.Reader, user *goth.User) error {
 	u := struct {
-		Data struct {
-			ID             string `json:"id"`
-			UserName       string `json:"username"`
-			FullName       string `json:"full_name"`
-			ProfilePicture string `json:"profile_picture"`
-			Bio            string `json:"bio"`
-			Website        string `json:"website"`
-			Counts         struct {
-				Media      int `json:"media"`
-				Follows    int `json:"follows"`
-				FollowedBy int `json:"followed_by"`
-			} `json:"counts"`
-		} `json:"data"`
+		ID             string `json:"id"`
+		UserID         string `json:"user_id"`
+		UserName       string `json:"username"`
+		Name           string `json:"name"`
+		AccountType    string `json:"account_type"`
+		ProfilePicture string `json:"profile_picture_url"`
 	}{}
 	err := json.NewDecoder(reader).Decode(&u)
 	if err != nil {
 		return err
 	}
-	user.UserID = u.Data.ID
-	user.Name = u.Data.FullName
-	user.NickName = u.Data.UserName
-	user.AvatarURL = u.Data.ProfilePicture
-	user.Description = u.Data.Bio
+	// id is app-scoped, user_id is the professional account's Instagram ID
+	user.UserID = u.ID
+	user.Name = u.Name
+	user.NickName = u.UserName
+	user.AvatarURL = u.ProfilePicture
 	return err
 }
 
@@ -143,27 +157,82 @@ func newConfig(p *Provider, scopes []string) *oauth2.Config {
 		ClientSecret: p.Secret,
 		RedirectURL:  p.CallbackURL,
 		Endpoint: oauth2.Endpoint{
-			AuthURL:  authURL,
-			TokenURL: tokenURL,
-		},
-		Scopes: []string{
-			"basic",
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
 		},
 	}
-	defaultScopes := map[string]struct{}{
-		"basic": {},
-	}
 
+	all := []string{ScopeBusinessBasic}
 	for _, scope := range scopes {
-		if _, exists := defaultScopes[scope]; !exists {
-			c.Scopes = append(c.Scopes, scope)
+		if scope != ScopeBusinessBasic {
+			all = append(all, scope)
 		}
 	}
+	// Instagram expects comma separated scopes, which oauth2 would join with spaces.
+	c.Scopes = []string{strings.Join(all, ",")}
 
 	return c
 }
 
-// RefreshToken refresh token is not provided by instagram
+// longLivedToken gets a long-lived token from the Graph API endpoint at path.
+func (p *Provider) longLivedToken(ctx context.Context, path string, v url.Values) (*oauth2.Token, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", graphURL+path+"?"+v.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with a %d trying to get a long-lived token", p.providerName, resp.StatusCode)
+	}
+
+	body := struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.AccessToken == "" {
+		return nil, errors.New("Invalid token received from provider")
+	}
+
+	token := &oauth2.Token{
+		AccessToken: body.AccessToken,
+		TokenType:   body.TokenType,
+	}
+	if body.ExpiresIn > 0 {
+		token.Expiry = goth.GetClock().Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// exchangeLongLived exchanges a short-lived access token for a long-lived one.
+func (p *Provider) exchangeLongLived(ctx context.Context, accessToken string) (*oauth2.Token, error) {
+	return p.longLivedToken(ctx, "/access_token", url.Values{
+		"grant_type":    {"ig_exchange_token"},
+		"client_secret": {p.Secret},
+		"access_token":  {accessToken},
+	})
+}
+
+// RefreshLongLivedToken extends a long-lived access token for another 60
+// days. The token must be at least 24 hours old and not yet expired.
+func (p *Provider) RefreshLongLivedToken(ctx context.Context, accessToken string) (*oauth2.Token, error) {
+	return p.longLivedToken(ctx, "/refresh_access_token", url.Values{
+		"grant_type":   {"ig_refresh_token"},
+		"access_token": {accessToken},
+	})
+}
+
+// RefreshToken refresh token is not provided by instagram, long-lived access
+// tokens are refreshed with RefreshLongLivedToken instead.
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
 	return nil, errors.New("Refresh token is not provided by instagram")
 }
@@ -2,10 +2,14 @@ package instagram_test
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/instagram"
 	"github.com/stretchr/testify/assert"
 )
@@ -32,10 +36,10 @@ func Test_BeginAuth(t *testing.T) {
 	session, err := provider.BeginAuth("test_state")
 	s := session.(*instagram.Session)
 	a.NoError(err)
-	a.Contains(s.AuthURL, "api.instagram.com/oauth/authorize/")
+	a.Contains(s.AuthURL, "www.instagram.com/oauth/authorize")
 	a.Contains(s.AuthURL, fmt.Sprintf("client_id=%s", os.Getenv("INSTAGRAM_KEY")))
 	a.Contains(s.AuthURL, "state=test_state")
-	a.Contains(s.AuthURL, "scope=basic")
+	a.Contains(s.AuthURL, "scope=instagram_business_basic")
 }
 
 func Test_SessionFromJSON(t *testing.T) {
@@ -51,6 +55,43 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal(session.AccessToken, "1234567890")
 }
 
+func Test_Authorize_LongLivedToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := instagramProvider()
+	provider.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		switch req.URL.Host + req.URL.Path {
+		case "api.instagram.com/oauth/access_token":
+			fmt.Fprint(rec, `{"access_token":"short","user_id":17841400000000000,"permissions":"instagram_business_basic"}`)
+		case "graph.instagram.com/access_token":
+			a.Equal("ig_exchange_token", req.URL.Query().Get("grant_type"))
+			a.Equal("short", req.URL.Query().Get("access_token"))
+			fmt.Fprint(rec, `{"access_token":"long","token_type":"bearer","expires_in":5183944}`)
+		case "graph.instagram.com/me":
+			a.Equal("long", req.URL.Query().Get("access_token"))
+			fmt.Fprint(rec, `{"id":"7345234","user_id":"17841400000000000","username":"jayposiris","name":"Jay","account_type":"BUSINESS"}`)
+		default:
+			t.Errorf("unexpected request to %s", req.URL)
+		}
+		return rec.Result(), nil
+	})}
+
+	s := &instagram.Session{}
+	token, err := s.Authorize(provider, url.Values{"code": {"code"}})
+	a.NoError(err)
+	a.Equal("long", token)
+	a.False(s.ExpiresAt.IsZero())
+
+	user, err := provider.FetchUser(s)
+	a.NoError(err)
+	a.Equal("7345234", user.UserID)
+	a.Equal("jayposiris", user.NickName)
+	a.Equal("Jay", user.Name)
+}
+
 func instagramProvider() *instagram.Provider {
-	return instagram.New(os.Getenv("INSTAGRAM_KEY"), os.Getenv("INSTAGRAM_SECRET"), "/foo", "basic")
+	return instagram.New(os.Getenv("INSTAGRAM_KEY"), os.Getenv("INSTAGRAM_SECRET"), "/foo")
 }
@@ -0,0 +1,266 @@
+// Package intuit implements the OAuth2 protocol for authenticating users
+// through Intuit, and connecting apps to QuickBooks Online companies.
+package intuit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL                string = "https://appcenter.intuit.com/connect/oauth2"
+	tokenURL               string = "https://oauth.platform.intuit.com/oauth2/v1/tokens/bearer"
+	endpointJWKS           string = "https://oauth.platform.intuit.com/op/v1/jwks"
+	endpointProfile        string = "https://accounts.platform.intuit.com/v1/openid_connect/userinfo"
+	endpointSandboxProfile string = "https://sandbox-accounts.platform.intuit.com/v1/openid_connect/userinfo"
+)
+
+// IssuerURL is the issuer of the id_tokens returned by Intuit.
+const IssuerURL = "https://oauth.platform.intuit.com/op/v1"
+
+// Intuit scopes. ScopeOpenID, ScopeEmail and ScopeProfile are requested if no
+// scopes are passed to New.
+const (
+	ScopeAccounting = "com.intuit.quickbooks.accounting"
+	ScopePayment    = "com.intuit.quickbooks.payment"
+	ScopeOpenID     = "openid"
+	ScopeProfile    = "profile"
+	ScopeEmail      = "email"
+	ScopePhone      = "phone"
+	ScopeAddress    = "address"
+)
+
+// RawDataRealmID is the key of User.RawData holding the ID of the QuickBooks
+// company (realm) the user connected the app to, which QuickBooks Online API
+// requests are made for. It is only set if an accounting or payment scope was
+// granted.
+const RawDataRealmID = "realmId"
+
+// Provider is the implementation of `goth.Provider` for accessing Intuit.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	sandbox      bool
+	jwks         *goth.JWKSCache
+	jwksOnce     sync.Once
+}
+
+// New creates a new Intuit provider and sets up important connection details.
+// You should always call `intuit.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "intuit",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, ScopeOpenID, ScopeEmail, ScopeProfile)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the intuit package.
+func (p *Provider) Debug(debug bool) {}
+
+// SetSandbox makes FetchUser look users up in Intuit's sandbox environment,
+// for apps using development keys.
+func (p *Provider) SetSandbox(sandbox bool) {
+	p.sandbox = sandbox
+}
+
+// BeginAuth asks Intuit for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to Intuit and access basic information about the user.
+// The ID of the QuickBooks company the app was connected to is stored in
+// RawData under RawDataRealmID.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	endpoint := endpointProfile
+	if p.sandbox {
+		endpoint = endpointSandboxProfile
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	req.Header.Set("Accept", "application/json")
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	u := struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"emailVerified"`
+		GivenName     string `json:"givenName"`
+		FamilyName    string `json:"familyName"`
+		Address       struct {
+			Locality string `json:"locality"`
+			Region   string `json:"region"`
+			Country  string `json:"country"`
+		} `json:"address"`
+	}{}
+	if err := json.Unmarshal(bits, &u); err != nil {
+		return user, err
+	}
+	user.UserID = u.Sub
+	user.Email = u.Email
+	user.EmailVerified = u.EmailVerified
+	user.FirstName = u.GivenName
+	user.LastName = u.FamilyName
+	if u.GivenName != "" && u.FamilyName != "" {
+		user.Name = u.GivenName + " " + u.FamilyName
+	} else {
+		user.Name = u.GivenName + u.FamilyName
+	}
+	user.Location = u.Address.Locality
+	if sess.RealmID != "" {
+		user.SetRawData(RawDataRealmID, sess.RealmID)
+	}
+	return user, nil
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	RealmID string `json:"realmid"`
+}
+
+// verifyIDToken validates the signature of an id_token against Intuit's
+// signing keys, and that it was issued by Intuit to the provider's client.
+func (p *Provider) verifyIDToken(ctx context.Context, idToken string) (*idTokenClaims, error) {
+	claims := &idTokenClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256"}), jwt.WithoutClaimsValidation())
+	_, err := parser.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.verificationKeys().PublicKey(ctx, endpointJWKS, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !claims.VerifyIssuer(IssuerURL, true):
+		return nil, &goth.ErrIssuerMismatch{Provider: p.Name(), Want: IssuerURL, Got: claims.Issuer}
+	case !claims.VerifyAudience(p.ClientKey, true):
+		return nil, fmt.Errorf("intuit: id_token was not issued for %q", p.ClientKey)
+	case !claims.VerifyExpiresAt(goth.GetClock().Now(), true):
+		return nil, errors.New("intuit: id_token has expired")
+	}
+	return claims, nil
+}
+
+// verificationKeys returns the cache of Intuit's id_token signing keys.
+func (p *Provider) verificationKeys() *goth.JWKSCache {
+	p.jwksOnce.Do(func() {
+		p.jwks = goth.NewJWKSCache(p.HTTPClient)
+	})
+	return p.jwks
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token. Intuit
+// rotates refresh tokens periodically, so the RefreshToken of the returned
+// token must be stored in place of the old one.
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
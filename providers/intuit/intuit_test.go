@@ -0,0 +1,213 @@
+package intuit_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/intuit"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("INTUIT_KEY"))
+	a.Equal(p.Secret, os.Getenv("INTUIT_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := intuit.New(os.Getenv("INTUIT_KEY"), os.Getenv("INTUIT_SECRET"), "/foo", intuit.ScopeAccounting, intuit.ScopeOpenID)
+	session, err := p.BeginAuth("test_state")
+	s := session.(*intuit.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "https://appcenter.intuit.com/connect/oauth2")
+	a.Contains(s.AuthURL, "scope=com.intuit.quickbooks.accounting+openid")
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	k, _ := jwk.New(&key.PublicKey)
+	k.Set(jwk.KeyIDKey, "key-1")
+	set := jwk.NewSet()
+	set.Add(k)
+	jwks, _ := json.Marshal(set)
+
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":     intuit.IssuerURL,
+			"aud":     []string{"myapp"},
+			"sub":     "1234",
+			"realmid": "9130",
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	sign := func(key *rsa.PrivateKey, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key-1"
+		s, _ := token.SignedString(key)
+		return s
+	}
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	for name, tc := range map[string]struct {
+		idToken func() string
+		valid   bool
+	}{
+		"valid":       {func() string { return sign(key, claims()) }, true},
+		"otherKey":    {func() string { return sign(other, claims()) }, false},
+		"otherApp":    {func() string { c := claims(); c["aud"] = "otherapp"; return sign(key, c) }, false},
+		"otherIssuer": {func() string { c := claims(); c["iss"] = "https://example.com"; return sign(key, c) }, false},
+		"expired":     {func() string { c := claims(); c["exp"] = time.Now().Add(-time.Hour).Unix(); return sign(key, c) }, false},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			idToken := tc.idToken()
+			p := intuit.New("myapp", "secret", "/foo")
+			p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				rec := httptest.NewRecorder()
+				if req.URL.String() == "https://oauth.platform.intuit.com/op/v1/jwks" {
+					rec.Write(jwks)
+					return rec.Result(), nil
+				}
+				a.Equal("https://oauth.platform.intuit.com/oauth2/v1/tokens/bearer", req.URL.String())
+				rec.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(rec, `{"access_token":"1234567890","refresh_token":"r","token_type":"bearer","expires_in":3600,"id_token":%q}`, idToken)
+				return rec.Result(), nil
+			})}
+
+			s := &intuit.Session{}
+			_, err := s.Authorize(p, url.Values{"code": {"code"}})
+			if !tc.valid {
+				a.Error(err)
+				return
+			}
+			a.NoError(err)
+			a.Equal("1234567890", s.AccessToken)
+			a.Equal(idToken, s.IDToken)
+			a.Equal("9130", s.RealmID)
+		})
+	}
+}
+
+func Test_Authorize_RealmID(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(rec, `{"access_token":"1234567890","refresh_token":"r","token_type":"bearer","expires_in":3600}`)
+		return rec.Result(), nil
+	})}
+
+	s := &intuit.Session{}
+	_, err := s.Authorize(p, url.Values{"code": {"code"}, "realmId": {"4620816365"}})
+	a.NoError(err)
+	a.Equal("4620816365", s.RealmID)
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		sandbox  bool
+		endpoint string
+	}{
+		"production": {false, "https://accounts.platform.intuit.com/v1/openid_connect/userinfo"},
+		"sandbox":    {true, "https://sandbox-accounts.platform.intuit.com/v1/openid_connect/userinfo"},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := provider()
+			p.SetSandbox(tc.sandbox)
+			p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				a.Equal(tc.endpoint, req.URL.String())
+				a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+				rec := httptest.NewRecorder()
+				fmt.Fprint(rec, `{"sub":"1234","email":"jane@example.com","emailVerified":true,
+					"givenName":"Jane","familyName":"Doe","address":{"locality":"Mountain View"}}`)
+				return rec.Result(), nil
+			})}
+
+			user, err := p.FetchUser(&intuit.Session{AccessToken: "1234567890", RealmID: "4620816365"})
+			a.NoError(err)
+			a.Equal("1234", user.UserID)
+			a.Equal("jane@example.com", user.Email)
+			a.True(user.EmailVerified)
+			a.Equal("Jane Doe", user.Name)
+			a.Equal("Mountain View", user.Location)
+			a.Equal("4620816365", user.RawData[intuit.RawDataRealmID])
+		})
+	}
+}
+
+func Test_FetchUser_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"sub":"1234","email":"jane@example.com"}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&intuit.Session{AccessToken: "1234567890", RealmID: "4620816365"})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("1234", raw["sub"])
+	a.Equal("4620816365", raw[intuit.RawDataRealmID])
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://appcenter.intuit.com/connect/oauth2","AccessToken":"1234567890","RealmID":"4620816365"}`)
+	a.NoError(err)
+
+	s := session.(*intuit.Session)
+	a.Equal(s.AuthURL, "https://appcenter.intuit.com/connect/oauth2")
+	a.Equal(s.AccessToken, "1234567890")
+	a.Equal(s.RealmID, "4620816365")
+}
+
+func provider() *intuit.Provider {
+	return intuit.New(os.Getenv("INTUIT_KEY"), os.Getenv("INTUIT_SECRET"), "/foo")
+}
@@ -0,0 +1,240 @@
+// Package jumpcloud implements the OpenID Connect protocol for authenticating
+// users through JumpCloud SSO applications.
+package jumpcloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// IssuerURL is the issuer of JumpCloud's id_tokens, shared by all organizations.
+const IssuerURL = "https://oauth.id.jumpcloud.com/"
+
+// The attribute JumpCloud sends the names of the user's groups in when the
+// application has "Include group attribute" enabled.
+const defaultGroupsClaim = "memberOf"
+
+// Provider is the implementation of `goth.Provider` for accessing JumpCloud.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	issuerURL    string
+	profileURL   string
+	revokeURL    string
+	groupsClaim  string
+}
+
+// New creates a new JumpCloud provider, and sets up important connection
+// details. The openid, profile and email scopes are requested unless other
+// scopes are passed.
+// You should always call `jumpcloud.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "jumpcloud",
+		issuerURL:    IssuerURL,
+		profileURL:   "https://oauth.id.jumpcloud.com/userinfo",
+		revokeURL:    "https://oauth.id.jumpcloud.com/oauth2/revoke",
+		groupsClaim:  defaultGroupsClaim,
+	}
+	p.config = newConfig(p, "https://oauth.id.jumpcloud.com/oauth2", scopes)
+	return p
+}
+
+func newConfig(provider *Provider, baseURL string, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  baseURL + "/auth",
+			TokenURL: baseURL + "/token",
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, "openid", "profile", "email")
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the jumpcloud package.
+func (p *Provider) Debug(debug bool) {}
+
+// SetGroupsClaim sets the claim User.Groups is filled in from. It must match
+// the group attribute name configured for the SSO application, "memberOf" by
+// default.
+func (p *Provider) SetGroupsClaim(claim string) {
+	p.groupsClaim = claim
+}
+
+// BeginAuth asks JumpCloud for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to JumpCloud and access basic information about the user.
+// User.Groups is filled in from the groups claim of the id_token, as JumpCloud
+// doesn't include it in the userinfo response, or of the userinfo response if
+// there is no id_token.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(bytes.NewReader(bits)).Decode(&claims); err != nil {
+		return user, err
+	}
+	user.UserID, _ = claims["sub"].(string)
+	user.Email, _ = claims["email"].(string)
+	user.EmailVerified, _ = claims["email_verified"].(bool)
+	user.Name, _ = claims["name"].(string)
+	user.NickName, _ = claims["preferred_username"].(string)
+	user.FirstName, _ = claims["given_name"].(string)
+	user.LastName, _ = claims["family_name"].(string)
+	user.AvatarURL, _ = claims["picture"].(string)
+	user.Groups = stringList(claims[p.groupsClaim])
+
+	if sess.IDToken != "" {
+		var token map[string]interface{}
+		if goth.DecodeJWTClaims(sess.IDToken, &token) == nil {
+			if groups := stringList(token[p.groupsClaim]); len(groups) > 0 {
+				user.Groups = groups
+			}
+		}
+	}
+	return user, nil
+}
+
+// stringList returns the values of a claim holding a list of strings or a
+// single string.
+func stringList(claim interface{}) []string {
+	var values []string
+	switch c := claim.(type) {
+	case []interface{}:
+		for _, v := range c {
+			if s, ok := v.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+	case string:
+		if c != "" {
+			values = append(values, c)
+		}
+	}
+	return values
+}
+
+// RevokeToken revokes an access or refresh token at JumpCloud's revocation endpoint.
+func (p *Provider) RevokeToken(ctx context.Context, token string) error {
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.revokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.ClientKey), url.QueryEscape(p.Secret))
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with a %d trying to revoke a token", p.providerName, response.StatusCode)
+	}
+	return nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
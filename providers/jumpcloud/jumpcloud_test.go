@@ -0,0 +1,114 @@
+package jumpcloud_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/jumpcloud"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "jumpcloud")
+	a.Equal(p.ClientKey, "myapp")
+	a.Equal(p.Secret, "secret")
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+	a.Implements((*goth.TokenRevoker)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*jumpcloud.Session)
+	a.Contains(s.AuthURL, "https://oauth.id.jumpcloud.com/oauth2/auth")
+	a.Contains(s.AuthURL, "scope=openid+profile+email")
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+
+	for name, claims := range map[string]string{
+		"valid":       `{"iss":"https://oauth.id.jumpcloud.com/","aud":["myapp"]}`,
+		"otherIssuer": `{"iss":"https://example.com/","aud":"myapp"}`,
+	} {
+		name, claims := name, claims
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := provider()
+			p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				a.Equal("https://oauth.id.jumpcloud.com/oauth2/token", req.URL.String())
+				rec := httptest.NewRecorder()
+				rec.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(rec, `{"access_token":"1234567890","token_type":"Bearer","expires_in":3600,"id_token":%q}`, gothtest.UnsignedJWT(claims))
+				return rec.Result(), nil
+			})}
+			session, _ := p.BeginAuth("test_state")
+			_, err := session.Authorize(p, url.Values{"code": {"code"}})
+			if name == "valid" {
+				a.NoError(err)
+			} else {
+				a.IsType(&goth.ErrIssuerMismatch{}, err)
+			}
+		})
+	}
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.SetGroupsClaim("groups")
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://oauth.id.jumpcloud.com/userinfo", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"sub":"5f1b2c","email":"jdoe@example.com","email_verified":true,"name":"John Doe","given_name":"John","family_name":"Doe"}`)
+		return rec.Result(), nil
+	})}
+	user, err := p.FetchUser(&jumpcloud.Session{
+		AccessToken: "1234567890",
+		IDToken:     gothtest.UnsignedJWT(`{"sub":"5f1b2c","groups":["Engineering","VPN Users"]}`),
+	})
+	a.NoError(err)
+	a.Equal("5f1b2c", user.UserID)
+	a.Equal("jdoe@example.com", user.Email)
+	a.True(user.EmailVerified)
+	a.Equal("John", user.FirstName)
+	a.Equal([]string{"Engineering", "VPN Users"}, user.Groups)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://oauth.id.jumpcloud.com/oauth2/auth","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*jumpcloud.Session)
+	a.Equal(s.AuthURL, "https://oauth.id.jumpcloud.com/oauth2/auth")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *jumpcloud.Provider {
+	return jumpcloud.New("myapp", "secret", "/foo")
+}
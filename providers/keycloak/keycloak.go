@@ -0,0 +1,241 @@
+// Package keycloak implements the OpenID Connect protocol for authenticating
+// users through a Keycloak realm.
+package keycloak
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// Provider is the implementation of `goth.Provider` for accessing Keycloak.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	issuerURL    string
+	profileURL   string
+	roleClients  []string
+}
+
+// New creates a new Keycloak provider for the given realm of the Keycloak
+// server at baseURL, and sets up important connection details. Include the
+// /auth path in baseURL for Keycloak versions before 17. The openid, profile
+// and email scopes are requested unless other scopes are passed.
+// You should always call `keycloak.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, baseURL, realm string, scopes ...string) *Provider {
+	issuerURL := strings.TrimSuffix(baseURL, "/") + "/realms/" + realm
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "keycloak",
+		issuerURL:    issuerURL,
+		profileURL:   issuerURL + "/protocol/openid-connect/userinfo",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.issuerURL + "/protocol/openid-connect/auth",
+			TokenURL: provider.issuerURL + "/protocol/openid-connect/token",
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, "openid", "profile", "email")
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the keycloak package.
+func (p *Provider) Debug(debug bool) {}
+
+// IssuerURL returns the issuer of the provider's realm.
+func (p *Provider) IssuerURL() string {
+	return p.issuerURL
+}
+
+// SetRoleClients makes FetchUser also include the client roles of the given
+// clients in User.Roles, as "client:role". The realm roles and the roles of
+// the provider's own client are always included, without a prefix.
+func (p *Provider) SetRoleClients(clients ...string) {
+	p.roleClients = clients
+}
+
+// BeginAuth asks Keycloak for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to Keycloak and access basic information about the user.
+// User.Roles is filled in from the realm_access and resource_access claims
+// of the access token and the userinfo response.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	var info keycloakClaims
+	if err := json.NewDecoder(bytes.NewReader(bits)).Decode(&info); err != nil {
+		return user, err
+	}
+	user.UserID = info.Subject
+	user.Email = info.Email
+	user.EmailVerified = info.EmailVerified
+	user.Name = info.Name
+	user.NickName = info.PreferredUsername
+	user.FirstName = info.GivenName
+	user.LastName = info.FamilyName
+	user.AvatarURL = info.Picture
+	user.Roles = p.roles(info)
+
+	// roles are in the access token by default, and only in the userinfo
+	// response if the role mappers are configured to add them there
+	var token keycloakClaims
+	if goth.DecodeJWTClaims(sess.AccessToken, &token) == nil {
+		user.Roles = appendMissing(user.Roles, p.roles(token)...)
+	}
+	return user, nil
+}
+
+// keycloakClaims are the claims of Keycloak's tokens and userinfo responses.
+type keycloakClaims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	GivenName         string `json:"given_name"`
+	FamilyName        string `json:"family_name"`
+	Picture           string `json:"picture"`
+	RealmAccess       struct {
+		Roles []string `json:"roles"`
+	} `json:"realm_access"`
+	ResourceAccess map[string]struct {
+		Roles []string `json:"roles"`
+	} `json:"resource_access"`
+}
+
+// roles returns the realm roles and the client roles of the provider's own
+// client followed by those of the clients set with SetRoleClients.
+func (p *Provider) roles(c keycloakClaims) []string {
+	roles := appendMissing(nil, c.RealmAccess.Roles...)
+	roles = appendMissing(roles, c.ResourceAccess[p.ClientKey].Roles...)
+	for _, client := range p.roleClients {
+		for _, role := range c.ResourceAccess[client].Roles {
+			roles = appendMissing(roles, client+":"+role)
+		}
+	}
+	return roles
+}
+
+func appendMissing(s []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, existing := range s {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			s = append(s, v)
+		}
+	}
+	return s
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
@@ -0,0 +1,114 @@
+package keycloak_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/keycloak"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "keycloak")
+	a.Equal(p.ClientKey, "myapp")
+	a.Equal(p.Secret, "secret")
+	a.Equal(p.CallbackURL, "/foo")
+	a.Equal("https://sso.example.com/realms/acme", p.IssuerURL())
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*keycloak.Session)
+	a.Contains(s.AuthURL, "https://sso.example.com/realms/acme/protocol/openid-connect/auth")
+	a.Contains(s.AuthURL, "scope=openid+profile+email")
+}
+
+func Test_AuthorizeChecksIssuer(t *testing.T) {
+	t.Parallel()
+
+	for name, claims := range map[string]string{
+		"valid":      `{"iss":"%s/realms/acme","aud":"myapp"}`,
+		"otherRealm": `{"iss":"%s/realms/other","aud":"myapp"}`,
+	} {
+		name, claims := name, claims
+		t.Run(name, func(t *testing.T) {
+			a := assert.New(t)
+			var ts *httptest.Server
+			ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				a.Equal("/realms/acme/protocol/openid-connect/token", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"access_token":"1234567890","token_type":"Bearer","expires_in":300,"id_token":%q}`, gothtest.UnsignedJWT(fmt.Sprintf(claims, ts.URL)))
+			}))
+			defer ts.Close()
+
+			p := keycloak.New("myapp", "secret", "/foo", ts.URL, "acme")
+			session, _ := p.BeginAuth("test_state")
+			_, err := session.Authorize(p, url.Values{"code": {"code"}})
+			if name == "valid" {
+				a.NoError(err)
+			} else {
+				a.IsType(&goth.ErrIssuerMismatch{}, err)
+			}
+		})
+	}
+}
+
+func Test_FetchUserRoles(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("/realms/acme/protocol/openid-connect/userinfo", r.URL.Path)
+		fmt.Fprint(w, `{"sub":"user-1","email":"jdoe@example.com","email_verified":true,"name":"John Doe",
+			"preferred_username":"jdoe","given_name":"John","family_name":"Doe",
+			"realm_access":{"roles":["offline_access"]}}`)
+	}))
+	defer ts.Close()
+
+	p := keycloak.New("myapp", "secret", "/foo", ts.URL, "acme")
+	p.SetRoleClients("billing")
+	accessToken := gothtest.UnsignedJWT(`{"realm_access":{"roles":["offline_access","user"]},
+		"resource_access":{"myapp":{"roles":["editor"]},"billing":{"roles":["viewer"]},"account":{"roles":["manage-account"]}}}`)
+
+	user, err := p.FetchUser(&keycloak.Session{AccessToken: accessToken})
+	a.NoError(err)
+	a.Equal("user-1", user.UserID)
+	a.Equal("jdoe", user.NickName)
+	a.Equal("John", user.FirstName)
+	a.True(user.EmailVerified)
+	a.Equal([]string{"offline_access", "user", "editor", "billing:viewer"}, user.Roles)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://sso.example.com/realms/acme/protocol/openid-connect/auth","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*keycloak.Session)
+	a.Equal(s.AuthURL, "https://sso.example.com/realms/acme/protocol/openid-connect/auth")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *keycloak.Provider {
+	return keycloak.New("myapp", "secret", "/foo", "https://sso.example.com/", "acme")
+}
@@ -3,6 +3,7 @@
 package lastfm
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/xml"
@@ -77,6 +78,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to LastFM and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken: sess.AccessToken,
@@ -112,7 +118,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	}{}
 
 	login := session.(*Session).Login
-	err := p.request(false, map[string]string{"method": "user.getinfo", "user": login}, &u)
+	err := p.request(ctx, false, map[string]string{"method": "user.getinfo", "user": login}, &u)
 
 	if err == nil {
 		user.Name = u.RealName
@@ -127,17 +133,21 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 
 // GetSession token from LastFM
 func (p *Provider) GetSession(token string) (map[string]string, error) {
+	return p.getSession(context.Background(), token)
+}
+
+func (p *Provider) getSession(ctx context.Context, token string) (map[string]string, error) {
 	sess := struct {
 		Name       string `xml:"name"`
 		Key        string `xml:"key"`
 		Subscriber bool   `xml:"subscriber"`
 	}{}
 
-	err := p.request(true, map[string]string{"method": "auth.getSession", "token": token}, &sess)
+	err := p.request(ctx, true, map[string]string{"method": "auth.getSession", "token": token}, &sess)
 	return map[string]string{"login": sess.Name, "token": sess.Key}, err
 }
 
-func (p *Provider) request(sign bool, params map[string]string, result interface{}) error {
+func (p *Provider) request(ctx context.Context, sign bool, params map[string]string, result interface{}) error {
 	urlParams := url.Values{}
 	urlParams.Add("method", params["method"])
 
@@ -152,7 +162,7 @@ func (p *Provider) request(sign bool, params map[string]string, result interface
 
 	uri := endpointProfile + "?" + urlParams.Encode()
 
-	req, err := http.NewRequest("GET", uri, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
 	if err != nil {
 		return err
 	}
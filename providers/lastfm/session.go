@@ -1,9 +1,9 @@
 package lastfm
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"strings"
 
 	"github.com/markbates/goth"
 )
@@ -25,8 +25,13 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with LastFM and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the session request to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	sess, err := p.GetSession(params.Get("token"))
+	sess, err := p.getSession(ctx, params.Get("token"))
 	if err != nil {
 		return "", err
 	}
@@ -49,6 +54,6 @@ func (s Session) String() string {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	sess := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(sess)
+	err := goth.DecodeSession(data, sess)
 	return sess, err
 }
@@ -0,0 +1,234 @@
+// Package linear implements the OAuth2 protocol for authenticating users
+// through Linear, using its GraphQL API for the user's details.
+package linear
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL  string = "https://linear.app/oauth/authorize"
+	tokenURL string = "https://api.linear.app/oauth/token"
+	apiURL   string = "https://api.linear.app/graphql"
+)
+
+// Linear scopes. ScopeRead is requested if no scopes are passed to New.
+const (
+	ScopeRead           = "read"
+	ScopeWrite          = "write"
+	ScopeIssuesCreate   = "issues:create"
+	ScopeCommentsCreate = "comments:create"
+	ScopeAdmin          = "admin"
+)
+
+// Actors for SetActor.
+const (
+	// ActorUser makes the token act as the user who authorized the app.
+	ActorUser = "user"
+	// ActorApplication makes the token act as the app itself, so that
+	// issues and comments it creates are attributed to the app.
+	ActorApplication = "app"
+)
+
+// Provider is the implementation of `goth.Provider` for accessing Linear.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	actor        string
+}
+
+// New creates a new Linear provider, and sets up important connection details.
+// You should always call `linear.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "linear",
+		actor:        ActorUser,
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, ScopeRead)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the linear package.
+func (p *Provider) Debug(debug bool) {}
+
+// SetActor sets who the tokens act as, ActorUser (the default) or
+// ActorApplication. Tokens acting as the application identify the app's own
+// user, so FetchUser returns the app rather than the user who authorized it.
+func (p *Provider) SetActor(actor string) {
+	p.actor = actor
+}
+
+// BeginAuth asks Linear for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	// Linear separates scopes with commas
+	url := p.config.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("scope", strings.Join(p.config.Scopes, ",")),
+		oauth2.SetAuthURLParam("actor", p.actor),
+	)
+	return &Session{
+		AuthURL: url,
+	}, nil
+}
+
+const viewerQuery = `{ viewer { id name displayName email avatarUrl description admin organization { id name urlKey } } }`
+
+// FetchUser will go to Linear and access basic information about the user
+// with the `viewer` query. The user's organization is included in RawData
+// under "organization".
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	body, err := json.Marshal(map[string]string{"query": viewerQuery})
+	if err != nil {
+		return user, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	var result struct {
+		Data struct {
+			Viewer json.RawMessage `json:"viewer"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(bits, &result); err != nil {
+		return user, err
+	}
+	if len(result.Errors) > 0 {
+		return user, fmt.Errorf("%s responded with an error trying to fetch user information: %s", p.providerName, result.Errors[0].Message)
+	}
+	if len(result.Data.Viewer) == 0 || string(result.Data.Viewer) == "null" {
+		return user, errors.New("linear: no user information returned")
+	}
+
+	err = user.SetRawJSON(result.Data.Viewer)
+	if err != nil {
+		return user, err
+	}
+
+	u := struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+		Email       string `json:"email"`
+		AvatarURL   string `json:"avatarUrl"`
+		Description string `json:"description"`
+	}{}
+	if err := json.Unmarshal(result.Data.Viewer, &u); err != nil {
+		return user, err
+	}
+	user.UserID = u.ID
+	user.Name = u.Name
+	user.NickName = u.DisplayName
+	user.Email = u.Email
+	user.AvatarURL = u.AvatarURL
+	user.Description = u.Description
+	return user, nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
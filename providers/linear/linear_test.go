@@ -0,0 +1,112 @@
+package linear_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/linear"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("LINEAR_KEY"))
+	a.Equal(p.Secret, os.Getenv("LINEAR_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := linear.New(os.Getenv("LINEAR_KEY"), os.Getenv("LINEAR_SECRET"), "/foo", linear.ScopeRead, linear.ScopeIssuesCreate)
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*linear.Session)
+	a.Contains(s.AuthURL, "linear.app/oauth/authorize")
+	a.Contains(s.AuthURL, "scope=read%2Cissues%3Acreate")
+	a.Contains(s.AuthURL, "actor=user")
+
+	p.SetActor(linear.ActorApplication)
+	session, err = p.BeginAuth("test_state")
+	a.NoError(err)
+	a.Contains(session.(*linear.Session).AuthURL, "actor=app")
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://api.linear.app/graphql", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		var body struct {
+			Query string `json:"query"`
+		}
+		a.NoError(json.NewDecoder(req.Body).Decode(&body))
+		a.Contains(body.Query, "viewer {")
+
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"data":{"viewer":{"id":"2d1c3a5e-7f4b","name":"Jane Doe","displayName":"jane","email":"jane@example.com",
+			"avatarUrl":"https://example.com/jane.png","description":null,"admin":false,
+			"organization":{"id":"8a9b","name":"Acme","urlKey":"acme"}}}}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&linear.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("2d1c3a5e-7f4b", user.UserID)
+	a.Equal("Jane Doe", user.Name)
+	a.Equal("jane", user.NickName)
+	a.Equal("jane@example.com", user.Email)
+	a.Equal("https://example.com/jane.png", user.AvatarURL)
+	a.Equal("acme", user.RawData["organization"].(map[string]interface{})["urlKey"])
+}
+
+func Test_FetchUser_Error(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"errors":[{"message":"Authentication required, not authenticated"}]}`)
+		return rec.Result(), nil
+	})}
+
+	_, err := p.FetchUser(&linear.Session{AccessToken: "1234567890"})
+	a.Error(err)
+	a.Contains(err.Error(), "Authentication required")
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://linear.app/oauth/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*linear.Session)
+	a.Equal(s.AuthURL, "https://linear.app/oauth/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *linear.Provider {
+	return linear.New(os.Getenv("LINEAR_KEY"), os.Getenv("LINEAR_SECRET"), "/foo")
+}
@@ -2,32 +2,35 @@
 package linkedin
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
-	"net/url"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
 )
 
-// more details about linkedin fields:
-// User Profile and Email Address - https://docs.microsoft.com/en-gb/linkedin/consumer/integrations/self-serve/sign-in-with-linkedin
-// User Avatar - https://docs.microsoft.com/en-gb/linkedin/shared/references/v2/digital-media-asset
+// more details about Sign In with LinkedIn using OpenID Connect:
+// https://learn.microsoft.com/en-us/linkedin/consumer/integrations/self-serve/sign-in-with-linkedin-v2
 
 const (
 	authURL  string = "https://www.linkedin.com/oauth/v2/authorization"
 	tokenURL string = "https://www.linkedin.com/oauth/v2/accessToken"
 
-	// userEndpoint requires scope "r_liteprofile"
-	userEndpoint string = "//api.linkedin.com/v2/me?projection=(id,firstName,lastName,profilePicture(displayImage~:playableStreams))"
-	// emailEndpoint requires scope "r_emailaddress"
-	emailEndpoint string = "//api.linkedin.com/v2/emailAddress?q=members&projection=(elements*(handle~))"
+	// userEndpoint requires scope "openid", and "profile" and "email" for
+	// the respective claims
+	userEndpoint string = "https://api.linkedin.com/v2/userinfo"
 )
 
 // New creates a new linkedin provider, and sets up important connection details.
+// The openid, profile and email scopes are requested unless other scopes are
+// passed; the legacy r_liteprofile and r_emailaddress permissions are no
+// longer supported.
 // You should always call `linkedin.New` to get a new Provider. Never try to create
 // one manually.
 func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
@@ -80,11 +83,17 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Linkedin and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	s := session.(*Session)
 	user := goth.User{
 		AccessToken: s.AccessToken,
 		Provider:    p.Name(),
 		ExpiresAt:   s.ExpiresAt,
+		IDToken:     s.IDToken,
 	}
 
 	if user.AccessToken == "" {
@@ -92,19 +101,10 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	// create request for user r_liteprofile
-	req, err := http.NewRequest("GET", "", nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", userEndpoint, nil)
 	if err != nil {
 		return user, err
 	}
-
-	// add url as opaque to avoid escaping of "("
-	req.URL = &url.URL{
-		Scheme: "https",
-		Host:   "api.linkedin.com",
-		Opaque: userEndpoint,
-	}
-
 	req.Header.Set("Authorization", "Bearer "+s.AccessToken)
 	resp, err := p.Client().Do(req)
 	if err != nil {
@@ -116,70 +116,33 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s responded with a %d trying to fetch user profile", p.providerName, resp.StatusCode)
 	}
 
-	// read r_liteprofile information
-	err = userFromReader(resp.Body, &user)
+	bits, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return user, err
 	}
 
-	// create request for user r_emailaddress
-	reqEmail, err := http.NewRequest("GET", "", nil)
+	err = user.SetRawJSON(bits)
 	if err != nil {
 		return user, err
 	}
 
-	// add url as opaque to avoid escaping of "("
-	reqEmail.URL = &url.URL{
-		Scheme: "https",
-		Host:   "api.linkedin.com",
-		Opaque: emailEndpoint,
-	}
-
-	reqEmail.Header.Set("Authorization", "Bearer "+s.AccessToken)
-	respEmail, err := p.Client().Do(reqEmail)
-	if err != nil {
-		return user, err
-	}
-	defer respEmail.Body.Close()
-
-	if respEmail.StatusCode != http.StatusOK {
-		return user, fmt.Errorf("%s responded with a %d trying to fetch user email", p.providerName, respEmail.StatusCode)
-	}
-
-	// read r_emailaddress information
-	err = emailFromReader(respEmail.Body, &user)
-
+	err = userFromReader(bytes.NewReader(bits), &user)
 	return user, err
 }
 
 func userFromReader(reader io.Reader, user *goth.User) error {
 	u := struct {
-		ID        string `json:"id"`
-		FirstName struct {
-			PreferredLocale struct {
-				Country  string `json:"country"`
-				Language string `json:"language"`
-			} `json:"preferredLocale"`
-			Localized map[string]string `json:"localized"`
-		} `json:"firstName"`
-		LastName struct {
-			Localized       map[string]string
-			PreferredLocale struct {
-				Country  string `json:"country"`
-				Language string `json:"language"`
-			} `json:"preferredLocale"`
-		} `json:"lastName"`
-		ProfilePicture struct {
-			DisplayImage struct {
-				Elements []struct {
-					AuthorizationMethod string `json:"authorizationMethod"`
-					Identifiers         []struct {
-						Identifier     string `json:"identifier"`
-						IdentifierType string `json:"identifierType"`
-					} `json:"identifiers"`
-				} `json:"elements"`
-			} `json:"displayImage~"`
-		} `json:"profilePicture"`
+		Subject       string `json:"sub"`
+		Name          string `json:"name"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+		Picture       string `json:"picture"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Locale        struct {
+			Country  string `json:"country"`
+			Language string `json:"language"`
+		} `json:"locale"`
 	}{}
 
 	err := json.NewDecoder(reader).Decode(&u)
@@ -187,60 +150,16 @@ func userFromReader(reader io.Reader, user *goth.User) error {
 		return err
 	}
 
-	user.FirstName = u.FirstName.Localized[u.FirstName.PreferredLocale.Language+"_"+u.FirstName.PreferredLocale.Country]
-	user.LastName = u.LastName.Localized[u.LastName.PreferredLocale.Language+"_"+u.LastName.PreferredLocale.Country]
-	user.Name = user.FirstName + " " + user.LastName
-	user.NickName = user.FirstName
-	user.UserID = u.ID
-
-	avatarURL := ""
-	// loop all displayimage elements
-	for _, element := range u.ProfilePicture.DisplayImage.Elements {
-		// only retrieve data where the authorization method allows public (unauthorized) access
-		if element.AuthorizationMethod == "PUBLIC" {
-			for _, identifier := range element.Identifiers {
-				// check to ensure the identifier type is a url linking to the image
-				if identifier.IdentifierType == "EXTERNAL_URL" {
-					avatarURL = identifier.Identifier
-					// we only need the first image url
-					break
-				}
-			}
-		}
-		// if we have a valid image, exit the loop as we only support a single avatar image
-		if len(avatarURL) > 0 {
-			break
-		}
-	}
-
-	user.AvatarURL = avatarURL
-
-	return err
-}
-
-func emailFromReader(reader io.Reader, user *goth.User) error {
-	e := struct {
-		Elements []struct {
-			Handle struct {
-				EmailAddress string `json:"emailAddress"`
-			} `json:"handle~"`
-		} `json:"elements"`
-	}{}
-
-	err := json.NewDecoder(reader).Decode(&e)
-	if err != nil {
-		return err
-	}
-
-	if len(e.Elements) > 0 {
-		user.Email = e.Elements[0].Handle.EmailAddress
-	}
-
-	if len(user.Email) == 0 {
-		return errors.New("Unable to retrieve email address")
-	}
-
-	return err
+	user.UserID = u.Subject
+	user.Name = u.Name
+	user.FirstName = u.GivenName
+	user.LastName = u.FamilyName
+	user.NickName = u.GivenName
+	user.AvatarURL = u.Picture
+	user.Email = u.Email
+	user.EmailVerified = u.EmailVerified
+	user.Location = u.Locale.Country
+	return nil
 }
 
 func newConfig(provider *Provider, scopes []string) *oauth2.Config {
@@ -256,8 +175,8 @@ func newConfig(provider *Provider, scopes []string) *oauth2.Config {
 	}
 
 	if len(scopes) == 0 {
-		// add helper as new API requires the scope to be specified and these are the minimum to retrieve profile information and user's email address
-		scopes = append(scopes, "r_liteprofile", "r_emailaddress")
+		// add helper as the API requires the scope to be specified and these are the minimum to retrieve profile information and user's email address
+		scopes = append(scopes, "openid", "profile", "email")
 	}
 
 	for _, scope := range scopes {
@@ -2,10 +2,13 @@ package linkedin_test
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/linkedin"
 	"github.com/stretchr/testify/assert"
 )
@@ -38,7 +41,7 @@ func Test_BeginAuth(t *testing.T) {
 	a.Contains(s.AuthURL, "linkedin.com/oauth/v2/authorization")
 	a.Contains(s.AuthURL, fmt.Sprintf("client_id=%s", os.Getenv("LINKEDIN_KEY")))
 	a.Contains(s.AuthURL, "state=test_state")
-	a.Contains(s.AuthURL, "scope=r_liteprofile+r_emailaddress&state")
+	a.Contains(s.AuthURL, "scope=openid+profile+email&state")
 }
 
 func Test_SessionFromJSON(t *testing.T) {
@@ -54,6 +57,33 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal(session.AccessToken, "1234567890")
 }
 
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := linkedinProvider()
+	provider.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://api.linkedin.com/v2/userinfo", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"sub":"782bbtaQ","name":"John Doe","given_name":"John","family_name":"Doe",
+			"picture":"https://media.licdn.com/dms/image/123","locale":{"country":"US","language":"en"},
+			"email":"jdoe@example.com","email_verified":true}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := provider.FetchUser(&linkedin.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("782bbtaQ", user.UserID)
+	a.Equal("John Doe", user.Name)
+	a.Equal("John", user.FirstName)
+	a.Equal("Doe", user.LastName)
+	a.Equal("jdoe@example.com", user.Email)
+	a.True(user.EmailVerified)
+	a.Equal("https://media.licdn.com/dms/image/123", user.AvatarURL)
+	a.Equal("US", user.Location)
+}
+
 func linkedinProvider() *linkedin.Provider {
-	return linkedin.New(os.Getenv("LINKEDIN_KEY"), os.Getenv("LINKEDIN_SECRET"), "/foo", "r_liteprofile", "r_emailaddress")
+	return linkedin.New(os.Getenv("LINKEDIN_KEY"), os.Getenv("LINKEDIN_SECRET"), "/foo")
 }
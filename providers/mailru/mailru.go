@@ -3,6 +3,7 @@
 package mailru
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/json"
 	"fmt"
@@ -76,7 +77,12 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 }
 
 // FetchUser will go to MAILRU and access basic information about the user.
-func (p *Provider) FetchUser(session goth.Session) (_ goth.User, err error) {
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (_ goth.User, err error) {
 	var (
 		sess = session.(*Session)
 		user = goth.User{
@@ -108,7 +114,11 @@ func (p *Provider) FetchUser(session goth.Session) (_ goth.User, err error) {
 		endpointUser, p.oauthConfig.ClientID, sess.AccessToken, hasher.Sum(nil),
 	)
 
-	res, err := p.Client().Get(reqURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return user, err
+	}
+	res, err := p.Client().Do(req)
 	if err != nil {
 		return user, err
 	}
@@ -153,8 +163,13 @@ func (p *Provider) Debug(debug bool) {}
 
 // RefreshToken refresh token is not provided by mailru.
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	t := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.oauthConfig.TokenSource(goth.ContextForClient(p.Client()), t)
+	ts := p.oauthConfig.TokenSource(goth.ContextWithClient(ctx, p.Client()), t)
 
 	return ts.Token()
 }
@@ -1,9 +1,9 @@
 package mailru
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"strings"
 	"time"
 
 	"github.com/markbates/goth"
@@ -34,8 +34,13 @@ func (s *Session) Marshal() string {
 
 // Authorize the session with MAILRU and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.oauthConfig.Exchange(goth.ContextForClient(p.Client()), params.Get("code"))
+	token, err := p.oauthConfig.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"))
 	if err != nil {
 		return "", err
 	}
@@ -54,6 +59,6 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	sess := new(Session)
-	err := json.NewDecoder(strings.NewReader(data)).Decode(&sess)
+	err := goth.DecodeSession(data, &sess)
 	return sess, err
 }
@@ -0,0 +1,197 @@
+// Package miro implements the OAuth2 protocol for authenticating users
+// through Miro, installing an app in one of their teams.
+package miro
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL         string = "https://miro.com/oauth/authorize"
+	tokenURL        string = "https://api.miro.com/v1/oauth/token"
+	endpointProfile string = "https://api.miro.com/v1/oauth-token"
+)
+
+// Miro scopes. Scopes are configured for the app in Miro's developer
+// settings, and are all granted if none are requested.
+const (
+	ScopeBoardsRead   = "boards:read"
+	ScopeBoardsWrite  = "boards:write"
+	ScopeTeamRead     = "team:read"
+	ScopeTeamWrite    = "team:write"
+	ScopeIdentityRead = "identity:read"
+)
+
+// Keys of User.RawData holding the team the app was installed in.
+const (
+	RawDataTeamID   = "team_id"
+	RawDataTeamName = "team_name"
+)
+
+// Provider is the implementation of `goth.Provider` for accessing Miro.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+}
+
+// New creates a new Miro provider, and sets up important connection details.
+// You should always call `miro.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "miro",
+	}
+	p.config = &oauth2.Config{
+		ClientID:     p.ClientKey,
+		ClientSecret: p.Secret,
+		RedirectURL:  p.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: scopes,
+	}
+	return p
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the miro package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks Miro for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to Miro and access the user and team of the access
+// token. Miro doesn't share the user's email address. The team is stored in
+// RawData under RawDataTeamID and RawDataTeamName, alongside the rest of the
+// token information.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointProfile, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	team, err := userFromReader(bytes.NewReader(bits), &user)
+	if err != nil {
+		return user, err
+	}
+	if team.ID == "" {
+		team.ID = sess.TeamID
+	}
+	user.SetRawData(RawDataTeamID, team.ID)
+	user.SetRawData(RawDataTeamName, team.Name)
+	return user, nil
+}
+
+type team struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func userFromReader(r io.Reader, user *goth.User) (team, error) {
+	u := struct {
+		Team team `json:"team"`
+		User struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"user"`
+	}{}
+	err := json.NewDecoder(r).Decode(&u)
+	if err != nil {
+		return u.Team, err
+	}
+	user.UserID = u.User.ID
+	user.Name = u.User.Name
+	return u.Team, nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token. Miro only
+// issues refresh tokens to apps with expiring access tokens enabled.
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
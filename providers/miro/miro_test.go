@@ -0,0 +1,117 @@
+package miro_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/miro"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("MIRO_KEY"))
+	a.Equal(p.Secret, os.Getenv("MIRO_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	s := session.(*miro.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "miro.com/oauth/authorize")
+}
+
+func Test_Authorize_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		switch req.URL.String() {
+		case "https://api.miro.com/v1/oauth/token":
+			rec.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(rec, `{"user_id":"3074457345","team_id":"3074457350","scope":"boards:read","access_token":"1234567890",
+				"refresh_token":"refresh","expires_in":3599,"token_type":"bearer"}`)
+		case "https://api.miro.com/v1/oauth-token":
+			a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+			fmt.Fprint(rec, `{"type":"oauth-token","team":{"type":"team","name":"Design","id":"3074457350"},
+				"createdBy":{"type":"user","name":"Jane Doe","id":"3074457345"},
+				"user":{"type":"user","name":"Jane Doe","id":"3074457345"},"scopes":["boards:read"]}`)
+		default:
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		return rec.Result(), nil
+	})}
+
+	s := &miro.Session{}
+	_, err := s.Authorize(p, url.Values{"code": {"code"}})
+	a.NoError(err)
+	a.Equal("3074457350", s.TeamID)
+
+	user, err := p.FetchUser(s)
+	a.NoError(err)
+	a.Equal("3074457345", user.UserID)
+	a.Equal("Jane Doe", user.Name)
+	a.Equal("refresh", user.RefreshToken)
+	a.Equal("3074457350", user.RawData[miro.RawDataTeamID])
+	a.Equal("Design", user.RawData[miro.RawDataTeamName])
+}
+
+func Test_FetchUser_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"type":"oauth-token","team":{"type":"team","name":"Design","id":"3074457350"},
+			"user":{"type":"user","name":"Jane Doe","id":"3074457345"}}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&miro.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("3074457350", raw[miro.RawDataTeamID])
+	a.Equal("Design", raw[miro.RawDataTeamName])
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://miro.com/oauth/authorize","AccessToken":"1234567890","TeamID":"3074457350"}`)
+	a.NoError(err)
+
+	s := session.(*miro.Session)
+	a.Equal(s.AuthURL, "https://miro.com/oauth/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+	a.Equal(s.TeamID, "3074457350")
+}
+
+func provider() *miro.Provider {
+	return miro.New(os.Getenv("MIRO_KEY"), os.Getenv("MIRO_SECRET"), "/foo")
+}
@@ -0,0 +1,256 @@
+// Package monday implements the OAuth2 protocol for authenticating users
+// through monday.com, using its GraphQL API for the user's details.
+package monday
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL  string = "https://auth.monday.com/oauth2/authorize"
+	tokenURL string = "https://auth.monday.com/oauth2/token"
+	apiURL   string = "https://api.monday.com/v2"
+)
+
+// monday.com scopes. ScopeMeRead is needed to fetch the user and is always
+// requested; ScopeWorkspacesRead makes FetchUser fetch the user's workspaces.
+const (
+	ScopeMeRead         = "me:read"
+	ScopeAccountRead    = "account:read"
+	ScopeUsersRead      = "users:read"
+	ScopeBoardsRead     = "boards:read"
+	ScopeBoardsWrite    = "boards:write"
+	ScopeWorkspacesRead = "workspaces:read"
+	ScopeTeamsRead      = "teams:read"
+)
+
+// RawDataWorkspaces is the key of User.RawData holding the workspaces the
+// user can access, when ScopeWorkspacesRead is requested.
+const RawDataWorkspaces = "workspaces"
+
+// ErrAccountMismatch is returned by FetchUser when the user authorized the
+// app for another account than the one set with SetAccount.
+type ErrAccountMismatch struct {
+	Want string
+	Got  string
+}
+
+func (e *ErrAccountMismatch) Error() string {
+	return fmt.Sprintf("monday: user authorized account %q, not %q", e.Got, e.Want)
+}
+
+// Provider is the implementation of `goth.Provider` for accessing monday.com.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	account      string
+	workspaces   bool
+}
+
+// New creates a new monday.com provider, and sets up important connection details.
+// You should always call `monday.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "monday",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: []string{ScopeMeRead},
+	}
+
+	for _, scope := range scopes {
+		if scope == ScopeMeRead {
+			continue
+		}
+		if scope == ScopeWorkspacesRead {
+			provider.workspaces = true
+		}
+		c.Scopes = append(c.Scopes, scope)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the monday package.
+func (p *Provider) Debug(debug bool) {}
+
+// SetAccount restricts logins to the monday.com account with the given
+// subdomain (the "acme" of acme.monday.com). Users skip the account picker,
+// and FetchUser returns an *ErrAccountMismatch for users of other accounts.
+func (p *Provider) SetAccount(subdomain string) {
+	p.account = subdomain
+}
+
+// BeginAuth asks monday.com for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	var opts []oauth2.AuthCodeOption
+	if p.account != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("subdomain", p.account))
+	}
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state, opts...),
+	}, nil
+}
+
+const (
+	meQuery         = `{ me { id name email photo_thumb title location url account { id name slug } } }`
+	workspacesQuery = `{ me { id name email photo_thumb title location url account { id name slug } } workspaces { id name kind } }`
+)
+
+// FetchUser will go to monday.com and access basic information about the
+// user with the `me` query. The user's account is included in RawData under
+// "account".
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken: sess.AccessToken,
+		Provider:    p.Name(),
+		ExpiresAt:   sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	query := meQuery
+	if p.workspaces {
+		query = workspacesQuery
+	}
+	body, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		return user, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// monday.com takes the bare token, without a Bearer prefix
+	req.Header.Set("Authorization", sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	var result struct {
+		Data struct {
+			Me         json.RawMessage `json:"me"`
+			Workspaces []interface{}   `json:"workspaces"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(bits, &result); err != nil {
+		return user, err
+	}
+	if len(result.Errors) > 0 {
+		return user, fmt.Errorf("%s responded with an error trying to fetch user information: %s", p.providerName, result.Errors[0].Message)
+	}
+	if len(result.Data.Me) == 0 || string(result.Data.Me) == "null" {
+		return user, errors.New("monday: no user information returned")
+	}
+
+	u := struct {
+		ID         json.Number `json:"id"`
+		Name       string      `json:"name"`
+		Email      string      `json:"email"`
+		PhotoThumb string      `json:"photo_thumb"`
+		Title      string      `json:"title"`
+		Location   string      `json:"location"`
+		Account    struct {
+			Slug string `json:"slug"`
+		} `json:"account"`
+	}{}
+	if err := json.Unmarshal(result.Data.Me, &u); err != nil {
+		return user, err
+	}
+	if p.account != "" && u.Account.Slug != p.account {
+		return user, &ErrAccountMismatch{Want: p.account, Got: u.Account.Slug}
+	}
+
+	err = user.SetRawJSON(result.Data.Me)
+	if err != nil {
+		return user, err
+	}
+	if p.workspaces {
+		user.SetRawData(RawDataWorkspaces, result.Data.Workspaces)
+	}
+
+	user.UserID = u.ID.String()
+	user.Name = u.Name
+	user.Email = u.Email
+	user.AvatarURL = u.PhotoThumb
+	user.Description = u.Title
+	user.Location = u.Location
+	return user, nil
+}
+
+// RefreshTokenAvailable refresh token is not provided by monday.com
+func (p *Provider) RefreshTokenAvailable() bool {
+	return false
+}
+
+// RefreshToken refresh token is not provided by monday.com
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return nil, errors.New("Refresh token is not provided by monday.com")
+}
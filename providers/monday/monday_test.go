@@ -0,0 +1,127 @@
+package monday_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/monday"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("MONDAY_KEY"))
+	a.Equal(p.Secret, os.Getenv("MONDAY_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := monday.New(os.Getenv("MONDAY_KEY"), os.Getenv("MONDAY_SECRET"), "/foo", monday.ScopeBoardsRead)
+	p.SetAccount("acme")
+	session, err := p.BeginAuth("test_state")
+	s := session.(*monday.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "auth.monday.com/oauth2/authorize")
+	a.Contains(s.AuthURL, "scope=me%3Aread+boards%3Aread")
+	a.Contains(s.AuthURL, "subdomain=acme")
+}
+
+func fakeAPI(a *assert.Assertions) *http.Client {
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://api.monday.com/v2", req.URL.String())
+		a.Equal("1234567890", req.Header.Get("Authorization"))
+		var body struct {
+			Query string `json:"query"`
+		}
+		a.NoError(json.NewDecoder(req.Body).Decode(&body))
+		a.Contains(body.Query, "me {")
+
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"data":{"me":{"id":"4012689","name":"Jane Doe","email":"jane@example.com",
+			"photo_thumb":"https://example.com/jane.png","title":"Designer","location":"Tel Aviv","url":"https://acme.monday.com/users/4012689",
+			"account":{"id":"9876","name":"Acme","slug":"acme"}},
+			"workspaces":[{"id":"1","name":"Main workspace","kind":"open"}]},"account_id":9876}`)
+		return rec.Result(), nil
+	})}
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := monday.New(os.Getenv("MONDAY_KEY"), os.Getenv("MONDAY_SECRET"), "/foo", monday.ScopeWorkspacesRead)
+	p.HTTPClient = fakeAPI(a)
+
+	user, err := p.FetchUser(&monday.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("4012689", user.UserID)
+	a.Equal("Jane Doe", user.Name)
+	a.Equal("jane@example.com", user.Email)
+	a.Equal("https://example.com/jane.png", user.AvatarURL)
+	a.Equal("Tel Aviv", user.Location)
+	a.Equal("acme", user.RawData["account"].(map[string]interface{})["slug"])
+	a.Len(user.RawData[monday.RawDataWorkspaces], 1)
+}
+
+func Test_FetchUser_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	p := monday.New(os.Getenv("MONDAY_KEY"), os.Getenv("MONDAY_SECRET"), "/foo", monday.ScopeWorkspacesRead)
+	p.HTTPClient = fakeAPI(a)
+
+	user, err := p.FetchUser(&monday.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("acme", raw["account"].(map[string]interface{})["slug"])
+	a.Len(raw[monday.RawDataWorkspaces], 1)
+}
+
+func Test_FetchUser_AccountMismatch(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.SetAccount("other")
+	p.HTTPClient = fakeAPI(a)
+
+	_, err := p.FetchUser(&monday.Session{AccessToken: "1234567890"})
+	a.Equal(&monday.ErrAccountMismatch{Want: "other", Got: "acme"}, err)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://auth.monday.com/oauth2/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*monday.Session)
+	a.Equal(s.AuthURL, "https://auth.monday.com/oauth2/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *monday.Provider {
+	return monday.New(os.Getenv("MONDAY_KEY"), os.Getenv("MONDAY_SECRET"), "/foo")
+}
@@ -0,0 +1,261 @@
+// Package notion implements the OAuth2 protocol for authenticating users
+// through Notion, installing a public integration in their workspace.
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL  string = "https://api.notion.com/v1/oauth/authorize"
+	tokenURL string = "https://api.notion.com/v1/oauth/token"
+)
+
+// Owner types of an integration, stored in Session.OwnerType.
+const (
+	// OwnerUser is the owner of integrations installed by a user, with
+	// access to the pages the user picked.
+	OwnerUser = "user"
+	// OwnerWorkspace is the owner of integrations installed for a whole
+	// workspace, which have no user.
+	OwnerWorkspace = "workspace"
+)
+
+// Keys of User.RawData holding the workspace the integration was installed in.
+const (
+	RawDataBotID         = "bot_id"
+	RawDataWorkspaceID   = "workspace_id"
+	RawDataWorkspaceName = "workspace_name"
+	RawDataWorkspaceIcon = "workspace_icon"
+	RawDataOwnerType     = "owner_type"
+)
+
+// ErrToken is returned when Notion refuses to issue a token.
+type ErrToken struct {
+	Code        string
+	Description string
+}
+
+func (e *ErrToken) Error() string {
+	if e.Description == "" {
+		return "notion: " + e.Code
+	}
+	return fmt.Sprintf("notion: %s: %s", e.Code, e.Description)
+}
+
+// Provider is the implementation of `goth.Provider` for accessing Notion.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+}
+
+// New creates a new Notion provider, and sets up important connection details.
+// Notion has no scopes; the pages an integration can access are picked by the
+// user during authorization.
+// You should always call `notion.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "notion",
+	}
+	p.config = &oauth2.Config{
+		ClientID:     p.ClientKey,
+		ClientSecret: p.Secret,
+		RedirectURL:  p.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+	}
+	return p
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the notion package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks Notion for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state, oauth2.SetAuthURLParam("owner", OwnerUser)),
+	}, nil
+}
+
+// FetchUser returns the user who installed the integration, as described by
+// the token response; Notion has no endpoint for the user behind a token.
+// For integrations owned by a workspace the user is the bot itself, named
+// after the workspace. The workspace is stored in RawData under
+// RawDataWorkspaceID, RawDataWorkspaceName and RawDataWorkspaceIcon.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	if sess.OwnerType == OwnerUser && len(sess.OwnerUser) > 0 {
+		err := user.SetRawJSON(sess.OwnerUser)
+		if err != nil {
+			return user, err
+		}
+		u := struct {
+			ID        string `json:"id"`
+			Name      string `json:"name"`
+			AvatarURL string `json:"avatar_url"`
+			Person    struct {
+				Email string `json:"email"`
+			} `json:"person"`
+		}{}
+		if err := json.Unmarshal(sess.OwnerUser, &u); err != nil {
+			return user, err
+		}
+		user.UserID = u.ID
+		user.Name = u.Name
+		user.AvatarURL = u.AvatarURL
+		user.Email = u.Person.Email
+	} else {
+		user.UserID = sess.BotID
+		user.Name = sess.WorkspaceName
+		user.AvatarURL = sess.WorkspaceIcon
+	}
+
+	user.SetRawData(RawDataBotID, sess.BotID)
+	user.SetRawData(RawDataWorkspaceID, sess.WorkspaceID)
+	user.SetRawData(RawDataWorkspaceName, sess.WorkspaceName)
+	user.SetRawData(RawDataWorkspaceIcon, sess.WorkspaceIcon)
+	user.SetRawData(RawDataOwnerType, sess.OwnerType)
+	return user, nil
+}
+
+// tokenResponse is the response of Notion's token endpoint.
+type tokenResponse struct {
+	Error            string          `json:"error"`
+	ErrorDescription string          `json:"error_description"`
+	AccessToken      string          `json:"access_token"`
+	TokenType        string          `json:"token_type"`
+	RefreshToken     string          `json:"refresh_token"`
+	ExpiresIn        int64           `json:"expires_in"`
+	BotID            string          `json:"bot_id"`
+	WorkspaceID      string          `json:"workspace_id"`
+	WorkspaceName    string          `json:"workspace_name"`
+	WorkspaceIcon    string          `json:"workspace_icon"`
+	Owner            json.RawMessage `json:"owner"`
+}
+
+// expiry returns when the token expires, or the zero time if it doesn't.
+func (r *tokenResponse) expiry() time.Time {
+	if r.ExpiresIn > 0 {
+		return goth.GetClock().Now().Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	return time.Time{}
+}
+
+// token posts params to the token endpoint. It doesn't use the oauth2
+// package, as Notion expects a JSON body and returns the integration's owner
+// and workspace alongside the token.
+func (p *Provider) token(ctx context.Context, params map[string]string) (*tokenResponse, error) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(p.ClientKey, p.Secret)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var r tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s responded with a %d trying to fetch a token", p.providerName, resp.StatusCode)
+		}
+		return nil, err
+	}
+	if r.Error != "" {
+		return nil, &ErrToken{Code: r.Error, Description: r.ErrorDescription}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with a %d trying to fetch a token", p.providerName, resp.StatusCode)
+	}
+	if r.AccessToken == "" {
+		return nil, errors.New("Invalid token received from provider")
+	}
+	return &r, nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	r, err := p.token(ctx, map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": refreshToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+	token := &oauth2.Token{
+		AccessToken:  r.AccessToken,
+		TokenType:    r.TokenType,
+		RefreshToken: r.RefreshToken,
+		Expiry:       r.expiry(),
+	}
+	return token.WithExtra(map[string]interface{}{
+		"bot_id":         r.BotID,
+		"workspace_id":   r.WorkspaceID,
+		"workspace_name": r.WorkspaceName,
+		"workspace_icon": r.WorkspaceIcon,
+	}), nil
+}
@@ -0,0 +1,166 @@
+package notion_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/notion"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("NOTION_KEY"))
+	a.Equal(p.Secret, os.Getenv("NOTION_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	s := session.(*notion.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "api.notion.com/v1/oauth/authorize")
+	a.Contains(s.AuthURL, "owner=user")
+	a.Contains(s.AuthURL, "response_type=code")
+}
+
+func Test_Authorize_UserOwner(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := notion.New("client", "secret", "/foo")
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://api.notion.com/v1/oauth/token", req.URL.String())
+		id, secret, ok := req.BasicAuth()
+		a.True(ok)
+		a.Equal("client", id)
+		a.Equal("secret", secret)
+		a.Equal("application/json", req.Header.Get("Content-Type"))
+		body := map[string]string{}
+		a.NoError(json.NewDecoder(req.Body).Decode(&body))
+		a.Equal("authorization_code", body["grant_type"])
+		a.Equal("abc", body["code"])
+		a.Equal("/foo", body["redirect_uri"])
+
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"access_token":"secret_123","token_type":"bearer","refresh_token":"ref_123",
+			"bot_id":"b1","workspace_id":"w1","workspace_name":"Acme","workspace_icon":"https://example.com/acme.png",
+			"owner":{"type":"user","user":{"object":"user","id":"u1","name":"Jane Doe",
+			"avatar_url":"https://example.com/jane.png","type":"person","person":{"email":"jane@example.com"}}},
+			"duplicated_template_id":null}`)
+		return rec.Result(), nil
+	})}
+
+	s := &notion.Session{}
+	token, err := s.Authorize(p, url.Values{"code": {"abc"}})
+	a.NoError(err)
+	a.Equal("secret_123", token)
+	a.Equal("ref_123", s.RefreshToken)
+	a.Equal(notion.OwnerUser, s.OwnerType)
+
+	user, err := p.FetchUser(s)
+	a.NoError(err)
+	a.Equal("u1", user.UserID)
+	a.Equal("Jane Doe", user.Name)
+	a.Equal("jane@example.com", user.Email)
+	a.Equal("https://example.com/jane.png", user.AvatarURL)
+	a.Equal("Acme", user.RawData[notion.RawDataWorkspaceName])
+	a.Equal("https://example.com/acme.png", user.RawData[notion.RawDataWorkspaceIcon])
+	a.Equal("w1", user.RawData[notion.RawDataWorkspaceID])
+}
+
+func Test_FetchUser_WorkspaceOwner(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	user, err := provider().FetchUser(&notion.Session{
+		AccessToken:   "secret_123",
+		BotID:         "b1",
+		WorkspaceID:   "w1",
+		WorkspaceName: "Acme",
+		WorkspaceIcon: "https://example.com/acme.png",
+		OwnerType:     notion.OwnerWorkspace,
+	})
+	a.NoError(err)
+	a.Equal("b1", user.UserID)
+	a.Equal("Acme", user.Name)
+	a.Equal("https://example.com/acme.png", user.AvatarURL)
+	a.Equal(notion.OwnerWorkspace, user.RawData[notion.RawDataOwnerType])
+}
+
+func Test_FetchUser_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	user, err := provider().FetchUser(&notion.Session{
+		AccessToken:   "secret_123",
+		BotID:         "b1",
+		WorkspaceID:   "w1",
+		WorkspaceName: "Acme",
+		OwnerType:     notion.OwnerUser,
+		OwnerUser:     json.RawMessage(`{"object":"user","id":"u1","name":"Jane Doe"}`),
+	})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("u1", raw["id"])
+	a.Equal("w1", raw[notion.RawDataWorkspaceID])
+	a.Equal("Acme", raw[notion.RawDataWorkspaceName])
+}
+
+func Test_Authorize_Error(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(rec, `{"error":"invalid_grant","error_description":"Invalid code."}`)
+		return rec.Result(), nil
+	})}
+
+	_, err := (&notion.Session{}).Authorize(p, url.Values{"code": {"abc"}})
+	a.Error(err)
+	e, ok := err.(*notion.ErrToken)
+	a.True(ok)
+	a.Equal("invalid_grant", e.Code)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://api.notion.com/v1/oauth/authorize","AccessToken":"1234567890","WorkspaceName":"Acme"}`)
+	a.NoError(err)
+
+	s := session.(*notion.Session)
+	a.Equal(s.AuthURL, "https://api.notion.com/v1/oauth/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+	a.Equal(s.WorkspaceName, "Acme")
+}
+
+func provider() *notion.Provider {
+	return notion.New(os.Getenv("NOTION_KEY"), os.Getenv("NOTION_SECRET"), "/foo")
+}
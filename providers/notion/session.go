@@ -0,0 +1,95 @@
+package notion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/markbates/goth"
+)
+
+// Session stores data during the auth process with Notion.
+type Session struct {
+	AuthURL      string
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+	// BotID identifies the integration's installation in the workspace.
+	BotID         string `json:",omitempty"`
+	WorkspaceID   string `json:",omitempty"`
+	WorkspaceName string `json:",omitempty"`
+	WorkspaceIcon string `json:",omitempty"`
+	// OwnerType is OwnerUser or OwnerWorkspace. For OwnerUser, OwnerUser is
+	// the Notion user object of the user who installed the integration.
+	OwnerType string          `json:",omitempty"`
+	OwnerUser json.RawMessage `json:",omitempty"`
+}
+
+var _ goth.Session = &Session{}
+
+// GetAuthURL will return the URL set by calling the `BeginAuth` function on the Notion provider.
+func (s Session) GetAuthURL() (string, error) {
+	if s.AuthURL == "" {
+		return "", errors.New(goth.NoAuthUrlErrorMessage)
+	}
+	return s.AuthURL, nil
+}
+
+// Authorize the session with Notion and return the access token to be stored for future use.
+func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
+	p := provider.(*Provider)
+	r, err := p.token(ctx, map[string]string{
+		"grant_type":   "authorization_code",
+		"code":         params.Get("code"),
+		"redirect_uri": p.CallbackURL,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.AccessToken = r.AccessToken
+	s.RefreshToken = r.RefreshToken
+	s.ExpiresAt = r.expiry()
+	s.BotID = r.BotID
+	s.WorkspaceID = r.WorkspaceID
+	s.WorkspaceName = r.WorkspaceName
+	s.WorkspaceIcon = r.WorkspaceIcon
+
+	owner := struct {
+		Type string          `json:"type"`
+		User json.RawMessage `json:"user"`
+	}{}
+	if len(r.Owner) > 0 {
+		if err := json.Unmarshal(r.Owner, &owner); err != nil {
+			return "", err
+		}
+	}
+	s.OwnerType = owner.Type
+	if owner.Type == OwnerUser {
+		s.OwnerUser = owner.User
+	}
+	return s.AccessToken, nil
+}
+
+// Marshal the session into a string
+func (s Session) Marshal() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func (s Session) String() string {
+	return s.Marshal()
+}
+
+// UnmarshalSession wil unmarshal a JSON string into a session.
+func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
+	s := &Session{}
+	err := goth.DecodeSession(data, s)
+	return s, err
+}
@@ -4,11 +4,13 @@ package okta
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -24,16 +26,35 @@ type Provider struct {
 	providerName string
 	issuerURL    string
 	profileURL   string
+	fetchGroups  bool
 }
 
-// New creates a new Okta provider and sets up important connection details.
+// ScopeGroups is the scope requesting the groups claim, see SetFetchGroups.
+const ScopeGroups = "groups"
+
+// New creates a new Okta provider using the default custom authorization
+// server of the org, and sets up important connection details.
 // You should always call `okta.New` to get a new provider.  Never try to
 // create one manually.
 func New(clientID, secret, orgURL, callbackURL string, scopes ...string) *Provider {
-	issuerURL := orgURL + "/oauth2/default"
-	authURL := issuerURL + "/v1/authorize"
-	tokenURL := issuerURL + "/v1/token"
-	profileURL := issuerURL + "/v1/userinfo"
+	return NewWithAuthServer(clientID, secret, orgURL, "default", callbackURL, scopes...)
+}
+
+// NewWithAuthServer is like New but uses the custom authorization server
+// with the given ID, i.e. the one at orgURL/oauth2/{authServerID}. If
+// authServerID is empty the org authorization server is used, whose issuer is
+// orgURL itself.
+func NewWithAuthServer(clientID, secret, orgURL, authServerID, callbackURL string, scopes ...string) *Provider {
+	orgURL = strings.TrimSuffix(orgURL, "/")
+	issuerURL := orgURL
+	endpointURL := orgURL + "/oauth2"
+	if authServerID != "" {
+		issuerURL = orgURL + "/oauth2/" + authServerID
+		endpointURL = issuerURL
+	}
+	authURL := endpointURL + "/v1/authorize"
+	tokenURL := endpointURL + "/v1/token"
+	profileURL := endpointURL + "/v1/userinfo"
 	return NewCustomisedURL(clientID, secret, callbackURL, authURL, tokenURL, issuerURL, profileURL, scopes...)
 }
 
@@ -68,6 +89,23 @@ func (p *Provider) Client() *http.Client {
 // Debug is a no-op for the okta package.
 func (p *Provider) Debug(debug bool) {}
 
+// SetFetchGroups makes FetchUser fill in User.Groups from the groups claim.
+// ScopeGroups is added to the requested scopes. Custom authorization servers
+// only issue the claim if a groups claim has been added to them in the Okta
+// admin console.
+func (p *Provider) SetFetchGroups(fetch bool) {
+	p.fetchGroups = fetch
+	if !fetch {
+		return
+	}
+	for _, scope := range p.config.Scopes {
+		if scope == ScopeGroups {
+			return
+		}
+	}
+	p.config.Scopes = append(p.config.Scopes, ScopeGroups)
+}
+
 // BeginAuth asks okta for an authentication end-point.
 func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 	return &Session{
@@ -77,6 +115,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to okta and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken:  sess.AccessToken,
@@ -84,6 +127,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		RefreshToken: sess.RefreshToken,
 		ExpiresAt:    sess.ExpiresAt,
 		UserID:       sess.UserID,
+		IDToken:      sess.IDToken,
 	}
 
 	if user.AccessToken == "" {
@@ -91,7 +135,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	req, err := http.NewRequest("GET", p.profileURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
 	if err != nil {
 		return user, err
 	}
@@ -114,16 +158,36 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, err
 	}
 
-	err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
+	err = user.SetRawJSON(bits)
 	if err != nil {
 		return user, err
 	}
 
 	err = userFromReader(bytes.NewReader(bits), &user)
+	if err != nil || !p.fetchGroups {
+		return user, err
+	}
 
+	// the groups claim is in the userinfo response or, depending on how it
+	// is configured on the authorization server, only in the id_token
+	user.Groups, err = groupsClaim(bits)
+	if err == nil && user.Groups == nil && sess.IDToken != "" {
+		var claims json.RawMessage
+		if err = goth.DecodeJWTClaims(sess.IDToken, &claims); err == nil {
+			user.Groups, err = groupsClaim(claims)
+		}
+	}
 	return user, err
 }
 
+func groupsClaim(claims []byte) ([]string, error) {
+	var c struct {
+		Groups []string `json:"groups"`
+	}
+	err := json.Unmarshal(claims, &c)
+	return c.Groups, err
+}
+
 func newConfig(provider *Provider, authURL, tokenURL string, scopes []string) *oauth2.Config {
 	c := &oauth2.Config{
 		ClientID:     provider.ClientKey,
@@ -188,8 +252,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -1,10 +1,15 @@
 package okta_test
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/okta"
 	"github.com/stretchr/testify/assert"
 )
@@ -29,6 +34,95 @@ func Test_NewCustomisedURL(t *testing.T) {
 	a.Contains(s.AuthURL, "http://authURL")
 }
 
+func Test_NewWithAuthServer(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := okta.NewWithAuthServer("client", "secret", "https://example.okta.com/", "aus1234", "/foo")
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	a.Contains(session.(*okta.Session).AuthURL, "https://example.okta.com/oauth2/aus1234/v1/authorize")
+
+	p = okta.NewWithAuthServer("client", "secret", "https://example.okta.com", "", "/foo")
+	session, err = p.BeginAuth("test_state")
+	a.NoError(err)
+	a.Contains(session.(*okta.Session).AuthURL, "https://example.okta.com/oauth2/v1/authorize")
+}
+
+// oktaServer serves the token endpoint, returning idToken, and a userinfo
+// endpoint returning userinfo.
+func oktaServer(idToken, userinfo string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth2/aus1234/v1/token":
+			fmt.Fprintf(w, `{"access_token":"1234567890","token_type":"Bearer","expires_in":3600,"id_token":%q}`, idToken)
+		case "/oauth2/aus1234/v1/userinfo":
+			fmt.Fprint(w, userinfo)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func Test_AuthorizeChecksIssuer(t *testing.T) {
+	t.Parallel()
+
+	for _, issuer := range []string{"/oauth2/aus1234", "/oauth2/default", ""} {
+		issuer := issuer
+		t.Run(issuer, func(t *testing.T) {
+			a := assert.New(t)
+			var ts *httptest.Server
+			ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"access_token":"1234567890","token_type":"Bearer","expires_in":3600,"id_token":%q}`,
+					gothtest.UnsignedJWT(`{"iss":"`+ts.URL+issuer+`","aud":"client","sub":"user-1"}`))
+			}))
+			defer ts.Close()
+
+			p := okta.NewWithAuthServer("client", "secret", ts.URL, "aus1234", "/foo")
+			session, _ := p.BeginAuth("test_state")
+			_, err := session.Authorize(p, url.Values{"code": {"code"}})
+			if issuer == "/oauth2/aus1234" {
+				a.NoError(err)
+				a.NotEmpty(session.(*okta.Session).IDToken)
+				return
+			}
+			a.IsType(&goth.ErrIssuerMismatch{}, err)
+		})
+	}
+}
+
+func Test_FetchGroups(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		idToken  string
+		userinfo string
+	}{
+		"userinfo": {gothtest.UnsignedJWT(`{}`), `{"sub":"user-1","groups":["Everyone","Admins"]}`},
+		"id_token": {gothtest.UnsignedJWT(`{"groups":["Everyone","Admins"]}`), `{"sub":"user-1"}`},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			a := assert.New(t)
+			ts := oktaServer(tc.idToken, tc.userinfo)
+			defer ts.Close()
+
+			p := okta.NewWithAuthServer("client", "secret", ts.URL, "aus1234", "/foo", "openid")
+			p.SetFetchGroups(true)
+			session, err := p.BeginAuth("test_state")
+			a.NoError(err)
+			a.Contains(session.(*okta.Session).AuthURL, "scope=openid+groups")
+
+			user, err := p.FetchUser(&okta.Session{AccessToken: "1234567890", IDToken: tc.idToken})
+			a.NoError(err)
+			a.Equal("user-1", user.UserID)
+			a.Equal([]string{"Everyone", "Admins"}, user.Groups)
+		})
+	}
+}
+
 func Test_Implements_Provider(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
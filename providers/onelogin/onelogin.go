@@ -0,0 +1,248 @@
+// Package onelogin implements the OpenID Connect protocol for authenticating
+// users through OneLogin.
+package onelogin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// ScopeGroups makes OneLogin include the groups claim, which by default holds
+// the user's OneLogin roles.
+const ScopeGroups = "groups"
+
+// Provider is the implementation of `goth.Provider` for accessing OneLogin.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	issuerURL    string
+	profileURL   string
+	revokeURL    string
+	rolesClaim   string
+}
+
+// New creates a new OneLogin provider for the account at
+// https://<subdomain>.onelogin.com, and sets up important connection details.
+// The openid, profile, email and groups scopes are requested unless other
+// scopes are passed.
+// You should always call `onelogin.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, subdomain string, scopes ...string) *Provider {
+	return NewWithIssuer(clientKey, secret, callbackURL, "https://"+subdomain+".onelogin.com/oidc/2", scopes...)
+}
+
+// NewWithIssuer is like New but takes the issuer URL, e.g. for accounts on a
+// custom domain.
+func NewWithIssuer(clientKey, secret, callbackURL, issuerURL string, scopes ...string) *Provider {
+	issuerURL = strings.TrimSuffix(issuerURL, "/")
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "onelogin",
+		issuerURL:    issuerURL,
+		profileURL:   issuerURL + "/me",
+		revokeURL:    issuerURL + "/token/revocation",
+		rolesClaim:   "groups",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.issuerURL + "/auth",
+			TokenURL: provider.issuerURL + "/token",
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, "openid", "profile", "email", ScopeGroups)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the onelogin package.
+func (p *Provider) Debug(debug bool) {}
+
+// IssuerURL returns the issuer of the provider's account.
+func (p *Provider) IssuerURL() string {
+	return p.issuerURL
+}
+
+// SetRolesClaim sets the claim User.Roles is filled in from, "groups" by
+// default. The claim can hold a list or a OneLogin style semicolon separated
+// string, as custom parameters mapped to a claim do.
+func (p *Provider) SetRolesClaim(claim string) {
+	p.rolesClaim = claim
+}
+
+// BeginAuth asks OneLogin for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to OneLogin and access basic information about the user.
+// User.Roles is filled in from the roles claim of the userinfo response, or
+// of the id_token if the userinfo response doesn't include it.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(bytes.NewReader(bits)).Decode(&claims); err != nil {
+		return user, err
+	}
+	user.UserID, _ = claims["sub"].(string)
+	user.Email, _ = claims["email"].(string)
+	user.Name, _ = claims["name"].(string)
+	user.NickName, _ = claims["preferred_username"].(string)
+	user.FirstName, _ = claims["given_name"].(string)
+	user.LastName, _ = claims["family_name"].(string)
+	user.Roles = stringList(claims[p.rolesClaim])
+
+	// the roles claim may only be configured for the id_token
+	if len(user.Roles) == 0 && sess.IDToken != "" {
+		var token map[string]interface{}
+		if goth.DecodeJWTClaims(sess.IDToken, &token) == nil {
+			user.Roles = stringList(token[p.rolesClaim])
+		}
+	}
+	return user, nil
+}
+
+// stringList returns the values of a claim holding a list of strings or a
+// semicolon separated string.
+func stringList(claim interface{}) []string {
+	var values []string
+	switch c := claim.(type) {
+	case []interface{}:
+		for _, v := range c {
+			if s, ok := v.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+	case string:
+		for _, s := range strings.Split(c, ";") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// RevokeToken revokes an access or refresh token at OneLogin's revocation endpoint.
+func (p *Provider) RevokeToken(ctx context.Context, token string) error {
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.revokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.ClientKey), url.QueryEscape(p.Secret))
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with a %d trying to revoke a token", p.providerName, response.StatusCode)
+	}
+	return nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
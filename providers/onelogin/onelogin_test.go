@@ -0,0 +1,137 @@
+package onelogin_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/onelogin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "onelogin")
+	a.Equal(p.ClientKey, "myapp")
+	a.Equal(p.Secret, "secret")
+	a.Equal(p.CallbackURL, "/foo")
+	a.Equal("https://acme.onelogin.com/oidc/2", p.IssuerURL())
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+	a.Implements((*goth.TokenRevoker)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*onelogin.Session)
+	a.Contains(s.AuthURL, "https://acme.onelogin.com/oidc/2/auth")
+	a.Contains(s.AuthURL, "scope=openid+profile+email+groups")
+}
+
+func Test_AuthorizeChecksIssuer(t *testing.T) {
+	t.Parallel()
+
+	for name, claims := range map[string]string{
+		"valid":        `{"iss":"%s/oidc/2","aud":"myapp"}`,
+		"otherAccount": `{"iss":"%s/oidc/2/other","aud":"myapp"}`,
+	} {
+		name, claims := name, claims
+		t.Run(name, func(t *testing.T) {
+			a := assert.New(t)
+			var ts *httptest.Server
+			ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				a.Equal("/oidc/2/token", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"access_token":"1234567890","token_type":"Bearer","expires_in":300,"id_token":%q}`, gothtest.UnsignedJWT(fmt.Sprintf(claims, ts.URL)))
+			}))
+			defer ts.Close()
+
+			p := onelogin.NewWithIssuer("myapp", "secret", "/foo", ts.URL+"/oidc/2")
+			session, _ := p.BeginAuth("test_state")
+			_, err := session.Authorize(p, url.Values{"code": {"code"}})
+			if name == "valid" {
+				a.NoError(err)
+			} else {
+				a.IsType(&goth.ErrIssuerMismatch{}, err)
+			}
+		})
+	}
+}
+
+func Test_FetchUserRoles(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("/oidc/2/me", r.URL.Path)
+		fmt.Fprint(w, `{"sub":"12345","email":"jdoe@example.com","name":"John Doe","preferred_username":"jdoe",
+			"given_name":"John","family_name":"Doe","groups":["Admin","Support"],"params":{"department":"IT"},
+			"departments":"Engineering;Sales"}`)
+	}))
+	defer ts.Close()
+
+	p := onelogin.NewWithIssuer("myapp", "secret", "/foo", ts.URL+"/oidc/2")
+	user, err := p.FetchUser(&onelogin.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("12345", user.UserID)
+	a.Equal("jdoe", user.NickName)
+	a.Equal([]string{"Admin", "Support"}, user.Roles)
+
+	p.SetRolesClaim("departments")
+	user, err = p.FetchUser(&onelogin.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal([]string{"Engineering", "Sales"}, user.Roles)
+
+	p.SetRolesClaim("roles")
+	user, err = p.FetchUser(&onelogin.Session{AccessToken: "1234567890", IDToken: gothtest.UnsignedJWT(`{"roles":["Auditor"]}`)})
+	a.NoError(err)
+	a.Equal([]string{"Auditor"}, user.Roles)
+}
+
+func Test_RevokeToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("/oidc/2/token/revocation", r.URL.Path)
+		user, pass, _ := r.BasicAuth()
+		a.Equal("myapp", user)
+		a.Equal("secret", pass)
+		a.Equal("1234567890", r.FormValue("token"))
+	}))
+	defer ts.Close()
+
+	p := onelogin.NewWithIssuer("myapp", "secret", "/foo", ts.URL+"/oidc/2")
+	a.NoError(p.RevokeToken(context.Background(), "1234567890"))
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://acme.onelogin.com/oidc/2/auth","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*onelogin.Session)
+	a.Equal(s.AuthURL, "https://acme.onelogin.com/oidc/2/auth")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *onelogin.Provider {
+	return onelogin.New("myapp", "secret", "/foo", "acme")
+}
@@ -2,6 +2,7 @@ package openidConnect
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -81,6 +82,12 @@ type OpenIDConfig struct {
 	// https://openid.net/specs/openid-connect-session-1_0-17.html#OPMetadata
 	EndSessionEndpoint string `json:"end_session_endpoint,omitempty"`
 	Issuer             string `json:"issuer"`
+
+	// The introspection_endpoint is not part of OpenID Connect Discovery but is commonly
+	// published alongside it. See: https://datatracker.ietf.org/doc/html/rfc8414#section-2
+	IntrospectionEndpoint string `json:"introspection_endpoint,omitempty"`
+	// The revocation_endpoint is defined by RFC 7009 and published the same way.
+	RevocationEndpoint string `json:"revocation_endpoint,omitempty"`
 }
 
 type RefreshTokenResponse struct {
@@ -90,7 +97,7 @@ type RefreshTokenResponse struct {
 	// refresh token flow. As a result, a new ID token may not be returned in a successful
 	// response.
 	// See more: https://openid.net/specs/openid-connect-core-1_0.html#RefreshingAccessToken
-	IdToken string `json:"id_token, omitempty"`
+	IdToken string `json:"id_token,omitempty"`
 
 	// The OAuth spec defines the refresh token as an optional response field in the
 	// refresh token flow. As a result, a new refresh token may not be returned in a successful
@@ -192,6 +199,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will use the id_token and access requested information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the userinfo request to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 
 	expiresAt := sess.ExpiresAt
@@ -216,7 +228,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		expiresAt = expiry
 	}
 
-	if err := p.getUserInfo(sess.AccessToken, claims); err != nil {
+	if err := p.getUserInfo(ctx, sess.AccessToken, claims); err != nil {
 		return goth.User{}, err
 	}
 
@@ -240,8 +252,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(oauth2.NoContext, token)
+	ts := p.config.TokenSource(ctx, token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -255,13 +272,18 @@ func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
 // compatibility purposes) that also returns the id_token in the OpenID refresh token flow API response
 // Learn more about ID tokens: https://openid.net/specs/openid-connect-core-1_0.html#IDToken
 func (p *Provider) RefreshTokenWithIDToken(refreshToken string) (*RefreshTokenResponse, error) {
+	return p.RefreshTokenWithIDTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenWithIDTokenContext is like RefreshTokenWithIDToken but binds the request to ctx.
+func (p *Provider) RefreshTokenWithIDTokenContext(ctx context.Context, refreshToken string) (*RefreshTokenResponse, error) {
 	urlValues := url.Values{
 		"grant_type":    {"refresh_token"},
 		"refresh_token": {refreshToken},
 		"client_id":     {p.ClientKey},
 		"client_secret": {p.Secret},
 	}
-	req, err := http.NewRequest("POST", p.OpenIDConfig.TokenEndpoint, strings.NewReader(urlValues.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.OpenIDConfig.TokenEndpoint, strings.NewReader(urlValues.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -292,6 +314,154 @@ func (p *Provider) RefreshTokenWithIDToken(refreshToken string) (*RefreshTokenRe
 	return refreshTokenResponse, nil
 }
 
+// IntrospectToken validates a bare access token at the provider's introspection
+// endpoint (https://datatracker.ietf.org/doc/html/rfc7662) and resolves the user
+// it was issued to, merging in the UserInfo claims. Tokens issued to other
+// clients are rejected with an *goth.ErrTokenAudience. The UserInfo endpoint
+// alone can't tell which client a token was issued to, so providers without an
+// introspection endpoint can't validate bearer tokens.
+func (p *Provider) IntrospectToken(ctx context.Context, accessToken string) (goth.User, error) {
+	if p.OpenIDConfig.IntrospectionEndpoint == "" {
+		return goth.User{}, fmt.Errorf("%s has no introspection endpoint to validate tokens with", p.providerName)
+	}
+	claims, err := p.introspect(ctx, accessToken)
+	if err != nil {
+		return goth.User{}, err
+	}
+	if !p.issuedToClient(claims) {
+		return goth.User{}, &goth.ErrTokenAudience{Provider: p.providerName, Audience: getClaimValue(claims, []string{"client_id", audienceClaim})}
+	}
+
+	if p.OpenIDConfig.UserInfoEndpoint != "" && !p.SkipUserInfoRequest {
+		userInfoClaims, err := p.fetchUserInfo(ctx, p.OpenIDConfig.UserInfoEndpoint, accessToken)
+		if err != nil {
+			return goth.User{}, err
+		}
+		if sub := getClaimValue(claims, []string{subjectClaim}); sub != "" && sub != getClaimValue(userInfoClaims, []string{subjectClaim}) {
+			return goth.User{}, fmt.Errorf("userinfo 'sub' claim did not match introspected token 'sub' claim (%s)", sub)
+		}
+		for k, v := range userInfoClaims {
+			claims[k] = v
+		}
+	}
+
+	user := goth.User{
+		AccessToken: accessToken,
+		Provider:    p.Name(),
+		RawData:     claims,
+	}
+	if exp, ok := claims[expiryClaim].(float64); ok {
+		user.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	p.userFromClaims(claims, &user)
+	if user.UserID == "" {
+		return user, fmt.Errorf("%s could not resolve a subject for the bearer token", p.providerName)
+	}
+	return user, nil
+}
+
+// HealthCheck fetches the discovery document of the issuer, or probes the
+// token endpoint if no issuer is configured.
+func (p *Provider) HealthCheck(ctx context.Context) error {
+	if p.OpenIDConfig.Issuer == "" {
+		return goth.ProbeEndpoint(ctx, p.providerName, p.HTTPClient, p.OpenIDConfig.TokenEndpoint)
+	}
+
+	endpoint := strings.TrimSuffix(p.OpenIDConfig.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return &goth.ErrUnhealthy{Provider: p.providerName, Endpoint: endpoint, Err: err}
+	}
+	res, err := p.Client().Do(req)
+	if err != nil {
+		return &goth.ErrUnhealthy{Provider: p.providerName, Endpoint: endpoint, Err: err}
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return &goth.ErrUnhealthy{Provider: p.providerName, Endpoint: endpoint, Err: fmt.Errorf("status %d", res.StatusCode)}
+	}
+	return nil
+}
+
+// RevokeToken revokes an access or refresh token at the revocation endpoint
+// (RFC 7009), if the provider publishes one.
+func (p *Provider) RevokeToken(ctx context.Context, token string) error {
+	if p.OpenIDConfig.RevocationEndpoint == "" {
+		return fmt.Errorf("%s does not publish a revocation endpoint", p.providerName)
+	}
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.OpenIDConfig.RevocationEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.ClientKey), url.QueryEscape(p.Secret))
+
+	resp, err := p.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Non-200 response from token revocation: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (p *Provider) introspect(ctx context.Context, accessToken string) (map[string]interface{}, error) {
+	form := url.Values{
+		"token":           {accessToken},
+		"token_type_hint": {"access_token"},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.OpenIDConfig.IntrospectionEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.ClientKey), url.QueryEscape(p.Secret))
+
+	resp, err := p.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Non-200 response from token introspection: %d", resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := unMarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	if active, _ := claims["active"].(bool); !active {
+		return nil, errors.New("bearer token is not active")
+	}
+	return claims, nil
+}
+
+// issuedToClient reports whether introspected token claims name the provider's
+// client as the client the token was issued to, or as one of its audiences.
+func (p *Provider) issuedToClient(claims map[string]interface{}) bool {
+	if getClaimValue(claims, []string{"client_id"}) == p.ClientKey || getClaimValue(claims, []string{"azp"}) == p.ClientKey {
+		return true
+	}
+	if getClaimValue(claims, []string{audienceClaim}) == p.ClientKey {
+		return true
+	}
+	for _, aud := range getClaimValues(claims, []string{audienceClaim}) {
+		if aud == p.ClientKey {
+			return true
+		}
+	}
+	return false
+}
+
 // validate according to standard, returns expiry
 // http://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation
 func (p *Provider) validateClaims(claims map[string]interface{}) (time.Time, error) {
@@ -319,7 +489,7 @@ func (p *Provider) validateClaims(claims map[string]interface{}) (time.Time, err
 	// is actually a int64, so force it in to that type
 	expiryClaim := int64(claims[expiryClaim].(float64))
 	expiry := time.Unix(expiryClaim, 0)
-	if expiry.Add(clockSkew).Before(time.Now()) {
+	if expiry.Add(clockSkew).Before(goth.GetClock().Now()) {
 		return time.Time{}, errors.New("user info JWT token is expired")
 	}
 	return expiry, nil
@@ -338,13 +508,13 @@ func (p *Provider) userFromClaims(claims map[string]interface{}, user *goth.User
 	user.Location = getClaimValue(claims, p.LocationClaims)
 }
 
-func (p *Provider) getUserInfo(accessToken string, claims map[string]interface{}) error {
+func (p *Provider) getUserInfo(ctx context.Context, accessToken string, claims map[string]interface{}) error {
 	// skip if there is no UserInfoEndpoint or is explicitly disabled
 	if p.OpenIDConfig.UserInfoEndpoint == "" || p.SkipUserInfoRequest {
 		return nil
 	}
 
-	userInfoClaims, err := p.fetchUserInfo(p.OpenIDConfig.UserInfoEndpoint, accessToken)
+	userInfoClaims, err := p.fetchUserInfo(ctx, p.OpenIDConfig.UserInfoEndpoint, accessToken)
 	if err != nil {
 		return err
 	}
@@ -373,8 +543,8 @@ func (p *Provider) getUserInfo(accessToken string, claims map[string]interface{}
 }
 
 // fetch and decode JSON from the given UserInfo URL
-func (p *Provider) fetchUserInfo(url, accessToken string) (map[string]interface{}, error) {
-	req, _ := http.NewRequest("GET", url, nil)
+func (p *Provider) fetchUserInfo(ctx context.Context, url, accessToken string) (map[string]interface{}, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 
 	resp, err := p.Client().Do(req)
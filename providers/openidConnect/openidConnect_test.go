@@ -1,6 +1,7 @@
 package openidConnect
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -99,7 +100,94 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal("abc", session.IDToken)
 }
 
+func Test_IntrospectToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/introspect":
+			r.ParseForm()
+			switch r.Form.Get("token") {
+			case "good":
+				fmt.Fprint(w, `{"active":true,"client_id":"key","sub":"1234","exp":4102444800}`)
+			case "audience":
+				fmt.Fprint(w, `{"active":true,"aud":["api","key"],"sub":"1234","exp":4102444800}`)
+			case "other":
+				fmt.Fprint(w, `{"active":true,"client_id":"otherapp","aud":"api","sub":"1234","exp":4102444800}`)
+			default:
+				fmt.Fprint(w, `{"active":false}`)
+			}
+		case "/userinfo":
+			fmt.Fprint(w, `{"sub":"1234","email":"homer@example.com","name":"Homer Simpson"}`)
+		}
+	}))
+	defer idp.Close()
+
+	provider, _ := NewCustomisedURL("key", "secret", "http://localhost/foo", idp.URL+"/auth", idp.URL+"/token", idp.URL, idp.URL+"/userinfo", "")
+	provider.OpenIDConfig.IntrospectionEndpoint = idp.URL + "/introspect"
+
+	user, err := provider.IntrospectToken(context.Background(), "good")
+	a.NoError(err)
+	a.Equal("1234", user.UserID)
+	a.Equal("homer@example.com", user.Email)
+	a.Equal("good", user.AccessToken)
+	a.Equal(int64(4102444800), user.ExpiresAt.Unix())
+
+	_, err = provider.IntrospectToken(context.Background(), "audience")
+	a.NoError(err)
+
+	_, err = provider.IntrospectToken(context.Background(), "bad")
+	a.Error(err)
+
+	// tokens issued to other clients of the provider are rejected
+	_, err = provider.IntrospectToken(context.Background(), "other")
+	a.Equal(&goth.ErrTokenAudience{Provider: "openid-connect", Audience: "otherapp"}, err)
+
+	// userinfo alone can't tell who a token was issued to
+	provider.OpenIDConfig.IntrospectionEndpoint = ""
+	_, err = provider.IntrospectToken(context.Background(), "good")
+	a.Error(err)
+}
+
 func openidConnectProvider() *Provider {
 	provider, _ := New(os.Getenv("OPENID_CONNECT_KEY"), os.Getenv("OPENID_CONNECT_SECRET"), "http://localhost/foo", server.URL)
 	return provider
 }
+
+func Test_HealthCheck(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer idp.Close()
+
+	provider, _ := NewCustomisedURL("key", "secret", "http://localhost/foo", idp.URL+"/auth", idp.URL+"/token", idp.URL, idp.URL+"/userinfo", "")
+	a.NoError(provider.HealthCheck(context.Background()))
+
+	provider.OpenIDConfig.Issuer = idp.URL + "/wrong"
+	a.Error(provider.HealthCheck(context.Background()))
+}
+
+func Test_RevokeToken(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var revoked string
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		revoked = r.Form.Get("token")
+	}))
+	defer idp.Close()
+
+	provider, _ := NewCustomisedURL("key", "secret", "http://localhost/foo", idp.URL+"/auth", idp.URL+"/token", idp.URL, idp.URL+"/userinfo", "")
+	a.Error(provider.RevokeToken(context.Background(), "tok"))
+
+	provider.OpenIDConfig.RevocationEndpoint = idp.URL + "/revoke"
+	a.NoError(provider.RevokeToken(context.Background(), "tok"))
+	a.Equal("tok", revoked)
+}
@@ -0,0 +1,284 @@
+// Package ory implements the OpenID Connect protocol for authenticating users
+// through Ory Network or a self-hosted Ory Hydra, with identities managed by
+// Ory Kratos.
+package ory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// TraitMapping maps goth.User fields to Kratos identity traits, given as
+// dot-separated paths into the traits object, e.g. "name.first". Fields whose
+// path is empty are not mapped.
+type TraitMapping struct {
+	Email     string
+	Name      string
+	FirstName string
+	LastName  string
+	NickName  string
+	AvatarURL string
+	Location  string
+}
+
+// DefaultTraitMapping matches the identity schemas Kratos ships with.
+var DefaultTraitMapping = TraitMapping{
+	Email:     "email",
+	FirstName: "name.first",
+	LastName:  "name.last",
+	NickName:  "username",
+}
+
+// Provider is the implementation of `goth.Provider` for accessing Ory.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	issuerURL    string
+	profileURL   string
+	revokeURL    string
+	audience     []string
+	traitsClaim  string
+	traits       TraitMapping
+}
+
+// New creates a new Ory provider for the issuer at issuerURL, the project's
+// Ory Network URL (e.g. https://<slug>.projects.oryapis.com) or the public URL
+// of a self-hosted Hydra, and sets up important connection details. The
+// openid, profile and email scopes are requested unless other scopes are
+// passed; request offline_access for a refresh token.
+// You should always call `ory.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, issuerURL string, scopes ...string) *Provider {
+	issuerURL = strings.TrimSuffix(issuerURL, "/")
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "ory",
+		issuerURL:    issuerURL,
+		profileURL:   issuerURL + "/userinfo",
+		revokeURL:    issuerURL + "/oauth2/revoke",
+		traitsClaim:  "traits",
+		traits:       DefaultTraitMapping,
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.issuerURL + "/oauth2/auth",
+			TokenURL: provider.issuerURL + "/oauth2/token",
+			// client_secret_basic is Hydra's default token_endpoint_auth_method
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, "openid", "profile", "email")
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the ory package.
+func (p *Provider) Debug(debug bool) {}
+
+// IssuerURL returns the provider's issuer.
+func (p *Provider) IssuerURL() string {
+	return p.issuerURL
+}
+
+// SetAudience sets the audiences access tokens are requested for, e.g. the
+// URLs of the APIs the application calls with them. Each must be in the
+// client's allowed audience list.
+func (p *Provider) SetAudience(audience ...string) {
+	p.audience = audience
+}
+
+// SetTraitMapping sets how Kratos identity traits found in the traitsClaim
+// claim of the userinfo response are mapped into goth.User, for fields the
+// standard claims leave empty. traitsClaim is "traits" by default; with a
+// self-hosted Hydra it is whichever claim the consent app puts the identity's
+// traits in.
+func (p *Provider) SetTraitMapping(traitsClaim string, mapping TraitMapping) {
+	p.traitsClaim = traitsClaim
+	p.traits = mapping
+}
+
+// BeginAuth asks Ory for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	var opts []oauth2.AuthCodeOption
+	if len(p.audience) > 0 {
+		opts = append(opts, oauth2.SetAuthURLParam("audience", strings.Join(p.audience, " ")))
+	}
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state, opts...),
+	}, nil
+}
+
+// FetchUser will go to Ory and access basic information about the user.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(bytes.NewReader(bits)).Decode(&claims); err != nil {
+		return user, err
+	}
+	user.UserID, _ = claims["sub"].(string)
+	user.Email, _ = claims["email"].(string)
+	user.EmailVerified, _ = claims["email_verified"].(bool)
+	user.Name, _ = claims["name"].(string)
+	user.NickName, _ = claims["preferred_username"].(string)
+	user.FirstName, _ = claims["given_name"].(string)
+	user.LastName, _ = claims["family_name"].(string)
+	user.AvatarURL, _ = claims["picture"].(string)
+	user.Location, _ = claims["locale"].(string)
+
+	if traits, ok := claims[p.traitsClaim].(map[string]interface{}); ok {
+		for _, f := range []struct {
+			field *string
+			path  string
+		}{
+			{&user.Email, p.traits.Email},
+			{&user.Name, p.traits.Name},
+			{&user.FirstName, p.traits.FirstName},
+			{&user.LastName, p.traits.LastName},
+			{&user.NickName, p.traits.NickName},
+			{&user.AvatarURL, p.traits.AvatarURL},
+			{&user.Location, p.traits.Location},
+		} {
+			if *f.field == "" && f.path != "" {
+				*f.field = trait(traits, f.path)
+			}
+		}
+	}
+	return user, nil
+}
+
+// trait returns the string at the dot-separated path in traits.
+func trait(traits map[string]interface{}, path string) string {
+	var v interface{} = traits
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		v = m[key]
+	}
+	s, _ := v.(string)
+	return s
+}
+
+// RevokeToken revokes an access or refresh token at Ory's revocation endpoint.
+func (p *Provider) RevokeToken(ctx context.Context, token string) error {
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.revokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.ClientKey), url.QueryEscape(p.Secret))
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with a %d trying to revoke a token", p.providerName, response.StatusCode)
+	}
+	return nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
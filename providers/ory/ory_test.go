@@ -0,0 +1,129 @@
+package ory_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/ory"
+	"github.com/stretchr/testify/assert"
+)
+
+const issuer = "https://example.projects.oryapis.com"
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "ory")
+	a.Equal(p.ClientKey, "myapp")
+	a.Equal(p.Secret, "secret")
+	a.Equal(p.CallbackURL, "/foo")
+	a.Equal(issuer, p.IssuerURL())
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+	a.Implements((*goth.TokenRevoker)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*ory.Session)
+	a.Contains(s.AuthURL, issuer+"/oauth2/auth")
+	a.Contains(s.AuthURL, "scope=openid+profile+email")
+	a.NotContains(s.AuthURL, "audience=")
+
+	p.SetAudience("https://api.example.com", "https://billing.example.com")
+	session, err = p.BeginAuth("test_state")
+	a.NoError(err)
+	u, _ := url.Parse(session.(*ory.Session).AuthURL)
+	a.Equal("https://api.example.com https://billing.example.com", u.Query().Get("audience"))
+}
+
+func Test_AuthorizeChecksIssuer(t *testing.T) {
+	t.Parallel()
+
+	for name, claims := range map[string]string{
+		"valid":       `{"iss":"` + issuer + `/","aud":["myapp"]}`,
+		"otherIssuer": `{"iss":"https://other.projects.oryapis.com","aud":["myapp"]}`,
+	} {
+		name, claims := name, claims
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := provider()
+			p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				a.Equal(issuer+"/oauth2/token", req.URL.String())
+				rec := httptest.NewRecorder()
+				rec.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(rec, `{"access_token":"ory_at_1234567890","token_type":"bearer","expires_in":3599,"id_token":%q}`, gothtest.UnsignedJWT(claims))
+				return rec.Result(), nil
+			})}
+			session, _ := p.BeginAuth("test_state")
+			_, err := session.Authorize(p, url.Values{"code": {"code"}})
+			if name == "valid" {
+				a.NoError(err)
+			} else {
+				a.IsType(&goth.ErrIssuerMismatch{}, err)
+			}
+		})
+	}
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal(issuer+"/userinfo", req.URL.String())
+		a.Equal("Bearer ory_at_1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"sub":"9f425a8d-7efc-4768-8f23-7647a74fdf13","email":"jdoe@example.com","email_verified":true,
+			"traits":{"email":"other@example.com","name":{"first":"John","last":"Doe"},"username":"jdoe"}}`)
+		return rec.Result(), nil
+	})}
+	user, err := p.FetchUser(&ory.Session{AccessToken: "ory_at_1234567890"})
+	a.NoError(err)
+	a.Equal("9f425a8d-7efc-4768-8f23-7647a74fdf13", user.UserID)
+	a.Equal("jdoe@example.com", user.Email)
+	a.True(user.EmailVerified)
+	a.Equal("John", user.FirstName)
+	a.Equal("Doe", user.LastName)
+	a.Equal("jdoe", user.NickName)
+
+	p.SetTraitMapping("traits", ory.TraitMapping{Name: "name.first"})
+	user, err = p.FetchUser(&ory.Session{AccessToken: "ory_at_1234567890"})
+	a.NoError(err)
+	a.Equal("John", user.Name)
+	a.Empty(user.FirstName)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://example.projects.oryapis.com/oauth2/auth","AccessToken":"ory_at_1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*ory.Session)
+	a.Equal(s.AuthURL, "https://example.projects.oryapis.com/oauth2/auth")
+	a.Equal(s.AccessToken, "ory_at_1234567890")
+}
+
+func provider() *ory.Provider {
+	return ory.New("myapp", "secret", "/foo", issuer)
+}
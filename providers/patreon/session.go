@@ -1,6 +1,7 @@
 package patreon
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"time"
@@ -28,8 +29,13 @@ func (s *Session) GetAuthURL() (string, error) {
 // Authorize completes the authorization with Patreon and returns the access
 // token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.config.Exchange(goth.ContextForClient(p.Client()), params.Get("code"))
+	token, err := p.config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"))
 	if err != nil {
 		return "", err
 	}
@@ -58,6 +64,6 @@ func (s *Session) String() string {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := Session{}
-	err := json.Unmarshal([]byte(data), &s)
+	err := goth.DecodeSession(data, &s)
 	return &s, err
 }
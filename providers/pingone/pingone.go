@@ -0,0 +1,290 @@
+// Package pingone implements the OpenID Connect protocol for authenticating
+// users through a PingOne environment.
+package pingone
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// The top level domains of the PingOne regions, for use with New.
+const (
+	RegionNorthAmerica = "com"
+	RegionEurope       = "eu"
+	RegionAsiaPacific  = "asia"
+	RegionCanada       = "ca"
+	RegionAustralia    = "com.au"
+)
+
+// requestObjectLifetime is how long a signed request object is valid for.
+const requestObjectLifetime = 5 * time.Minute
+
+// Provider is the implementation of `goth.Provider` for accessing PingOne.
+type Provider struct {
+	ClientKey     string
+	Secret        string
+	CallbackURL   string
+	HTTPClient    *http.Client
+	config        *oauth2.Config
+	providerName  string
+	issuerURL     string
+	profileURL    string
+	groupsClaim   string
+	requestMethod jwt.SigningMethod
+	requestKey    interface{}
+}
+
+// New creates a new PingOne provider for the environment with the given ID in
+// region, one of the Region constants, and sets up important connection
+// details. The openid, profile and email scopes are requested unless other
+// scopes are passed.
+// You should always call `pingone.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, region, environmentID string, scopes ...string) *Provider {
+	return NewWithIssuer(clientKey, secret, callbackURL, "https://auth.pingone."+region+"/"+environmentID+"/as", scopes...)
+}
+
+// NewWithIssuer is like New but takes the issuer URL, e.g. for environments
+// on a custom domain.
+func NewWithIssuer(clientKey, secret, callbackURL, issuerURL string, scopes ...string) *Provider {
+	issuerURL = strings.TrimSuffix(issuerURL, "/")
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "pingone",
+		issuerURL:    issuerURL,
+		profileURL:   issuerURL + "/userinfo",
+		groupsClaim:  "groups",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.issuerURL + "/authorize",
+			TokenURL: provider.issuerURL + "/token",
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, "openid", "profile", "email")
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the pingone package.
+func (p *Provider) Debug(debug bool) {}
+
+// IssuerURL returns the issuer of the provider's environment.
+func (p *Provider) IssuerURL() string {
+	return p.issuerURL
+}
+
+// SetGroupsClaim sets the claim User.Groups is filled in from, "groups" by
+// default. PingOne only issues group claims that have been mapped to an
+// attribute such as memberOfGroupNames in the application's attribute mappings.
+func (p *Provider) SetGroupsClaim(claim string) {
+	p.groupsClaim = claim
+}
+
+// SetRequestObjectKey makes BeginAuth pass the authorization request as a
+// request object signed with key, for applications that require signed
+// requests. Use jwt.SigningMethodHS256 with the client secret as a []byte, or
+// jwt.SigningMethodRS256 with the *rsa.PrivateKey whose public key has been
+// added to the application.
+func (p *Provider) SetRequestObjectKey(method jwt.SigningMethod, key interface{}) {
+	p.requestMethod = method
+	p.requestKey = key
+}
+
+// BeginAuth asks PingOne for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	var opts []oauth2.AuthCodeOption
+	if p.requestMethod != nil {
+		request, err := p.requestObject(state)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, oauth2.SetAuthURLParam("request", request))
+	}
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state, opts...),
+	}, nil
+}
+
+// requestObject returns the signed request object for an authorization
+// request with the given state.
+func (p *Provider) requestObject(state string) (string, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	now := goth.GetClock().Now()
+	claims := jwt.MapClaims{
+		"iss":           p.ClientKey,
+		"aud":           p.issuerURL,
+		"iat":           now.Unix(),
+		"exp":           now.Add(requestObjectLifetime).Unix(),
+		"jti":           base64.RawURLEncoding.EncodeToString(jti),
+		"client_id":     p.ClientKey,
+		"response_type": "code",
+		"redirect_uri":  p.CallbackURL,
+		"scope":         strings.Join(p.config.Scopes, " "),
+		"state":         state,
+	}
+	return jwt.NewWithClaims(p.requestMethod, claims).SignedString(p.requestKey)
+}
+
+// FetchUser will go to PingOne and access basic information about the user.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(bytes.NewReader(bits)).Decode(&claims); err != nil {
+		return user, err
+	}
+	user.UserID, _ = claims["sub"].(string)
+	user.Email, _ = claims["email"].(string)
+	user.EmailVerified, _ = claims["email_verified"].(bool)
+	user.Name, _ = claims["name"].(string)
+	user.NickName, _ = claims["preferred_username"].(string)
+	user.FirstName, _ = claims["given_name"].(string)
+	user.LastName, _ = claims["family_name"].(string)
+	user.AvatarURL, _ = claims["picture"].(string)
+	user.Groups = stringList(claims[p.groupsClaim])
+	return user, nil
+}
+
+// PopulationID returns the ID of the population a user returned by FetchUser
+// belongs to. It requires an attribute mapping of population.id to the
+// population claim; the claim may also hold the population object.
+func PopulationID(user goth.User) (string, error) {
+	var c struct {
+		Population interface{} `json:"population"`
+	}
+	if err := user.DecodeRawJSON(&c); err != nil {
+		return "", err
+	}
+	switch population := c.Population.(type) {
+	case string:
+		return population, nil
+	case map[string]interface{}:
+		id, _ := population["id"].(string)
+		return id, nil
+	}
+	return "", nil
+}
+
+// stringList returns the values of a claim holding a list of strings or a
+// single string.
+func stringList(claim interface{}) []string {
+	var values []string
+	switch c := claim.(type) {
+	case []interface{}:
+		for _, v := range c {
+			if s, ok := v.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+	case string:
+		if c != "" {
+			values = append(values, c)
+		}
+	}
+	return values
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
@@ -0,0 +1,137 @@
+package pingone_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/pingone"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "pingone")
+	a.Equal(p.ClientKey, "myapp")
+	a.Equal(p.Secret, "secret")
+	a.Equal(p.CallbackURL, "/foo")
+	a.Equal("https://auth.pingone.eu/env-1/as", p.IssuerURL())
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*pingone.Session)
+	a.Contains(s.AuthURL, "https://auth.pingone.eu/env-1/as/authorize")
+	a.Contains(s.AuthURL, "scope=openid+profile+email")
+	a.NotContains(s.AuthURL, "request=")
+}
+
+func Test_BeginAuth_RequestObject(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+	p.SetRequestObjectKey(jwt.SigningMethodHS256, []byte("secret"))
+
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	u, err := url.Parse(session.(*pingone.Session).AuthURL)
+	a.NoError(err)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(u.Query().Get("request"), claims, func(*jwt.Token) (interface{}, error) {
+		return []byte("secret"), nil
+	})
+	a.NoError(err)
+	a.Equal("myapp", claims["client_id"])
+	a.Equal("https://auth.pingone.eu/env-1/as", claims["aud"])
+	a.Equal("test_state", claims["state"])
+	a.Equal("openid profile email", claims["scope"])
+}
+
+func Test_AuthorizeChecksIssuer(t *testing.T) {
+	t.Parallel()
+
+	for name, claims := range map[string]string{
+		"valid":            `{"iss":"%s/env-1/as","aud":"myapp"}`,
+		"otherEnvironment": `{"iss":"%s/env-2/as","aud":"myapp"}`,
+	} {
+		name, claims := name, claims
+		t.Run(name, func(t *testing.T) {
+			a := assert.New(t)
+			var ts *httptest.Server
+			ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				a.Equal("/env-1/as/token", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"access_token":"1234567890","token_type":"Bearer","expires_in":3600,"id_token":%q}`, gothtest.UnsignedJWT(fmt.Sprintf(claims, ts.URL)))
+			}))
+			defer ts.Close()
+
+			p := pingone.NewWithIssuer("myapp", "secret", "/foo", ts.URL+"/env-1/as")
+			session, _ := p.BeginAuth("test_state")
+			_, err := session.Authorize(p, url.Values{"code": {"code"}})
+			if name == "valid" {
+				a.NoError(err)
+			} else {
+				a.IsType(&goth.ErrIssuerMismatch{}, err)
+			}
+		})
+	}
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("/env-1/as/userinfo", r.URL.Path)
+		fmt.Fprint(w, `{"sub":"user-1","email":"jdoe@example.com","email_verified":true,"name":"John Doe",
+			"preferred_username":"jdoe","given_name":"John","family_name":"Doe",
+			"groups":["Admins","Staff"],"population":{"id":"pop-1"}}`)
+	}))
+	defer ts.Close()
+
+	p := pingone.NewWithIssuer("myapp", "secret", "/foo", ts.URL+"/env-1/as")
+	user, err := p.FetchUser(&pingone.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("user-1", user.UserID)
+	a.Equal("jdoe", user.NickName)
+	a.True(user.EmailVerified)
+	a.Equal([]string{"Admins", "Staff"}, user.Groups)
+
+	population, err := pingone.PopulationID(user)
+	a.NoError(err)
+	a.Equal("pop-1", population)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://auth.pingone.eu/env-1/as/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*pingone.Session)
+	a.Equal(s.AuthURL, "https://auth.pingone.eu/env-1/as/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *pingone.Provider {
+	return pingone.New("myapp", "secret", "/foo", pingone.RegionEurope, "env-1")
+}
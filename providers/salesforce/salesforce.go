@@ -4,11 +4,13 @@ package salesforce
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -21,6 +23,8 @@ import (
 //
 //	salesforce.AuthURL = "https://salesforce.acme.com/services/oauth2/authorize
 //	salesforce.TokenURL = "https://salesforce.acme.com/services/oauth2/token
+//
+// NewWithHost can be used instead to log in to sandboxes or My Domain hosts.
 var (
 	AuthURL  = "https://login.salesforce.com/services/oauth2/authorize"
 	TokenURL = "https://login.salesforce.com/services/oauth2/token"
@@ -28,6 +32,24 @@ var (
 	// endpointProfile    string = "https://api.salesforce.com/2.0/users/me"
 )
 
+// Hosts of the Salesforce login endpoints, for use with NewWithHost.
+const (
+	// LoginHost is used to log in to production orgs.
+	LoginHost = "login.salesforce.com"
+	// TestHost is used to log in to sandbox orgs.
+	TestHost = "test.salesforce.com"
+)
+
+// ErrIDHost is returned when the identity URL issued with a token is not
+// served from Salesforce, so it cannot be trusted with the access token.
+type ErrIDHost struct {
+	ID string
+}
+
+func (e *ErrIDHost) Error() string {
+	return fmt.Sprintf("salesforce: identity URL %q is not on a Salesforce host", e.ID)
+}
+
 // Provider is the implementation of `goth.Provider` for accessing Salesforce.
 type Provider struct {
 	ClientKey    string
@@ -52,6 +74,17 @@ func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
 	return p
 }
 
+// NewWithHost is like New but logs in through host, e.g. TestHost for
+// sandboxes or an org's My Domain host such as "acme.my.salesforce.com".
+func NewWithHost(clientKey, secret, callbackURL, host string, scopes ...string) *Provider {
+	p := New(clientKey, secret, callbackURL, scopes...)
+	p.config.Endpoint = oauth2.Endpoint{
+		AuthURL:  "https://" + host + "/services/oauth2/authorize",
+		TokenURL: "https://" + host + "/services/oauth2/token",
+	}
+	return p
+}
+
 // Name is the name used to retrieve this provider later.
 func (p *Provider) Name() string {
 	return p.providerName
@@ -78,6 +111,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Salesforce and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	s := session.(*Session)
 	user := goth.User{
 		AccessToken:  s.AccessToken,
@@ -90,14 +128,13 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	url, err := url.Parse(s.ID)
+	// the identity URL is also where the user information is retrieved from
+	idURL, err := p.checkID(s.ID)
 	if err != nil {
 		return user, err
 	}
 
-	// creating dynamic url to retrieve user information
-	userURL := url.Scheme + "://" + url.Host + "/" + url.Path
-	req, err := http.NewRequest("GET", userURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", idURL.String(), nil)
 	if err != nil {
 		return user, err
 	}
@@ -116,6 +153,40 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	return user, err
 }
 
+// checkID parses the identity URL returned with a token. It must be an https
+// URL on the host the provider logs in through, or on a salesforce.com or
+// force.com domain.
+func (p *Provider) checkID(id string) (*url.URL, error) {
+	u, err := url.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+
+	host := strings.ToLower(u.Hostname())
+	trusted := host == LoginHost || host == TestHost ||
+		strings.HasSuffix(host, ".salesforce.com") || strings.HasSuffix(host, ".force.com")
+	if tokenURL, err := url.Parse(p.config.Endpoint.TokenURL); err == nil && strings.EqualFold(tokenURL.Hostname(), host) {
+		trusted = true
+	}
+	if u.Scheme != "https" || !trusted {
+		return nil, &ErrIDHost{ID: id}
+	}
+
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u, nil
+}
+
+// orgID returns the organization ID from an identity URL of the form
+// https://login.salesforce.com/id/<org ID>/<user ID>.
+func orgID(u *url.URL) string {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) == 3 && parts[0] == "id" {
+		return parts[1]
+	}
+	return ""
+}
+
 func newConfig(provider *Provider, scopes []string) *oauth2.Config {
 	c := &oauth2.Config{
 		ClientID:     provider.ClientKey,
@@ -181,8 +252,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -1,10 +1,15 @@
 package salesforce_test
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/salesforce"
 	"github.com/stretchr/testify/assert"
 )
@@ -48,6 +53,46 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal(s.AccessToken, "1234567890")
 }
 
+func tokenClient(id string) *http.Client {
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(rec, `{"access_token":"1234567890","token_type":"Bearer","id":%q,"instance_url":"https://acme--dev.sandbox.my.salesforce.com"}`, id)
+		return rec.Result(), nil
+	})}
+}
+
+func Test_NewWithHost(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := salesforce.NewWithHost(os.Getenv("SALESFORCE_KEY"), os.Getenv("SALESFORCE_SECRET"), "/foo", salesforce.TestHost)
+	p.HTTPClient = tokenClient("https://test.salesforce.com/id/00D000000000001AAA/005000000000001AAA")
+
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*salesforce.Session)
+	a.Contains(s.AuthURL, "https://test.salesforce.com/services/oauth2/authorize")
+
+	_, err = s.Authorize(p, url.Values{"code": {"code"}})
+	a.NoError(err)
+	a.Equal("00D000000000001AAA", s.OrgID)
+	a.Equal("https://acme--dev.sandbox.my.salesforce.com", s.InstanceURL)
+}
+
+func Test_Authorize_UntrustedID(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = tokenClient("https://evil.example.com/id/00D000000000001AAA/005000000000001AAA")
+
+	s := &salesforce.Session{}
+	_, err := s.Authorize(p, url.Values{"code": {"code"}})
+	a.IsType(&salesforce.ErrIDHost{}, err)
+	a.Empty(s.AccessToken)
+}
+
 func provider() *salesforce.Provider {
 	return salesforce.New(os.Getenv("SALESFORCE_KEY"), os.Getenv("SALESFORCE_SECRET"), "/foo")
 }
@@ -1,9 +1,9 @@
 package salesforce
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"strings"
 
 	"github.com/markbates/goth"
 )
@@ -23,6 +23,10 @@ type Session struct {
 	AccessToken  string
 	RefreshToken string
 	ID           string // Required to get the user info from sales force
+	// OrgID is the ID of the user's organization.
+	OrgID string `json:",omitempty"`
+	// InstanceURL is the base URL of the org's instance, for subsequent API calls.
+	InstanceURL string `json:",omitempty"`
 }
 
 var _ goth.Session = &Session{}
@@ -37,8 +41,13 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with Salesforce and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.config.Exchange(goth.ContextForClient(p.Client()), params.Get("code"))
+	token, err := p.config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"))
 
 	if err != nil {
 		return "", err
@@ -48,9 +57,17 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 		return "", errors.New("Invalid token received from provider")
 	}
 
+	id, _ := token.Extra("id").(string) // Required to get the user info from sales force
+	idURL, err := p.checkID(id)
+	if err != nil {
+		return "", err
+	}
+
 	s.AccessToken = token.AccessToken
 	s.RefreshToken = token.RefreshToken
-	s.ID = token.Extra("id").(string) // Required to get the user info from sales force
+	s.ID = id
+	s.OrgID = orgID(idURL)
+	s.InstanceURL, _ = token.Extra("instance_url").(string)
 	return token.AccessToken, err
 }
 
@@ -67,6 +84,6 @@ func (s Session) String() string {
 // UnmarshalSession wil unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(s)
+	err := goth.DecodeSession(data, s)
 	return s, err
 }
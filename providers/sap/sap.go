@@ -0,0 +1,333 @@
+// Package sap implements the OAuth2 and OpenID Connect protocols for
+// authenticating users through SAP: the XSUAA service of SAP BTP subaccounts,
+// and SAP Cloud Identity Services - Identity Authentication (IAS) tenants,
+// which SuccessFactors and other SAP cloud applications delegate their logins
+// to.
+package sap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// OpenID Connect scopes. ScopeOpenID is always requested.
+const (
+	ScopeOpenID  = "openid"
+	ScopeEmail   = "email"
+	ScopeProfile = "profile"
+	ScopeGroups  = "groups"
+)
+
+// GrantTypeSAML2Bearer is the RawDataGrantType of access tokens exchanged for
+// a SAML assertion, as done for principal propagation from SuccessFactors and
+// other SAML service providers, rather than issued to an interactive login.
+const GrantTypeSAML2Bearer = "urn:ietf:params:oauth:grant-type:saml2-bearer"
+
+// Keys of User.RawData set by FetchUser from the claims of XSUAA access
+// tokens, in addition to the userinfo claims. They are not set for IAS, whose
+// access tokens are opaque.
+const (
+	// RawDataZoneID holds the ID of the identity zone (tenant) of the user.
+	RawDataZoneID = "zid"
+	// RawDataSubdomain holds the subdomain of the subaccount of the user.
+	RawDataSubdomain = "zdn"
+	// RawDataOrigin holds the origin key of the identity provider that
+	// authenticated the user, e.g. "sap.default", or the origin of a trusted
+	// SAML identity provider.
+	RawDataOrigin = "origin"
+	// RawDataGrantType holds the grant type the access token was issued for,
+	// e.g. "authorization_code" or GrantTypeSAML2Bearer.
+	RawDataGrantType = "grant_type"
+	// RawDataSAMLAttributes holds the attributes of the user mapped from the
+	// SAML assertion of the identity provider, as a map of string lists. They
+	// are only as trustworthy as the identity provider's attribute mappings.
+	RawDataSAMLAttributes = "xs.user.attributes"
+)
+
+// Provider is the implementation of `goth.Provider` for accessing SAP.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	issuerURL    string
+	userInfoURL  string
+	jwksURL      string
+	jwks         *goth.JWKSCache
+	jwksOnce     sync.Once
+}
+
+// NewXSUAA creates a new provider for the XSUAA service of the SAP BTP
+// subaccount with the given subdomain, in the given region (e.g. "eu10"),
+// using the clientid and clientsecret of a service binding. Multitenant
+// applications need a provider for the subdomain of each subscribed
+// subaccount, told apart with SetName.
+// You should always call `sap.NewXSUAA` or `sap.NewIAS` to get a new
+// provider.  Never try to create one manually.
+func NewXSUAA(clientKey, secret, callbackURL, subdomain, region string, scopes ...string) *Provider {
+	baseURL := "https://" + subdomain + ".authentication." + region + ".hana.ondemand.com"
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "sap",
+		issuerURL:    baseURL + "/oauth/token",
+		userInfoURL:  baseURL + "/userinfo",
+		jwksURL:      baseURL + "/token_keys",
+	}
+	p.config = newConfig(p, baseURL+"/oauth/authorize", baseURL+"/oauth/token", scopes)
+	return p
+}
+
+// NewIAS creates a new provider for the Identity Authentication tenant with
+// the given subdomain (the "acme" of acme.accounts.ondemand.com).
+// You should always call `sap.NewXSUAA` or `sap.NewIAS` to get a new
+// provider.  Never try to create one manually.
+func NewIAS(clientKey, secret, callbackURL, tenant string, scopes ...string) *Provider {
+	baseURL := "https://" + tenant + ".accounts.ondemand.com"
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "sap",
+		issuerURL:    baseURL,
+		userInfoURL:  baseURL + "/oauth2/userinfo",
+		jwksURL:      baseURL + "/oauth2/certs",
+	}
+	p.config = newConfig(p, baseURL+"/oauth2/authorize", baseURL+"/oauth2/token", scopes)
+	return p
+}
+
+func newConfig(provider *Provider, authURL, tokenURL string, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+		Scopes: []string{ScopeOpenID},
+	}
+
+	for _, scope := range scopes {
+		if scope != ScopeOpenID {
+			c.Scopes = append(c.Scopes, scope)
+		}
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the sap package.
+func (p *Provider) Debug(debug bool) {}
+
+// IssuerURL returns the issuer of the id_tokens the provider accepts.
+func (p *Provider) IssuerURL() string {
+	return p.issuerURL
+}
+
+// BeginAuth asks SAP for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to SAP and access basic information about the user. The
+// role collections of XSUAA users are stored in Roles, and the groups of IAS
+// users in Groups.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.userInfoURL, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	req.Header.Set("Accept", "application/json")
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	u := struct {
+		Sub           string      `json:"sub"`
+		UserID        string      `json:"user_id"`
+		UserName      string      `json:"user_name"`
+		Name          string      `json:"name"`
+		GivenName     string      `json:"given_name"`
+		FamilyName    string      `json:"family_name"`
+		Email         string      `json:"email"`
+		EmailVerified interface{} `json:"email_verified"`
+		Groups        []string    `json:"groups"`
+	}{}
+	if err := json.Unmarshal(bits, &u); err != nil {
+		return user, err
+	}
+	user.UserID = u.UserID
+	if user.UserID == "" {
+		user.UserID = u.Sub
+	}
+	user.NickName = u.UserName
+	user.FirstName = u.GivenName
+	user.LastName = u.FamilyName
+	user.Name = u.Name
+	if user.Name == "" {
+		user.Name = strings.TrimSpace(u.GivenName + " " + u.FamilyName)
+	}
+	user.Email = u.Email
+	// IAS returns email_verified as a string
+	switch v := u.EmailVerified.(type) {
+	case bool:
+		user.EmailVerified = v
+	case string:
+		user.EmailVerified = v == "true"
+	}
+	user.Groups = u.Groups
+
+	if claims, ok := accessTokenClaims(sess.AccessToken); ok {
+		user.Roles = claims.System.RoleCollections
+		user.SetRawData(RawDataZoneID, claims.ZoneID)
+		user.SetRawData(RawDataSubdomain, claims.Ext.Subdomain)
+		user.SetRawData(RawDataOrigin, claims.Origin)
+		user.SetRawData(RawDataGrantType, claims.GrantType)
+		if claims.UserAttributes != nil {
+			user.SetRawData(RawDataSAMLAttributes, claims.UserAttributes)
+		}
+	}
+	return user, nil
+}
+
+// xsuaaClaims are the claims of XSUAA access tokens.
+type xsuaaClaims struct {
+	ZoneID    string `json:"zid"`
+	Origin    string `json:"origin"`
+	GrantType string `json:"grant_type"`
+	Ext       struct {
+		Subdomain string `json:"zdn"`
+	} `json:"ext_attr"`
+	UserAttributes map[string][]string `json:"xs.user.attributes"`
+	System         struct {
+		RoleCollections []string `json:"xs.rolecollections"`
+	} `json:"xs.system.attributes"`
+}
+
+// accessTokenClaims reads the claims of an XSUAA access token, which has been
+// accepted by the userinfo endpoint. It returns false for opaque tokens.
+func accessTokenClaims(token string) (*xsuaaClaims, bool) {
+	claims := &xsuaaClaims{}
+	if err := goth.DecodeJWTClaims(token, claims); err != nil || claims.ZoneID == "" {
+		return nil, false
+	}
+	return claims, true
+}
+
+// verifyIDToken validates the signature of an id_token against the signing
+// keys of the subaccount or tenant, and that it was issued by it to the
+// provider's client.
+func (p *Provider) verifyIDToken(ctx context.Context, idToken string) error {
+	claims := &jwt.RegisteredClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256"}), jwt.WithoutClaimsValidation())
+	_, err := parser.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.verificationKeys().PublicKey(ctx, p.jwksURL, kid)
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !claims.VerifyIssuer(p.issuerURL, true):
+		return &goth.ErrIssuerMismatch{Provider: p.Name(), Want: p.issuerURL, Got: claims.Issuer}
+	case !claims.VerifyAudience(p.ClientKey, true):
+		return fmt.Errorf("sap: id_token was not issued for %q", p.ClientKey)
+	case !claims.VerifyExpiresAt(goth.GetClock().Now(), true):
+		return errors.New("sap: id_token has expired")
+	}
+	return nil
+}
+
+// verificationKeys returns the cache of the id_token signing keys.
+func (p *Provider) verificationKeys() *goth.JWKSCache {
+	p.jwksOnce.Do(func() {
+		p.jwks = goth.NewJWKSCache(p.HTTPClient)
+	})
+	return p.jwks
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
@@ -0,0 +1,226 @@
+package sap_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/sap"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("SAP_KEY"))
+	a.Equal(p.Secret, os.Getenv("SAP_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+	a.Equal(p.IssuerURL(), "https://acme.authentication.eu10.hana.ondemand.com/oauth/token")
+	a.Equal(sap.NewIAS("", "", "/foo", "acme").IssuerURL(), "https://acme.accounts.ondemand.com")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().BeginAuth("test_state")
+	s := session.(*sap.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "https://acme.authentication.eu10.hana.ondemand.com/oauth/authorize")
+	a.Contains(s.AuthURL, "scope=openid")
+
+	session, err = sap.NewIAS(os.Getenv("SAP_KEY"), os.Getenv("SAP_SECRET"), "/foo", "acme", sap.ScopeEmail, sap.ScopeGroups).BeginAuth("test_state")
+	s = session.(*sap.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "https://acme.accounts.ondemand.com/oauth2/authorize")
+	a.Contains(s.AuthURL, "scope=openid+email+groups")
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	k, _ := jwk.New(&key.PublicKey)
+	k.Set(jwk.KeyIDKey, "key-1")
+	set := jwk.NewSet()
+	set.Add(k)
+	jwks, _ := json.Marshal(set)
+
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": "https://acme.accounts.ondemand.com",
+			"aud": "myapp",
+			"sub": "P000001",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	sign := func(key *rsa.PrivateKey, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key-1"
+		s, _ := token.SignedString(key)
+		return s
+	}
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	for name, tc := range map[string]struct {
+		idToken func() string
+		valid   bool
+	}{
+		"valid":       {func() string { return sign(key, claims()) }, true},
+		"otherKey":    {func() string { return sign(other, claims()) }, false},
+		"otherApp":    {func() string { c := claims(); c["aud"] = "otherapp"; return sign(key, c) }, false},
+		"otherTenant": {func() string { c := claims(); c["iss"] = "https://evil.accounts.ondemand.com"; return sign(key, c) }, false},
+		"expired":     {func() string { c := claims(); c["exp"] = time.Now().Add(-time.Hour).Unix(); return sign(key, c) }, false},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			idToken := tc.idToken()
+			p := sap.NewIAS("myapp", "secret", "/foo", "acme")
+			p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				rec := httptest.NewRecorder()
+				if req.URL.String() == "https://acme.accounts.ondemand.com/oauth2/certs" {
+					rec.Write(jwks)
+					return rec.Result(), nil
+				}
+				a.Equal("https://acme.accounts.ondemand.com/oauth2/token", req.URL.String())
+				rec.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(rec, `{"access_token":"1234567890","refresh_token":"r","token_type":"bearer","expires_in":3600,"id_token":%q}`, idToken)
+				return rec.Result(), nil
+			})}
+
+			s := &sap.Session{}
+			_, err := s.Authorize(p, url.Values{"code": {"code"}})
+			if !tc.valid {
+				a.Error(err)
+				return
+			}
+			a.NoError(err)
+			a.Equal("1234567890", s.AccessToken)
+			a.Equal(idToken, s.IDToken)
+		})
+	}
+}
+
+func accessToken(claims string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"RS256"}`)) + "." + enc([]byte(claims)) + ".sig"
+}
+
+func Test_FetchUser_XSUAA(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	token := accessToken(`{"zid":"2bd7a1e2-2d10-4c4b-8c2f-6b361dbb4ea0","origin":"acme-saml","grant_type":"` + sap.GrantTypeSAML2Bearer + `",
+		"user_name":"jane.doe@example.com","ext_attr":{"zdn":"acme","subaccountid":"2bd7a1e2"},
+		"xs.user.attributes":{"costcenter":["1000"]},"xs.system.attributes":{"xs.rolecollections":["Administrator","Viewer"]}}`)
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://acme.authentication.eu10.hana.ondemand.com/userinfo", req.URL.String())
+		a.Equal("Bearer "+token, req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"user_id":"b2f3d6c8-5e7a","user_name":"jane.doe@example.com","given_name":"Jane","family_name":"Doe",
+			"email":"jane.doe@example.com","email_verified":false,"origin":"acme-saml","zid":"2bd7a1e2-2d10-4c4b-8c2f-6b361dbb4ea0","sub":"b2f3d6c8-5e7a"}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&sap.Session{AccessToken: token})
+	a.NoError(err)
+	a.Equal("b2f3d6c8-5e7a", user.UserID)
+	a.Equal("jane.doe@example.com", user.NickName)
+	a.Equal("Jane Doe", user.Name)
+	a.Equal("jane.doe@example.com", user.Email)
+	a.False(user.EmailVerified)
+	a.Equal([]string{"Administrator", "Viewer"}, user.Roles)
+	a.Equal("2bd7a1e2-2d10-4c4b-8c2f-6b361dbb4ea0", user.RawData[sap.RawDataZoneID])
+	a.Equal("acme", user.RawData[sap.RawDataSubdomain])
+	a.Equal("acme-saml", user.RawData[sap.RawDataOrigin])
+	a.Equal(sap.GrantTypeSAML2Bearer, user.RawData[sap.RawDataGrantType])
+	a.Equal(map[string][]string{"costcenter": {"1000"}}, user.RawData[sap.RawDataSAMLAttributes])
+}
+
+func Test_FetchUser_XSUAA_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	token := accessToken(`{"zid":"2bd7a1e2-2d10-4c4b-8c2f-6b361dbb4ea0","ext_attr":{"zdn":"acme"},"xs.user.attributes":{"costcenter":["1000"]}}`)
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"user_id":"b2f3d6c8-5e7a","user_name":"jane.doe@example.com","sub":"b2f3d6c8-5e7a"}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&sap.Session{AccessToken: token})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("jane.doe@example.com", raw["user_name"])
+	a.Equal("2bd7a1e2-2d10-4c4b-8c2f-6b361dbb4ea0", raw[sap.RawDataZoneID])
+	a.Equal("acme", raw[sap.RawDataSubdomain])
+	a.Equal(map[string][]string{"costcenter": {"1000"}}, raw[sap.RawDataSAMLAttributes])
+}
+
+func Test_FetchUser_IAS(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := sap.NewIAS(os.Getenv("SAP_KEY"), os.Getenv("SAP_SECRET"), "/foo", "acme")
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://acme.accounts.ondemand.com/oauth2/userinfo", req.URL.String())
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"sub":"P000001","given_name":"Jane","family_name":"Doe","email":"jane.doe@example.com",
+			"email_verified":"true","groups":["HR"]}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&sap.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("P000001", user.UserID)
+	a.Equal("Jane Doe", user.Name)
+	a.True(user.EmailVerified)
+	a.Equal([]string{"HR"}, user.Groups)
+	a.Nil(user.Roles)
+	a.NotContains(user.RawData, sap.RawDataZoneID)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://acme.authentication.eu10.hana.ondemand.com/oauth/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*sap.Session)
+	a.Equal(s.AuthURL, "https://acme.authentication.eu10.hana.ondemand.com/oauth/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *sap.Provider {
+	return sap.NewXSUAA(os.Getenv("SAP_KEY"), os.Getenv("SAP_SECRET"), "/foo", "acme", "eu10")
+}
@@ -1,10 +1,15 @@
 package seatalk_test
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/seatalk"
 	"github.com/stretchr/testify/assert"
 )
@@ -48,6 +53,32 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal(s.AccessToken, "1234567890")
 }
 
+func Test_FetchUserContext(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		a.Equal("https://seatalkweb.com/webapp/oauth2/profile?access_token=1234567890", req.URL.String())
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"user_id":"42","name":"Jane Doe","email":"jane@example.com"}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&seatalk.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("42", user.UserID)
+	a.Equal("jane@example.com", user.Email)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.FetchUserContext(ctx, &seatalk.Session{AccessToken: "1234567890"})
+	a.Error(err)
+}
+
 func provider() *seatalk.Provider {
 	return seatalk.New(os.Getenv("SEATALK_KEY"), os.Getenv("SEATALK_SECRET"), "/foo")
 }
@@ -0,0 +1,207 @@
+// Package servicenow implements the OAuth2 protocol for authenticating users
+// through a ServiceNow instance.
+package servicenow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// ScopeUserAccount gives the same access as the user has in the instance,
+// and is requested if no scopes are passed to New.
+const ScopeUserAccount = "useraccount"
+
+// sysUserFields are the fields of the sys_user record FetchUser asks for.
+var sysUserFields = []string{
+	"sys_id", "user_name", "name", "first_name", "last_name", "email",
+	"title", "department", "location", "company", "time_zone",
+}
+
+// Provider is the implementation of `goth.Provider` for accessing ServiceNow.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	instanceURL  string
+}
+
+// New creates a new ServiceNow provider for the instance at instanceURL, e.g.
+// https://acme.service-now.com, using the client ID and secret of an
+// application registry entry, and sets up important connection details.
+// You should always call `servicenow.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, instanceURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "servicenow",
+		instanceURL:  strings.TrimSuffix(instanceURL, "/"),
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   provider.instanceURL + "/oauth_auth.do",
+			TokenURL:  provider.instanceURL + "/oauth_token.do",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, ScopeUserAccount)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the servicenow package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks ServiceNow for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to ServiceNow and look up the sys_user record of the
+// user. Reference fields such as department and location hold their display
+// values.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	query := url.Values{
+		"sysparm_query":                  {"sys_id=javascript:gs.getUserID()"},
+		"sysparm_fields":                 {strings.Join(sysUserFields, ",")},
+		"sysparm_display_value":          {"true"},
+		"sysparm_exclude_reference_link": {"true"},
+		"sysparm_limit":                  {"1"},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", p.instanceURL+"/api/now/table/sys_user?"+query.Encode(), nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	req.Header.Set("Accept", "application/json")
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	var result struct {
+		Result []json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(bits, &result); err != nil {
+		return user, err
+	}
+	if len(result.Result) == 0 {
+		return user, errors.New("servicenow: no sys_user record found for the user")
+	}
+
+	err = user.SetRawJSON(result.Result[0])
+	if err != nil {
+		return user, err
+	}
+
+	u := struct {
+		SysID     string `json:"sys_id"`
+		UserName  string `json:"user_name"`
+		Name      string `json:"name"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+		Email     string `json:"email"`
+		Title     string `json:"title"`
+		Location  string `json:"location"`
+	}{}
+	if err := json.Unmarshal(result.Result[0], &u); err != nil {
+		return user, err
+	}
+	user.UserID = u.SysID
+	user.NickName = u.UserName
+	user.Name = u.Name
+	user.FirstName = u.FirstName
+	user.LastName = u.LastName
+	user.Email = u.Email
+	user.Description = u.Title
+	user.Location = u.Location
+	return user, nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
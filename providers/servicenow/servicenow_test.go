@@ -0,0 +1,101 @@
+package servicenow_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/servicenow"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("SERVICENOW_KEY"))
+	a.Equal(p.Secret, os.Getenv("SERVICENOW_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	s := session.(*servicenow.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "https://acme.service-now.com/oauth_auth.do")
+	a.Contains(s.AuthURL, "scope=useraccount")
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("/api/now/table/sys_user", req.URL.Path)
+		a.Equal("sys_id=javascript:gs.getUserID()", req.URL.Query().Get("sysparm_query"))
+		a.Equal("true", req.URL.Query().Get("sysparm_display_value"))
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"result":[{"sys_id":"62826bf03710200044e0bfc8bcbe5df1","user_name":"abel.tuter",
+			"name":"Abel Tuter","first_name":"Abel","last_name":"Tuter","email":"abel.tuter@example.com",
+			"title":"Service Desk Agent","department":"IT","location":"San Diego","company":"ACME","time_zone":"US/Pacific"}]}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&servicenow.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("62826bf03710200044e0bfc8bcbe5df1", user.UserID)
+	a.Equal("abel.tuter", user.NickName)
+	a.Equal("Abel Tuter", user.Name)
+	a.Equal("Abel", user.FirstName)
+	a.Equal("Tuter", user.LastName)
+	a.Equal("abel.tuter@example.com", user.Email)
+	a.Equal("Service Desk Agent", user.Description)
+	a.Equal("San Diego", user.Location)
+	a.Equal("IT", user.RawData["department"])
+}
+
+func Test_FetchUser_NoRecord(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"result":[]}`)
+		return rec.Result(), nil
+	})}
+
+	_, err := p.FetchUser(&servicenow.Session{AccessToken: "1234567890"})
+	a.Error(err)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://acme.service-now.com/oauth_auth.do","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*servicenow.Session)
+	a.Equal(s.AuthURL, "https://acme.service-now.com/oauth_auth.do")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *servicenow.Provider {
+	return servicenow.New(os.Getenv("SERVICENOW_KEY"), os.Getenv("SERVICENOW_SECRET"), "/foo", "https://acme.service-now.com/")
+}
@@ -1,14 +1,15 @@
 package shopify
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"os"
+	"net/url"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -26,6 +27,10 @@ type Session struct {
 	Hostname    string
 	HMAC        string
 	ExpiresAt   time.Time
+	// Shop is the myshopify.com domain passed to BeginAuthForShop.
+	Shop string `json:",omitempty"`
+	// AssociatedUserID is the ID of the user an online access token was issued for.
+	AssociatedUserID int64 `json:",omitempty"`
 }
 
 var _ goth.Session = &Session{}
@@ -40,22 +45,16 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with Shopify and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
+	p := provider.(*Provider)
+
 	// Validate the incoming HMAC is valid.
-	// See: https://help.shopify.com/en/api/getting-started/authentication/oauth#verification
-	digest := fmt.Sprintf(
-		"code=%s&host=%s&shop=%s&state=%s&timestamp=%s",
-		params.Get("code"),
-		params.Get("host"),
-		params.Get("shop"),
-		params.Get("state"),
-		params.Get("timestamp"),
-	)
-	h := hmac.New(sha256.New, []byte(os.Getenv("SHOPIFY_SECRET")))
-	h.Write([]byte(digest))
-	sha := hex.EncodeToString(h.Sum(nil))
-
-	// Ensure our HMAC hash's match.
-	if sha != params.Get("hmac") {
+	// See: https://shopify.dev/docs/apps/auth/oauth/getting-started#step-2-verify-the-installation-request
+	if !hmac.Equal([]byte(signParams(p.Secret, params)), []byte(params.Get("hmac"))) {
 		return "", errors.New("Invalid HMAC received")
 	}
 
@@ -66,9 +65,20 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 		return "", errors.New("Invalid hostname received")
 	}
 
+	config := p.config
+	if s.Shop != "" {
+		domain, err := ShopDomain(params.Get("shop"))
+		if err != nil {
+			return "", err
+		}
+		if domain != s.Shop {
+			return "", &ErrInvalidShop{Shop: params.Get("shop")}
+		}
+		config = p.configFor(domain)
+	}
+
 	// Make the exchange for an access token.
-	p := provider.(*Provider)
-	token, err := p.config.Exchange(goth.ContextForClient(p.Client()), params.Get("code"))
+	token, err := config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"))
 	if err != nil {
 		return "", err
 	}
@@ -79,12 +89,51 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 	}
 
 	s.AccessToken = token.AccessToken
-	s.Hostname = params.Get("hostname")
+	s.Hostname = params.Get("shop")
 	s.HMAC = params.Get("hmac")
+	// Online access tokens expire and are issued for a single user.
+	s.ExpiresAt = token.Expiry
+	if user, ok := token.Extra("associated_user").(map[string]interface{}); ok {
+		if id, ok := user["id"].(float64); ok {
+			s.AssociatedUserID = int64(id)
+		}
+	}
 
 	return token.AccessToken, err
 }
 
+// signParams computes the hex encoded HMAC Shopify signs callbacks with: all
+// parameters but hmac itself, sorted by name. Params other than url.Values can
+// only be checked for the parameters Shopify always sends.
+func signParams(secret string, params goth.Params) string {
+	values, ok := params.(url.Values)
+	if !ok {
+		values = url.Values{}
+		for _, name := range []string{"code", "host", "shop", "state", "timestamp"} {
+			if v := params.Get(name); v != "" {
+				values.Set(name, v)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if name != "hmac" && name != "signature" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	message := make([]string, 0, len(names))
+	for _, name := range names {
+		message = append(message, name+"="+strings.Join(values[name], ","))
+	}
+
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(strings.Join(message, "&")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // Marshal the session into a string
 func (s Session) Marshal() string {
 	b, _ := json.Marshal(s)
@@ -98,6 +147,6 @@ func (s Session) String() string {
 // UnmarshalSession wil unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(s)
+	err := goth.DecodeSession(data, s)
 	return s, err
 }
@@ -3,12 +3,15 @@
 package shopify
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -17,12 +20,49 @@ import (
 const (
 	providerName = "shopify"
 
-	// URL protocol and subdomain will be populated by newConfig().
-	authURL         = "myshopify.com/admin/oauth/authorize"
-	tokenURL        = "myshopify.com/admin/oauth/access_token"
-	endpointProfile = "myshopify.com/admin/api/2019-04/shop.json"
+	// URL protocol and shop domain will be populated by newConfig().
+	authURL         = "/admin/oauth/authorize"
+	tokenURL        = "/admin/oauth/access_token"
+	endpointProfile = "/admin/api/2019-04/shop.json"
+
+	shopDomainSuffix = ".myshopify.com"
+)
+
+// AccessMode selects the kind of access token Shopify issues.
+// See: https://shopify.dev/docs/apps/auth/access-token-types
+type AccessMode string
+
+const (
+	// AccessModeOffline tokens don't expire and are meant for background work on behalf of the shop.
+	AccessModeOffline AccessMode = "offline"
+	// AccessModeOnline tokens are tied to the user who installed the app and expire with their session.
+	AccessModeOnline AccessMode = "online"
 )
 
+var shopDomainRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9\-]*\.myshopify\.com$`)
+
+// ErrInvalidShop is returned when a shop is not a valid myshopify.com domain.
+type ErrInvalidShop struct {
+	Shop string
+}
+
+func (e *ErrInvalidShop) Error() string {
+	return fmt.Sprintf("shopify: invalid shop %q", e.Shop)
+}
+
+// ShopDomain returns the myshopify.com domain of shop, which may be given as
+// the bare shop name or as its domain.
+func ShopDomain(shop string) (string, error) {
+	domain := strings.ToLower(shop)
+	if !strings.HasSuffix(domain, shopDomainSuffix) {
+		domain += shopDomainSuffix
+	}
+	if !shopDomainRegex.MatchString(domain) {
+		return "", &ErrInvalidShop{Shop: shop}
+	}
+	return domain, nil
+}
+
 // Provider is the implementation of `goth.Provider` for accessing Shopify.
 type Provider struct {
 	ClientKey    string
@@ -33,6 +73,7 @@ type Provider struct {
 	providerName string
 	shopName     string
 	scopes       []string
+	accessMode   AccessMode
 }
 
 // New creates a new Shopify provider and sets up important connection details.
@@ -73,16 +114,60 @@ func (p *Provider) SetShopName(name string) {
 	p.config = newConfig(p, p.scopes)
 }
 
+// SetAccessMode sets whether offline (the default) or online access tokens are requested.
+func (p *Provider) SetAccessMode(mode AccessMode) {
+	p.accessMode = mode
+}
+
 // Debug is a no-op for the Shopify package.
 func (p *Provider) Debug(debug bool) {}
 
-// BeginAuth asks Shopify for an authentication end-point.
+// BeginAuth asks Shopify for an authentication end-point, for the shop set with SetShopName.
 func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 	return &Session{
-		AuthURL: p.config.AuthCodeURL(state),
+		AuthURL: p.config.AuthCodeURL(state, p.authCodeOptions()...),
+	}, nil
+}
+
+// BeginAuthForShop is like BeginAuth but authenticates with the given shop,
+// e.g. the shop parameter Shopify passes to the app on installation. This lets
+// a single provider serve many shops. The callback must come from the same shop.
+func (p *Provider) BeginAuthForShop(state, shop string) (goth.Session, error) {
+	domain, err := ShopDomain(shop)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		AuthURL: p.configFor(domain).AuthCodeURL(state, p.authCodeOptions()...),
+		Shop:    domain,
 	}, nil
 }
 
+func (p *Provider) authCodeOptions() []oauth2.AuthCodeOption {
+	if p.accessMode == AccessModeOnline {
+		return []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("grant_options[]", "per-user")}
+	}
+	return nil
+}
+
+// configFor returns the config for authenticating with the shop at domain.
+func (p *Provider) configFor(domain string) *oauth2.Config {
+	c := *p.config
+	c.Endpoint = oauth2.Endpoint{
+		AuthURL:  "https://" + domain + authURL,
+		TokenURL: "https://" + domain + tokenURL,
+	}
+	return &c
+}
+
+// shopDomain is the domain of the shop set with SetShopName.
+func (p *Provider) shopDomain() string {
+	if strings.HasSuffix(p.shopName, shopDomainSuffix) {
+		return p.shopName
+	}
+	return p.shopName + shopDomainSuffix
+}
+
 // RefreshTokenAvailable refresh token is provided by auth provider or not
 func (p *Provider) RefreshTokenAvailable() bool {
 	return false
@@ -95,6 +180,11 @@ func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
 
 // FetchUser will go to Shopify and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	s := session.(*Session)
 	shop := goth.User{
 		AccessToken: s.AccessToken,
@@ -106,8 +196,13 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return shop, fmt.Errorf("%s cannot get shop information without accessToken", p.providerName)
 	}
 
+	domain := s.Shop
+	if domain == "" {
+		domain = p.shopDomain()
+	}
+
 	// Build the request.
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s.%s", p.shopName, endpointProfile), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://"+domain+endpointProfile, nil)
 	if err != nil {
 		return shop, err
 	}
@@ -168,8 +263,8 @@ func newConfig(p *Provider, scopes []string) *oauth2.Config {
 		ClientSecret: p.Secret,
 		RedirectURL:  p.CallbackURL,
 		Endpoint: oauth2.Endpoint{
-			AuthURL:  fmt.Sprintf("https://%s.%s", p.shopName, authURL),
-			TokenURL: fmt.Sprintf("https://%s.%s", p.shopName, tokenURL),
+			AuthURL:  "https://" + p.shopDomain() + authURL,
+			TokenURL: "https://" + p.shopDomain() + tokenURL,
 		},
 		Scopes: []string{},
 	}
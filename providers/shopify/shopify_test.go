@@ -1,10 +1,18 @@
 package shopify_test
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/shopify"
 	"github.com/stretchr/testify/assert"
 )
@@ -48,6 +56,62 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal(s.AccessToken, "1234567890")
 }
 
+func Test_BeginAuthForShop(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+	p.SetAccessMode(shopify.AccessModeOnline)
+
+	session, err := p.BeginAuthForShop("test_state", "Other-Shop")
+	a.NoError(err)
+	s := session.(*shopify.Session)
+	a.Equal("other-shop.myshopify.com", s.Shop)
+	a.Contains(s.AuthURL, "https://other-shop.myshopify.com/admin/oauth/authorize")
+	a.Contains(s.AuthURL, "grant_options%5B%5D=per-user")
+
+	_, err = p.BeginAuthForShop("test_state", "evil.com/other-shop")
+	a.IsType(&shopify.ErrInvalidShop{}, err)
+}
+
+func signed(secret string, v url.Values) url.Values {
+	v.Del("hmac")
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(v.Encode()))
+	v.Set("hmac", hex.EncodeToString(h.Sum(nil)))
+	return v
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := shopify.New("key", "secret", "/foo")
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("other-shop.myshopify.com", req.URL.Host)
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(rec, `{"access_token":"1234567890","scope":"read_customers","expires_in":86399,"associated_user":{"id":902541635}}`)
+		return rec.Result(), nil
+	})}
+
+	session, err := p.BeginAuthForShop("test_state", "other-shop")
+	a.NoError(err)
+	s := session.(*shopify.Session)
+
+	params := url.Values{"code": {"code"}, "shop": {"other-shop.myshopify.com"}, "state": {"test_state"}, "timestamp": {"1337178173"}}
+	_, err = s.Authorize(p, signed("wrong", params))
+	a.Error(err)
+
+	_, err = s.Authorize(p, signed("secret", url.Values{"code": {"code"}, "shop": {"third-shop.myshopify.com"}}))
+	a.IsType(&shopify.ErrInvalidShop{}, err)
+
+	token, err := s.Authorize(p, signed("secret", params))
+	a.NoError(err)
+	a.Equal("1234567890", token)
+	a.Equal(int64(902541635), s.AssociatedUserID)
+	a.False(s.ExpiresAt.IsZero())
+}
+
 func provider() *shopify.Provider {
 	p := shopify.New(os.Getenv("SHOPIFY_KEY"), os.Getenv("SHOPIFY_SECRET"), "/foo")
 	p.SetShopName("test-shop")
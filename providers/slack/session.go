@@ -1,8 +1,12 @@
 package slack
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -11,10 +15,19 @@ import (
 
 // Session stores data during the auth process with Slack.
 type Session struct {
-	AuthURL      string
+	AuthURL string
+	// AccessToken, RefreshToken and ExpiresAt are those of the user token
+	// (xoxp-), which acts on behalf of the user.
 	AccessToken  string
 	RefreshToken string
 	ExpiresAt    time.Time
+	IDToken      string `json:",omitempty"`
+	// BotAccessToken is the bot token (xoxb-) of an app installed with
+	// NewV2 and bot scopes, which acts on behalf of the app.
+	BotAccessToken string `json:",omitempty"`
+	BotUserID      string `json:",omitempty"`
+	TeamID         string `json:",omitempty"`
+	EnterpriseID   string `json:",omitempty"`
 }
 
 var _ goth.Session = &Session{}
@@ -29,8 +42,16 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with Slack and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.config.Exchange(goth.ContextForClient(p.Client()), params.Get("code"))
+	if p.v2 {
+		return s.authorizeV2(ctx, p, params.Get("code"))
+	}
+	token, err := p.config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"))
 	if err != nil {
 		return "", err
 	}
@@ -42,9 +63,87 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 	s.AccessToken = token.AccessToken
 	s.RefreshToken = token.RefreshToken
 	s.ExpiresAt = token.Expiry
+	s.IDToken, _ = token.Extra("id_token").(string)
 	return token.AccessToken, err
 }
 
+// v2AccessResponse is the response of oauth.v2.access. The top-level token is
+// the bot token, the user token is in authed_user; either is missing if no
+// scopes of its kind were requested.
+type v2AccessResponse struct {
+	OK          bool   `json:"ok"`
+	Error       string `json:"error"`
+	AccessToken string `json:"access_token"`
+	BotUserID   string `json:"bot_user_id"`
+	Team        struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	Enterprise *struct {
+		ID string `json:"id"`
+	} `json:"enterprise"`
+	AuthedUser struct {
+		ID           string `json:"id"`
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	} `json:"authed_user"`
+}
+
+// authorizeV2 redeems code with oauth.v2.access. It doesn't use the oauth2
+// package, which rejects responses without a top-level access token.
+func (s *Session) authorizeV2(ctx context.Context, p *Provider, code string) (string, error) {
+	form := url.Values{
+		"code":          {code},
+		"client_id":     {p.ClientKey},
+		"client_secret": {p.Secret},
+		"redirect_uri":  {p.CallbackURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.Endpoint.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.Client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s responded with a %d trying to redeem the code", p.providerName, resp.StatusCode)
+	}
+
+	var r v2AccessResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", err
+	}
+	if !r.OK {
+		return "", fmt.Errorf("slack: %s", r.Error)
+	}
+	if r.AccessToken == "" && r.AuthedUser.AccessToken == "" {
+		return "", errors.New("Invalid token received from provider")
+	}
+
+	s.BotAccessToken = r.AccessToken
+	s.BotUserID = r.BotUserID
+	s.AccessToken = r.AuthedUser.AccessToken
+	s.RefreshToken = r.AuthedUser.RefreshToken
+	if r.AuthedUser.ExpiresIn > 0 {
+		s.ExpiresAt = goth.GetClock().Now().Add(time.Duration(r.AuthedUser.ExpiresIn) * time.Second)
+	}
+	s.TeamID = r.Team.ID
+	if r.Enterprise != nil {
+		s.EnterpriseID = r.Enterprise.ID
+	}
+
+	// the user token is what identifies the user; an app installed with bot
+	// scopes only has none
+	if s.AccessToken != "" {
+		return s.AccessToken, nil
+	}
+	return s.BotAccessToken, nil
+}
+
 // Marshal the session into a string
 func (s Session) Marshal() string {
 	b, _ := json.Marshal(s)
@@ -58,6 +157,6 @@ func (s Session) String() string {
 // UnmarshalSession wil unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(s)
+	err := goth.DecodeSession(data, s)
 	return s, err
 }
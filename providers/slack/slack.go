@@ -4,11 +4,13 @@ package slack
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -17,16 +19,30 @@ import (
 // Scopes
 const (
 	ScopeUserRead string = "users:read"
+
+	ScopeOpenID  string = "openid"
+	ScopeProfile string = "profile"
+	ScopeEmail   string = "email"
 )
 
 // URLs and endpoints
 const (
-	authURL         string = "https://slack.com/oauth/authorize"
-	tokenURL        string = "https://slack.com/api/oauth.access"
+	authURL          string = "https://slack.com/openid/connect/authorize"
+	tokenURL         string = "https://slack.com/api/openid.connect.token"
+	endpointUserInfo string = "https://slack.com/api/openid.connect.userInfo"
+
+	authURLV2       string = "https://slack.com/oauth/v2/authorize"
+	tokenURLV2      string = "https://slack.com/api/oauth.v2.access"
 	endpointUser    string = "https://slack.com/api/auth.test"
 	endpointProfile string = "https://slack.com/api/users.info"
 )
 
+// Keys of User.RawData holding the workspace the user signed in to.
+const (
+	RawDataTeamID       = "team_id"
+	RawDataEnterpriseID = "enterprise_id"
+)
+
 // Provider is the implementation of `goth.Provider` for accessing Slack.
 type Provider struct {
 	ClientKey    string
@@ -35,9 +51,17 @@ type Provider struct {
 	HTTPClient   *http.Client
 	config       *oauth2.Config
 	providerName string
+	// v2 is set for providers created with NewV2, which install the app
+	// instead of signing the user in with OpenID Connect.
+	v2         bool
+	userScopes []string
 }
 
-// New creates a new Slack provider and sets up important connection details.
+// New creates a new Slack provider using Sign in with Slack, Slack's OpenID
+// Connect flow, and sets up important connection details. The openid, profile
+// and email scopes are requested unless other scopes are passed. Use NewV2 to
+// request bot or other user scopes.
+//
 // You should always call `slack.New` to get a new provider.  Never try to
 // create one manually.
 func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
@@ -51,6 +75,34 @@ func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
 	return p
 }
 
+// NewV2 creates a new Slack provider installing the app with Slack's OAuth
+// v2 flow, requesting botScopes for the app's bot and userScopes for the
+// user. The bot token ends up in Session.BotAccessToken and the user token in
+// Session.AccessToken; the user can only be fetched if userScopes is not
+// empty, and only fully if it includes ScopeUserRead.
+func NewV2(clientKey, secret, callbackURL string, botScopes, userScopes []string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "slack",
+		v2:           true,
+		userScopes:   userScopes,
+	}
+	p.config = &oauth2.Config{
+		ClientID:     p.ClientKey,
+		ClientSecret: p.Secret,
+		RedirectURL:  p.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURLV2,
+			TokenURL:  tokenURLV2,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: botScopes,
+	}
+	return p
+}
+
 // Name is the name used to retrieve this provider later.
 func (p *Provider) Name() string {
 	return p.providerName
@@ -71,19 +123,31 @@ func (p *Provider) Debug(debug bool) {}
 
 // BeginAuth asks Slack for an authentication end-point.
 func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	var opts []oauth2.AuthCodeOption
+	if p.v2 {
+		opts = append(opts, oauth2.SetAuthURLParam("user_scope", strings.Join(p.userScopes, ",")))
+	}
 	return &Session{
-		AuthURL: p.config.AuthCodeURL(state),
+		AuthURL: p.config.AuthCodeURL(state, opts...),
 	}, nil
 }
 
-// FetchUser will go to Slack and access basic information about the user.
+// FetchUser will go to Slack and access basic information about the user,
+// using the user token. The IDs of the user's team and, on Enterprise Grid,
+// enterprise are stored in RawData under RawDataTeamID and RawDataEnterpriseID.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken:  sess.AccessToken,
 		Provider:     p.Name(),
 		RefreshToken: sess.RefreshToken,
 		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
 	}
 
 	if user.AccessToken == "" {
@@ -91,66 +155,135 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	// Get the userID, Slack needs userID in order to get user profile info
-	req, _ := http.NewRequest("GET", endpointUser, nil)
-	req.Header.Add("Authorization", "Bearer "+sess.AccessToken)
+	var err error
+	if p.v2 {
+		err = p.fetchUserV2(ctx, sess, &user)
+	} else {
+		err = p.fetchUserInfo(ctx, sess, &user)
+	}
+	if sess.TeamID != "" {
+		user.SetRawData(RawDataTeamID, sess.TeamID)
+	}
+	if sess.EnterpriseID != "" {
+		user.SetRawData(RawDataEnterpriseID, sess.EnterpriseID)
+	}
+	return user, err
+}
+
+// get fetches url with the user token, failing if Slack reports an error.
+func (p *Provider) get(ctx context.Context, url, token string) ([]byte, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req.Header.Add("Authorization", "Bearer "+token)
 	response, err := p.Client().Do(req)
 	if err != nil {
-		return user, err
+		return nil, err
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+		return nil, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
 	}
 
 	bits, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return user, err
+		return nil, err
 	}
+	return bits, checkResponse(bits)
+}
 
-	err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
-	if err != nil {
-		return user, err
+// checkResponse returns the error of a Slack Web API response, which is
+// reported with a 200 status code.
+func checkResponse(bits []byte) error {
+	r := struct {
+		OK    *bool  `json:"ok"`
+		Error string `json:"error"`
+	}{}
+	if err := json.Unmarshal(bits, &r); err != nil {
+		return err
 	}
+	if r.OK != nil && !*r.OK {
+		return fmt.Errorf("slack: %s", r.Error)
+	}
+	return nil
+}
 
-	err = simpleUserFromReader(bytes.NewReader(bits), &user)
-
-	if p.hasScope(ScopeUserRead) {
-		// Get user profile info
-		req, _ := http.NewRequest("GET", endpointProfile+"?user="+user.UserID, nil)
-		req.Header.Add("Authorization", "Bearer "+sess.AccessToken)
-		response, err = p.Client().Do(req)
-		if err != nil {
-			return user, err
-		}
-		defer response.Body.Close()
-
-		if response.StatusCode != http.StatusOK {
-			return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
-		}
+func (p *Provider) fetchUserInfo(ctx context.Context, sess *Session, user *goth.User) error {
+	bits, err := p.get(ctx, endpointUserInfo, sess.AccessToken)
+	if err != nil {
+		return err
+	}
+	if err := user.SetRawJSON(bits); err != nil {
+		return err
+	}
 
-		bits, err = ioutil.ReadAll(response.Body)
-		if err != nil {
-			return user, err
-		}
+	u := struct {
+		Subject       string `json:"sub"`
+		UserID        string `json:"https://slack.com/user_id"`
+		TeamID        string `json:"https://slack.com/team_id"`
+		EnterpriseID  string `json:"https://slack.com/enterprise_id"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+		Picture       string `json:"picture"`
+	}{}
+	if err := json.Unmarshal(bits, &u); err != nil {
+		return err
+	}
+	user.UserID = u.UserID
+	if user.UserID == "" {
+		user.UserID = u.Subject
+	}
+	user.Email = u.Email
+	user.EmailVerified = u.EmailVerified
+	user.Name = u.Name
+	user.NickName = u.Name
+	user.FirstName = u.GivenName
+	user.LastName = u.FamilyName
+	user.AvatarURL = u.Picture
+	if sess.TeamID == "" {
+		sess.TeamID = u.TeamID
+	}
+	if sess.EnterpriseID == "" {
+		sess.EnterpriseID = u.EnterpriseID
+	}
+	return nil
+}
 
-		err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
-		if err != nil {
-			return user, err
-		}
+func (p *Provider) fetchUserV2(ctx context.Context, sess *Session, user *goth.User) error {
+	// Get the userID, Slack needs userID in order to get user profile info
+	bits, err := p.get(ctx, endpointUser, sess.AccessToken)
+	if err != nil {
+		return err
+	}
+	if err := user.SetRawJSON(bits); err != nil {
+		return err
+	}
+	if err := simpleUserFromReader(bytes.NewReader(bits), user); err != nil {
+		return err
+	}
 
-		err = userFromReader(bytes.NewReader(bits), &user)
+	if !p.hasScope(ScopeUserRead) {
+		return nil
 	}
 
-	return user, err
+	// Get user profile info
+	bits, err = p.get(ctx, endpointProfile+"?user="+user.UserID, sess.AccessToken)
+	if err != nil {
+		return err
+	}
+	if err := user.SetRawJSON(bits); err != nil {
+		return err
+	}
+	return userFromReader(bytes.NewReader(bits), user)
 }
 
 func (p *Provider) hasScope(scope string) bool {
 	hasScope := false
 
-	for i := range p.config.Scopes {
-		if p.config.Scopes[i] == scope {
+	for i := range p.userScopes {
+		if p.userScopes[i] == scope {
 			hasScope = true
 			break
 		}
@@ -176,7 +309,7 @@ func newConfig(provider *Provider, scopes []string) *oauth2.Config {
 			c.Scopes = append(c.Scopes, scope)
 		}
 	} else {
-		c.Scopes = append(c.Scopes, ScopeUserRead)
+		c.Scopes = append(c.Scopes, ScopeOpenID, ScopeProfile, ScopeEmail)
 	}
 	return c
 }
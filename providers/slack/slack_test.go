@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
@@ -21,6 +22,20 @@ var (
 		"user_id": "user1234",
 	}
 
+	testOpenIDUserInfoResponseData = map[string]interface{}{
+		"ok":                              true,
+		"sub":                             "user1234",
+		"https://slack.com/user_id":       "user1234",
+		"https://slack.com/team_id":       "team1234",
+		"https://slack.com/enterprise_id": "enterprise1234",
+		"email":                           "test@example.org",
+		"email_verified":                  true,
+		"name":                            "Test User",
+		"given_name":                      "Test",
+		"family_name":                     "User",
+		"picture":                         "http://example.org/avatar.png",
+	}
+
 	testUserInfoResponseData = map[string]interface{}{
 		"user": map[string]interface{}{
 			"id":   testAuthTestResponseData["user_id"],
@@ -59,7 +74,73 @@ func Test_BeginAuth(t *testing.T) {
 	session, err := p.BeginAuth("test_state")
 	s := session.(*slack.Session)
 	a.NoError(err)
-	a.Contains(s.AuthURL, "slack.com/oauth/authorize")
+	a.Contains(s.AuthURL, "slack.com/openid/connect/authorize")
+	a.Contains(s.AuthURL, "scope=openid+profile+email")
+}
+
+func Test_BeginAuthV2(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := providerV2()
+	session, err := p.BeginAuth("test_state")
+	s := session.(*slack.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "slack.com/oauth/v2/authorize")
+	a.Contains(s.AuthURL, "scope=commands")
+	a.Contains(s.AuthURL, "user_scope=users%3Aread%2Cchat%3Awrite")
+}
+
+func Test_AuthorizeV2(t *testing.T) {
+	t.Parallel()
+
+	for _, testData := range []struct {
+		name     string
+		response string
+		expected slack.Session
+		err      bool
+	}{
+		{
+			name:     "SeparatesBotAndUserTokens",
+			response: `{"ok":true,"access_token":"xoxb-bot","token_type":"bot","bot_user_id":"bot1234","team":{"id":"team1234"},"enterprise":{"id":"enterprise1234"},"authed_user":{"id":"user1234","access_token":"xoxp-user","token_type":"user"}}`,
+			expected: slack.Session{
+				AccessToken:    "xoxp-user",
+				BotAccessToken: "xoxb-bot",
+				BotUserID:      "bot1234",
+				TeamID:         "team1234",
+				EnterpriseID:   "enterprise1234",
+			},
+		},
+		{
+			name:     "UserScopesOnly",
+			response: `{"ok":true,"team":{"id":"team1234"},"enterprise":null,"authed_user":{"id":"user1234","access_token":"xoxp-user","token_type":"user"}}`,
+			expected: slack.Session{AccessToken: "xoxp-user", TeamID: "team1234"},
+		},
+		{
+			name:     "SlackError",
+			response: `{"ok":false,"error":"invalid_code"}`,
+			err:      true,
+		},
+	} {
+		testData := testData
+		t.Run(testData.name, func(t *testing.T) {
+			a := assert.New(t)
+			handler := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				a.Equal("/api/oauth.v2.access", req.URL.Path)
+				a.Equal("code1234", req.FormValue("code"))
+				res.Write([]byte(testData.response))
+			})
+			withMockServer(providerV2(), handler, func(p *slack.Provider) {
+				s := &slack.Session{}
+				_, err := s.Authorize(p, url.Values{"code": {"code1234"}})
+				if testData.err {
+					a.Error(err)
+					return
+				}
+				a.NoError(err)
+				a.Equal(testData.expected, *s)
+			})
+		})
+	}
 }
 
 func Test_FetchUser(t *testing.T) {
@@ -74,9 +155,50 @@ func Test_FetchUser(t *testing.T) {
 		expectErr    bool
 	}{
 		{
-			name:     "FetchesFullProfile",
+			name:     "FetchesOpenIDProfile",
 			provider: provider(),
 			session:  &slack.Session{AccessToken: "TOKEN"},
+			handler: http.HandlerFunc(
+				func(res http.ResponseWriter, req *http.Request) {
+					switch req.URL.Path {
+					case "/api/openid.connect.userInfo":
+						res.WriteHeader(http.StatusOK)
+						json.NewEncoder(res).Encode(testOpenIDUserInfoResponseData)
+					default:
+						res.WriteHeader(http.StatusNotFound)
+					}
+				},
+			),
+			expectedUser: goth.User{
+				UserID:      "user1234",
+				NickName:    "Test User",
+				Name:        "Test User",
+				FirstName:   "Test",
+				LastName:    "User",
+				AvatarURL:   "http://example.org/avatar.png",
+				Email:       "test@example.org",
+				AccessToken: "TOKEN",
+			},
+			expectErr: false,
+		},
+		{
+			name:     "FailsWithOpenIDError",
+			provider: provider(),
+			session:  &slack.Session{AccessToken: "TOKEN"},
+			handler: http.HandlerFunc(
+				func(res http.ResponseWriter, req *http.Request) {
+					res.Write([]byte(`{"ok":false,"error":"invalid_auth"}`))
+				},
+			),
+			expectedUser: goth.User{
+				AccessToken: "TOKEN",
+			},
+			expectErr: true,
+		},
+		{
+			name:     "FetchesFullProfile",
+			provider: providerV2(),
+			session:  &slack.Session{AccessToken: "TOKEN"},
 			handler: http.HandlerFunc(
 				func(res http.ResponseWriter, req *http.Request) {
 					switch req.URL.Path {
@@ -105,7 +227,7 @@ func Test_FetchUser(t *testing.T) {
 		},
 		{
 			name:     "FetchesBasicProfileWhenLackingUserReadScope",
-			provider: slack.New(os.Getenv("SLACK_KEY"), os.Getenv("SLACK_SECRET"), "/foo", "commands"),
+			provider: slack.NewV2(os.Getenv("SLACK_KEY"), os.Getenv("SLACK_SECRET"), "/foo", []string{"commands"}, []string{"chat:write"}),
 			session:  &slack.Session{AccessToken: "TOKEN"},
 			handler: http.HandlerFunc(
 				func(res http.ResponseWriter, req *http.Request) {
@@ -134,7 +256,7 @@ func Test_FetchUser(t *testing.T) {
 		},
 		{
 			name:     "FailsWithBadAuthTestResponse",
-			provider: provider(),
+			provider: providerV2(),
 			session:  &slack.Session{AccessToken: "TOKEN"},
 			handler: http.HandlerFunc(
 				func(res http.ResponseWriter, req *http.Request) {
@@ -151,7 +273,7 @@ func Test_FetchUser(t *testing.T) {
 		},
 		{
 			name:     "FailsWithBadUserInfoResponse",
-			provider: provider(),
+			provider: providerV2(),
 			session:  &slack.Session{AccessToken: "TOKEN"},
 			handler: http.HandlerFunc(
 				func(res http.ResponseWriter, req *http.Request) {
@@ -198,6 +320,44 @@ func Test_FetchUser(t *testing.T) {
 	}
 }
 
+func Test_FetchUserTeam(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	handler := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(res).Encode(testOpenIDUserInfoResponseData)
+	})
+	withMockServer(provider(), handler, func(p *slack.Provider) {
+		s := &slack.Session{AccessToken: "TOKEN"}
+		user, err := p.FetchUser(s)
+		a.NoError(err)
+		a.True(user.EmailVerified)
+		a.Equal("team1234", user.RawData[slack.RawDataTeamID])
+		a.Equal("enterprise1234", user.RawData[slack.RawDataEnterpriseID])
+		a.Equal("team1234", s.TeamID)
+	})
+}
+
+func Test_FetchUserTeam_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	handler := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(res).Encode(testOpenIDUserInfoResponseData)
+	})
+	withMockServer(provider(), handler, func(p *slack.Provider) {
+		user, err := p.FetchUser(&slack.Session{AccessToken: "TOKEN"})
+		a.NoError(err)
+		a.Nil(user.RawData)
+
+		raw, err := user.LoadRawData()
+		a.NoError(err)
+		a.Equal("team1234", raw[slack.RawDataTeamID])
+		a.Equal("enterprise1234", raw[slack.RawDataEnterpriseID])
+	})
+}
+
 func Test_SessionFromJSON(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
@@ -215,6 +375,10 @@ func provider() *slack.Provider {
 	return slack.New(os.Getenv("SLACK_KEY"), os.Getenv("SLACK_SECRET"), "/foo")
 }
 
+func providerV2() *slack.Provider {
+	return slack.NewV2(os.Getenv("SLACK_KEY"), os.Getenv("SLACK_SECRET"), "/foo", []string{"commands"}, []string{slack.ScopeUserRead, "chat:write"})
+}
+
 func withMockServer(p *slack.Provider, handler http.Handler, fn func(p *slack.Provider)) {
 	server := httptest.NewTLSServer(handler)
 	defer server.Close()
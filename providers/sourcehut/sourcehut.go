@@ -0,0 +1,213 @@
+// Package sourcehut implements the OAuth2 protocol for authenticating users
+// through sourcehut's meta.sr.ht.
+package sourcehut
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// ScopeProfileRO grants read-only access to the user's profile, and is
+// requested if no scopes are passed to New. sourcehut scopes are grants of the
+// form "<service>/<scope>:<RO|RW>".
+const ScopeProfileRO = "meta.sr.ht/PROFILE:RO"
+
+// profileQuery is the meta.sr.ht GraphQL query for the user's profile.
+const profileQuery = `query { me { id canonicalName username email url location bio } }`
+
+// Provider is the implementation of `goth.Provider` for accessing sourcehut.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	queryURL     string
+}
+
+// New creates a new sourcehut provider, and sets up important connection
+// details. Use NewWithBaseURL for self-hosted instances.
+// You should always call `sourcehut.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	return NewWithBaseURL(clientKey, secret, callbackURL, "https://meta.sr.ht", scopes...)
+}
+
+// NewWithBaseURL is like New but for the meta.sr.ht of a self-hosted sourcehut
+// instance, e.g. https://meta.sr.example.com.
+func NewWithBaseURL(clientKey, secret, callbackURL, baseURL string, scopes ...string) *Provider {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "sourcehut",
+		queryURL:     baseURL + "/query",
+	}
+	p.config = newConfig(p, baseURL, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, baseURL string, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   baseURL + "/oauth2/authorize",
+			TokenURL:  baseURL + "/oauth2/access-token",
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, ScopeProfileRO)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the sourcehut package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks sourcehut for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to sourcehut and access basic information about the user,
+// with a query to meta.sr.ht's GraphQL API. RawData holds the "me" object.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	body, err := json.Marshal(map[string]string{"query": profileQuery})
+	if err != nil {
+		return user, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.queryURL, bytes.NewReader(body))
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	var result struct {
+		Data struct {
+			Me json.RawMessage `json:"me"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(bits, &result); err != nil {
+		return user, err
+	}
+	if len(result.Errors) > 0 {
+		return user, fmt.Errorf("%s responded with an error trying to fetch user information: %s", p.providerName, result.Errors[0].Message)
+	}
+	if len(result.Data.Me) == 0 || string(result.Data.Me) == "null" {
+		return user, errors.New("sourcehut: no user information returned")
+	}
+
+	err = user.SetRawJSON(result.Data.Me)
+	if err != nil {
+		return user, err
+	}
+
+	u := struct {
+		ID            int    `json:"id"`
+		CanonicalName string `json:"canonicalName"`
+		Username      string `json:"username"`
+		Email         string `json:"email"`
+		URL           string `json:"url"`
+		Location      string `json:"location"`
+		Bio           string `json:"bio"`
+	}{}
+	if err := json.Unmarshal(result.Data.Me, &u); err != nil {
+		return user, err
+	}
+	user.UserID = strconv.Itoa(u.ID)
+	user.NickName = u.Username
+	user.Name = u.CanonicalName
+	user.Email = u.Email
+	user.Location = u.Location
+	user.Description = u.Bio
+	return user, nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
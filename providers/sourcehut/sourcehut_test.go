@@ -0,0 +1,103 @@
+package sourcehut_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/sourcehut"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("SOURCEHUT_KEY"))
+	a.Equal(p.Secret, os.Getenv("SOURCEHUT_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	s := session.(*sourcehut.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "https://meta.sr.ht/oauth2/authorize")
+	a.Contains(s.AuthURL, "scope=meta.sr.ht%2FPROFILE%3ARO")
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("POST", req.Method)
+		a.Equal("https://meta.sr.ht/query", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		var body struct {
+			Query string `json:"query"`
+		}
+		a.NoError(json.NewDecoder(req.Body).Decode(&body))
+		a.Contains(body.Query, "me {")
+
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"data":{"me":{"id":1234,"canonicalName":"~jdoe","username":"jdoe","email":"jdoe@example.com",
+			"url":"https://example.com","location":"Amsterdam","bio":"Hacker"}}}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&sourcehut.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("1234", user.UserID)
+	a.Equal("jdoe", user.NickName)
+	a.Equal("~jdoe", user.Name)
+	a.Equal("jdoe@example.com", user.Email)
+	a.Equal("Amsterdam", user.Location)
+	a.Equal("https://example.com", user.RawData["url"])
+}
+
+func Test_FetchUser_GraphQLError(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"data":null,"errors":[{"message":"Access denied"}]}`)
+		return rec.Result(), nil
+	})}
+
+	_, err := p.FetchUser(&sourcehut.Session{AccessToken: "1234567890"})
+	a.EqualError(err, "sourcehut responded with an error trying to fetch user information: Access denied")
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://meta.sr.ht/oauth2/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*sourcehut.Session)
+	a.Equal(s.AuthURL, "https://meta.sr.ht/oauth2/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *sourcehut.Provider {
+	return sourcehut.New(os.Getenv("SOURCEHUT_KEY"), os.Getenv("SOURCEHUT_SECRET"), "/foo")
+}
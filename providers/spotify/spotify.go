@@ -3,10 +3,14 @@
 package spotify
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -121,7 +125,15 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 }
 
 // FetchUser will go to Spotify and access basic information about the user.
+// The user's country is stored in Location. RawData holds the full profile,
+// including the user's subscription level in "product", which like "country"
+// is only returned if ScopeUserReadPrivate was granted.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	s := session.(*Session)
 	user := goth.User{
 		AccessToken:  s.AccessToken,
@@ -135,7 +147,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	req, err := http.NewRequest("GET", userEndpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", userEndpoint, nil)
 	if err != nil {
 		return user, err
 	}
@@ -150,8 +162,17 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, resp.StatusCode)
 	}
 
-	// err = userFromReader(io.TeeReader(resp.Body, os.Stdout), &user)
-	err = userFromReader(resp.Body, &user)
+	bits, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	err = userFromReader(bytes.NewReader(bits), &user)
 	return user, err
 }
 
@@ -212,10 +233,41 @@ func (p *Provider) RefreshTokenAvailable() bool {
 	return true
 }
 
+// RefreshSession refreshes the session's access token. Spotify may rotate the
+// refresh token, in which case the replacement is stored in the session;
+// otherwise the current one stays valid and is kept. The granted scopes are
+// updated from the response, or kept if Spotify doesn't return them.
+func (p *Provider) RefreshSession(ctx context.Context, s *Session) error {
+	token, err := p.RefreshTokenContext(ctx, s.RefreshToken)
+	if err != nil {
+		return err
+	}
+	s.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		s.RefreshToken = token.RefreshToken
+	}
+	s.ExpiresAt = token.Expiry
+	if scopes := tokenScopes(token); len(scopes) > 0 {
+		s.Scopes = scopes
+	}
+	return nil
+}
+
+// tokenScopes returns the scopes granted to token, if the response contained them.
+func tokenScopes(token *oauth2.Token) []string {
+	scope, _ := token.Extra("scope").(string)
+	return strings.Fields(scope)
+}
+
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -1,10 +1,16 @@
 package spotify_test
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/spotify"
 	"github.com/stretchr/testify/assert"
 )
@@ -52,3 +58,62 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal(s.AuthURL, "http://accounts.spotify.com/authorize")
 	a.Equal(s.AccessToken, "1234567890")
 }
+
+// spotifyClient serves the Spotify accounts service and Web API with handler.
+func spotifyClient(handler http.HandlerFunc) *http.Client {
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Result(), nil
+	})}
+}
+
+func Test_RefreshSession(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var response string
+	p := provider()
+	p.HTTPClient = spotifyClient(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("/api/token", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, response)
+	})
+
+	response = `{"access_token":"access-1","token_type":"Bearer","expires_in":3600,"refresh_token":"refresh-1","scope":"user-read-email user-read-private"}`
+	s := &spotify.Session{}
+	_, err := s.Authorize(p, url.Values{"code": {"code"}})
+	a.NoError(err)
+	a.Equal([]string{"user-read-email", "user-read-private"}, s.Scopes)
+
+	// the refresh token is kept if Spotify doesn't rotate it, and so are the scopes
+	response = `{"access_token":"access-2","token_type":"Bearer","expires_in":3600}`
+	a.NoError(p.RefreshSession(context.Background(), s))
+	a.Equal("access-2", s.AccessToken)
+	a.Equal("refresh-1", s.RefreshToken)
+	a.Equal([]string{"user-read-email", "user-read-private"}, s.Scopes)
+
+	response = `{"access_token":"access-3","token_type":"Bearer","expires_in":3600,"refresh_token":"refresh-2","scope":"user-read-email"}`
+	a.NoError(p.RefreshSession(context.Background(), s))
+	a.Equal("access-3", s.AccessToken)
+	a.Equal("refresh-2", s.RefreshToken)
+	a.Equal([]string{"user-read-email"}, s.Scopes)
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = spotifyClient(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("/v1/me", r.URL.Path)
+		fmt.Fprint(w, `{"id":"jdoe","display_name":"John Doe","email":"jdoe@example.com","country":"SE","product":"premium"}`)
+	})
+
+	user, err := p.FetchUser(&spotify.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("jdoe", user.UserID)
+	a.Equal("SE", user.Location)
+	a.Equal("premium", user.RawData["product"])
+	a.Equal("SE", user.RawData["country"])
+}
@@ -2,9 +2,12 @@
 package steam
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
@@ -28,15 +31,49 @@ func (s Session) GetAuthURL() (string, error) {
 	return s.AuthURL, nil
 }
 
+// requiredSigned are the fields Steam must sign for an assertion to be accepted.
+var requiredSigned = []string{"op_endpoint", "claimed_id", "identity", "return_to", "response_nonce", "assoc_handle"}
+
+// claimedIDPattern matches the claimed_id of a Steam account, capturing its 64 bit Steam ID.
+var claimedIDPattern = regexp.MustCompile(`^https://steamcommunity\.com/openid/id/([0-9]{17})$`)
+
 // Authorize the session with Steam and return the unique response_nonce by OpenID.
+// The assertion is verified with Steam and its claimed_id is checked before the
+// Steam ID is accepted; any rejection is reported as an ErrVerificationFailed.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the verification request to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
 	if params.Get("openid.mode") != "id_res" {
-		return "", errors.New("Mode must equal to \"id_res\".")
+		return "", &ErrVerificationFailed{Reason: "mode must equal to \"id_res\""}
+	}
+	if params.Get("openid.ns") != openIDNs {
+		return "", &ErrVerificationFailed{Reason: "unexpected ns " + params.Get("openid.ns")}
+	}
+	if params.Get("openid.op_endpoint") != apiLoginEndpoint {
+		return "", &ErrVerificationFailed{Reason: "unexpected op_endpoint " + params.Get("openid.op_endpoint")}
 	}
-
 	if params.Get("openid.return_to") != s.CallbackURL {
-		return "", errors.New("The \"return_to url\" must match the url of current request.")
+		return "", &ErrVerificationFailed{Reason: "the return_to url must match the url of current request"}
+	}
+
+	claimedID := params.Get("openid.claimed_id")
+	match := claimedIDPattern.FindStringSubmatch(claimedID)
+	if match == nil {
+		return "", &ErrVerificationFailed{Reason: "invalid claimed_id " + claimedID}
+	}
+	if params.Get("openid.identity") != claimedID {
+		return "", &ErrVerificationFailed{Reason: "identity does not match claimed_id"}
+	}
+
+	signed := strings.Split(params.Get("openid.signed"), ",")
+	for _, field := range requiredSigned {
+		if !contains(signed, field) {
+			return "", &ErrVerificationFailed{Reason: "field " + field + " is not signed"}
+		}
 	}
 
 	v := make(url.Values)
@@ -44,44 +81,58 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 	v.Set("openid.signed", params.Get("openid.signed"))
 	v.Set("openid.sig", params.Get("openid.sig"))
 	v.Set("openid.ns", params.Get("openid.ns"))
-
-	split := strings.Split(params.Get("openid.signed"), ",")
-	for _, item := range split {
+	for _, item := range signed {
 		v.Set("openid."+item, params.Get("openid."+item))
 	}
 	v.Set("openid.mode", "check_authentication")
 
-	resp, err := p.Client().PostForm(apiLoginEndpoint, v)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiLoginEndpoint, strings.NewReader(v.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.Client().Do(req)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s responded with a %d trying to verify the assertion", p.providerName, resp.StatusCode)
+	}
 	content, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return "", err
 	}
 
-	response := strings.Split(string(content), "\n")
-	if response[0] != "ns:"+openIDNs {
-		return "", errors.New("Wrong ns in the response.")
+	// the response is in key-value form, one "key:value" pair per line
+	fields := map[string]string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if i := strings.Index(line, ":"); i > 0 {
+			fields[line[:i]] = line[i+1:]
+		}
 	}
-
-	if response[1] == "is_valid:false" {
-		return "", errors.New("Unable validate openId.")
+	if fields["ns"] != openIDNs {
+		return "", &ErrVerificationFailed{Reason: "wrong ns in the response"}
 	}
-
-	openIDURL := params.Get("openid.claimed_id")
-	validationRegExp := regexp.MustCompile("^(http|https)://steamcommunity.com/openid/id/[0-9]{15,25}$")
-	if !validationRegExp.MatchString(openIDURL) {
-		return "", errors.New("Invalid Steam ID pattern.")
+	if fields["is_valid"] != "true" {
+		return "", &ErrVerificationFailed{Reason: "steam did not validate the signature"}
 	}
 
-	s.SteamID = regexp.MustCompile("\\D+").ReplaceAllString(openIDURL, "")
+	s.SteamID = match[1]
 	s.ResponseNonce = params.Get("openid.response_nonce")
 
 	return s.ResponseNonce, nil
 }
 
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 // Marshal the session into a string
 func (s Session) Marshal() string {
 	b, _ := json.Marshal(s)
@@ -95,6 +146,6 @@ func (s Session) String() string {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(s)
+	err := goth.DecodeSession(data, s)
 	return s, err
 }
@@ -2,6 +2,7 @@
 package steam
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,7 +16,7 @@ import (
 const (
 	// Steam API Endpoints
 	apiLoginEndpoint       = "https://steamcommunity.com/openid/login"
-	apiUserSummaryEndpoint = "http://api.steampowered.com/ISteamUser/GetPlayerSummaries/v0002/?key=%s&steamids=%s"
+	apiUserSummaryEndpoint = "https://api.steampowered.com/ISteamUser/GetPlayerSummaries/v0002/"
 
 	// OpenID settings
 	openIDMode       = "checkid_setup"
@@ -23,7 +24,37 @@ const (
 	openIDIdentifier = "http://specs.openid.net/auth/2.0/identifier_select"
 )
 
+// ErrVerificationFailed is returned by Session.Authorize when the OpenID
+// assertion returned to the callback cannot be verified.
+type ErrVerificationFailed struct {
+	Reason string
+}
+
+func (e *ErrVerificationFailed) Error() string {
+	return "steam: openid verification failed: " + e.Reason
+}
+
+// ErrPlayerSummaries is returned by FetchUser when the player summary of a
+// verified user cannot be fetched from the Steam Web API.
+type ErrPlayerSummaries struct {
+	// StatusCode is the HTTP status of the response, or 0 if none was received.
+	StatusCode int
+	Err        error
+}
+
+func (e *ErrPlayerSummaries) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("steam: GetPlayerSummaries responded with a %d", e.StatusCode)
+	}
+	return "steam: GetPlayerSummaries failed: " + e.Err.Error()
+}
+
+func (e *ErrPlayerSummaries) Unwrap() error {
+	return e.Err
+}
+
 // New creates a new Steam provider, and sets up important connection details.
+// apiKey is a Steam Web API key, used to fetch the player summary of users.
 // You should always call `steam.New` to get a new Provider. Never try to create
 // one manually.
 func New(apiKey string, callbackURL string) *Provider {
@@ -103,6 +134,11 @@ func (p *Provider) getAuthURL() (*url.URL, error) {
 
 // FetchUser will go to Steam and access basic info about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	s := session.(*Session)
 	u := goth.User{
 		Provider:    p.Name(),
@@ -114,28 +150,35 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return u, fmt.Errorf("%s cannot get user information without SteamID", p.providerName)
 	}
 
-	apiURL := fmt.Sprintf(apiUserSummaryEndpoint, p.APIKey, s.SteamID)
-	req, err := http.NewRequest("GET", apiURL, nil)
+	u.UserID = s.SteamID
+
+	q := url.Values{"key": {p.APIKey}, "steamids": {s.SteamID}}
+	req, err := http.NewRequestWithContext(ctx, "GET", apiUserSummaryEndpoint+"?"+q.Encode(), nil)
 	if err != nil {
 		return u, err
 	}
 	req.Header.Add("Accept", "application/json")
 	resp, err := p.Client().Do(req)
 	if err != nil {
-		if resp != nil {
-			resp.Body.Close()
+		if ue, ok := err.(*url.Error); ok {
+			// keep the API key in the request URL out of the error
+			q.Set("key", goth.RedactedValue)
+			err = &url.Error{Op: ue.Op, URL: apiUserSummaryEndpoint + "?" + q.Encode(), Err: ue.Err}
 		}
-		return u, err
+		return u, &ErrPlayerSummaries{Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return u, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, resp.StatusCode)
+		return u, &ErrPlayerSummaries{StatusCode: resp.StatusCode}
 	}
 
 	u, err = buildUserObject(resp.Body, u)
+	if err != nil {
+		return u, &ErrPlayerSummaries{Err: err}
+	}
 
-	return u, err
+	return u, nil
 }
 
 // buildUserObject is an internal function to build a goth.User object
@@ -144,14 +187,7 @@ func buildUserObject(r io.Reader, u goth.User) (goth.User, error) {
 	// Response object from Steam
 	apiResponse := struct {
 		Response struct {
-			Players []struct {
-				UserID              string `json:"steamid"`
-				NickName            string `json:"personaname"`
-				Name                string `json:"realname"`
-				AvatarURL           string `json:"avatarfull"`
-				LocationCountryCode string `json:"loccountrycode"`
-				LocationStateCode   string `json:"locstatecode"`
-			} `json:"players"`
+			Players []json.RawMessage `json:"players"`
 		} `json:"response"`
 	}{}
 
@@ -161,11 +197,31 @@ func buildUserObject(r io.Reader, u goth.User) (goth.User, error) {
 	}
 
 	if l := len(apiResponse.Response.Players); l != 1 {
-		return u, fmt.Errorf("Expected one player in API response. Got %d.", l)
+		return u, fmt.Errorf("expected one player in API response, got %d", l)
+	}
+
+	player := struct {
+		UserID              string `json:"steamid"`
+		NickName            string `json:"personaname"`
+		Name                string `json:"realname"`
+		AvatarURL           string `json:"avatarfull"`
+		ProfileURL          string `json:"profileurl"`
+		LocationCountryCode string `json:"loccountrycode"`
+		LocationStateCode   string `json:"locstatecode"`
+	}{}
+	raw := apiResponse.Response.Players[0]
+	if err := json.Unmarshal(raw, &player); err != nil {
+		return u, err
+	}
+	if player.UserID != u.UserID {
+		return u, fmt.Errorf("API responded with player %s, expected %s", player.UserID, u.UserID)
+	}
+
+	// the player summary, including its profileurl, is kept in RawData
+	if err := u.SetRawJSON(raw); err != nil {
+		return u, err
 	}
 
-	player := apiResponse.Response.Players[0]
-	u.UserID = player.UserID
 	u.Name = player.Name
 	if len(player.Name) == 0 {
 		u.Name = "No name is provided by the Steam API"
@@ -1,10 +1,16 @@
 package steam_test
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/steam"
 	"github.com/stretchr/testify/assert"
 )
@@ -50,6 +56,120 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal(s.ResponseNonce, "2016-03-13T16:56:30ZJ8tlKVquwHi9ZSPV4ElU5PY2dmI=")
 }
 
+func steamClient(status int, body string) *http.Client {
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.WriteHeader(status)
+		fmt.Fprint(rec, body)
+		return rec.Result(), nil
+	})}
+}
+
+func assertion() url.Values {
+	return url.Values{
+		"openid.ns":             {"http://specs.openid.net/auth/2.0"},
+		"openid.mode":           {"id_res"},
+		"openid.op_endpoint":    {"https://steamcommunity.com/openid/login"},
+		"openid.claimed_id":     {"https://steamcommunity.com/openid/id/76561197960435530"},
+		"openid.identity":       {"https://steamcommunity.com/openid/id/76561197960435530"},
+		"openid.return_to":      {"/foo"},
+		"openid.response_nonce": {"2016-03-13T16:56:30ZJ8tlKVquwHi9ZSPV4ElU5PY2dmI="},
+		"openid.assoc_handle":   {"1234567890"},
+		"openid.signed":         {"signed,op_endpoint,claimed_id,identity,return_to,response_nonce,assoc_handle"},
+		"openid.sig":            {"sig"},
+	}
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = steamClient(http.StatusOK, "ns:http://specs.openid.net/auth/2.0\nis_valid:true\n")
+	s := &steam.Session{CallbackURL: "/foo"}
+	nonce, err := s.Authorize(p, assertion())
+	a.NoError(err)
+	a.Equal("2016-03-13T16:56:30ZJ8tlKVquwHi9ZSPV4ElU5PY2dmI=", nonce)
+	a.Equal("76561197960435530", s.SteamID)
+}
+
+func Test_Authorize_Rejected(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = steamClient(http.StatusOK, "ns:http://specs.openid.net/auth/2.0\nis_valid:false\n")
+
+	tamper := map[string]func(url.Values){
+		"invalid signature": func(v url.Values) {},
+		"claimed_id host": func(v url.Values) {
+			v.Set("openid.claimed_id", "https://steamcommunity.com.evil.com/openid/id/76561197960435530")
+		},
+		"identity mismatch": func(v url.Values) { v.Set("openid.identity", "https://steamcommunity.com/openid/id/76561197960435531") },
+		"unsigned field":    func(v url.Values) { v.Set("openid.signed", "signed,claimed_id,identity,return_to") },
+		"op_endpoint":       func(v url.Values) { v.Set("openid.op_endpoint", "https://evil.com/openid/login") },
+	}
+	for name, f := range tamper {
+		v := assertion()
+		f(v)
+		s := &steam.Session{CallbackURL: "/foo"}
+		_, err := s.Authorize(p, v)
+		a.IsType(&steam.ErrVerificationFailed{}, err, name)
+		a.Empty(s.SteamID, name)
+	}
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("api.steampowered.com", req.URL.Host)
+		a.Equal("76561197960435530", req.URL.Query().Get("steamids"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"response":{"players":[{"steamid":"76561197960435530","personaname":"Robin",
+			"profileurl":"https://steamcommunity.com/id/robinwalker/","avatarfull":"https://avatars.steamstatic.com/full.jpg",
+			"loccountrycode":"US","locstatecode":"WA"}]}}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&steam.Session{SteamID: "76561197960435530"})
+	a.NoError(err)
+	a.Equal("76561197960435530", user.UserID)
+	a.Equal("Robin", user.NickName)
+	a.Equal("https://avatars.steamstatic.com/full.jpg", user.AvatarURL)
+	a.Equal("WA, US", user.Location)
+	a.Equal("https://steamcommunity.com/id/robinwalker/", user.RawData["profileurl"])
+}
+
+func Test_FetchUser_APIError(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = steamClient(http.StatusForbidden, "")
+	user, err := p.FetchUser(&steam.Session{SteamID: "76561197960435530"})
+	a.Equal(&steam.ErrPlayerSummaries{StatusCode: http.StatusForbidden}, err)
+	a.Equal("76561197960435530", user.UserID)
+}
+
+func Test_FetchUserRequestFailedHidesKey(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := steam.New("secret-api-key", "/foo")
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})}
+	_, err := p.FetchUser(&steam.Session{SteamID: "76561197960435530"})
+	a.IsType(&steam.ErrPlayerSummaries{}, err)
+	a.NotContains(err.Error(), "secret-api-key")
+	a.Contains(err.Error(), "connection refused")
+	var ue *url.Error
+	a.True(errors.As(err, &ue))
+}
+
 func provider() *steam.Provider {
 	return steam.New(os.Getenv("STEAM_KEY"), "/foo")
 }
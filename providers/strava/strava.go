@@ -4,12 +4,14 @@ package strava
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -21,6 +23,35 @@ const (
 	endpointProfile string = "https://www.strava.com/api/v3/athlete"
 )
 
+// Scopes supported by Strava.
+// See: https://developers.strava.com/docs/authentication/#details-about-requesting-access
+const (
+	ScopeRead            = "read"
+	ScopeReadAll         = "read_all"
+	ScopeProfileReadAll  = "profile:read_all"
+	ScopeProfileWrite    = "profile:write"
+	ScopeActivityRead    = "activity:read"
+	ScopeActivityReadAll = "activity:read_all"
+	ScopeActivityWrite   = "activity:write"
+)
+
+// Athlete holds the Strava specific details of a user, as returned by FetchUser.
+type Athlete struct {
+	// MeasurementPreference is "feet" or "meters". It requires ScopeProfileReadAll.
+	MeasurementPreference string `json:"measurement_preference"`
+	// Premium reports whether the athlete has a subscription.
+	Premium bool `json:"premium"`
+	// Summit is Strava's current name for Premium.
+	Summit bool `json:"summit"`
+}
+
+// AthleteOf returns the Strava specific details of a user returned by FetchUser.
+func AthleteOf(user goth.User) (Athlete, error) {
+	var a Athlete
+	err := user.DecodeRawJSON(&a)
+	return a, err
+}
+
 // New creates a new Strava provider, and sets up important connection details.
 // You should always call `strava.New` to get a new Provider. Never try to create
 // one manually.
@@ -74,6 +105,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Strava and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken:  sess.AccessToken,
@@ -90,7 +126,11 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	reqUrl := fmt.Sprint(endpointProfile,
 		"?access_token=", url.QueryEscape(sess.AccessToken),
 	)
-	response, err := p.Client().Get(reqUrl)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqUrl, nil)
+	if err != nil {
+		return user, err
+	}
+	response, err := p.Client().Do(req)
 	if err != nil {
 		return user, err
 	}
@@ -105,7 +145,7 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, err
 	}
 
-	err = json.NewDecoder(bytes.NewReader(bits)).Decode(&user.RawData)
+	err = user.SetRawJSON(bits)
 	if err != nil {
 		return user, err
 	}
@@ -156,26 +196,47 @@ func newConfig(provider *Provider, scopes []string) *oauth2.Config {
 		Scopes: []string{},
 	}
 
+	// Strava requires comma separated scopes, which oauth2 would join with spaces.
 	if len(scopes) > 0 {
-		for _, scope := range scopes {
-			c.Scopes = append(c.Scopes, scope)
-		}
+		c.Scopes = append(c.Scopes, strings.Join(scopes, ","))
 	} else {
-		c.Scopes = []string{"read"}
+		c.Scopes = []string{ScopeRead}
 	}
 
 	return c
 }
 
-// RefreshTokenAvailable refresh token is not provided by Strava
+// RefreshTokenAvailable refresh token is provided by Strava
 func (p *Provider) RefreshTokenAvailable() bool {
 	return true
 }
 
-// RefreshToken refresh token is not provided by Strava
+// RefreshSession refreshes the access token of s in place. Strava may rotate
+// the refresh token on every refresh, invalidating the old one, so s must be
+// stored again afterwards.
+func (p *Provider) RefreshSession(ctx context.Context, s *Session) error {
+	token, err := p.RefreshTokenContext(ctx, s.RefreshToken)
+	if err != nil {
+		return err
+	}
+	s.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		s.RefreshToken = token.RefreshToken
+	}
+	s.ExpiresAt = token.Expiry
+	return nil
+}
+
+// RefreshToken get new access token based on the refresh token. The returned
+// token's RefreshToken replaces the one passed in.
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -1,11 +1,16 @@
 package strava_test
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/strava"
 	"github.com/stretchr/testify/assert"
 )
@@ -54,6 +59,71 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal(session.AccessToken, "1234567890")
 }
 
+func Test_BeginAuth_Scopes(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := strava.New(os.Getenv("STRAVA_KEY"), os.Getenv("STRAVA_SECRET"), "/foo", strava.ScopeRead, strava.ScopeActivityReadAll)
+	session, err := provider.BeginAuth("test_state")
+	a.NoError(err)
+	a.Contains(session.(*strava.Session).AuthURL, "scope=read%2Cactivity%3Aread_all")
+}
+
+func stravaClient(body string) *http.Client {
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(rec, body)
+		return rec.Result(), nil
+	})}
+}
+
+func Test_Authorize_GrantedScopes(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := stravaProvider()
+	provider.HTTPClient = stravaClient(`{"token_type":"Bearer","access_token":"1234567890","refresh_token":"refresh","expires_in":21600}`)
+
+	s := &strava.Session{}
+	_, err := s.Authorize(provider, url.Values{"code": {"code"}, "scope": {"read,activity:read"}})
+	a.NoError(err)
+	a.Equal([]string{"read", "activity:read"}, s.Scopes)
+}
+
+func Test_RefreshSession(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := stravaProvider()
+	provider.HTTPClient = stravaClient(`{"token_type":"Bearer","access_token":"new-access","refresh_token":"new-refresh","expires_in":21600}`)
+
+	s := &strava.Session{AccessToken: "old-access", RefreshToken: "old-refresh"}
+	a.NoError(provider.RefreshSession(context.Background(), s))
+	a.Equal("new-access", s.AccessToken)
+	a.Equal("new-refresh", s.RefreshToken)
+	a.False(s.ExpiresAt.IsZero())
+}
+
+func Test_FetchUser_Athlete(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := stravaProvider()
+	provider.HTTPClient = stravaClient(`{"id":1234,"username":"marianne","firstname":"Marianne","lastname":"V.",
+		"measurement_preference":"meters","premium":true,"summit":true}`)
+
+	user, err := provider.FetchUser(&strava.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("1234", user.UserID)
+
+	athlete, err := strava.AthleteOf(user)
+	a.NoError(err)
+	a.Equal("meters", athlete.MeasurementPreference)
+	a.True(athlete.Premium)
+	a.True(athlete.Summit)
+}
+
 func stravaProvider() *strava.Provider {
 	return strava.New(os.Getenv("STRAVA_KEY"), os.Getenv("STRAVA_SECRET"), "/foo", "read")
 }
@@ -1,6 +1,7 @@
 package tiktok
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
@@ -32,6 +33,11 @@ func (s Session) GetAuthURL() (string, error) {
 // Authorize the session with TikTok and return the access token to be stored for future use. Note that
 // we call the endpoints directly vs calling *oauth2.Config.Exchange() due to inconsistent TikTok param names.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
 
 	// Set up the url params to post to get a new access token from a code
@@ -43,7 +49,7 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 		v.Set("redirect_uri", p.config.RedirectURL)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, endpointToken, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointToken, nil)
 	if err != nil {
 		return "", err
 	}
@@ -86,10 +92,10 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 
 	// Create and Bind the Access Token
 	s.AccessToken = tokenResp.Data.AccessToken
-	s.ExpiresAt = time.Now().UTC().Add(time.Second * time.Duration(tokenResp.Data.ExpiresIn))
+	s.ExpiresAt = goth.GetClock().Now().UTC().Add(time.Second * time.Duration(tokenResp.Data.ExpiresIn))
 	s.OpenID = tokenResp.Data.OpenID
 	s.RefreshToken = tokenResp.Data.RefreshToken
-	s.RefreshExpiresAt = time.Now().UTC().Add(time.Second * time.Duration(tokenResp.Data.RefreshExpiresIn))
+	s.RefreshExpiresAt = goth.GetClock().Now().UTC().Add(time.Second * time.Duration(tokenResp.Data.RefreshExpiresIn))
 	return s.AccessToken, nil
 }
 
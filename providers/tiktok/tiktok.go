@@ -4,6 +4,7 @@ package tiktok
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -101,6 +102,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to TikTok and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken:  sess.AccessToken,
@@ -120,7 +126,11 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		"access_token": {user.AccessToken},
 		"open_id":      {user.UserID},
 	}
-	response, err := p.GetClient().Get(endpointUserInfo + "?" + v.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointUserInfo+"?"+v.Encode(), nil)
+	if err != nil {
+		return user, err
+	}
+	response, err := p.GetClient().Do(req)
 	if err != nil {
 		return user, err
 	}
@@ -189,7 +199,12 @@ func newConfig(p *Provider, scopes []string) *oauth2.Config {
 
 // RefreshToken will refresh a TikTok access token.
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
-	req, err := http.NewRequest(http.MethodPost, endpointRefresh, nil)
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointRefresh, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -237,7 +252,7 @@ func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
 		AccessToken:  refresh.Data.AccessToken,
 		TokenType:    "Bearer",
 		RefreshToken: refresh.Data.RefreshToken,
-		Expiry:       time.Now().Add(time.Second * time.Duration(refresh.Data.ExpiresIn)),
+		Expiry:       goth.GetClock().Now().Add(time.Second * time.Duration(refresh.Data.ExpiresIn)),
 	}
 
 	tokenExtra := map[string]interface{}{
@@ -257,7 +272,7 @@ func (p *Provider) RefreshTokenAvailable() bool {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(s)
+	err := goth.DecodeSession(data, s)
 	return s, err
 }
 
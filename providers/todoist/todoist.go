@@ -0,0 +1,189 @@
+// Package todoist implements the OAuth2 protocol for authenticating users
+// through Todoist, using its Sync API for the user's details.
+package todoist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL  string = "https://todoist.com/oauth/authorize"
+	tokenURL string = "https://todoist.com/oauth/access_token"
+	syncURL  string = "https://api.todoist.com/api/v1/sync"
+)
+
+// Todoist scopes. ScopeDataRead is requested if no scopes are passed to New.
+const (
+	ScopeTaskAdd       = "task:add"
+	ScopeDataRead      = "data:read"
+	ScopeDataReadWrite = "data:read_write"
+	ScopeDataDelete    = "data:delete"
+	ScopeProjectDelete = "project:delete"
+)
+
+// Provider is the implementation of `goth.Provider` for accessing Todoist.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+}
+
+// New creates a new Todoist provider, and sets up important connection details.
+// You should always call `todoist.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "todoist",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, ScopeDataRead)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the todoist package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks Todoist for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	// Todoist separates scopes with commas
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state, oauth2.SetAuthURLParam("scope", strings.Join(p.config.Scopes, ","))),
+	}, nil
+}
+
+// FetchUser will go to Todoist and access the user resource of the Sync API.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken: sess.AccessToken,
+		Provider:    p.Name(),
+		ExpiresAt:   sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	form := url.Values{
+		"sync_token":     {"*"},
+		"resource_types": {`["user"]`},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", syncURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	var result struct {
+		User json.RawMessage `json:"user"`
+	}
+	if err := json.Unmarshal(bits, &result); err != nil {
+		return user, err
+	}
+	if len(result.User) == 0 || string(result.User) == "null" {
+		return user, errors.New("todoist: no user information returned")
+	}
+
+	err = user.SetRawJSON(result.User)
+	if err != nil {
+		return user, err
+	}
+
+	u := struct {
+		// IDs are numbers in older versions of the API
+		ID        json.RawMessage `json:"id"`
+		Email     string          `json:"email"`
+		FullName  string          `json:"full_name"`
+		AvatarBig string          `json:"avatar_big"`
+	}{}
+	if err := json.Unmarshal(result.User, &u); err != nil {
+		return user, err
+	}
+	user.UserID = strings.Trim(string(u.ID), `"`)
+	user.Email = u.Email
+	user.Name = u.FullName
+	user.AvatarURL = u.AvatarBig
+	return user, nil
+}
+
+// RefreshTokenAvailable refresh token is not provided by Todoist
+func (p *Provider) RefreshTokenAvailable() bool {
+	return false
+}
+
+// RefreshToken refresh token is not provided by Todoist
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return nil, errors.New("Refresh token is not provided by Todoist")
+}
@@ -0,0 +1,94 @@
+package todoist_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/todoist"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("TODOIST_KEY"))
+	a.Equal(p.Secret, os.Getenv("TODOIST_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := todoist.New(os.Getenv("TODOIST_KEY"), os.Getenv("TODOIST_SECRET"), "/foo", todoist.ScopeDataRead, todoist.ScopeTaskAdd)
+	session, err := p.BeginAuth("test_state")
+	s := session.(*todoist.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "todoist.com/oauth/authorize")
+	a.Contains(s.AuthURL, "scope=data%3Aread%2Ctask%3Aadd")
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+
+	for name, id := range map[string]string{
+		"string": `"2671355"`,
+		"number": `2671355`,
+	} {
+		name, id := name, id
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := provider()
+			p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				a.Equal("https://api.todoist.com/api/v1/sync", req.URL.String())
+				a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+				a.NoError(req.ParseForm())
+				a.Equal("*", req.PostForm.Get("sync_token"))
+				a.Equal(`["user"]`, req.PostForm.Get("resource_types"))
+				rec := httptest.NewRecorder()
+				fmt.Fprintf(rec, `{"full_sync":true,"sync_token":"abc","user":{"id":%s,"email":"jane@example.com",
+					"full_name":"Jane Doe","avatar_big":"https://example.com/jane_big.jpg","is_premium":true,
+					"tz_info":{"timezone":"Europe/London"}}}`, id)
+				return rec.Result(), nil
+			})}
+
+			user, err := p.FetchUser(&todoist.Session{AccessToken: "1234567890"})
+			a.NoError(err)
+			a.Equal("2671355", user.UserID)
+			a.Equal("Jane Doe", user.Name)
+			a.Equal("jane@example.com", user.Email)
+			a.Equal("https://example.com/jane_big.jpg", user.AvatarURL)
+			a.Equal(true, user.RawData["is_premium"])
+		})
+	}
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://todoist.com/oauth/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*todoist.Session)
+	a.Equal(s.AuthURL, "https://todoist.com/oauth/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *todoist.Provider {
+	return todoist.New(os.Getenv("TODOIST_KEY"), os.Getenv("TODOIST_SECRET"), "/foo")
+}
@@ -0,0 +1,210 @@
+// Package trello implements the OAuth protocol for authenticating users through Trello.
+package trello
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/markbates/goth"
+	"github.com/mrjones/oauth"
+	"golang.org/x/oauth2"
+)
+
+var (
+	requestURL      = "https://trello.com/1/OAuthGetRequestToken"
+	authorizeURL    = "https://trello.com/1/OAuthAuthorizeToken"
+	tokenURL        = "https://trello.com/1/OAuthGetAccessToken"
+	endpointProfile = "https://api.trello.com/1/members/me"
+)
+
+// Trello scopes. ScopeRead is requested if no scopes are passed to New.
+// ScopeAccount is needed for the user's email address.
+const (
+	ScopeRead    = "read"
+	ScopeWrite   = "write"
+	ScopeAccount = "account"
+)
+
+// Token expirations for SetExpiration.
+const (
+	ExpirationOneHour    = "1hour"
+	ExpirationOneDay     = "1day"
+	ExpirationThirtyDays = "30days"
+	ExpirationNever      = "never"
+)
+
+// Provider is the implementation of `goth.Provider` for accessing Trello.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	providerName string
+	scopes       []string
+	appName      string
+	expiration   string
+}
+
+// New creates a new Trello provider, and sets up important connection details.
+// clientKey and secret are the API key and secret of a Trello Power-Up.
+// You should always call `trello.New` to get a new Provider. Never try to create
+// one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "trello",
+		scopes:       scopes,
+	}
+	if len(p.scopes) == 0 {
+		p.scopes = []string{ScopeRead}
+	}
+	return p
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the trello package.
+func (p *Provider) Debug(debug bool) {}
+
+// SetAppName sets the application name Trello shows the user when asking for
+// access.
+func (p *Provider) SetAppName(name string) {
+	p.appName = name
+}
+
+// SetExpiration sets how long access tokens are valid for, one of the
+// Expiration constants. Trello issues tokens valid for 30 days by default.
+func (p *Provider) SetExpiration(expiration string) {
+	p.expiration = expiration
+}
+
+// BeginAuth asks Trello for an authentication end-point and a request token for a session.
+// Trello does not support the "state" variable.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	requestToken, url, err := p.consumer().GetRequestTokenAndUrl(p.CallbackURL)
+	session := &Session{
+		AuthURL:      url,
+		RequestToken: requestToken,
+	}
+	return session, err
+}
+
+// FetchUser will go to Trello and access basic information about the user.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		Provider: p.Name(),
+	}
+
+	if sess.AccessToken == nil {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+	user.AccessToken = sess.AccessToken.Token
+	user.AccessTokenSecret = sess.AccessToken.Secret
+
+	response, err := p.consumer().Get(
+		endpointProfile,
+		map[string]string{"fields": "id,username,fullName,email,avatarUrl,bio,url"},
+		sess.AccessToken)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	err = userFromReader(bytes.NewReader(bits), &user)
+	return user, err
+}
+
+func userFromReader(r io.Reader, user *goth.User) error {
+	u := struct {
+		ID        string `json:"id"`
+		Username  string `json:"username"`
+		FullName  string `json:"fullName"`
+		Email     string `json:"email"`
+		AvatarURL string `json:"avatarUrl"`
+		Bio       string `json:"bio"`
+	}{}
+	err := json.NewDecoder(r).Decode(&u)
+	if err != nil {
+		return err
+	}
+	user.UserID = u.ID
+	user.NickName = u.Username
+	user.Name = u.FullName
+	user.Email = u.Email
+	user.Description = u.Bio
+	if u.AvatarURL != "" {
+		// avatarUrl is the base of the avatar's sizes
+		user.AvatarURL = u.AvatarURL + "/170.png"
+	}
+	return nil
+}
+
+// consumer returns the OAuth client for Trello, sending the scopes, name and
+// expiration to the authorization page.
+func (p *Provider) consumer() *oauth.Consumer {
+	c := oauth.NewCustomHttpClientConsumer(
+		p.ClientKey,
+		p.Secret,
+		oauth.ServiceProvider{
+			RequestTokenUrl:   requestURL,
+			AuthorizeTokenUrl: authorizeURL,
+			AccessTokenUrl:    tokenURL,
+		},
+		p.Client())
+
+	c.AdditionalAuthorizationUrlParams["scope"] = strings.Join(p.scopes, ",")
+	if p.appName != "" {
+		c.AdditionalAuthorizationUrlParams["name"] = p.appName
+	}
+	if p.expiration != "" {
+		c.AdditionalAuthorizationUrlParams["expiration"] = p.expiration
+	}
+	return c
+}
+
+// RefreshToken refresh token is not provided by Trello
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return nil, errors.New("Refresh token is not provided by Trello")
+}
+
+// RefreshTokenAvailable refresh token is not provided by Trello
+func (p *Provider) RefreshTokenAvailable() bool {
+	return false
+}
@@ -0,0 +1,99 @@
+package trello_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/trello"
+	"github.com/mrjones/oauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("TRELLO_KEY"))
+	a.Equal(p.Secret, os.Getenv("TRELLO_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := trello.New(os.Getenv("TRELLO_KEY"), os.Getenv("TRELLO_SECRET"), "/foo", trello.ScopeRead, trello.ScopeAccount)
+	p.SetAppName("My App")
+	p.SetExpiration(trello.ExpirationNever)
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://trello.com/1/OAuthGetRequestToken", req.URL.String())
+		a.Contains(req.Header.Get("Authorization"), "OAuth ")
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, "oauth_token=TOKEN&oauth_token_secret=SECRET&oauth_callback_confirmed=true")
+		return rec.Result(), nil
+	})}
+
+	session, err := p.BeginAuth("state")
+	a.NoError(err)
+	s := session.(*trello.Session)
+	a.Contains(s.AuthURL, "https://trello.com/1/OAuthAuthorizeToken?")
+	a.Contains(s.AuthURL, "oauth_token=TOKEN")
+	a.Contains(s.AuthURL, "scope=read%2Caccount")
+	a.Contains(s.AuthURL, "name=My+App")
+	a.Contains(s.AuthURL, "expiration=never")
+	a.Equal("SECRET", s.RequestToken.Secret)
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("/1/members/me", req.URL.Path)
+		a.Contains(req.Header.Get("Authorization"), `oauth_token="TOKEN"`)
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"id":"5abbe4b7ddc1b351ef961414","username":"janedoe","fullName":"Jane Doe","email":"jane@example.com",
+			"avatarUrl":"https://trello-members.s3.amazonaws.com/5abbe4b7ddc1b351ef961414/abc","bio":"Designer","url":"https://trello.com/janedoe"}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&trello.Session{AccessToken: &oauth.AccessToken{Token: "TOKEN", Secret: "SECRET"}})
+	a.NoError(err)
+	a.Equal("5abbe4b7ddc1b351ef961414", user.UserID)
+	a.Equal("janedoe", user.NickName)
+	a.Equal("Jane Doe", user.Name)
+	a.Equal("jane@example.com", user.Email)
+	a.Equal("Designer", user.Description)
+	a.Equal("https://trello-members.s3.amazonaws.com/5abbe4b7ddc1b351ef961414/abc/170.png", user.AvatarURL)
+	a.Equal("TOKEN", user.AccessToken)
+	a.Equal("SECRET", user.AccessTokenSecret)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	s, err := provider().UnmarshalSession(`{"AuthURL":"https://trello.com/1/OAuthAuthorizeToken","AccessToken":{"Token":"1234567890","Secret":"secret!!","AdditionalData":{}},"RequestToken":{"Token":"0987654321","Secret":"!!secret"}}`)
+	a.NoError(err)
+	session := s.(*trello.Session)
+	a.Equal(session.AuthURL, "https://trello.com/1/OAuthAuthorizeToken")
+	a.Equal(session.AccessToken.Token, "1234567890")
+	a.Equal(session.RequestToken.Secret, "!!secret")
+}
+
+func provider() *trello.Provider {
+	return trello.New(os.Getenv("TRELLO_KEY"), os.Getenv("TRELLO_SECRET"), "/foo")
+}
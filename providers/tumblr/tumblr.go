@@ -50,7 +50,6 @@ type Provider struct {
 	Secret       string
 	CallbackURL  string
 	HTTPClient   *http.Client
-	debug        bool
 	consumer     *oauth.Consumer
 	providerName string
 }
@@ -69,10 +68,8 @@ func (p *Provider) Client() *http.Client {
 	return goth.HTTPClientWithFallBack(p.HTTPClient)
 }
 
-// Debug sets the logging of the OAuth client to verbose.
-func (p *Provider) Debug(debug bool) {
-	p.debug = debug
-}
+// Debug is a no-op for the tumblr package.
+func (p *Provider) Debug(debug bool) {}
 
 // BeginAuth asks Tumblr for an authentication end-point and a request token for a session.
 // Tumblr does not support the "state" variable.
@@ -136,8 +133,6 @@ func newConsumer(provider *Provider, authURL string) *oauth.Consumer {
 			AuthorizeTokenUrl: authURL,
 			AccessTokenUrl:    tokenURL,
 		})
-
-	c.Debug(provider.debug)
 	return c
 }
 
@@ -1,9 +1,9 @@
 package twitch
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"strings"
 	"time"
 
 	"github.com/markbates/goth"
@@ -15,6 +15,16 @@ type Session struct {
 	AccessToken  string
 	RefreshToken string
 	ExpiresAt    time.Time
+	// ValidatedAt is when the access token was last validated, see Provider.Revalidate.
+	ValidatedAt time.Time
+	// Scopes are the scopes granted to the access token when it was last validated.
+	Scopes []string `json:",omitempty"`
+}
+
+// ValidationDue reports whether the access token was last validated more than
+// ValidationInterval ago.
+func (s Session) ValidationDue() bool {
+	return goth.GetClock().Now().Sub(s.ValidatedAt) >= ValidationInterval
 }
 
 // GetAuthURL will return the URL set by calling the `BeginAuth` function on
@@ -29,8 +39,13 @@ func (s Session) GetAuthURL() (string, error) {
 // Authorize completes the authorization with Twitch and returns the access
 // token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.config.Exchange(goth.ContextForClient(p.Client()), params.Get("code"))
+	token, err := p.config.Exchange(goth.ContextWithClient(ctx, p.Client()), params.Get("code"))
 	if err != nil {
 		return "", err
 	}
@@ -60,6 +75,6 @@ func (s Session) String() string {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(s)
+	err := goth.DecodeSession(data, s)
 	return s, err
 }
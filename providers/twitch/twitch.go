@@ -3,11 +3,13 @@
 package twitch
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/markbates/goth"
 	"golang.org/x/oauth2"
@@ -17,8 +19,33 @@ const (
 	authURL      string = "https://id.twitch.tv/oauth2/authorize"
 	tokenURL     string = "https://id.twitch.tv/oauth2/token"
 	userEndpoint string = "https://api.twitch.tv/helix/users"
+
+	validateEndpoint string = "https://id.twitch.tv/oauth2/validate"
 )
 
+// ValidationInterval is how often Twitch requires apps to validate the
+// access tokens of users that stay logged in, see Revalidate.
+const ValidationInterval = time.Hour
+
+// ErrInvalidToken is returned when Twitch reports an access token as invalid,
+// e.g. because the user disconnected the app or changed their password.
+type ErrInvalidToken struct {
+	Message string
+}
+
+func (e *ErrInvalidToken) Error() string {
+	return "twitch: invalid access token: " + e.Message
+}
+
+// TokenValidation is Twitch's response to validating an access token.
+type TokenValidation struct {
+	ClientID  string   `json:"client_id"`
+	Login     string   `json:"login"`
+	UserID    string   `json:"user_id"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn int      `json:"expires_in"`
+}
+
 const (
 	// ScopeAnalyticsReadExtensions provides access to view analytics data for
 	// the Twitch Extensions owned by the authenticated account.
@@ -201,6 +228,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to Twitch and access basic info about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 
 	s := session.(*Session)
 
@@ -216,7 +248,18 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
 	}
 
-	req, err := http.NewRequest("GET", userEndpoint, nil)
+	// Twitch requires tokens to be validated when they are first used
+	validation, err := p.Revalidate(ctx, s)
+	if err != nil {
+		return user, err
+	}
+	user.RawData = map[string]interface{}{
+		"client_id": validation.ClientID,
+		"login":     validation.Login,
+		"scopes":    validation.Scopes,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", userEndpoint, nil)
 	if err != nil {
 		return user, err
 	}
@@ -236,6 +279,55 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	return user, err
 }
 
+// ValidateToken asks Twitch whether accessToken is still valid. Tokens issued
+// to other applications are rejected with an ErrInvalidToken.
+func (p *Provider) ValidateToken(ctx context.Context, accessToken string) (*TokenValidation, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", validateEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "OAuth "+accessToken)
+	resp, err := p.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return nil, &ErrInvalidToken{Message: body.Message}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with a %d trying to validate the access token", p.providerName, resp.StatusCode)
+	}
+
+	v := &TokenValidation{}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, err
+	}
+	if v.ClientID != p.config.ClientID {
+		return nil, &ErrInvalidToken{Message: "token was issued to client " + v.ClientID}
+	}
+	return v, nil
+}
+
+// Revalidate validates the session's access token and records when it did
+// so in the session. Call it for logged in users whenever
+// Session.ValidationDue reports true, and log the user out if it fails with
+// an ErrInvalidToken.
+func (p *Provider) Revalidate(ctx context.Context, session *Session) (*TokenValidation, error) {
+	v, err := p.ValidateToken(ctx, session.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	session.ValidatedAt = goth.GetClock().Now()
+	session.Scopes = v.Scopes
+	return v, nil
+}
+
 func userFromReader(r io.Reader, user *goth.User) error {
 	var users struct {
 		Data []struct {
@@ -296,8 +388,13 @@ func (p *Provider) RefreshTokenAvailable() bool {
 
 // RefreshToken get new access token based on the refresh token
 func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
 	token := &oauth2.Token{RefreshToken: refreshToken}
-	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
 	newToken, err := ts.Token()
 	if err != nil {
 		return nil, err
@@ -1,10 +1,16 @@
 package twitch
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -52,3 +58,66 @@ func Test_SessionFromJSON(t *testing.T) {
 	a.Equal(s.AuthURL, "https://id.twitch.tv/oauth2/authorize")
 	a.Equal(s.AccessToken, "1234567890")
 }
+
+// twitchClient serves the Twitch API with handler.
+func twitchClient(handler http.HandlerFunc) *http.Client {
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Result(), nil
+	})}
+}
+
+func Test_FetchUserValidatesToken(t *testing.T) {
+	a := assert.New(t)
+
+	clock := gothtest.NewClock(time.Now())
+	goth.UseClock(clock)
+	defer goth.UseClock(nil)
+
+	valid := true
+	p := New("client", "secret", "/foo")
+	p.HTTPClient = twitchClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth2/validate":
+			a.Equal("OAuth 1234567890", r.Header.Get("Authorization"))
+			if !valid {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"status":401,"message":"invalid access token"}`)
+				return
+			}
+			fmt.Fprint(w, `{"client_id":"client","login":"jdoe","scopes":["user:read:email"],"user_id":"42","expires_in":5520}`)
+		case "/helix/users":
+			fmt.Fprint(w, `{"data":[{"id":"42","login":"jdoe","display_name":"JDoe"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	s := &Session{AccessToken: "1234567890"}
+	a.True(s.ValidationDue())
+	user, err := p.FetchUser(s)
+	a.NoError(err)
+	a.Equal("42", user.UserID)
+	a.Equal("jdoe", user.RawData["login"])
+	a.Equal([]string{"user:read:email"}, s.Scopes)
+	a.False(s.ValidationDue())
+
+	clock.Advance(ValidationInterval)
+	a.True(s.ValidationDue())
+	valid = false
+	_, err = p.Revalidate(context.Background(), s)
+	a.IsType(&ErrInvalidToken{}, err)
+}
+
+func Test_ValidateTokenOtherClient(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := New("client", "secret", "/foo")
+	p.HTTPClient = twitchClient(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"client_id":"other","login":"jdoe","scopes":[],"user_id":"42","expires_in":5520}`)
+	})
+	_, err := p.ValidateToken(context.Background(), "1234567890")
+	a.IsType(&ErrInvalidToken{}, err)
+}
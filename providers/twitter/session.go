@@ -3,7 +3,6 @@ package twitter
 import (
 	"encoding/json"
 	"errors"
-	"strings"
 
 	"github.com/markbates/goth"
 	"github.com/mrjones/oauth"
@@ -49,6 +48,6 @@ func (s Session) String() string {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	sess := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(sess)
+	err := goth.DecodeSession(data, sess)
 	return sess, err
 }
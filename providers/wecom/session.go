@@ -1,9 +1,9 @@
 package wecom
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"strings"
 
 	"github.com/markbates/goth"
 )
@@ -25,14 +25,19 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with WeCom and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the requests to WeCom to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
-	token, err := p.fetchToken()
+	token, err := p.fetchToken(ctx)
 	if err != nil {
 		return "", err
 	}
 	s.AccessToken = token.AccessToken
 
-	userID, err := p.fetchUserID(s, params.Get("code"))
+	userID, err := p.fetchUserID(ctx, s, params.Get("code"))
 	if err != nil {
 		return "", err
 	}
@@ -50,6 +55,6 @@ func (s Session) Marshal() string {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	sess := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(sess)
+	err := goth.DecodeSession(data, sess)
 	return sess, err
 }
@@ -1,9 +1,15 @@
 package wecom_test
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
 	"github.com/markbates/goth/providers/wecom"
 	"github.com/stretchr/testify/assert"
 )
@@ -38,3 +44,37 @@ func Test_Marshal(t *testing.T) {
 	data := s.Marshal()
 	a.Equal(data, `{"AuthURL":"","AccessToken":"","UserID":""}`)
 }
+
+func Test_AuthorizeContext(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := wecomProvider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		rec := httptest.NewRecorder()
+		switch req.URL.Path {
+		case "/cgi-bin/gettoken":
+			fmt.Fprint(rec, `{"errcode":0,"access_token":"1234567890","expires_in":7200}`)
+		case "/cgi-bin/user/getuserinfo":
+			a.Equal("code", req.URL.Query().Get("code"))
+			fmt.Fprint(rec, `{"errcode":0,"UserId":"1122334455"}`)
+		default:
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		return rec.Result(), nil
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := (&wecom.Session{}).AuthorizeContext(ctx, p, url.Values{"code": {"code"}})
+	a.Error(err)
+
+	s := &wecom.Session{}
+	token, err := s.AuthorizeContext(context.Background(), p, url.Values{"code": {"code"}})
+	a.NoError(err)
+	a.Equal("1234567890", token)
+	a.Equal("1122334455", s.UserID)
+}
@@ -3,6 +3,7 @@
 package wecom
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -81,6 +82,11 @@ func (p *Provider) BeginAuth(state string) (goth.Session, error) {
 
 // FetchUser will go to WeCom and access basic information about the user.
 func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
 	sess := session.(*Session)
 	user := goth.User{
 		AccessToken: sess.AccessToken,
@@ -94,7 +100,11 @@ func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
 	params := url.Values{}
 	params.Add("access_token", user.AccessToken)
 	params.Add("userid", sess.UserID)
-	resp, err := p.Client().Get(fmt.Sprintf("%s/user/get?%s", p.baseURL, params.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/user/get?%s", p.baseURL, params.Encode()), nil)
+	if err != nil {
+		return user, err
+	}
+	resp, err := p.Client().Do(req)
 	if err != nil {
 		return user, err
 	}
@@ -120,7 +130,7 @@ func (p *Provider) RefreshTokenAvailable() bool {
 	return false
 }
 
-func (p *Provider) fetchToken() (*oauth2.Token, error) {
+func (p *Provider) fetchToken(ctx context.Context) (*oauth2.Token, error) {
 	if p.token != nil && p.token.Valid() {
 		return p.token, nil
 	}
@@ -128,7 +138,11 @@ func (p *Provider) fetchToken() (*oauth2.Token, error) {
 	params := url.Values{}
 	params.Add("corpid", p.ClientKey)
 	params.Add("corpsecret", p.Secret)
-	resp, err := p.Client().Get(fmt.Sprintf("%s/gettoken?%s", p.baseURL, params.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/gettoken?%s", p.baseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Client().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -152,18 +166,22 @@ func (p *Provider) fetchToken() (*oauth2.Token, error) {
 
 	p.token = &oauth2.Token{
 		AccessToken: obj.AccessToken,
-		Expiry:      time.Now().Add(obj.ExpiresIn * time.Second),
+		Expiry:      goth.GetClock().Now().Add(obj.ExpiresIn * time.Second),
 	}
 
 	return p.token, nil
 }
 
-func (p *Provider) fetchUserID(session goth.Session, code string) (string, error) {
+func (p *Provider) fetchUserID(ctx context.Context, session goth.Session, code string) (string, error) {
 	sess := session.(*Session)
 	params := url.Values{}
 	params.Add("access_token", sess.AccessToken)
 	params.Add("code", code)
-	resp, err := p.Client().Get(fmt.Sprintf("%s/user/getuserinfo?%s", p.baseURL, params.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/user/getuserinfo?%s", p.baseURL, params.Encode()), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := p.Client().Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,198 @@
+// Package workday implements the OAuth2 protocol for authenticating users
+// through a Workday tenant, with an API client registered in the tenant.
+package workday
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// ScopeOpenID makes Workday return an id_token, if the API client has been
+// set up to include one.
+const ScopeOpenID = "openid"
+
+// Provider is the implementation of `goth.Provider` for accessing Workday.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	restAPIURL   string
+}
+
+// New creates a new Workday provider and sets up important connection details.
+// The endpoints are those shown by the View API Clients task of the tenant,
+// e.g. https://impl.workday.com/acme/authorize for authURL,
+// https://wd2-impl-services1.workday.com/ccx/oauth2/acme/token for tokenURL
+// and https://wd2-impl-services1.workday.com/ccx/api/v1/acme for restAPIURL.
+// The functional areas the tokens give access to are set on the API client;
+// scopes only need to be passed to ask for an id_token.
+// You should always call `workday.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, authURL, tokenURL, restAPIURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "workday",
+		restAPIURL:   strings.TrimSuffix(restAPIURL, "/"),
+	}
+	p.config = newConfig(p, authURL, tokenURL, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, authURL, tokenURL string, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+		Scopes: []string{},
+	}
+
+	c.Scopes = append(c.Scopes, scopes...)
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the workday package.
+func (p *Provider) Debug(debug bool) {}
+
+// RESTAPIURL returns the Workday REST API endpoint of the tenant, which
+// further requests with the user's access token can be made to.
+func (p *Provider) RESTAPIURL() string {
+	return p.restAPIURL
+}
+
+// APIClient returns an http.Client authorizing requests with the user's
+// tokens, refreshing the access token when it expires. Use RESTAPIURL to build
+// the URLs of the requests.
+func (p *Provider) APIClient(ctx context.Context, user goth.User) *http.Client {
+	token := &oauth2.Token{
+		AccessToken:  user.AccessToken,
+		RefreshToken: user.RefreshToken,
+		Expiry:       user.ExpiresAt,
+	}
+	return p.config.Client(goth.ContextWithClient(ctx, p.Client()), token)
+}
+
+// BeginAuth asks Workday for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to Workday and access the worker record of the user.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.restAPIURL+"/workers/me", nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	req.Header.Set("Accept", "application/json")
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	u := struct {
+		ID               string `json:"id"`
+		Descriptor       string `json:"descriptor"`
+		PrimaryWorkEmail string `json:"primaryWorkEmail"`
+		BusinessTitle    string `json:"businessTitle"`
+		Organization     struct {
+			Descriptor string `json:"descriptor"`
+		} `json:"primarySupervisoryOrganization"`
+	}{}
+	if err := json.Unmarshal(bits, &u); err != nil {
+		return user, err
+	}
+	user.UserID = u.ID
+	user.Name = u.Descriptor
+	user.Email = u.PrimaryWorkEmail
+	user.Description = u.BusinessTitle
+	if u.Organization.Descriptor != "" {
+		user.Groups = []string{u.Organization.Descriptor}
+	}
+	return user, nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token. Workday
+// only issues refresh tokens to API clients set up to do so.
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
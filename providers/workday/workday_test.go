@@ -0,0 +1,139 @@
+package workday_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/workday"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("WORKDAY_KEY"))
+	a.Equal(p.Secret, os.Getenv("WORKDAY_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+	a.Equal(p.RESTAPIURL(), "https://wd2-impl-services1.workday.com/ccx/api/v1/acme")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	s := session.(*workday.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "https://impl.workday.com/acme/authorize")
+	a.NotContains(s.AuthURL, "scope=")
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://wd2-impl-services1.workday.com/ccx/oauth2/acme/token", req.URL.String())
+		_, _, ok := req.BasicAuth()
+		a.True(ok)
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(rec, `{"access_token":"1234567890","refresh_token":"r","token_type":"Bearer","id_token":"a.b.c"}`)
+		return rec.Result(), nil
+	})}
+
+	s := &workday.Session{}
+	_, err := s.Authorize(p, url.Values{"code": {"code"}})
+	a.NoError(err)
+	a.Equal("1234567890", s.AccessToken)
+	a.Equal("r", s.RefreshToken)
+	a.Equal("a.b.c", s.IDToken)
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://wd2-impl-services1.workday.com/ccx/api/v1/acme/workers/me", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"id":"3aa5550b7fe348b98d7b5741afc65534","descriptor":"Logan McNeil","isManager":true,
+			"primaryWorkEmail":"lmcneil@example.com","businessTitle":"Chief Human Resources Officer",
+			"primarySupervisoryOrganization":{"id":"80938777cac5440fab50d729f9634969","descriptor":"Human Resources"}}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&workday.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("3aa5550b7fe348b98d7b5741afc65534", user.UserID)
+	a.Equal("Logan McNeil", user.Name)
+	a.Equal("lmcneil@example.com", user.Email)
+	a.Equal("Chief Human Resources Officer", user.Description)
+	a.Equal([]string{"Human Resources"}, user.Groups)
+	a.Equal(true, user.RawData["isManager"])
+}
+
+func Test_APIClient(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		if req.URL.Path == "/ccx/oauth2/acme/token" {
+			rec.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(rec, `{"access_token":"0987654321","token_type":"Bearer","expires_in":3600}`)
+			return rec.Result(), nil
+		}
+		a.Equal("Bearer 0987654321", req.Header.Get("Authorization"))
+		fmt.Fprint(rec, `{}`)
+		return rec.Result(), nil
+	})}
+
+	// the access token has expired, so it is refreshed before the request
+	client := p.APIClient(context.Background(), goth.User{
+		AccessToken:  "1234567890",
+		RefreshToken: "r",
+		ExpiresAt:    time.Now().Add(-time.Minute),
+	})
+	response, err := client.Get(p.RESTAPIURL() + "/workers")
+	a.NoError(err)
+	a.Equal(http.StatusOK, response.StatusCode)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://impl.workday.com/acme/authorize","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*workday.Session)
+	a.Equal(s.AuthURL, "https://impl.workday.com/acme/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *workday.Provider {
+	return workday.New(os.Getenv("WORKDAY_KEY"), os.Getenv("WORKDAY_SECRET"), "/foo",
+		"https://impl.workday.com/acme/authorize",
+		"https://wd2-impl-services1.workday.com/ccx/oauth2/acme/token",
+		"https://wd2-impl-services1.workday.com/ccx/api/v1/acme/")
+}
@@ -0,0 +1,267 @@
+// Package workos implements the OAuth2 protocol for authenticating users
+// through WorkOS SSO, which connects to the identity provider of each of an
+// application's enterprise customers.
+package workos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL         string = "https://api.workos.com/sso/authorize"
+	tokenURL        string = "https://api.workos.com/sso/token"
+	endpointProfile string = "https://api.workos.com/sso/profile"
+)
+
+// ErrOrganizationMismatch is returned by FetchUser when the user did not log
+// in through the organization the session was started for.
+type ErrOrganizationMismatch struct {
+	Want string
+	Got  string
+}
+
+func (e *ErrOrganizationMismatch) Error() string {
+	return fmt.Sprintf("workos: user logged in to organization %q, not %q", e.Got, e.Want)
+}
+
+// ErrConnectionMismatch is returned by FetchUser when the user did not log
+// in through the connection the session was started for.
+type ErrConnectionMismatch struct {
+	Want string
+	Got  string
+}
+
+func (e *ErrConnectionMismatch) Error() string {
+	return fmt.Sprintf("workos: user logged in through connection %q, not %q", e.Got, e.Want)
+}
+
+// Provider is the implementation of `goth.Provider` for accessing WorkOS.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	connection   string
+	organization string
+	idp          string
+}
+
+// New creates a new WorkOS provider and sets up important connection details.
+// clientKey is the WorkOS client ID and secret is the API key. One of
+// SetConnection, SetOrganization or SetProvider must be called for BeginAuth
+// to know where to send users, unless BeginAuthWithConnection or
+// BeginAuthWithOrganization is used.
+// You should always call `workos.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "workos",
+	}
+	p.config = newConfig(p)
+	return p
+}
+
+func newConfig(provider *Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the workos package.
+func (p *Provider) Debug(debug bool) {}
+
+// SetConnection makes BeginAuth log users in through the WorkOS connection
+// with the given ID (conn_...).
+func (p *Provider) SetConnection(connection string) {
+	p.connection = connection
+}
+
+// SetOrganization makes BeginAuth log users in through the connection of the
+// WorkOS organization with the given ID (org_...).
+func (p *Provider) SetOrganization(organization string) {
+	p.organization = organization
+}
+
+// SetProvider makes BeginAuth log users in through an OAuth provider instead
+// of an enterprise connection, e.g. "GoogleOAuth" or "MicrosoftOAuth".
+func (p *Provider) SetProvider(idp string) {
+	p.idp = idp
+}
+
+// BeginAuth asks WorkOS for an authentication end-point, for the connection,
+// organization or provider the provider has been configured with.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	switch {
+	case p.connection != "":
+		return p.BeginAuthWithConnection(state, p.connection)
+	case p.organization != "":
+		return p.BeginAuthWithOrganization(state, p.organization)
+	case p.idp != "":
+		return &Session{
+			AuthURL: p.config.AuthCodeURL(state, oauth2.SetAuthURLParam("provider", p.idp)),
+		}, nil
+	}
+	return nil, errors.New("workos: one of a connection, organization or provider must be set")
+}
+
+// BeginAuthWithConnection is like BeginAuth but logs the user in through the
+// given connection.
+func (p *Provider) BeginAuthWithConnection(state, connection string) (goth.Session, error) {
+	return &Session{
+		AuthURL:    p.config.AuthCodeURL(state, oauth2.SetAuthURLParam("connection", connection)),
+		Connection: connection,
+	}, nil
+}
+
+// BeginAuthWithOrganization is like BeginAuth but logs the user in through
+// the connection of the given organization, e.g. one looked up from the
+// domain of the user's email address.
+func (p *Provider) BeginAuthWithOrganization(state, organization string) (goth.Session, error) {
+	return &Session{
+		AuthURL:      p.config.AuthCodeURL(state, oauth2.SetAuthURLParam("organization", organization)),
+		Organization: organization,
+	}, nil
+}
+
+// profile is a WorkOS SSO profile.
+// See: https://workos.com/docs/reference/sso/profile
+type profile struct {
+	ID             string `json:"id"`
+	ConnectionID   string `json:"connection_id"`
+	ConnectionType string `json:"connection_type"`
+	OrganizationID string `json:"organization_id"`
+	Email          string `json:"email"`
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	IdpID          string `json:"idp_id"`
+	Role           struct {
+		Slug string `json:"slug"`
+	} `json:"role"`
+	Roles []struct {
+		Slug string `json:"slug"`
+	} `json:"roles"`
+	Groups []string `json:"groups"`
+}
+
+// FetchUser will go to WorkOS and access the profile of the user. The whole
+// profile is included in RawData, with the attributes mapped from the
+// customer's directory under custom_attributes and those sent by the identity
+// provider under raw_attributes.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken: sess.AccessToken,
+		Provider:    p.Name(),
+		ExpiresAt:   sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpointProfile, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	var prof profile
+	if err := json.NewDecoder(bytes.NewReader(bits)).Decode(&prof); err != nil {
+		return user, err
+	}
+	if sess.Organization != "" && prof.OrganizationID != sess.Organization {
+		return user, &ErrOrganizationMismatch{Want: sess.Organization, Got: prof.OrganizationID}
+	}
+	if sess.Connection != "" && prof.ConnectionID != sess.Connection {
+		return user, &ErrConnectionMismatch{Want: sess.Connection, Got: prof.ConnectionID}
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	user.UserID = prof.ID
+	user.Email = prof.Email
+	user.FirstName = prof.FirstName
+	user.LastName = prof.LastName
+	if prof.FirstName != "" && prof.LastName != "" {
+		user.Name = prof.FirstName + " " + prof.LastName
+	} else {
+		user.Name = prof.FirstName + prof.LastName
+	}
+	user.Groups = prof.Groups
+	for _, role := range prof.Roles {
+		user.Roles = append(user.Roles, role.Slug)
+	}
+	if len(user.Roles) == 0 && prof.Role.Slug != "" {
+		user.Roles = []string{prof.Role.Slug}
+	}
+	return user, nil
+}
+
+// RefreshTokenAvailable refresh token is not provided by workos
+func (p *Provider) RefreshTokenAvailable() bool {
+	return false
+}
+
+// RefreshToken refresh token is not provided by workos
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return nil, errors.New("Refresh token is not provided by workos")
+}
@@ -0,0 +1,110 @@
+package workos_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/workos"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "workos")
+	a.Equal(p.ClientKey, "client_123")
+	a.Equal(p.Secret, "sk_test")
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	_, err := p.BeginAuth("test_state")
+	a.Error(err)
+
+	p.SetOrganization("org_123")
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*workos.Session)
+	a.Contains(s.AuthURL, "https://api.workos.com/sso/authorize")
+	a.Contains(s.AuthURL, "organization=org_123")
+	a.Equal("org_123", s.Organization)
+
+	session, err = p.BeginAuthWithConnection("test_state", "conn_123")
+	a.NoError(err)
+	a.Contains(session.(*workos.Session).AuthURL, "connection=conn_123")
+}
+
+func profileClient(a *assert.Assertions) *http.Client {
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://api.workos.com/sso/profile", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"id":"prof_123","connection_id":"conn_123","connection_type":"OktaSAML",
+			"organization_id":"org_123","email":"todd@example.com","first_name":"Todd","last_name":"Rundgren",
+			"idp_id":"00u1a0ufowBJlzPlk357","role":{"slug":"admin"},"groups":["Engineering"],
+			"custom_attributes":{"department":"Engineering"},"raw_attributes":{"title":"Engineer"}}`)
+		return rec.Result(), nil
+	})}
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+	p.HTTPClient = profileClient(a)
+
+	user, err := p.FetchUser(&workos.Session{AccessToken: "1234567890", Organization: "org_123"})
+	a.NoError(err)
+	a.Equal("prof_123", user.UserID)
+	a.Equal("todd@example.com", user.Email)
+	a.Equal("Todd Rundgren", user.Name)
+	a.Equal([]string{"admin"}, user.Roles)
+	a.Equal([]string{"Engineering"}, user.Groups)
+	a.Equal(map[string]interface{}{"department": "Engineering"}, user.RawData["custom_attributes"])
+}
+
+func Test_FetchUser_OrganizationMismatch(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+	p.HTTPClient = profileClient(a)
+
+	_, err := p.FetchUser(&workos.Session{AccessToken: "1234567890", Organization: "org_456"})
+	a.Equal(&workos.ErrOrganizationMismatch{Want: "org_456", Got: "org_123"}, err)
+
+	_, err = p.FetchUser(&workos.Session{AccessToken: "1234567890", Connection: "conn_456"})
+	a.IsType(&workos.ErrConnectionMismatch{}, err)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://api.workos.com/sso/authorize","AccessToken":"1234567890","Organization":"org_123"}`)
+	a.NoError(err)
+
+	s := session.(*workos.Session)
+	a.Equal(s.AuthURL, "https://api.workos.com/sso/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+	a.Equal(s.Organization, "org_123")
+}
+
+func provider() *workos.Provider {
+	return workos.New("client_123", "sk_test", "/foo")
+}
@@ -0,0 +1,57 @@
+package x_test
+
+import (
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/x"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Implements_Session(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	s := &x.Session{}
+
+	a.Implements((*goth.Session)(nil), s)
+}
+
+func Test_GetAuthURL(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	s := &x.Session{}
+
+	_, err := s.GetAuthURL()
+	a.Error(err)
+
+	s.AuthURL = "/foo"
+
+	url, _ := s.GetAuthURL()
+	a.Equal(url, "/foo")
+}
+
+func Test_ToJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	s := &x.Session{}
+
+	data := s.Marshal()
+	a.Equal(data, `{"AuthURL":"","CodeVerifier":"","AccessToken":"","RefreshToken":"","ExpiresAt":"0001-01-01T00:00:00Z"}`)
+}
+
+func Test_String(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	s := &x.Session{}
+
+	a.Equal(s.String(), s.Marshal())
+}
+
+func Test_AuthorizeWithoutVerifier(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	s := &x.Session{AuthURL: "/foo"}
+
+	_, err := s.Authorize(x.New("key", "secret", "/foo"), goth.Params(nil))
+	a.Error(err)
+}
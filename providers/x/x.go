@@ -0,0 +1,245 @@
+// Package x implements the OAuth2 protocol, with PKCE, for authenticating
+// users through X (formerly Twitter). Unlike the twitter and twitterv2
+// packages it does not need OAuth 1.0a keys.
+package x
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// These vars define the default Authentication, Token, API and revocation URLs for X.
+var (
+	AuthURL    = "https://x.com/i/oauth2/authorize"
+	TokenURL   = "https://api.x.com/2/oauth2/token"
+	ProfileURL = "https://api.x.com/2/users/me"
+	RevokeURL  = "https://api.x.com/2/oauth2/revoke"
+)
+
+// DefaultScopes are requested when New is called without scopes. offline.access
+// makes X issue a refresh token.
+var DefaultScopes = []string{"tweet.read", "users.read", "offline.access"}
+
+// New creates a new X provider, and sets up important connection details.
+// You should always call `x.New` to get a new Provider. Never try to create
+// one manually.
+//
+// Leave secret empty for an app registered as a public client, e.g. a native
+// or single page app; confidential clients authenticate with their secret.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	return NewCustomisedURL(clientKey, secret, callbackURL, AuthURL, TokenURL, ProfileURL, scopes...)
+}
+
+// NewCustomisedURL is similar to New(...) but can be used to set custom URLs to connect to
+func NewCustomisedURL(clientKey, secret, callbackURL, authURL, tokenURL, profileURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "x",
+		profileURL:   profileURL,
+		revokeURL:    RevokeURL,
+	}
+	p.config = newConfig(p, authURL, tokenURL, scopes)
+	return p
+}
+
+// Provider is the implementation of `goth.Provider` for accessing X.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	profileURL   string
+	revokeURL    string
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns an HTTP client to be used in all fetch operations.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the x package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks X for an authentication end-point. A PKCE code verifier is
+// generated and kept in the session until the code is exchanged.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(b)
+	challenge := sha256.Sum256([]byte(verifier))
+
+	url := p.config.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+	return &Session{
+		AuthURL:      url,
+		CodeVerifier: verifier,
+	}, nil
+}
+
+type xUser struct {
+	Data struct {
+		ID              string `json:"id"`
+		Name            string `json:"name"`
+		Username        string `json:"username"`
+		Description     string `json:"description"`
+		Location        string `json:"location"`
+		ProfileImageURL string `json:"profile_image_url"`
+	} `json:"data"`
+}
+
+// FetchUser will go to X and access basic information about the user.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL+"?user.fields=description,location,profile_image_url", nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+	if err := user.SetRawJSON(bits); err != nil {
+		return user, err
+	}
+
+	var u xUser
+	if err := json.Unmarshal(bits, &u); err != nil {
+		return user, err
+	}
+	user.UserID = u.Data.ID
+	user.Name = u.Data.Name
+	user.NickName = u.Data.Username
+	user.Description = u.Data.Description
+	user.Location = u.Data.Location
+	user.AvatarURL = u.Data.ProfileImageURL
+	return user, nil
+}
+
+// RevokeToken revokes an access or refresh token.
+func (p *Provider) RevokeToken(ctx context.Context, token string) error {
+	form := url.Values{"token": {token}}
+	if p.Secret == "" {
+		form.Set("client_id", p.ClientKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.revokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p.Secret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.ClientKey), url.QueryEscape(p.Secret))
+	}
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with a %d trying to revoke a token", p.providerName, response.StatusCode)
+	}
+	return nil
+}
+
+func newConfig(provider *Provider, authURL, tokenURL string, scopes []string) *oauth2.Config {
+	// confidential clients must use HTTP basic authentication, public
+	// clients identify themselves with client_id in the request body
+	authStyle := oauth2.AuthStyleInHeader
+	if provider.Secret == "" {
+		authStyle = oauth2.AuthStyleInParams
+	}
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+			AuthStyle: authStyle,
+		},
+		Scopes: append([]string{}, DefaultScopes...),
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append([]string{}, scopes...)
+	}
+	return c
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token. X issues
+// refresh tokens only if the offline.access scope was granted.
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	if refreshToken == "" {
+		return nil, errors.New("x: no refresh token, request the offline.access scope")
+	}
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
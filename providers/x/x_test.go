@@ -0,0 +1,129 @@
+package x_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/x"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := xProvider()
+	a.Equal(provider.ClientKey, os.Getenv("X_KEY"))
+	a.Equal(provider.Secret, os.Getenv("X_SECRET"))
+	a.Equal(provider.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Implements((*goth.Provider)(nil), xProvider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := xProvider()
+	session, err := provider.BeginAuth("test_state")
+	s := session.(*x.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "x.com/i/oauth2/authorize")
+	a.Contains(s.AuthURL, fmt.Sprintf("client_id=%s", os.Getenv("X_KEY")))
+	a.Contains(s.AuthURL, "state=test_state")
+	a.Contains(s.AuthURL, "scope=tweet.read+users.read+offline.access")
+	a.Contains(s.AuthURL, "code_challenge_method=S256")
+
+	challenge := sha256.Sum256([]byte(s.CodeVerifier))
+	u, _ := url.Parse(s.AuthURL)
+	a.Equal(base64.RawURLEncoding.EncodeToString(challenge[:]), u.Query().Get("code_challenge"))
+
+	other, _ := provider.BeginAuth("test_state")
+	a.NotEqual(s.CodeVerifier, other.(*x.Session).CodeVerifier)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	provider := xProvider()
+
+	s, err := provider.UnmarshalSession(`{"AuthURL":"https://x.com/i/oauth2/authorize","CodeVerifier":"verifier","AccessToken":"1234567890"}`)
+	a.NoError(err)
+	session := s.(*x.Session)
+	a.Equal(session.AuthURL, "https://x.com/i/oauth2/authorize")
+	a.Equal(session.CodeVerifier, "verifier")
+	a.Equal(session.AccessToken, "1234567890")
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+
+	for _, secret := range []string{"secret", ""} {
+		a := assert.New(t)
+		var verifier string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/token":
+				r.ParseForm()
+				verifier = r.Form.Get("code_verifier")
+				user, pass, basic := r.BasicAuth()
+				if secret != "" {
+					a.True(basic)
+					a.Equal("client-id", user)
+					a.Equal(secret, pass)
+				} else {
+					a.False(basic)
+					a.Equal("client-id", r.Form.Get("client_id"))
+					a.Empty(r.Form.Get("client_secret"))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"token_type":"bearer","expires_in":7200,"access_token":"access","refresh_token":"refresh","scope":"tweet.read users.read offline.access"}`)
+			case "/users/me":
+				a.Equal("Bearer access", r.Header.Get("Authorization"))
+				fmt.Fprint(w, `{"data":{"id":"2244994945","name":"X Dev","username":"XDevelopers","profile_image_url":"https://pbs.twimg.com/profile.jpg"}}`)
+			}
+		}))
+
+		provider := x.NewCustomisedURL("client-id", secret, "/foo", ts.URL+"/authorize", ts.URL+"/token", ts.URL+"/users/me")
+		session, _ := provider.BeginAuth("test_state")
+		sess := session.(*x.Session)
+		pending := sess.CodeVerifier
+		_, err := sess.Authorize(provider, url.Values{"code": {"code"}})
+		a.NoError(err)
+		a.Equal(pending, verifier)
+		a.Empty(sess.CodeVerifier)
+		a.Equal("refresh", sess.RefreshToken)
+		a.False(sess.ExpiresAt.IsZero())
+
+		user, err := provider.FetchUser(sess)
+		a.NoError(err)
+		a.Equal("2244994945", user.UserID)
+		a.Equal("XDevelopers", user.NickName)
+		a.Equal("X Dev", user.Name)
+		a.Equal("refresh", user.RefreshToken)
+		ts.Close()
+	}
+}
+
+func xProvider() *x.Provider {
+	return x.New(os.Getenv("X_KEY"), os.Getenv("X_SECRET"), "/foo")
+}
+
+func Test_Conformance(t *testing.T) {
+	t.Parallel()
+	gothtest.RunProviderConformance(t, xProvider(), gothtest.Fixtures{})
+}
@@ -3,7 +3,6 @@ package xero
 import (
 	"encoding/json"
 	"errors"
-	"strings"
 	"time"
 
 	"github.com/markbates/goth"
@@ -37,7 +36,7 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 		return "", err
 	}
 
-	s.AccessTokenExpires = time.Now().UTC().Add(30 * time.Minute)
+	s.AccessTokenExpires = goth.GetClock().Now().UTC().Add(30 * time.Minute)
 	s.AccessToken = accessToken
 
 	return accessToken.Token, err
@@ -56,6 +55,6 @@ func (s Session) String() string {
 // UnmarshalSession will unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	sess := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(sess)
+	err := goth.DecodeSession(data, sess)
 	return sess, err
 }
@@ -88,7 +88,6 @@ type Provider struct {
 	CallbackURL  string
 	HTTPClient   *http.Client
 	Method       string
-	debug        bool
 	consumer     *oauth.Consumer
 	providerName string
 }
@@ -108,10 +107,8 @@ func (p *Provider) Client() *http.Client {
 	return goth.HTTPClientWithFallBack(p.HTTPClient)
 }
 
-// Debug sets the logging of the OAuth client to verbose.
-func (p *Provider) Debug(debug bool) {
-	p.debug = debug
-}
+// Debug is a no-op for the xero package.
+func (p *Provider) Debug(debug bool) {}
 
 // BeginAuth asks Xero for an authentication end-point and a request token for a session.
 // Xero does not support the "state" variable.
@@ -190,8 +187,6 @@ func newPublicConsumer(provider *Provider, authURL string) *oauth.Consumer {
 			AccessTokenUrl:    tokenURL},
 	)
 
-	c.Debug(provider.debug)
-
 	accepttype := []string{"application/json"}
 	useragent := []string{userAgentString}
 	c.AdditionalHeaders = map[string][]string{
@@ -224,8 +219,6 @@ func newPrivateOrPartnerConsumer(provider *Provider, authURL string) *oauth.Cons
 			AccessTokenUrl:    tokenURL},
 	)
 
-	c.Debug(provider.debug)
-
 	accepttype := []string{"application/json"}
 	useragent := []string{userAgentString}
 	c.AdditionalHeaders = map[string][]string{
@@ -243,7 +236,7 @@ func (p *Provider) RefreshOAuth1Token(session *Session) error {
 		return err
 	}
 	session.AccessToken = newAccessToken
-	session.AccessTokenExpires = time.Now().UTC().Add(30 * time.Minute)
+	session.AccessTokenExpires = goth.GetClock().Now().UTC().Add(30 * time.Minute)
 	return nil
 }
 
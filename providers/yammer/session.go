@@ -1,6 +1,7 @@
 package yammer
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,6 +32,11 @@ func (s Session) GetAuthURL() (string, error) {
 
 // Authorize the session with Yammer and return the access token to be stored for future use.
 func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.AuthorizeContext(context.Background(), provider, params)
+}
+
+// AuthorizeContext is like Authorize but binds the token exchange to ctx.
+func (s *Session) AuthorizeContext(ctx context.Context, provider goth.Provider, params goth.Params) (string, error) {
 	p := provider.(*Provider)
 	v := url.Values{
 		"grant_type":   {"authorization_code"},
@@ -41,7 +47,7 @@ func (s *Session) Authorize(provider goth.Provider, params goth.Params) (string,
 	// Cant use standard auth2 implementation as yammer returns access_token as json rather than string
 	// stand methods are throwing exception
 	// token, err := p.config.Exchange(goth.ContextForClient(p.Client), params.Get("code"))
-	autData, err := retrieveAuthData(p, tokenURL, v)
+	autData, err := retrieveAuthData(ctx, p, tokenURL, v)
 	if err != nil {
 		return "", err
 	}
@@ -62,10 +68,10 @@ func (s Session) String() string {
 
 // Custom implementation for yammer to get access token and user data
 // Yammer provides user data along with access token, no separate api available
-func retrieveAuthData(p *Provider, TokenURL string, v url.Values) (map[string]map[string]interface{}, error) {
+func retrieveAuthData(ctx context.Context, p *Provider, TokenURL string, v url.Values) (map[string]map[string]interface{}, error) {
 	v.Set("client_id", p.ClientKey)
 	v.Set("client_secret", p.Secret)
-	req, err := http.NewRequest("POST", TokenURL, strings.NewReader(v.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", TokenURL, strings.NewReader(v.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +111,6 @@ func CondVal(v string) []string {
 // UnmarshalSession wil unmarshal a JSON string into a session.
 func (p *Provider) UnmarshalSession(data string) (goth.Session, error) {
 	s := &Session{}
-	err := json.NewDecoder(strings.NewReader(data)).Decode(s)
+	err := goth.DecodeSession(data, s)
 	return s, err
 }
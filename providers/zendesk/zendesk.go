@@ -0,0 +1,226 @@
+// Package zendesk implements the OAuth2 protocol for authenticating users
+// through a Zendesk account.
+package zendesk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// Zendesk scopes. ScopeRead is requested if no scopes are passed to New.
+const (
+	ScopeRead              = "read"
+	ScopeWrite             = "write"
+	ScopeUsersRead         = "users:read"
+	ScopeTicketsRead       = "tickets:read"
+	ScopeTicketsWrite      = "tickets:write"
+	ScopeOrganizationsRead = "organizations:read"
+)
+
+// Roles of Zendesk users, stored in User.Roles.
+const (
+	RoleEndUser = "end-user"
+	RoleAgent   = "agent"
+	RoleAdmin   = "admin"
+)
+
+// RawDataOrganizations is the key of User.RawData holding the organizations
+// the user belongs to, as a list of objects with an id and a name.
+const RawDataOrganizations = "organizations"
+
+// Provider is the implementation of `goth.Provider` for accessing Zendesk.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	baseURL      string
+}
+
+// New creates a new Zendesk provider for the account with the given
+// subdomain (the "acme" of acme.zendesk.com), and sets up important
+// connection details. clientKey is the unique identifier of the OAuth client.
+// You should always call `zendesk.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, subdomain string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "zendesk",
+		baseURL:      "https://" + subdomain + ".zendesk.com",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   provider.baseURL + "/oauth/authorizations/new",
+			TokenURL:  provider.baseURL + "/oauth/tokens",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, ScopeRead)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the zendesk package.
+func (p *Provider) Debug(debug bool) {}
+
+// BeginAuth asks Zendesk for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// FetchUser will go to Zendesk and access basic information about the user.
+// The user's role is stored in Roles, and the organizations the user belongs
+// to in RawData under RawDataOrganizations.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	// the user's organizations are sideloaded alongside the user
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/api/v2/users/me?include=organizations", nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	var result struct {
+		User          json.RawMessage `json:"user"`
+		Organizations []struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		} `json:"organizations"`
+	}
+	if err := json.Unmarshal(bits, &result); err != nil {
+		return user, err
+	}
+	if len(result.User) == 0 || string(result.User) == "null" {
+		return user, errors.New("zendesk: no user information returned")
+	}
+
+	err = user.SetRawJSON(result.User)
+	if err != nil {
+		return user, err
+	}
+
+	u := struct {
+		ID       int64  `json:"id"`
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Verified bool   `json:"verified"`
+		Role     string `json:"role"`
+		Photo    *struct {
+			ContentURL string `json:"content_url"`
+		} `json:"photo"`
+	}{}
+	if err := json.Unmarshal(result.User, &u); err != nil {
+		return user, err
+	}
+	user.UserID = strconv.FormatInt(u.ID, 10)
+	user.Name = u.Name
+	user.Email = u.Email
+	user.EmailVerified = u.Verified
+	if u.Role != "" {
+		user.Roles = []string{u.Role}
+	}
+	if u.Photo != nil {
+		user.AvatarURL = u.Photo.ContentURL
+	}
+
+	organizations := make([]interface{}, 0, len(result.Organizations))
+	for _, o := range result.Organizations {
+		organizations = append(organizations, map[string]interface{}{
+			"id":   strconv.FormatInt(o.ID, 10),
+			"name": o.Name,
+		})
+	}
+	user.SetRawData(RawDataOrganizations, organizations)
+	return user, nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token. Zendesk only
+// issues refresh tokens to OAuth clients with expiring access tokens.
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
@@ -0,0 +1,105 @@
+package zendesk_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/zendesk"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("ZENDESK_KEY"))
+	a.Equal(p.Secret, os.Getenv("ZENDESK_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	s := session.(*zendesk.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "https://acme.zendesk.com/oauth/authorizations/new")
+	a.Contains(s.AuthURL, "scope=read")
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://acme.zendesk.com/api/v2/users/me?include=organizations", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"user":{"id":35436,"name":"Jane Doe","email":"jane@example.com","verified":true,
+			"role":"agent","role_type":null,"organization_id":57542,
+			"photo":{"content_url":"https://acme.zendesk.com/photos/jane.png"}},
+			"organizations":[{"id":57542,"name":"Acme Support"}]}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&zendesk.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("35436", user.UserID)
+	a.Equal("Jane Doe", user.Name)
+	a.Equal("jane@example.com", user.Email)
+	a.True(user.EmailVerified)
+	a.Equal([]string{zendesk.RoleAgent}, user.Roles)
+	a.Equal("https://acme.zendesk.com/photos/jane.png", user.AvatarURL)
+	a.Equal([]interface{}{map[string]interface{}{"id": "57542", "name": "Acme Support"}}, user.RawData[zendesk.RawDataOrganizations])
+}
+
+func Test_FetchUser_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"user":{"id":35436,"name":"Jane Doe"},"organizations":[{"id":57542,"name":"Acme Support"}]}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&zendesk.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("Jane Doe", raw["name"])
+	a.Equal([]interface{}{map[string]interface{}{"id": "57542", "name": "Acme Support"}}, raw[zendesk.RawDataOrganizations])
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://acme.zendesk.com/oauth/authorizations/new","AccessToken":"1234567890"}`)
+	a.NoError(err)
+
+	s := session.(*zendesk.Session)
+	a.Equal(s.AuthURL, "https://acme.zendesk.com/oauth/authorizations/new")
+	a.Equal(s.AccessToken, "1234567890")
+}
+
+func provider() *zendesk.Provider {
+	return zendesk.New(os.Getenv("ZENDESK_KEY"), os.Getenv("ZENDESK_SECRET"), "/foo", "acme")
+}
@@ -0,0 +1,387 @@
+// Package zitadel implements the OpenID Connect protocol for authenticating
+// users through a ZITADEL instance.
+package zitadel
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// Reserved ZITADEL scopes.
+const (
+	// ScopeProjectRoles includes the roles the user was granted in the
+	// project of the application, which User.Roles is filled in from.
+	ScopeProjectRoles = "urn:zitadel:iam:org:project:roles"
+	// ScopeResourceOwner includes the ID, name and domain of the
+	// organization the user belongs to.
+	ScopeResourceOwner = "urn:zitadel:iam:user:resourceowner"
+	// ScopeOfflineAccess makes ZITADEL issue a refresh token.
+	ScopeOfflineAccess = "offline_access"
+)
+
+const (
+	organizationScopePrefix = "urn:zitadel:iam:org:id:"
+	resourceOwnerIDClaim    = "urn:zitadel:iam:user:resourceowner:id"
+	clientAssertionType     = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	assertionLifetime       = 5 * time.Minute
+)
+
+// OrganizationScope returns the scope restricting the login to the users of
+// the organization with the given ID.
+func OrganizationScope(organizationID string) string {
+	return organizationScopePrefix + organizationID
+}
+
+// ErrOrganizationMismatch is returned when the user logged in for an
+// organization does not belong to it.
+type ErrOrganizationMismatch struct {
+	Want string
+	Got  string
+}
+
+func (e *ErrOrganizationMismatch) Error() string {
+	return fmt.Sprintf("zitadel: user belongs to organization %q, not %q", e.Got, e.Want)
+}
+
+// Key is an application key, as downloaded from the ZITADEL console, for
+// authenticating with a JWT signed by the key instead of the client secret.
+type Key struct {
+	Type     string `json:"type"`
+	KeyID    string `json:"keyId"`
+	Key      string `json:"key"`
+	AppID    string `json:"appId"`
+	ClientID string `json:"clientId"`
+}
+
+// ParseKey parses the JSON key file of an application key.
+func ParseKey(data []byte) (*Key, error) {
+	key := &Key{}
+	if err := json.Unmarshal(data, key); err != nil {
+		return nil, err
+	}
+	if key.Type != "application" || key.ClientID == "" || key.KeyID == "" {
+		return nil, errors.New("zitadel: not an application key")
+	}
+	return key, nil
+}
+
+// Provider is the implementation of `goth.Provider` for accessing ZITADEL.
+type Provider struct {
+	ClientKey    string
+	Secret       string
+	CallbackURL  string
+	HTTPClient   *http.Client
+	config       *oauth2.Config
+	providerName string
+	issuerURL    string
+	profileURL   string
+	organization string
+	keyID        string
+	privateKey   *rsa.PrivateKey
+}
+
+// New creates a new ZITADEL provider for the instance with the given issuer
+// URL, e.g. https://my-instance.zitadel.cloud, and sets up important
+// connection details. The openid, profile, email and ScopeProjectRoles scopes
+// are requested unless other scopes are passed.
+// You should always call `zitadel.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL, issuerURL string, scopes ...string) *Provider {
+	issuerURL = strings.TrimSuffix(issuerURL, "/")
+	p := &Provider{
+		ClientKey:    clientKey,
+		Secret:       secret,
+		CallbackURL:  callbackURL,
+		providerName: "zitadel",
+		issuerURL:    issuerURL,
+		profileURL:   issuerURL + "/oidc/v1/userinfo",
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+// NewWithKey is like New but authenticates the application with a JWT
+// signed by key ("Private Key JWT" authentication) instead of a client secret.
+func NewWithKey(key *Key, callbackURL, issuerURL string, scopes ...string) (*Provider, error) {
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(key.Key))
+	if err != nil {
+		return nil, err
+	}
+	p := New(key.ClientID, "", callbackURL, issuerURL, scopes...)
+	p.config.Endpoint.AuthStyle = oauth2.AuthStyleInParams
+	p.keyID = key.KeyID
+	p.privateKey = privateKey
+	return p, nil
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.issuerURL + "/oauth/v2/authorize",
+			TokenURL: provider.issuerURL + "/oauth/v2/token",
+		},
+		Scopes: []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, "openid", "profile", "email", ScopeProjectRoles)
+	}
+	return c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the zitadel package.
+func (p *Provider) Debug(debug bool) {}
+
+// IssuerURL returns the issuer of the provider's instance.
+func (p *Provider) IssuerURL() string {
+	return p.issuerURL
+}
+
+// SetOrganization makes BeginAuth log users in to the organization with the
+// given ID.
+func (p *Provider) SetOrganization(organizationID string) {
+	p.organization = organizationID
+}
+
+// BeginAuth asks ZITADEL for an authentication end-point, for the
+// organization the provider has been configured with, if any.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	if p.organization != "" {
+		return p.BeginAuthForOrganization(state, p.organization)
+	}
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state),
+	}, nil
+}
+
+// BeginAuthForOrganization is like BeginAuth but only lets users of the
+// organization with the given ID log in, and shows them its branding.
+// FetchUser checks that the user belongs to the organization.
+func (p *Provider) BeginAuthForOrganization(state, organizationID string) (goth.Session, error) {
+	scopes := append(p.config.Scopes[:len(p.config.Scopes):len(p.config.Scopes)], OrganizationScope(organizationID))
+	return &Session{
+		AuthURL:      p.config.AuthCodeURL(state, oauth2.SetAuthURLParam("scope", strings.Join(scopes, " "))),
+		Organization: organizationID,
+	}, nil
+}
+
+// authOptions returns the parameters authenticating the application when it
+// uses a key.
+func (p *Provider) authOptions() ([]oauth2.AuthCodeOption, error) {
+	if p.privateKey == nil {
+		return nil, nil
+	}
+	assertion, err := p.clientAssertion()
+	if err != nil {
+		return nil, err
+	}
+	return []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("client_assertion_type", clientAssertionType),
+		oauth2.SetAuthURLParam("client_assertion", assertion),
+	}, nil
+}
+
+// clientAssertion returns a JWT authenticating the application, signed by its key.
+func (p *Provider) clientAssertion() (string, error) {
+	now := goth.GetClock().Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": p.ClientKey,
+		"sub": p.ClientKey,
+		"aud": p.issuerURL,
+		"iat": now.Unix(),
+		"exp": now.Add(assertionLifetime).Unix(),
+	})
+	token.Header["kid"] = p.keyID
+	return token.SignedString(p.privateKey)
+}
+
+// FetchUser will go to ZITADEL and access basic information about the user.
+// User.Roles is filled in from the project roles claim, and only holds the
+// roles granted by the session's organization, if it has one.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the requests to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+		IDToken:      sess.IDToken,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.profileURL, nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(bytes.NewReader(bits)).Decode(&claims); err != nil {
+		return user, err
+	}
+	if sess.Organization != "" {
+		if owner, ok := claims[resourceOwnerIDClaim].(string); ok && owner != sess.Organization {
+			return user, &ErrOrganizationMismatch{Want: sess.Organization, Got: owner}
+		}
+	}
+
+	user.UserID, _ = claims["sub"].(string)
+	user.Email, _ = claims["email"].(string)
+	user.EmailVerified, _ = claims["email_verified"].(bool)
+	user.Name, _ = claims["name"].(string)
+	user.NickName, _ = claims["preferred_username"].(string)
+	user.FirstName, _ = claims["given_name"].(string)
+	user.LastName, _ = claims["family_name"].(string)
+	user.AvatarURL, _ = claims["picture"].(string)
+	user.Location, _ = claims["locale"].(string)
+	user.Roles = projectRoles(claims[ScopeProjectRoles], sess.Organization)
+	return user, nil
+}
+
+// projectRoles returns the names of the roles in a project roles claim, which
+// maps each role to the IDs of the organizations that granted it. If
+// organization is set only the roles it granted are returned.
+func projectRoles(claim interface{}, organization string) []string {
+	grants, _ := claim.(map[string]interface{})
+	var roles []string
+	for role, orgs := range grants {
+		if organization != "" {
+			if orgs, _ := orgs.(map[string]interface{}); orgs[organization] == nil {
+				continue
+			}
+		}
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	if p.privateKey != nil {
+		return p.refreshWithKey(ctx, refreshToken)
+	}
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.config.TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
+
+// refreshWithKey refreshes a token authenticating with the application key,
+// which oauth2.TokenSource has no way of passing on.
+func (p *Provider) refreshWithKey(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	assertion, err := p.clientAssertion()
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":            {"refresh_token"},
+		"refresh_token":         {refreshToken},
+		"client_id":             {p.ClientKey},
+		"client_assertion_type": {clientAssertionType},
+		"client_assertion":      {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.Endpoint.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with a %d trying to refresh a token", p.providerName, response.StatusCode)
+	}
+
+	var raw map[string]interface{}
+	if err := json.NewDecoder(response.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	token.AccessToken, _ = raw["access_token"].(string)
+	token.TokenType, _ = raw["token_type"].(string)
+	if rt, _ := raw["refresh_token"].(string); rt != "" {
+		token.RefreshToken = rt
+	}
+	if expiresIn, ok := raw["expires_in"].(float64); ok && expiresIn > 0 {
+		token.Expiry = goth.GetClock().Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("Invalid token received from provider")
+	}
+	return token.WithExtra(raw), nil
+}
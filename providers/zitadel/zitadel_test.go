@@ -0,0 +1,198 @@
+package zitadel_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/zitadel"
+	"github.com/stretchr/testify/assert"
+)
+
+const issuer = "https://my-instance.zitadel.cloud"
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.Name(), "zitadel")
+	a.Equal(p.ClientKey, "myapp")
+	a.Equal(p.Secret, "secret")
+	a.Equal(p.CallbackURL, "/foo")
+	a.Equal(issuer, p.IssuerURL())
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	session, err := provider().BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*zitadel.Session)
+	a.Contains(s.AuthURL, issuer+"/oauth/v2/authorize")
+	a.Contains(s.AuthURL, "scope=openid+profile+email+urn%3Azitadel%3Aiam%3Aorg%3Aproject%3Aroles&")
+	a.Empty(s.Organization)
+}
+
+func Test_BeginAuthForOrganization(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+	p.SetOrganization("164652366974255361")
+
+	session, err := p.BeginAuth("test_state")
+	a.NoError(err)
+	s := session.(*zitadel.Session)
+	a.Contains(s.AuthURL, "urn%3Azitadel%3Aiam%3Aorg%3Aproject%3Aroles+urn%3Azitadel%3Aiam%3Aorg%3Aid%3A164652366974255361")
+	a.Equal("164652366974255361", s.Organization)
+
+	// the provider's scopes are left alone
+	session, _ = provider().BeginAuth("test_state")
+	a.NotContains(session.(*zitadel.Session).AuthURL, "org%3Aid")
+}
+
+func Test_NewWithKey(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	a.NoError(err)
+	keyFile, _ := json.Marshal(map[string]string{
+		"type":     "application",
+		"keyId":    "181828078849229057",
+		"key":      string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})),
+		"appId":    "181827847684718337",
+		"clientId": "181827847684784129@project",
+	})
+	key, err := zitadel.ParseKey(keyFile)
+	a.NoError(err)
+	p, err := zitadel.NewWithKey(key, "/foo", issuer)
+	a.NoError(err)
+	a.Equal("181827847684784129@project", p.ClientKey)
+
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal(issuer+"/oauth/v2/token", req.URL.String())
+		a.NoError(req.ParseForm())
+		a.Empty(req.Header.Get("Authorization"))
+		a.Equal("urn:ietf:params:oauth:client-assertion-type:jwt-bearer", req.PostForm.Get("client_assertion_type"))
+		assertion, err := jwt.Parse(req.PostForm.Get("client_assertion"), func(token *jwt.Token) (interface{}, error) {
+			a.Equal("181828078849229057", token.Header["kid"])
+			return &rsaKey.PublicKey, nil
+		})
+		a.NoError(err)
+		a.Equal(issuer, assertion.Claims.(jwt.MapClaims)["aud"])
+		a.Equal("181827847684784129@project", assertion.Claims.(jwt.MapClaims)["sub"])
+
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		if req.PostForm.Get("grant_type") == "refresh_token" {
+			fmt.Fprint(rec, `{"access_token":"refreshed","token_type":"Bearer","expires_in":43199}`)
+		} else {
+			fmt.Fprintf(rec, `{"access_token":"1234567890","refresh_token":"refresh","token_type":"Bearer","expires_in":43199,"id_token":%q}`,
+				gothtest.UnsignedJWT(`{"iss":"`+issuer+`","aud":["181827847684784129@project","181827847684718337"]}`))
+		}
+		return rec.Result(), nil
+	})}
+
+	session, _ := p.BeginAuth("test_state")
+	_, err = session.Authorize(p, url.Values{"code": {"code"}})
+	a.NoError(err)
+	a.Equal("refresh", session.(*zitadel.Session).RefreshToken)
+
+	token, err := p.RefreshToken("refresh")
+	a.NoError(err)
+	a.Equal("refreshed", token.AccessToken)
+	a.Equal("refresh", token.RefreshToken)
+}
+
+func Test_ParseKey(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	_, err := zitadel.ParseKey([]byte(`{"type":"serviceaccount","keyId":"1","key":"","userId":"2"}`))
+	a.Error(err)
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal(issuer+"/oidc/v1/userinfo", req.URL.String())
+		a.Equal("Bearer 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"sub":"164652366974255362","email":"jdoe@example.com","email_verified":true,
+			"name":"John Doe","preferred_username":"jdoe@acme.zitadel.cloud","given_name":"John","family_name":"Doe",
+			"urn:zitadel:iam:user:resourceowner:id":"164652366974255361",
+			"urn:zitadel:iam:org:project:roles":{
+				"admin":{"164652366974255361":"acme.zitadel.cloud"},
+				"viewer":{"164652366974255361":"acme.zitadel.cloud","999":"other.zitadel.cloud"},
+				"auditor":{"999":"other.zitadel.cloud"}}}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&zitadel.Session{AccessToken: "1234567890"})
+	a.NoError(err)
+	a.Equal("164652366974255362", user.UserID)
+	a.Equal("jdoe@acme.zitadel.cloud", user.NickName)
+	a.True(user.EmailVerified)
+	a.Equal([]string{"admin", "auditor", "viewer"}, user.Roles)
+
+	user, err = p.FetchUser(&zitadel.Session{AccessToken: "1234567890", Organization: "164652366974255361"})
+	a.NoError(err)
+	a.Equal([]string{"admin", "viewer"}, user.Roles)
+
+	_, err = p.FetchUser(&zitadel.Session{AccessToken: "1234567890", Organization: "999"})
+	a.Equal(&zitadel.ErrOrganizationMismatch{Want: "999", Got: "164652366974255361"}, err)
+}
+
+func Test_AuthorizeChecksIssuer(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(rec, `{"access_token":"1234567890","token_type":"Bearer","expires_in":3600,"id_token":%q}`,
+			gothtest.UnsignedJWT(`{"iss":"https://other.zitadel.cloud","aud":"myapp"}`))
+		return rec.Result(), nil
+	})}
+	session, _ := p.BeginAuth("test_state")
+	_, err := session.Authorize(p, url.Values{"code": {"code"}})
+	a.IsType(&goth.ErrIssuerMismatch{}, err)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://my-instance.zitadel.cloud/oauth/v2/authorize","AccessToken":"1234567890","Organization":"1"}`)
+	a.NoError(err)
+
+	s := session.(*zitadel.Session)
+	a.Equal(s.AuthURL, "https://my-instance.zitadel.cloud/oauth/v2/authorize")
+	a.Equal(s.AccessToken, "1234567890")
+	a.Equal(s.Organization, "1")
+}
+
+func provider() *zitadel.Provider {
+	return zitadel.New("myapp", "secret", "/foo", issuer)
+}
@@ -0,0 +1,276 @@
+// Package zoho implements the OAuth2 protocol for authenticating users
+// through Zoho, whose accounts are hosted in one of several data centers.
+package zoho
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// Accounts servers of the Zoho data centers. A user's account, the tokens
+// issued for it and the APIs it can be used with all live in one of them.
+const (
+	DataCenterUS = "https://accounts.zoho.com"
+	DataCenterEU = "https://accounts.zoho.eu"
+	DataCenterIN = "https://accounts.zoho.in"
+	DataCenterAU = "https://accounts.zoho.com.au"
+	DataCenterJP = "https://accounts.zoho.jp"
+	DataCenterCA = "https://accounts.zohocloud.ca"
+	DataCenterCN = "https://accounts.zoho.com.cn"
+	DataCenterSA = "https://accounts.zoho.sa"
+	DataCenterUK = "https://accounts.zoho.uk"
+)
+
+var dataCenters = map[string]bool{
+	DataCenterUS: true,
+	DataCenterEU: true,
+	DataCenterIN: true,
+	DataCenterAU: true,
+	DataCenterJP: true,
+	DataCenterCA: true,
+	DataCenterCN: true,
+	DataCenterSA: true,
+	DataCenterUK: true,
+}
+
+// ScopeProfileRead gives access to the basic profile of the user, and is
+// requested if no scopes are passed to New.
+const ScopeProfileRead = "AaaServer.profile.Read"
+
+// Keys of User.RawData set by FetchUser.
+const (
+	// RawDataAccountsServer holds the accounts server of the data center the
+	// user's account is in, to be passed to RefreshTokenFrom.
+	RawDataAccountsServer = "accounts_server"
+	// RawDataAPIDomain holds the domain Zoho API requests for the user must
+	// be made to, e.g. https://www.zohoapis.eu.
+	RawDataAPIDomain = "api_domain"
+)
+
+// ErrUnknownDataCenter is returned when Zoho redirects the user back from an
+// accounts server that is not one of the known data centers.
+type ErrUnknownDataCenter struct {
+	AccountsServer string
+}
+
+func (e *ErrUnknownDataCenter) Error() string {
+	return fmt.Sprintf("zoho: %q is not the accounts server of a Zoho data center", e.AccountsServer)
+}
+
+// Provider is the implementation of `goth.Provider` for accessing Zoho.
+type Provider struct {
+	ClientKey       string
+	Secret          string
+	CallbackURL     string
+	HTTPClient      *http.Client
+	config          *oauth2.Config
+	providerName    string
+	accountsServer  string
+	authCodeOptions []oauth2.AuthCodeOption
+}
+
+// New creates a new Zoho provider for the US data center, and sets up
+// important connection details. Use SetDataCenter for apps registered in
+// another data center.
+// You should always call `zoho.New` to get a new provider.  Never try to
+// create one manually.
+func New(clientKey, secret, callbackURL string, scopes ...string) *Provider {
+	p := &Provider{
+		ClientKey:      clientKey,
+		Secret:         secret,
+		CallbackURL:    callbackURL,
+		providerName:   "zoho",
+		accountsServer: DataCenterUS,
+		// refresh tokens are only issued for offline access
+		authCodeOptions: []oauth2.AuthCodeOption{oauth2.AccessTypeOffline},
+	}
+	p.config = newConfig(p, scopes)
+	return p
+}
+
+func newConfig(provider *Provider, scopes []string) *oauth2.Config {
+	c := &oauth2.Config{
+		ClientID:     provider.ClientKey,
+		ClientSecret: provider.Secret,
+		RedirectURL:  provider.CallbackURL,
+		Endpoint:     endpoint(provider.accountsServer),
+		Scopes:       []string{},
+	}
+
+	if len(scopes) > 0 {
+		c.Scopes = append(c.Scopes, scopes...)
+	} else {
+		c.Scopes = append(c.Scopes, ScopeProfileRead)
+	}
+	return c
+}
+
+func endpoint(accountsServer string) oauth2.Endpoint {
+	return oauth2.Endpoint{
+		AuthURL:   accountsServer + "/oauth/v2/auth",
+		TokenURL:  accountsServer + "/oauth/v2/token",
+		AuthStyle: oauth2.AuthStyleInParams,
+	}
+}
+
+// configFor returns the provider's config with the token endpoint of the
+// given accounts server.
+func (p *Provider) configFor(accountsServer string) *oauth2.Config {
+	c := *p.config
+	c.Endpoint = endpoint(accountsServer)
+	return &c
+}
+
+// Name is the name used to retrieve this provider later.
+func (p *Provider) Name() string {
+	return p.providerName
+}
+
+// SetName is to update the name of the provider (needed in case of multiple providers of 1 type)
+func (p *Provider) SetName(name string) {
+	p.providerName = name
+}
+
+// Client returns the http.Client used in the provider.
+func (p *Provider) Client() *http.Client {
+	return goth.HTTPClientWithFallBack(p.HTTPClient)
+}
+
+// Debug is a no-op for the zoho package.
+func (p *Provider) Debug(debug bool) {}
+
+// SetDataCenter sets the accounts server of the data center the app is
+// registered in, one of the DataCenter constants. Users of apps with
+// multi-DC support enabled are sent back from the accounts server of their
+// own data center, which is then used for their tokens instead.
+func (p *Provider) SetDataCenter(accountsServer string) {
+	p.accountsServer = accountsServer
+	p.config.Endpoint = endpoint(accountsServer)
+}
+
+// SetPrompt sets the prompt parameter of the authentication URL. Zoho only
+// issues a refresh token the first time a user consents to the app, unless
+// the prompt is "consent".
+func (p *Provider) SetPrompt(prompt string) {
+	if prompt == "" {
+		return
+	}
+	p.authCodeOptions = append(p.authCodeOptions, oauth2.SetAuthURLParam("prompt", prompt))
+}
+
+// BeginAuth asks Zoho for an authentication end-point.
+func (p *Provider) BeginAuth(state string) (goth.Session, error) {
+	return &Session{
+		AuthURL: p.config.AuthCodeURL(state, p.authCodeOptions...),
+	}, nil
+}
+
+// FetchUser will go to Zoho and access basic information about the user.
+// The user's accounts server and API domain are stored in RawData under
+// RawDataAccountsServer and RawDataAPIDomain.
+func (p *Provider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.FetchUserContext(context.Background(), session)
+}
+
+// FetchUserContext is like FetchUser but binds the request to the provider to ctx.
+func (p *Provider) FetchUserContext(ctx context.Context, session goth.Session) (goth.User, error) {
+	sess := session.(*Session)
+	user := goth.User{
+		AccessToken:  sess.AccessToken,
+		Provider:     p.Name(),
+		RefreshToken: sess.RefreshToken,
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	if user.AccessToken == "" {
+		// data is not yet retrieved since accessToken is still empty
+		return user, fmt.Errorf("%s cannot get user information without accessToken", p.providerName)
+	}
+
+	accountsServer := sess.AccountsServer
+	if accountsServer == "" {
+		accountsServer = p.accountsServer
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", accountsServer+"/oauth/user/info", nil)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Authorization", "Zoho-oauthtoken "+sess.AccessToken)
+	response, err := p.Client().Do(req)
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return user, fmt.Errorf("%s responded with a %d trying to fetch user information", p.providerName, response.StatusCode)
+	}
+
+	bits, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return user, err
+	}
+
+	err = user.SetRawJSON(bits)
+	if err != nil {
+		return user, err
+	}
+
+	u := struct {
+		ZUID        int64  `json:"ZUID"`
+		Email       string `json:"Email"`
+		FirstName   string `json:"First_Name"`
+		LastName    string `json:"Last_Name"`
+		DisplayName string `json:"Display_Name"`
+	}{}
+	if err := json.Unmarshal(bits, &u); err != nil {
+		return user, err
+	}
+	user.UserID = strconv.FormatInt(u.ZUID, 10)
+	user.Email = u.Email
+	user.FirstName = u.FirstName
+	user.LastName = u.LastName
+	user.Name = u.DisplayName
+	user.NickName = u.DisplayName
+
+	user.SetRawData(RawDataAccountsServer, accountsServer)
+	if sess.APIDomain != "" {
+		user.SetRawData(RawDataAPIDomain, sess.APIDomain)
+	}
+	return user, nil
+}
+
+// RefreshTokenAvailable refresh token is provided by auth provider or not
+func (p *Provider) RefreshTokenAvailable() bool {
+	return true
+}
+
+// RefreshToken get new access token based on the refresh token, from the
+// data center the provider is set to. Use RefreshTokenFrom for users of
+// other data centers.
+func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but binds the request to ctx.
+func (p *Provider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	return p.RefreshTokenFrom(ctx, p.accountsServer, refreshToken)
+}
+
+// RefreshTokenFrom is like RefreshTokenContext but gets the new access token
+// from the given accounts server, the RawDataAccountsServer of the user.
+func (p *Provider) RefreshTokenFrom(ctx context.Context, accountsServer, refreshToken string) (*oauth2.Token, error) {
+	if !dataCenters[accountsServer] && accountsServer != p.accountsServer {
+		return nil, &ErrUnknownDataCenter{AccountsServer: accountsServer}
+	}
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	ts := p.configFor(accountsServer).TokenSource(goth.ContextWithClient(ctx, p.Client()), token)
+	return ts.Token()
+}
@@ -0,0 +1,190 @@
+package zoho_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/zoho"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+
+	a.Equal(p.ClientKey, os.Getenv("ZOHO_KEY"))
+	a.Equal(p.Secret, os.Getenv("ZOHO_SECRET"))
+	a.Equal(p.CallbackURL, "/foo")
+}
+
+func Test_Implements_Provider(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	a.Implements((*goth.Provider)(nil), provider())
+}
+
+func Test_BeginAuth(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := provider()
+	p.SetDataCenter(zoho.DataCenterEU)
+	p.SetPrompt("consent")
+	session, err := p.BeginAuth("test_state")
+	s := session.(*zoho.Session)
+	a.NoError(err)
+	a.Contains(s.AuthURL, "https://accounts.zoho.eu/oauth/v2/auth")
+	a.Contains(s.AuthURL, "scope=AaaServer.profile.Read")
+	a.Contains(s.AuthURL, "access_type=offline")
+	a.Contains(s.AuthURL, "prompt=consent")
+}
+
+func tokenClient(a *assert.Assertions, tokenURL string) *http.Client {
+	return &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal(tokenURL, req.URL.String())
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(rec, `{"access_token":"1234567890","refresh_token":"r","api_domain":"https://www.zohoapis.in","token_type":"Bearer","expires_in":3600}`)
+		return rec.Result(), nil
+	})}
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		params   url.Values
+		tokenURL string
+		server   string
+	}{
+		"default":  {url.Values{"code": {"code"}}, "https://accounts.zoho.com/oauth/v2/token", "https://accounts.zoho.com"},
+		"multiDC":  {url.Values{"code": {"code"}, "location": {"in"}, "accounts-server": {"https://accounts.zoho.in"}}, "https://accounts.zoho.in/oauth/v2/token", "https://accounts.zoho.in"},
+		"unknown":  {url.Values{"code": {"code"}, "accounts-server": {"https://accounts.example.com"}}, "", ""},
+		"redirect": {url.Values{"code": {"code"}, "accounts-server": {"https://accounts.zoho.com.evil.com"}}, "", ""},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := provider()
+			p.HTTPClient = tokenClient(a, tc.tokenURL)
+
+			s := &zoho.Session{}
+			_, err := s.Authorize(p, tc.params)
+			if tc.server == "" {
+				a.IsType(&zoho.ErrUnknownDataCenter{}, err)
+				return
+			}
+			a.NoError(err)
+			a.Equal("1234567890", s.AccessToken)
+			a.Equal("r", s.RefreshToken)
+			a.Equal(tc.server, s.AccountsServer)
+			a.Equal("https://www.zohoapis.in", s.APIDomain)
+		})
+	}
+}
+
+func Test_FetchUser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://accounts.zoho.in/oauth/user/info", req.URL.String())
+		a.Equal("Zoho-oauthtoken 1234567890", req.Header.Get("Authorization"))
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"First_Name":"Jane","Email":"jane@example.com","Last_Name":"Doe","Display_Name":"jane.doe","ZUID":60012345}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&zoho.Session{
+		AccessToken:    "1234567890",
+		AccountsServer: zoho.DataCenterIN,
+		APIDomain:      "https://www.zohoapis.in",
+	})
+	a.NoError(err)
+	a.Equal("60012345", user.UserID)
+	a.Equal("jane@example.com", user.Email)
+	a.Equal("Jane", user.FirstName)
+	a.Equal("Doe", user.LastName)
+	a.Equal("jane.doe", user.Name)
+	a.Equal(zoho.DataCenterIN, user.RawData[zoho.RawDataAccountsServer])
+	a.Equal("https://www.zohoapis.in", user.RawData[zoho.RawDataAPIDomain])
+}
+
+func Test_FetchUser_LazyRawData(t *testing.T) {
+	a := assert.New(t)
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"Email":"jane@example.com","ZUID":60012345}`)
+		return rec.Result(), nil
+	})}
+
+	user, err := p.FetchUser(&zoho.Session{
+		AccessToken:    "1234567890",
+		AccountsServer: zoho.DataCenterIN,
+		APIDomain:      "https://www.zohoapis.in",
+	})
+	a.NoError(err)
+	a.Nil(user.RawData)
+
+	raw, err := user.LoadRawData()
+	a.NoError(err)
+	a.Equal("jane@example.com", raw["Email"])
+	a.Equal(zoho.DataCenterIN, raw[zoho.RawDataAccountsServer])
+	a.Equal("https://www.zohoapis.in", raw[zoho.RawDataAPIDomain])
+}
+
+func Test_RefreshTokenFrom(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := provider()
+	p.HTTPClient = &http.Client{Transport: gothtest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		a.Equal("https://accounts.zoho.eu/oauth/v2/token", req.URL.String())
+		body, _ := ioutil.ReadAll(req.Body)
+		a.Contains(string(body), "refresh_token=r")
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(rec, `{"access_token":"0987654321","api_domain":"https://www.zohoapis.eu","token_type":"Bearer","expires_in":3600}`)
+		return rec.Result(), nil
+	})}
+
+	token, err := p.RefreshTokenFrom(context.Background(), zoho.DataCenterEU, "r")
+	a.NoError(err)
+	a.Equal("0987654321", token.AccessToken)
+	a.Equal("r", token.RefreshToken)
+
+	_, err = p.RefreshTokenFrom(context.Background(), "https://accounts.example.com", "r")
+	a.IsType(&zoho.ErrUnknownDataCenter{}, err)
+}
+
+func Test_SessionFromJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	session, err := provider().UnmarshalSession(`{"AuthURL":"https://accounts.zoho.com/oauth/v2/auth","AccessToken":"1234567890","AccountsServer":"https://accounts.zoho.eu"}`)
+	a.NoError(err)
+
+	s := session.(*zoho.Session)
+	a.Equal(s.AuthURL, "https://accounts.zoho.com/oauth/v2/auth")
+	a.Equal(s.AccessToken, "1234567890")
+	a.Equal(s.AccountsServer, zoho.DataCenterEU)
+}
+
+func provider() *zoho.Provider {
+	return zoho.New(os.Getenv("ZOHO_KEY"), os.Getenv("ZOHO_SECRET"), "/foo")
+}
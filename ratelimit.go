@@ -0,0 +1,117 @@
+package goth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter throttles outbound requests to a provider. Wait blocks until a
+// request may be sent or ctx is done. A *rate.Limiter from golang.org/x/time/rate
+// satisfies this interface.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a simple RateLimiter allowing an average rate of requests
+// with bursts of up to a fixed size.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a TokenBucket allowing perSecond requests per second
+// on average, with bursts of up to burst requests.
+func NewTokenBucket(perSecond float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   GetClock().Now(),
+	}
+}
+
+// reserve takes a token, possibly borrowing against the future, and returns
+// how long the caller has to wait before using it.
+func (b *TokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := GetClock().Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	if b.rate <= 0 {
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *TokenBucket) cancel() {
+	b.mu.Lock()
+	b.tokens++
+	b.mu.Unlock()
+}
+
+// Wait blocks until a request may be sent or ctx is done.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	wait := b.reserve()
+	if wait == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		b.cancel()
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type rateLimitTransport struct {
+	next    http.RoundTripper
+	limiter RateLimiter
+}
+
+// NewRateLimitTransport returns an http.RoundTripper that waits for limiter
+// before sending each request, so bursts of logins don't trip the provider's
+// API rate limits. If next is nil http.DefaultTransport is used.
+func NewRateLimitTransport(next http.RoundTripper, limiter RateLimiter) http.RoundTripper {
+	return &rateLimitTransport{next: next, limiter: limiter}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return next.RoundTrip(req)
+}
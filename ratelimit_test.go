@@ -0,0 +1,68 @@
+package goth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TokenBucket(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	b := goth.NewTokenBucket(100, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		a.NoError(b.Wait(context.Background()))
+	}
+	// two requests in the burst, then two more at 10ms intervals
+	a.True(time.Since(start) >= 15*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	slow := goth.NewTokenBucket(0.001, 1)
+	a.NoError(slow.Wait(ctx))
+	a.Error(slow.Wait(ctx))
+}
+
+func Test_TokenBucket_Clock(t *testing.T) {
+	a := assert.New(t)
+
+	clock := gothtest.NewClock(time.Now())
+	goth.UseClock(clock)
+	defer goth.UseClock(nil)
+
+	b := goth.NewTokenBucket(1, 1)
+	a.NoError(b.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	a.Error(b.Wait(ctx))
+	clock.Advance(time.Second)
+	a.NoError(b.Wait(ctx))
+}
+
+func Test_RateLimitTransport(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: goth.NewRateLimitTransport(nil, goth.NewTokenBucket(0.001, 1))}
+	res, err := client.Get(ts.URL)
+	a.NoError(err)
+	res.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	_, err = client.Do(req.WithContext(ctx))
+	a.Error(err)
+}
@@ -0,0 +1,127 @@
+package goth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// RefreshReuseWindow is how long the token returned by a refresh keeps being
+// handed to callers refreshing the same refresh token. Requests racing for the
+// same user often read the old refresh token just after the first refresh
+// completed; with providers that rotate refresh tokens, refreshing it a second
+// time fails or even revokes the new one. By default only refreshes that are
+// in flight at the same time are coalesced.
+var RefreshReuseWindow time.Duration
+
+type refreshKey struct {
+	provider string
+	token    [sha256.Size]byte
+}
+
+// refreshCall is a refresh in flight, or one that completed within RefreshReuseWindow.
+type refreshCall struct {
+	done    chan struct{}
+	token   *oauth2.Token
+	err     error
+	expires time.Time // set once done
+}
+
+var refreshesLock sync.Mutex
+var refreshes = map[refreshKey]*refreshCall{}
+
+// RefreshTimeout bounds how long a refresh shared by concurrent callers may
+// take. The refresh does not stop when the caller that started it gives up,
+// since the others are waiting for its result, and with providers rotating
+// refresh tokens, abandoning it after the provider consumed the old refresh
+// token would log all of them out.
+var RefreshTimeout = 30 * time.Second
+
+// errRefreshPanicked is handed to the callers waiting for a refresh whose
+// function panicked.
+var errRefreshPanicked = errors.New("goth: token refresh panicked")
+
+// refreshOnce calls fn unless a refresh of the same refresh token with the
+// same provider is in flight or completed recently, in which case its result
+// is returned instead. fn is passed a context carrying the values of ctx, but
+// which is only cancelled after RefreshTimeout, so that the refresh completes
+// for the others even if the caller that started it gives up. Callers waiting
+// for the refresh of another get ctx's error when they give up.
+func refreshOnce(ctx context.Context, provider, refreshToken string, fn func(ctx context.Context) (*oauth2.Token, error)) (*oauth2.Token, error) {
+	key := refreshKey{provider: provider, token: sha256.Sum256([]byte(refreshToken))}
+
+	refreshesLock.Lock()
+	if c, ok := refreshes[key]; ok && !c.expired() {
+		refreshesLock.Unlock()
+		select {
+		case <-c.done:
+			return c.token, c.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	c := &refreshCall{done: make(chan struct{})}
+	refreshes[key] = c
+	refreshesLock.Unlock()
+
+	finished := false
+	defer func() {
+		if !finished {
+			// fn panicked; release the waiters before the panic goes on
+			c.finish(key, nil, errRefreshPanicked)
+		}
+	}()
+
+	fnCtx, cancel := context.WithTimeout(detachedContext{ctx}, RefreshTimeout)
+	defer cancel()
+	token, err := fn(fnCtx)
+	finished = true
+	c.finish(key, token, err)
+	return c.token, c.err
+}
+
+// finish hands the result of a refresh to the callers waiting for it, and
+// keeps it for RefreshReuseWindow if it succeeded.
+func (c *refreshCall) finish(key refreshKey, token *oauth2.Token, err error) {
+	refreshesLock.Lock()
+	c.token, c.err = token, err
+	c.expires = GetClock().Now().Add(RefreshReuseWindow)
+	refreshesLock.Unlock()
+	close(c.done)
+
+	// failures are not reused, the next caller may well succeed
+	if c.err != nil || RefreshReuseWindow <= 0 {
+		forgetRefresh(key, c)
+	} else {
+		time.AfterFunc(RefreshReuseWindow, func() { forgetRefresh(key, c) })
+	}
+}
+
+// detachedContext carries the values of a context, such as the HTTP client
+// and transcript, without its deadline and cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// expired reports whether a completed call is past RefreshReuseWindow. It
+// must be called with refreshesLock held.
+func (c *refreshCall) expired() bool {
+	return !c.expires.IsZero() && GetClock().Now().After(c.expires)
+}
+
+func forgetRefresh(key refreshKey, c *refreshCall) {
+	refreshesLock.Lock()
+	if refreshes[key] == c {
+		delete(refreshes, key)
+	}
+	refreshesLock.Unlock()
+}
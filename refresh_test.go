@@ -0,0 +1,172 @@
+package goth_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/faux"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+type rotatingProvider struct {
+	faux.Provider
+	refreshes int32
+	release   chan struct{}
+}
+
+func (p *rotatingProvider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	n := atomic.AddInt32(&p.refreshes, 1)
+	<-p.release
+	return &oauth2.Token{AccessToken: "access", RefreshToken: refreshToken + "-" + strconv.Itoa(int(n))}, nil
+}
+
+func Test_RefreshToken_Coalesced(t *testing.T) {
+	a := assert.New(t)
+
+	goth.RefreshReuseWindow = time.Minute
+	defer func() { goth.RefreshReuseWindow = 0 }()
+
+	// tokens stay shared for RefreshReuseWindow, so don't reuse them across runs
+	rt := "coalesced-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	p := &rotatingProvider{release: make(chan struct{})}
+	var wg sync.WaitGroup
+	tokens := make([]*oauth2.Token, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := goth.RefreshToken(p, rt)
+			a.NoError(err)
+			tokens[i] = token
+		}(i)
+	}
+
+	// a caller giving up does not cancel the refresh for the others
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	time.Sleep(5 * time.Millisecond)
+	_, err := goth.RefreshTokenContext(ctx, p, rt)
+	a.Equal(context.DeadlineExceeded, err)
+
+	close(p.release)
+	wg.Wait()
+	a.Equal(int32(1), atomic.LoadInt32(&p.refreshes))
+	for _, token := range tokens {
+		a.Equal(rt+"-1", token.RefreshToken)
+	}
+
+	// callers that read the old refresh token just too late get the result as well
+	token, err := goth.RefreshToken(p, rt)
+	a.NoError(err)
+	a.Equal(rt+"-1", token.RefreshToken)
+	a.Equal(int32(1), atomic.LoadInt32(&p.refreshes))
+
+	// other tokens are refreshed separately
+	token, err = goth.RefreshToken(p, rt+"-1")
+	a.NoError(err)
+	a.Equal(rt+"-1-2", token.RefreshToken)
+}
+
+func Test_RefreshToken_NoReuse(t *testing.T) {
+	a := assert.New(t)
+
+	p := &rotatingProvider{release: make(chan struct{})}
+	close(p.release)
+	goth.RefreshToken(p, "no-reuse")
+	goth.RefreshToken(p, "no-reuse")
+	a.Equal(int32(2), p.refreshes)
+}
+
+// ctxProvider refreshes tokens once released, failing when its ctx is done first.
+type ctxProvider struct {
+	faux.Provider
+	started chan struct{}
+	once    sync.Once
+	release chan struct{}
+	panics  bool
+}
+
+func (p *ctxProvider) RefreshTokenContext(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	p.once.Do(func() { close(p.started) })
+	if p.panics {
+		<-p.release
+		panic("boom")
+	}
+	select {
+	case <-p.release:
+		return &oauth2.Token{AccessToken: "access", RefreshToken: refreshToken + "-1"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func Test_RefreshToken_LeaderCancelled(t *testing.T) {
+	a := assert.New(t)
+
+	rt := "leader-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	p := &ctxProvider{started: make(chan struct{}), release: make(chan struct{})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := goth.RefreshTokenContext(ctx, p, rt)
+		leader <- err
+	}()
+	<-p.started
+
+	waiter := make(chan *oauth2.Token, 1)
+	go func() {
+		token, err := goth.RefreshToken(p, rt)
+		a.NoError(err)
+		waiter <- token
+	}()
+
+	// the caller that started the refresh goes away before it completes
+	cancel()
+	time.Sleep(5 * time.Millisecond)
+	close(p.release)
+
+	a.NoError(<-leader)
+	a.Equal(rt+"-1", (<-waiter).RefreshToken)
+}
+
+func Test_RefreshToken_Panic(t *testing.T) {
+	a := assert.New(t)
+
+	rt := "panic-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	p := &ctxProvider{started: make(chan struct{}), release: make(chan struct{}), panics: true}
+
+	go func() {
+		defer func() { recover() }()
+		goth.RefreshToken(p, rt)
+	}()
+	<-p.started
+
+	waiter := make(chan error, 1)
+	go func() {
+		defer func() {
+			// the waiter was too late to share the refresh
+			if recover() != nil {
+				waiter <- errors.New("panicked")
+			}
+		}()
+		_, err := goth.RefreshToken(p, rt)
+		waiter <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	close(p.release)
+
+	select {
+	case err := <-waiter:
+		a.Error(err)
+	case <-time.After(time.Second):
+		t.Fatal("waiter blocked after the refresh panicked")
+	}
+}
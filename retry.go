@@ -0,0 +1,189 @@
+package goth
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy configures the retries performed by a transport created with
+// NewRetryTransport.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a request is retried after the first attempt.
+	MaxRetries int
+	// BaseDelay is the delay before the first retry; it doubles with every attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts, if it is positive. A
+	// Retry-After header asking for a longer wait causes the response to be
+	// returned instead.
+	MaxDelay time.Duration
+	// RetryTokenRefresh allows POST requests performing a refresh_token grant to
+	// be retried. These are not idempotent: if the provider rotates refresh
+	// tokens and the response was lost, the retry spends a token that was
+	// already replaced and the user is logged out. Only enable it for providers
+	// that don't rotate refresh tokens.
+	RetryTokenRefresh bool
+}
+
+// DefaultRetryPolicy is a reasonable RetryPolicy for calls to identity providers.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  200 * time.Millisecond,
+	MaxDelay:   5 * time.Second,
+}
+
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+}
+
+// NewRetryTransport returns an http.RoundTripper that retries requests failing
+// with a network error or a 429, 500, 502, 503 or 504 response, using jittered
+// exponential backoff and respecting Retry-After. Only idempotent requests (by
+// method, an Idempotency-Key header or, if enabled, token refreshes) are
+// retried. If next is nil http.DefaultTransport is used.
+func NewRetryTransport(next http.RoundTripper, policy RetryPolicy) http.RoundTripper {
+	return &retryTransport{next: next, policy: policy}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if !t.retryable(req) {
+		return next.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		res, err := next.RoundTrip(r)
+		if attempt >= t.policy.MaxRetries || !shouldRetry(req.Context(), res, err) {
+			return res, err
+		}
+
+		delay := t.backoff(attempt)
+		if res != nil {
+			if wait, ok := retryAfter(res); ok {
+				if t.policy.MaxDelay > 0 && wait > t.policy.MaxDelay {
+					return res, err
+				}
+				delay = wait
+			}
+			io.Copy(ioutil.Discard, io.LimitReader(res.Body, 4096))
+			res.Body.Close()
+		}
+
+		GetLogger().Debug("retrying provider request", "method", req.Method, "url", RedactURL(req.URL),
+			"attempt", attempt+1, "delay", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *retryTransport) retryable(req *http.Request) bool {
+	if t.policy.MaxRetries <= 0 {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	}
+	if req.Header.Get("Idempotency-Key") != "" {
+		return true
+	}
+	return t.policy.RetryTokenRefresh && isRefreshGrant(req)
+}
+
+func isRefreshGrant(req *http.Request) bool {
+	if req.Method != http.MethodPost || req.GetBody == nil {
+		return false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return false
+	}
+	defer body.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(body, 64*1024))
+	if err != nil {
+		return false
+	}
+	form, err := url.ParseQuery(string(b))
+	if err != nil {
+		return false
+	}
+	return form.Get("grant_type") == "refresh_token"
+}
+
+func shouldRetry(ctx context.Context, res *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+var jitterLock sync.Mutex
+var jitter = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// backoff returns a delay drawn uniformly from [d/2, d), where d is the base
+// delay doubled attempt times and capped at the maximum delay.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	d := t.policy.BaseDelay << uint(attempt)
+	if d <= 0 || (t.policy.MaxDelay > 0 && d > t.policy.MaxDelay) {
+		d = t.policy.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	jitterLock.Lock()
+	defer jitterLock.Unlock()
+	return d/2 + time.Duration(jitter.Int63n(int64(d-d/2)))
+}
+
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	h := res.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(h); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		d := t.Sub(GetClock().Now())
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
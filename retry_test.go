@@ -0,0 +1,193 @@
+package goth_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+var testRetryPolicy = goth.RetryPolicy{
+	MaxRetries:        2,
+	BaseDelay:         time.Millisecond,
+	MaxDelay:          10 * time.Millisecond,
+	RetryTokenRefresh: true,
+}
+
+func flakyServer(failures int32, status int) (*httptest.Server, *int32) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		if n <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write(body)
+	}))
+	return ts, &calls
+}
+
+func Test_RetryTransport_RetriesGet(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts, calls := flakyServer(2, http.StatusBadGateway)
+	defer ts.Close()
+
+	client := &http.Client{Transport: goth.NewRetryTransport(nil, testRetryPolicy)}
+	res, err := client.Get(ts.URL)
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal(http.StatusOK, res.StatusCode)
+	a.Equal(int32(3), atomic.LoadInt32(calls))
+}
+
+func Test_RetryTransport_GivesUp(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts, calls := flakyServer(10, http.StatusServiceUnavailable)
+	defer ts.Close()
+
+	client := &http.Client{Transport: goth.NewRetryTransport(nil, testRetryPolicy)}
+	res, err := client.Get(ts.URL)
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal(http.StatusServiceUnavailable, res.StatusCode)
+	a.Equal(int32(3), atomic.LoadInt32(calls))
+}
+
+func Test_RetryTransport_DoesNotRetryClientErrors(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts, calls := flakyServer(1, http.StatusBadRequest)
+	defer ts.Close()
+
+	client := &http.Client{Transport: goth.NewRetryTransport(nil, testRetryPolicy)}
+	res, err := client.Get(ts.URL)
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal(http.StatusBadRequest, res.StatusCode)
+	a.Equal(int32(1), atomic.LoadInt32(calls))
+}
+
+func Test_RetryTransport_TokenRefresh(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts, calls := flakyServer(1, http.StatusInternalServerError)
+	defer ts.Close()
+
+	client := &http.Client{Transport: goth.NewRetryTransport(nil, testRetryPolicy)}
+	form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"abc"}}
+	res, err := client.PostForm(ts.URL, form)
+	a.NoError(err)
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	a.Equal(http.StatusOK, res.StatusCode)
+	a.Equal(form.Encode(), string(body))
+	a.Equal(int32(2), atomic.LoadInt32(calls))
+
+	// other POSTs are not idempotent and must not be retried
+	ts2, calls2 := flakyServer(1, http.StatusInternalServerError)
+	defer ts2.Close()
+	res, err = client.Post(ts2.URL, "application/x-www-form-urlencoded", strings.NewReader("grant_type=authorization_code&code=abc"))
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal(http.StatusInternalServerError, res.StatusCode)
+	a.Equal(int32(1), atomic.LoadInt32(calls2))
+}
+
+func Test_RetryTransport_RetryAfter(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	// a Retry-After beyond MaxDelay is respected by giving up immediately
+	client := &http.Client{Transport: goth.NewRetryTransport(nil, testRetryPolicy)}
+	res, err := client.Get(ts.URL)
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal(http.StatusTooManyRequests, res.StatusCode)
+	a.Equal(int32(1), atomic.LoadInt32(&calls))
+}
+
+func Test_RetryTransport_RetryAfterUncapped(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	// without a MaxDelay any Retry-After is waited for
+	policy := goth.RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond}
+	client := &http.Client{Transport: goth.NewRetryTransport(nil, policy)}
+	res, err := client.Get(ts.URL)
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal(http.StatusOK, res.StatusCode)
+	a.Equal(int32(2), atomic.LoadInt32(&calls))
+}
+
+func Test_RetryTransport_RetryAfterClock(t *testing.T) {
+	a := assert.New(t)
+
+	clock := gothtest.NewClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	goth.UseClock(clock)
+	defer goth.UseClock(nil)
+
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", clock.Now().Add(time.Hour).Format(http.TimeFormat))
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	// the date is an hour after the configured clock's time, beyond MaxDelay
+	client := &http.Client{Transport: goth.NewRetryTransport(nil, testRetryPolicy)}
+	res, err := client.Get(ts.URL)
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal(int32(1), atomic.LoadInt32(&calls))
+}
+
+func Test_DefaultRetryPolicy(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts, calls := flakyServer(1, http.StatusInternalServerError)
+	defer ts.Close()
+
+	// refresh_token grants are only retried when enabled
+	a.False(goth.DefaultRetryPolicy.RetryTokenRefresh)
+	client := &http.Client{Transport: goth.NewRetryTransport(nil, goth.DefaultRetryPolicy)}
+	res, err := client.PostForm(ts.URL, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"abc"}})
+	a.NoError(err)
+	res.Body.Close()
+	a.Equal(http.StatusInternalServerError, res.StatusCode)
+	a.Equal(int32(1), atomic.LoadInt32(calls))
+}
@@ -0,0 +1,187 @@
+package goth
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// RefreshSchedulerSettings configures a RefreshScheduler.
+type RefreshSchedulerSettings struct {
+	// Interval is how often the store is scanned. Defaults to a minute.
+	Interval time.Duration
+	// Window is how long before their expiry tokens are refreshed. Defaults to 5 minutes.
+	Window time.Duration
+	// Jitter spreads refreshes out by refreshing each token up to Jitter
+	// earlier than Window, so tokens issued together are not refreshed in
+	// lockstep. Defaults to a minute; set it to a negative value to disable jitter.
+	Jitter time.Duration
+	// MaxBackoff caps the delay before a token whose refresh failed is tried
+	// again. The delay starts at Interval and doubles with every failure.
+	// Defaults to an hour.
+	MaxBackoff time.Duration
+	// OnError, if set, is called for every failed refresh.
+	OnError func(token StoredToken, err error)
+}
+
+// RefreshScheduler proactively refreshes the tokens in a TokenStore that are
+// about to expire, so integrations acting on behalf of users keep working
+// while the users are away. Refreshes go through RefreshTokenContext and so
+// are reported to the configured Metrics and AuditSink.
+type RefreshScheduler struct {
+	store    TokenStore
+	settings RefreshSchedulerSettings
+
+	mu       sync.Mutex
+	failures map[string]refreshFailure
+}
+
+type refreshFailure struct {
+	count     int
+	nextRetry time.Time
+}
+
+// NewRefreshScheduler returns a RefreshScheduler for the tokens in store.
+func NewRefreshScheduler(store TokenStore, settings RefreshSchedulerSettings) *RefreshScheduler {
+	if settings.Interval <= 0 {
+		settings.Interval = time.Minute
+	}
+	if settings.Window <= 0 {
+		settings.Window = 5 * time.Minute
+	}
+	if settings.Jitter < 0 {
+		settings.Jitter = 0
+	} else if settings.Jitter == 0 {
+		settings.Jitter = time.Minute
+	}
+	if settings.MaxBackoff <= 0 {
+		settings.MaxBackoff = time.Hour
+	}
+	return &RefreshScheduler{
+		store:    store,
+		settings: settings,
+		failures: map[string]refreshFailure{},
+	}
+}
+
+// Start scans the store every Interval in the background. It returns
+// immediately and stops when ctx is done.
+func (s *RefreshScheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.settings.Interval)
+		defer ticker.Stop()
+		for {
+			if err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				GetLogger().Warn("failed to scan token store", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce refreshes the tokens that are due once. It returns an error only if
+// the store could not be scanned; failed refreshes are reported to OnError and
+// retried with backoff on later runs, for as long as the store returns them.
+func (s *RefreshScheduler) RunOnce(ctx context.Context) error {
+	now := GetClock().Now()
+	tokens, err := s.store.ExpiringBefore(ctx, now.Add(s.settings.Window+s.settings.Jitter))
+	if err != nil {
+		return err
+	}
+	s.forget(tokens)
+	for _, st := range tokens {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !s.due(st, now) {
+			continue
+		}
+		if err := s.refresh(ctx, st); err != nil {
+			s.failed(st, err, now)
+			continue
+		}
+		s.mu.Lock()
+		delete(s.failures, st.Key)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// forget drops the failures of tokens the store no longer returns, e.g.
+// because they were deleted, so they don't accumulate.
+func (s *RefreshScheduler) forget(tokens []StoredToken) {
+	keys := make(map[string]bool, len(tokens))
+	for _, st := range tokens {
+		keys[st.Key] = true
+	}
+	s.mu.Lock()
+	for key := range s.failures {
+		if !keys[key] {
+			delete(s.failures, key)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// due reports whether the token should be refreshed now. Each token gets a
+// fixed share of the jitter, derived from its key.
+func (s *RefreshScheduler) due(st StoredToken, now time.Time) bool {
+	s.mu.Lock()
+	f, failed := s.failures[st.Key]
+	s.mu.Unlock()
+	if failed {
+		return !now.Before(f.nextRetry)
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(st.Key))
+	jitter := time.Duration(0)
+	if s.settings.Jitter > 0 {
+		jitter = time.Duration(h.Sum64() % uint64(s.settings.Jitter))
+	}
+	return st.Token.Expiry.Before(now.Add(s.settings.Window + jitter))
+}
+
+func (s *RefreshScheduler) refresh(ctx context.Context, st StoredToken) error {
+	provider, err := GetProvider(st.Provider)
+	if err != nil {
+		return err
+	}
+	token, err := RefreshTokenContext(ctx, provider, st.Token.RefreshToken)
+	if err != nil {
+		return err
+	}
+	refreshed := *token
+	// not every provider rotates the refresh token
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = st.Token.RefreshToken
+	}
+	st.Token = &refreshed
+	return s.store.SaveToken(ctx, st)
+}
+
+func (s *RefreshScheduler) failed(st StoredToken, err error, now time.Time) {
+	s.mu.Lock()
+	f := s.failures[st.Key]
+	backoff := s.settings.Interval
+	for i := 0; i < f.count && backoff < s.settings.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > s.settings.MaxBackoff {
+		backoff = s.settings.MaxBackoff
+	}
+	f.count++
+	f.nextRetry = now.Add(backoff)
+	s.failures[st.Key] = f
+	s.mu.Unlock()
+
+	GetLogger().Warn("failed to refresh token", "provider", st.Provider, "key", st.Key, "error", err)
+	if s.settings.OnError != nil {
+		s.settings.OnError(st, err)
+	}
+}
@@ -0,0 +1,117 @@
+package goth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func Test_RefreshScheduler(t *testing.T) {
+	a := assert.New(t)
+
+	p := gothtest.New("scheduled", "http://localhost/callback")
+	p.AddUser("homer", goth.User{UserID: "1"})
+	goth.UseProviders(p)
+	defer goth.ClearProviders()
+
+	store := goth.NewMemoryTokenStore()
+	ctx := context.Background()
+	store.SaveToken(ctx, goth.StoredToken{Key: "expiring", Provider: "scheduled", Token: &oauth2.Token{
+		RefreshToken: gothtest.RefreshToken("homer"),
+		Expiry:       time.Now().Add(time.Minute),
+	}})
+	store.SaveToken(ctx, goth.StoredToken{Key: "fresh", Provider: "scheduled", Token: &oauth2.Token{
+		RefreshToken: "fresh",
+		Expiry:       time.Now().Add(time.Hour),
+	}})
+	store.SaveToken(ctx, goth.StoredToken{Key: "unknown", Provider: "unknown", Token: &oauth2.Token{
+		RefreshToken: "unknown",
+		Expiry:       time.Now().Add(time.Minute),
+	}})
+
+	var failed []string
+	s := goth.NewRefreshScheduler(store, goth.RefreshSchedulerSettings{
+		Interval: time.Hour,
+		Jitter:   -1,
+		OnError: func(st goth.StoredToken, err error) {
+			failed = append(failed, st.Key)
+		},
+	})
+	a.NoError(s.RunOnce(ctx))
+
+	st, _ := store.Token("expiring")
+	a.Equal(gothtest.AccessToken("homer"), st.Token.AccessToken)
+	a.True(st.Token.Expiry.After(time.Now().Add(30 * time.Minute)))
+	st, _ = store.Token("fresh")
+	a.Empty(st.Token.AccessToken)
+	a.Equal([]string{"unknown"}, failed)
+
+	// failed refreshes back off
+	a.NoError(s.RunOnce(ctx))
+	a.Equal([]string{"unknown"}, failed)
+}
+
+type listTokenStore struct {
+	tokens []goth.StoredToken
+}
+
+func (s *listTokenStore) ExpiringBefore(context.Context, time.Time) ([]goth.StoredToken, error) {
+	return s.tokens, nil
+}
+
+func (s *listTokenStore) SaveToken(context.Context, goth.StoredToken) error {
+	return nil
+}
+
+func Test_RefreshScheduler_ForgetsRemovedTokens(t *testing.T) {
+	a := assert.New(t)
+
+	goth.ClearProviders()
+	unknown := goth.StoredToken{Key: "unknown", Provider: "unknown", Token: &oauth2.Token{
+		RefreshToken: "unknown",
+		Expiry:       time.Now().Add(time.Minute),
+	}}
+	store := &listTokenStore{tokens: []goth.StoredToken{unknown}}
+
+	var failed int
+	s := goth.NewRefreshScheduler(store, goth.RefreshSchedulerSettings{
+		Interval: time.Hour,
+		Jitter:   -1,
+		OnError: func(goth.StoredToken, error) {
+			failed++
+		},
+	})
+	ctx := context.Background()
+	a.NoError(s.RunOnce(ctx))
+	a.NoError(s.RunOnce(ctx))
+	a.Equal(1, failed)
+
+	// once the token leaves the store its backoff is forgotten
+	store.tokens = nil
+	a.NoError(s.RunOnce(ctx))
+	store.tokens = []goth.StoredToken{unknown}
+	a.NoError(s.RunOnce(ctx))
+	a.Equal(2, failed)
+}
+
+type failingTokenStore struct {
+	*goth.MemoryTokenStore
+}
+
+func (failingTokenStore) ExpiringBefore(context.Context, time.Time) ([]goth.StoredToken, error) {
+	return nil, errors.New("boom")
+}
+
+func Test_RefreshScheduler_StoreError(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	s := goth.NewRefreshScheduler(failingTokenStore{goth.NewMemoryTokenStore()}, goth.RefreshSchedulerSettings{})
+	a.Error(s.RunOnce(context.Background()))
+}
@@ -0,0 +1,158 @@
+package goth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StrictParsing hardens the handling of data an attacker may control: the
+// sessions handed to Provider.UnmarshalSession, the callback requests parsed
+// by gothic and the responses received from providers. When it is set
+//
+//   - sessions larger than MaxSessionSize, with fields unknown to the
+//     provider's session type, or with an expiry outside MaxSessionLifetime
+//     are rejected,
+//   - gothic rejects callbacks larger than MaxCallbackSize, POST callbacks that
+//     are not form encoded and callbacks repeating the code or state parameter,
+//   - the clients returned by HTTPClientWithFallBack fail responses larger than
+//     MaxResponseSize and successful responses serving HTML, except to HEAD
+//     requests.
+//
+// Sessions stored by an older version of goth may fail to decode once their
+// type has changed, so enable this together with a deploy that logs users out.
+var StrictParsing = false
+
+var (
+	// MaxSessionSize limits the size of a marshalled session in strict mode.
+	MaxSessionSize = 64 << 10
+	// MaxCallbackSize limits the size of the query or form body of a callback in strict mode.
+	MaxCallbackSize int64 = 64 << 10
+	// MaxResponseSize limits the size of responses from providers in strict mode.
+	MaxResponseSize int64 = 4 << 20
+	// MaxSessionLifetime is how far in the future the expiry of a session may
+	// lie in strict mode.
+	MaxSessionLifetime = 366 * 24 * time.Hour
+)
+
+// ErrSessionTooLarge is returned for sessions exceeding MaxSessionSize in strict mode.
+var ErrSessionTooLarge = errors.New("goth: session too large")
+
+// ErrResponseTooLarge is returned for provider responses exceeding MaxResponseSize in strict mode.
+var ErrResponseTooLarge = errors.New("goth: response too large")
+
+// ErrUnexpectedContentType is returned when a request or response has a
+// content type goth does not expect in strict mode.
+type ErrUnexpectedContentType struct {
+	ContentType string
+}
+
+func (e *ErrUnexpectedContentType) Error() string {
+	return fmt.Sprintf("goth: unexpected content type %q", e.ContentType)
+}
+
+// DecodeSession decodes a session marshalled as JSON into v, applying the
+// checks of strict mode when StrictParsing is set. Providers should use it in
+// UnmarshalSession.
+func DecodeSession(data string, v interface{}) error {
+	if !StrictParsing {
+		return json.NewDecoder(strings.NewReader(data)).Decode(v)
+	}
+	if len(data) > MaxSessionSize {
+		return ErrSessionTooLarge
+	}
+
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("goth: trailing data after session")
+	}
+
+	// the type of v is unknown here, so look for the expiry fields used by
+	// the providers' sessions separately
+	var expiries struct {
+		ExpiresAt          time.Time
+		AccessTokenExpires time.Time
+		RefreshExpiresAt   time.Time
+	}
+	if err := json.Unmarshal([]byte(data), &expiries); err != nil {
+		return nil
+	}
+	for _, t := range []time.Time{expiries.ExpiresAt, expiries.AccessTokenExpires, expiries.RefreshExpiresAt} {
+		if err := checkExpiry(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkExpiry(t time.Time) error {
+	if t.IsZero() {
+		return nil
+	}
+	if t.Before(time.Unix(0, 0)) || t.After(GetClock().Now().Add(MaxSessionLifetime)) {
+		return fmt.Errorf("goth: session expiry %s out of range", t.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// strictResponses is the Middleware enforcing the response checks of strict mode.
+func strictResponses(next http.RoundTripper) http.RoundTripper {
+	return strictTransport{next}
+}
+
+type strictTransport struct {
+	next http.RoundTripper
+}
+
+func (t strictTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+	if res.ContentLength > MaxResponseSize {
+		res.Body.Close()
+		return nil, ErrResponseTooLarge
+	}
+	// HEAD requests have no body to misparse, and are sent by ProbeEndpoint
+	// to authorization endpoints, which do serve HTML
+	if res.StatusCode >= 200 && res.StatusCode < 300 && req.Method != http.MethodHead {
+		// providers' APIs never serve HTML; captive portals and
+		// misconfigured endpoints do
+		if mt, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mt == "text/html" {
+			res.Body.Close()
+			return nil, &ErrUnexpectedContentType{mt}
+		}
+	}
+	res.Body = &limitedBody{ReadCloser: res.Body, remaining: MaxResponseSize}
+	return res, nil
+}
+
+// limitedBody fails reads past its limit rather than truncating silently.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n, ErrResponseTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,75 @@
+package goth_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func strictParsing(t *testing.T) {
+	goth.StrictParsing = true
+	t.Cleanup(func() { goth.StrictParsing = false })
+}
+
+func Test_DecodeSession(t *testing.T) {
+	a := assert.New(t)
+
+	sess := &gothtest.Session{}
+	a.NoError(goth.DecodeSession(`{"Code":"1","Unknown":true}`, sess))
+	a.Equal("1", sess.Code)
+
+	strictParsing(t)
+	a.NoError(goth.DecodeSession(`{"Code":"1","ExpiresAt":"`+time.Now().Add(time.Hour).Format(time.RFC3339)+`"}`, &gothtest.Session{}))
+	a.Error(goth.DecodeSession(`{"Code":"1","Unknown":true}`, &gothtest.Session{}))
+	a.Error(goth.DecodeSession(`{"Code":"1"} {"Code":"2"}`, &gothtest.Session{}))
+	a.Error(goth.DecodeSession(`{"ExpiresAt":"9999-12-31T23:59:59Z"}`, &gothtest.Session{}))
+	a.Error(goth.DecodeSession(`{"ExpiresAt":"1900-01-01T00:00:00Z"}`, &gothtest.Session{}))
+	err := goth.DecodeSession(`{"AuthURL":"`+strings.Repeat("a", goth.MaxSessionSize)+`"}`, &gothtest.Session{})
+	a.Equal(goth.ErrSessionTooLarge, err)
+}
+
+func Test_StrictResponses(t *testing.T) {
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html>"))
+		case "/large":
+			w.Header().Set("Content-Type", "application/json")
+			// flushing first makes the response chunked, with no Content-Length
+			w.(http.Flusher).Flush()
+			w.Write([]byte(strings.Repeat(" ", int(goth.MaxResponseSize)+1)))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{}"))
+		}
+	}))
+	defer ts.Close()
+
+	get := func(path string) error {
+		res, err := goth.HTTPClientWithFallBack(nil).Get(ts.URL + path)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		_, err = ioutil.ReadAll(res.Body)
+		return err
+	}
+
+	a.NoError(get("/html"))
+	a.NoError(get("/large"))
+
+	strictParsing(t)
+	a.NoError(get("/"))
+	a.Error(get("/html"))
+	a.Equal(goth.ErrResponseTooLarge, get("/large"))
+}
@@ -0,0 +1,69 @@
+package goth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// StoredToken is a token kept in a TokenStore on behalf of a user.
+type StoredToken struct {
+	// Key identifies the token in the store, e.g. the user's ID.
+	Key string
+	// Provider is the name of the provider that issued the token.
+	Provider string
+	Token    *oauth2.Token
+}
+
+// TokenStore persists the tokens of users, for applications that act on their
+// behalf while they are not around. Implementations must be safe for
+// concurrent use.
+type TokenStore interface {
+	// ExpiringBefore returns the tokens that have a refresh token and expire before t.
+	ExpiringBefore(ctx context.Context, t time.Time) ([]StoredToken, error)
+	// SaveToken stores the token under the key, replacing the previous one.
+	SaveToken(ctx context.Context, token StoredToken) error
+}
+
+// MemoryTokenStore is a TokenStore keeping tokens in memory, for tests and
+// single instance applications.
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]StoredToken
+}
+
+// NewMemoryTokenStore returns an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: map[string]StoredToken{}}
+}
+
+// ExpiringBefore implements TokenStore.
+func (s *MemoryTokenStore) ExpiringBefore(_ context.Context, t time.Time) ([]StoredToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expiring []StoredToken
+	for _, st := range s.tokens {
+		if st.Token.RefreshToken != "" && !st.Token.Expiry.IsZero() && st.Token.Expiry.Before(t) {
+			expiring = append(expiring, st)
+		}
+	}
+	return expiring, nil
+}
+
+// SaveToken implements TokenStore.
+func (s *MemoryTokenStore) SaveToken(_ context.Context, token StoredToken) error {
+	s.mu.Lock()
+	s.tokens[token.Key] = token
+	s.mu.Unlock()
+	return nil
+}
+
+// Token returns the token stored under key.
+func (s *MemoryTokenStore) Token(key string) (StoredToken, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.tokens[key]
+	return st, ok
+}
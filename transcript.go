@@ -0,0 +1,207 @@
+package goth
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MaxTranscriptBody limits how much of each request and response body is kept
+// in a Transcript.
+var MaxTranscriptBody = 16 << 10
+
+// TranscriptEntry is a provider request recorded in a Transcript, with
+// secrets redacted.
+type TranscriptEntry struct {
+	Time           time.Time     `json:"time"`
+	Duration       time.Duration `json:"duration"`
+	Method         string        `json:"method"`
+	URL            string        `json:"url"`
+	RequestHeader  http.Header   `json:"request_header,omitempty"`
+	RequestBody    string        `json:"request_body,omitempty"`
+	StatusCode     int           `json:"status_code,omitempty"`
+	ResponseHeader http.Header   `json:"response_header,omitempty"`
+	ResponseBody   string        `json:"response_body,omitempty"`
+	// Error is set if no response was received.
+	Error string `json:"error,omitempty"`
+}
+
+// Transcript records the requests made to a provider during one auth
+// attempt, to troubleshoot logins that fail for some users only. Requests
+// are recorded by TranscriptMiddleware when their context carries a
+// Transcript, see ContextWithTranscript; gothic does this for every callback
+// when gothic.Transcripts is set.
+type Transcript struct {
+	// ID identifies the auth attempt, e.g. StateID(state), which also
+	// appears in the attempt's audit events.
+	ID       string
+	Provider string
+	Started  time.Time
+
+	mu      sync.Mutex
+	entries []TranscriptEntry
+	err     string
+}
+
+// NewTranscript returns an empty Transcript for the auth attempt id.
+func NewTranscript(id, provider string) *Transcript {
+	return &Transcript{ID: id, Provider: provider, Started: GetClock().Now()}
+}
+
+// Entries returns the requests recorded so far.
+func (t *Transcript) Entries() []TranscriptEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TranscriptEntry(nil), t.entries...)
+}
+
+// SetError records the error the auth attempt failed with.
+func (t *Transcript) SetError(err error) {
+	t.mu.Lock()
+	t.err = errorString(err)
+	t.mu.Unlock()
+}
+
+// Err returns the error recorded with SetError, or "" if there is none.
+func (t *Transcript) Err() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *Transcript) add(e TranscriptEntry) {
+	t.mu.Lock()
+	t.entries = append(t.entries, e)
+	t.mu.Unlock()
+}
+
+type transcriptKey struct{}
+
+// ContextWithTranscript returns a child of ctx whose provider requests are
+// recorded to t.
+func ContextWithTranscript(ctx context.Context, t *Transcript) context.Context {
+	return context.WithValue(ctx, transcriptKey{}, t)
+}
+
+// TranscriptFromContext returns the Transcript carried by ctx, or nil.
+func TranscriptFromContext(ctx context.Context) *Transcript {
+	t, _ := ctx.Value(transcriptKey{}).(*Transcript)
+	return t
+}
+
+// TranscriptMiddleware records requests whose context carries a Transcript
+// to it. Install it for all providers with
+//
+//	goth.UseMiddleware(goth.TranscriptMiddleware)
+//
+// URLs, headers and bodies are redacted before they are recorded; see RedactBody.
+func TranscriptMiddleware(next http.RoundTripper) http.RoundTripper {
+	return &transcriptTransport{next: next}
+}
+
+type transcriptTransport struct {
+	next http.RoundTripper
+}
+
+func (t *transcriptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transcript := TranscriptFromContext(req.Context())
+	if transcript == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	e := TranscriptEntry{
+		Time:          GetClock().Now(),
+		Method:        req.Method,
+		URL:           RedactURL(req.URL),
+		RequestHeader: RedactHeader(req.Header),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			b, _ := ioutil.ReadAll(io.LimitReader(body, int64(MaxTranscriptBody)))
+			body.Close()
+			e.RequestBody = string(RedactBody(req.Header.Get("Content-Type"), b))
+		}
+	}
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	e.Duration = time.Since(start)
+	if err != nil {
+		e.Error = err.Error()
+		transcript.add(e)
+		return res, err
+	}
+
+	e.StatusCode = res.StatusCode
+	e.ResponseHeader = RedactHeader(res.Header)
+	b, readErr := ioutil.ReadAll(io.LimitReader(res.Body, int64(MaxTranscriptBody)))
+	e.ResponseBody = string(RedactBody(res.Header.Get("Content-Type"), b))
+	if readErr != nil {
+		e.Error = readErr.Error()
+	}
+	// hand the provider the whole body, including what was read here
+	res.Body = &prefixedBody{Reader: io.MultiReader(bytes.NewReader(b), res.Body), Closer: res.Body}
+	transcript.add(e)
+	return res, nil
+}
+
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// TranscriptStore keeps the most recent transcripts in memory, for looking
+// them up when a login is reported to have failed.
+type TranscriptStore struct {
+	max int
+
+	mu    sync.Mutex
+	order []*Transcript
+	byID  map[string]*Transcript
+}
+
+// NewTranscriptStore returns a TranscriptStore keeping the last max transcripts.
+func NewTranscriptStore(max int) *TranscriptStore {
+	if max < 1 {
+		max = 1
+	}
+	return &TranscriptStore{max: max, byID: map[string]*Transcript{}}
+}
+
+// Add stores t, dropping the oldest transcript if the store is full.
+func (s *TranscriptStore) Add(t *Transcript) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.order) >= s.max {
+		oldest := s.order[0]
+		s.order = s.order[1:]
+		if s.byID[oldest.ID] == oldest {
+			delete(s.byID, oldest.ID)
+		}
+	}
+	s.order = append(s.order, t)
+	s.byID[t.ID] = t
+}
+
+// Get returns the transcript of the auth attempt id.
+func (s *TranscriptStore) Get(id string) (*Transcript, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.byID[id]
+	return t, ok
+}
+
+// Recent returns the stored transcripts, most recent first.
+func (s *TranscriptStore) Recent() []*Transcript {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recent := make([]*Transcript, len(s.order))
+	for i, t := range s.order {
+		recent[len(s.order)-1-i] = t
+	}
+	return recent
+}
@@ -0,0 +1,77 @@
+package goth_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TranscriptMiddleware(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"secret-token","token_type":"bearer"}`))
+	}))
+	defer ts.Close()
+
+	c := goth.WrapClient(ts.Client(), goth.TranscriptMiddleware)
+	form := url.Values{"code": {"secret-code"}, "client_secret": {"hunter2"}, "grant_type": {"authorization_code"}}
+
+	// requests without a transcript are passed through
+	res, err := c.PostForm(ts.URL, form)
+	a.NoError(err)
+	res.Body.Close()
+
+	tr := goth.NewTranscript("attempt-1", "faux")
+	req, _ := http.NewRequestWithContext(goth.ContextWithTranscript(context.Background(), tr), "POST", ts.URL+"/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Basic Zm9vOmJhcg==")
+	res, err = c.Do(req)
+	a.NoError(err)
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	// the provider still reads the whole response
+	a.Contains(string(body), "secret-token")
+
+	entries := tr.Entries()
+	a.Len(entries, 1)
+	e := entries[0]
+	a.Equal("POST", e.Method)
+	a.Equal(http.StatusOK, e.StatusCode)
+	a.Contains(e.RequestBody, "grant_type=authorization_code")
+	a.NotContains(e.RequestBody, "secret-code")
+	a.NotContains(e.RequestBody, "hunter2")
+	a.NotContains(e.RequestHeader.Get("Authorization"), "Zm9v")
+	a.Contains(e.ResponseBody, "bearer")
+	a.NotContains(e.ResponseBody, "secret-token")
+}
+
+func Test_TranscriptStore(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	s := goth.NewTranscriptStore(2)
+	s.Add(goth.NewTranscript("1", "faux"))
+	s.Add(goth.NewTranscript("2", "faux"))
+	s.Add(goth.NewTranscript("3", "faux"))
+
+	_, ok := s.Get("1")
+	a.False(ok)
+	tr, ok := s.Get("3")
+	a.True(ok)
+	a.Equal("faux", tr.Provider)
+
+	recent := s.Recent()
+	a.Len(recent, 2)
+	a.Equal("3", recent[0].ID)
+	a.Equal("2", recent[1].ID)
+}
@@ -2,6 +2,8 @@ package goth
 
 import (
 	"encoding/gob"
+	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -9,10 +11,24 @@ func init() {
 	gob.Register(User{})
 }
 
+// LazyRawData stops providers from decoding the userinfo response into
+// User.RawData up front. The response is kept in User.RawJSON instead, to be
+// decoded on demand with LoadRawData or DecodeRawJSON. Decoding into a
+// map[string]interface{} is the bulk of the allocations made by a login, so
+// enable this if RawData is rarely used.
+var LazyRawData = false
+
 // User contains the information common amongst most OAuth and OAuth2 providers.
 // All the "raw" data from the provider can be found in the `RawData` field.
 type User struct {
-	RawData           map[string]interface{}
+	RawData map[string]interface{}
+	// RawJSON is the undecoded userinfo response, for providers that fetch
+	// their user data as a single JSON document.
+	RawJSON json.RawMessage
+	// RawDataExtra holds the RawData entries set with SetRawData before
+	// RawJSON was decoded, i.e. with LazyRawData set. LoadRawData merges them
+	// into RawData.
+	RawDataExtra      map[string]interface{}
 	Provider          string
 	Email             string
 	Name              string
@@ -28,4 +44,79 @@ type User struct {
 	RefreshToken      string
 	ExpiresAt         time.Time
 	IDToken           string
+	// Groups are the groups the user is a member of, for providers that have
+	// been configured to fetch them.
+	Groups []string
+	// EmailVerified reports whether the provider has verified Email, for
+	// providers that tell.
+	EmailVerified bool
+	// Roles are the application roles assigned to the user, for providers
+	// that issue them.
+	Roles []string
+}
+
+// SetRawJSON stores the userinfo response b in RawJSON and, unless LazyRawData
+// is set, decodes it into RawData. Providers should use it instead of decoding
+// into RawData themselves.
+func (u *User) SetRawJSON(b []byte) error {
+	if LazyRawData {
+		if !json.Valid(b) {
+			return errors.New("goth: invalid JSON in userinfo response")
+		}
+		u.RawJSON = b
+		return nil
+	}
+	u.RawJSON = b
+	return json.Unmarshal(b, &u.RawData)
+}
+
+// LoadRawData returns RawData, decoding it from RawJSON first if that has not
+// happened yet.
+func (u *User) LoadRawData() (map[string]interface{}, error) {
+	if u.RawData == nil && len(u.RawJSON) > 0 {
+		if err := json.Unmarshal(u.RawJSON, &u.RawData); err != nil {
+			return nil, err
+		}
+	}
+	if len(u.RawDataExtra) > 0 {
+		if u.RawData == nil {
+			u.RawData = make(map[string]interface{}, len(u.RawDataExtra))
+		}
+		for k, v := range u.RawDataExtra {
+			u.RawData[k] = v
+		}
+		u.RawDataExtra = nil
+	}
+	return u.RawData, nil
+}
+
+// SetRawData sets the RawData entry key, for data the provider fetches besides
+// the userinfo response. If RawJSON has not been decoded yet, the entry is kept
+// in RawDataExtra until LoadRawData is called.
+func (u *User) SetRawData(key string, value interface{}) {
+	if u.RawData == nil && len(u.RawJSON) > 0 {
+		if u.RawDataExtra == nil {
+			u.RawDataExtra = map[string]interface{}{}
+		}
+		u.RawDataExtra[key] = value
+		return
+	}
+	if u.RawData == nil {
+		u.RawData = map[string]interface{}{}
+	}
+	u.RawData[key] = value
+}
+
+// DecodeRawJSON decodes the userinfo response into v, which is typically a
+// struct holding just the provider specific fields the caller needs. For
+// providers that don't set RawJSON, RawData is decoded into v instead.
+func (u User) DecodeRawJSON(v interface{}) error {
+	raw := []byte(u.RawJSON)
+	if len(raw) == 0 {
+		var err error
+		if raw, err = json.Marshal(u.RawData); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(raw, v)
 }
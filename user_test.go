@@ -1 +1,76 @@
 package goth_test
+
+import (
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_User_SetRawJSON(t *testing.T) {
+	a := assert.New(t)
+
+	u := goth.User{}
+	a.NoError(u.SetRawJSON([]byte(`{"id": "1", "hd": "example.com"}`)))
+	a.Equal("example.com", u.RawData["hd"])
+	a.Error(u.SetRawJSON([]byte(`{`)))
+
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	u = goth.User{}
+	a.NoError(u.SetRawJSON([]byte(`{"id": "1", "hd": "example.com"}`)))
+	a.Nil(u.RawData)
+	a.Error(u.SetRawJSON([]byte(`{`)))
+
+	var typed struct {
+		HD string `json:"hd"`
+	}
+	a.NoError(u.DecodeRawJSON(&typed))
+	a.Equal("example.com", typed.HD)
+
+	raw, err := u.LoadRawData()
+	a.NoError(err)
+	a.Equal("1", raw["id"])
+	a.Equal(raw, u.RawData)
+}
+
+func Test_User_SetRawData(t *testing.T) {
+	a := assert.New(t)
+
+	u := goth.User{}
+	a.NoError(u.SetRawJSON([]byte(`{"id": "1"}`)))
+	u.SetRawData("teams", []string{"acme"})
+	a.Equal([]string{"acme"}, u.RawData["teams"])
+	a.Nil(u.RawDataExtra)
+
+	goth.LazyRawData = true
+	defer func() { goth.LazyRawData = false }()
+
+	u = goth.User{}
+	a.NoError(u.SetRawJSON([]byte(`{"id": "1", "teams": null}`)))
+	u.SetRawData("teams", []string{"acme"})
+	a.Nil(u.RawData)
+
+	raw, err := u.LoadRawData()
+	a.NoError(err)
+	a.Equal("1", raw["id"])
+	a.Equal([]string{"acme"}, raw["teams"])
+	a.Nil(u.RawDataExtra)
+
+	u = goth.User{}
+	u.SetRawData("teams", []string{"acme"})
+	a.Equal([]string{"acme"}, u.RawData["teams"])
+}
+
+func Test_User_DecodeRawJSON_FromRawData(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	u := goth.User{RawData: map[string]interface{}{"hd": "example.com"}}
+	var typed struct {
+		HD string `json:"hd"`
+	}
+	a.NoError(u.DecodeRawJSON(&typed))
+	a.Equal("example.com", typed.HD)
+}
@@ -0,0 +1,125 @@
+package goth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// UserCache caches the users returned by Provider.FetchUser for a short time,
+// keyed by a hash of the session's access token. It is meant for middleware
+// that re-validates the user on every request and would otherwise hit the
+// provider's userinfo endpoint each time. Failed fetches are not cached.
+//
+// Cached users are shared between callers, who must not modify RawData.
+type UserCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]userCacheEntry
+}
+
+type userCacheEntry struct {
+	user    User
+	expires time.Time
+}
+
+// NewUserCache returns a UserCache keeping users for ttl, holding at most
+// maxEntries users at once (unbounded if maxEntries <= 0).
+func NewUserCache(ttl time.Duration, maxEntries int) *UserCache {
+	return &UserCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    map[[sha256.Size]byte]userCacheEntry{},
+	}
+}
+
+// cacheKey hashes the provider name and the access token of the session.
+// It returns false for sessions that do not hold an access token yet.
+func cacheKey(provider Provider, session Session) ([sha256.Size]byte, bool) {
+	var s struct {
+		AccessToken json.RawMessage
+	}
+	if err := json.Unmarshal([]byte(session.Marshal()), &s); err != nil {
+		return [sha256.Size]byte{}, false
+	}
+	if len(s.AccessToken) == 0 || bytes.Equal(s.AccessToken, []byte(`""`)) || bytes.Equal(s.AccessToken, []byte("null")) {
+		return [sha256.Size]byte{}, false
+	}
+
+	h := sha256.New()
+	h.Write([]byte(provider.Name()))
+	h.Write([]byte{0})
+	h.Write(s.AccessToken)
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key, true
+}
+
+// FetchUser returns the cached user for the session's access token, or asks
+// the provider for it.
+func (c *UserCache) FetchUser(provider Provider, session Session) (User, error) {
+	key, ok := cacheKey(provider, session)
+	if !ok {
+		return provider.FetchUser(session)
+	}
+
+	c.mu.Lock()
+	e, found := c.entries[key]
+	c.mu.Unlock()
+	if found && GetClock().Now().Before(e.expires) {
+		return e.user, nil
+	}
+
+	user, err := provider.FetchUser(session)
+	if err != nil {
+		return user, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+	c.entries[key] = userCacheEntry{user: user, expires: GetClock().Now().Add(c.ttl)}
+	return user, nil
+}
+
+// Invalidate drops the cached user for the session's access token, e.g. on logout.
+func (c *UserCache) Invalidate(provider Provider, session Session) {
+	key, ok := cacheKey(provider, session)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// Len returns the number of cached users, including expired ones not yet evicted.
+func (c *UserCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// evict removes expired entries, or an arbitrary one if none have expired.
+// It must be called with c.mu held.
+func (c *UserCache) evict() {
+	now := GetClock().Now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+	for k := range c.entries {
+		delete(c.entries, k)
+		return
+	}
+}
@@ -0,0 +1,71 @@
+package goth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothtest"
+	"github.com/markbates/goth/providers/faux"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingProvider struct {
+	faux.Provider
+	fetches int
+}
+
+func (p *countingProvider) FetchUser(s goth.Session) (goth.User, error) {
+	p.fetches++
+	return p.Provider.FetchUser(s)
+}
+
+func Test_UserCache(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := &countingProvider{}
+	c := goth.NewUserCache(time.Hour, 2)
+
+	sess := &faux.Session{ID: "1", AccessToken: "token-1"}
+	for i := 0; i < 3; i++ {
+		user, err := c.FetchUser(p, sess)
+		a.NoError(err)
+		a.Equal("1", user.UserID)
+	}
+	a.Equal(1, p.fetches)
+
+	// sessions without an access token are never cached
+	_, err := c.FetchUser(p, &faux.Session{ID: "2"})
+	a.Error(err)
+	_, err = c.FetchUser(p, &faux.Session{ID: "2"})
+	a.Error(err)
+	a.Equal(3, p.fetches)
+
+	c.FetchUser(p, &faux.Session{ID: "2", AccessToken: "token-2"})
+	c.FetchUser(p, &faux.Session{ID: "3", AccessToken: "token-3"})
+	a.Equal(2, c.Len())
+
+	c.Invalidate(p, &faux.Session{ID: "3", AccessToken: "token-3"})
+	a.Equal(1, c.Len())
+}
+
+func Test_UserCache_Expiry(t *testing.T) {
+	a := assert.New(t)
+
+	clock := gothtest.NewClock(time.Now())
+	goth.UseClock(clock)
+	defer goth.UseClock(nil)
+
+	p := &countingProvider{}
+	c := goth.NewUserCache(time.Minute, 0)
+
+	sess := &faux.Session{ID: "1", AccessToken: "token-1"}
+	c.FetchUser(p, sess)
+	clock.Advance(59 * time.Second)
+	c.FetchUser(p, sess)
+	a.Equal(1, p.fetches)
+	clock.Advance(2 * time.Second)
+	c.FetchUser(p, sess)
+	a.Equal(2, p.fetches)
+}